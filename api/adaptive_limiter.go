@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultAdaptiveMinLimit         = rate.Limit(1)
+	defaultAdaptiveRecoveryInterval = 10 * time.Second
+)
+
+// AdaptiveRateLimitConfig configures the adaptation of the client's rate
+// limiters to the rate limit quotas of the server. When a request is rejected
+// with a 429 response, the limit of the limiter it waited on is halved, down
+// to MinLimit, and no request is sent until the time given by the response's
+// Retry-After header has passed. The limit then recovers by a tenth of its
+// configured value every RecoveryInterval, as long as requests succeed. The
+// pause only applies to the requests waiting on the same limiter, so a quota
+// hit in one namespace or path prefix does not hold up the others.
+//
+// Requests rejected by a quota are not retried, so they do not use up the
+// retry budget; they fail with an error matching ErrRateLimited.
+type AdaptiveRateLimitConfig struct {
+	// MinLimit is the limit below which limiters are not reduced. Limiters
+	// configured below it are left at their limit. Defaults to one request
+	// per second.
+	MinLimit rate.Limit
+
+	// RecoveryInterval is the interval at which reduced limits are raised
+	// again. Defaults to 10 seconds.
+	RecoveryInterval time.Duration
+}
+
+type adaptiveRateLimiter struct {
+	minLimit         rate.Limit
+	recoveryInterval time.Duration
+
+	l sync.Mutex
+
+	// pausedUntil is keyed by the limiter the rejected requests waited on,
+	// nil for requests not rate limited by the client.
+	pausedUntil map[*rate.Limiter]time.Time
+	reduced     map[*rate.Limiter]*reducedLimit
+}
+
+// reducedLimit tracks a limiter whose limit was reduced.
+type reducedLimit struct {
+	base     rate.Limit
+	adjusted time.Time
+}
+
+func newAdaptiveRateLimiter(config *AdaptiveRateLimitConfig) *adaptiveRateLimiter {
+	a := &adaptiveRateLimiter{
+		minLimit:         config.MinLimit,
+		recoveryInterval: config.RecoveryInterval,
+		pausedUntil:      make(map[*rate.Limiter]time.Time),
+		reduced:          make(map[*rate.Limiter]*reducedLimit),
+	}
+	if a.minLimit <= 0 {
+		a.minLimit = defaultAdaptiveMinLimit
+	}
+	if a.recoveryInterval <= 0 {
+		a.recoveryInterval = defaultAdaptiveRecoveryInterval
+	}
+	return a
+}
+
+// wait blocks until the pause requested by the last Retry-After header
+// received for requests waiting on the limiter has passed.
+func (a *adaptiveRateLimiter) wait(ctx context.Context, limiter *rate.Limiter) error {
+	a.l.Lock()
+	pause := time.Until(a.pausedUntil[limiter])
+	if pause <= 0 {
+		delete(a.pausedUntil, limiter)
+	}
+	a.l.Unlock()
+
+	if pause <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(pause)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// observe adapts the limiter the request waited on, which may be nil, to the
+// response.
+func (a *adaptiveRateLimiter) observe(limiter *rate.Limiter, resp *http.Response) {
+	now := time.Now()
+	rejected := resp.StatusCode == http.StatusTooManyRequests &&
+		(resp.Request == nil || !isHealthPath(resp.Request.URL.Path))
+
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	if rejected {
+		if until := now.Add(parseRetryAfter(resp.Header.Get("Retry-After"), now)); until.After(a.pausedUntil[limiter]) {
+			a.pausedUntil[limiter] = until
+		}
+	}
+	if limiter == nil || limiter.Limit() == rate.Inf {
+		return
+	}
+
+	reduced, ok := a.reduced[limiter]
+	switch {
+	case rejected:
+		if !ok {
+			reduced = &reducedLimit{base: limiter.Limit()}
+			a.reduced[limiter] = reduced
+		}
+		// Limits already below the minimum are not raised to it
+		floor := a.minLimit
+		if current := limiter.Limit(); current < floor {
+			floor = current
+		}
+		limit := limiter.Limit() / 2
+		if limit < floor {
+			limit = floor
+		}
+		limiter.SetLimitAt(now, limit)
+		reduced.adjusted = now
+
+	case ok && resp.StatusCode < 400 && now.Sub(reduced.adjusted) >= a.recoveryInterval:
+		limit := limiter.Limit() + reduced.base/10
+		if limit >= reduced.base {
+			limit = reduced.base
+			delete(a.reduced, limiter)
+		}
+		limiter.SetLimitAt(now, limit)
+		reduced.adjusted = now
+	}
+}
+
+// parseRetryAfter returns the delay given by a Retry-After header, either in
+// seconds or as a date.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestClientAdaptiveRateLimit(t *testing.T) {
+	var limited int32
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&limited) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"errors": ["rate limit quota exceeded"]}`))
+			return
+		}
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	defer ln.Close()
+
+	limiter := rate.NewLimiter(100, 10)
+	config.Limiter = limiter
+	config.AdaptiveRateLimit = &AdaptiveRateLimitConfig{
+		MinLimit:         30,
+		RecoveryInterval: 10 * time.Millisecond,
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atomic.StoreInt32(&limited, 1)
+	for _, expected := range []rate.Limit{50, 30} {
+		if _, err := client.Logical().Read("secret/foo"); !IsRateLimited(err) {
+			t.Fatalf("expected rate limit error, got %v", err)
+		}
+		if limiter.Limit() != expected {
+			t.Fatalf("expected limit %v, got %v", expected, limiter.Limit())
+		}
+	}
+
+	// The next request waits for the Retry-After delay
+	atomic.StoreInt32(&limited, 0)
+	secret, err := client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait := secret.ResponseMetadata.RateLimiterWait; wait < 900*time.Millisecond {
+		t.Fatalf("expected request to wait for Retry-After, waited %s", wait)
+	}
+	if limiter.Limit() != 40 {
+		t.Fatalf("expected limit to recover gradually, got %v", limiter.Limit())
+	}
+
+	// The limit then recovers gradually, up to the configured one
+	for i := 0; i < 6; i++ {
+		time.Sleep(15 * time.Millisecond)
+		if _, err := client.Logical().Read("secret/foo"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if limiter.Limit() != 100 {
+		t.Fatalf("expected limit to recover, got %v", limiter.Limit())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Now()
+	cases := map[string]time.Duration{
+		"":   0,
+		"5":  5 * time.Second,
+		"-1": 0,
+		now.Add(time.Minute).UTC().Format(http.TimeFormat): time.Minute,
+		"garbage": 0,
+	}
+	for header, expected := range cases {
+		actual := parseRetryAfter(header, now)
+		if d := actual - expected; d < -time.Second || d > time.Second {
+			t.Fatalf("%q: expected %s, got %s", header, expected, actual)
+		}
+	}
+}
+
+func TestClientAdaptiveRateLimit_BelowMinLimit(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ln.Close()
+
+	limiter := rate.NewLimiter(0.5, 1)
+	config.Limiter = limiter
+	config.AdaptiveRateLimit = &AdaptiveRateLimitConfig{}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Logical().Read("secret/foo"); !IsRateLimited(err) {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	if limiter.Limit() != 0.5 {
+		t.Fatalf("expected limit below the minimum to be kept, got %v", limiter.Limit())
+	}
+}
+
+func TestClientAdaptiveRateLimit_PausePerLimiter(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/transit/encrypt/key" {
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	defer ln.Close()
+
+	transitLimiter := rate.NewLimiter(100, 10)
+	config.Limiter = rate.NewLimiter(100, 10)
+	config.PathLimiters = map[string]*rate.Limiter{"transit/": transitLimiter}
+	config.AdaptiveRateLimit = &AdaptiveRateLimitConfig{}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Logical().Write("transit/encrypt/key", nil); !IsRateLimited(err) {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+
+	// Requests waiting on other limiters are not paused
+	secret, err := client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait := secret.ResponseMetadata.RateLimiterWait; wait > time.Second {
+		t.Fatalf("expected request on another limiter not to be paused, waited %s", wait)
+	}
+
+	// Requests waiting on the same limiter are
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := client.Logical().Do(ctx, "POST", "transit/encrypt/key", nil); err == nil {
+		t.Fatal("expected paused request to time out")
+	} else if _, ok := err.(*LimiterWaitError); !ok {
+		t.Fatalf("expected limiter wait error, got %T: %v", err, err)
+	}
+}
+
+func TestClientAdaptiveRateLimit_HealthBehindPrefix(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/vault/v1/sys/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"initialized": true, "standby": true}`))
+	}))
+	defer ln.Close()
+
+	limiter := rate.NewLimiter(100, 10)
+	config.Address += "/vault"
+	config.Limiter = limiter
+	config.AdaptiveRateLimit = &AdaptiveRateLimitConfig{}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The standby status code of sys/health is not a rate limit, even when
+	// the address has a path prefix
+	health, err := client.Sys().Health()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !health.Standby {
+		t.Fatalf("unexpected health %#v", health)
+	}
+	if limiter.Limit() != 100 {
+		t.Fatalf("expected limit to be kept, got %v", limiter.Limit())
+	}
+}
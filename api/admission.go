@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// RequestPriority is the admission class of a request.
+type RequestPriority int
+
+const (
+	// PriorityInteractive is for latency-sensitive requests. It is the
+	// default.
+	PriorityInteractive RequestPriority = iota
+
+	// PriorityBackground is for batch and other bulk work that should not
+	// delay interactive requests.
+	PriorityBackground
+)
+
+func (p RequestPriority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityBackground:
+		return "background"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrAdmissionQueueFull is returned when a request cannot be queued because
+// the queue for its priority class is full.
+var ErrAdmissionQueueFull = errors.New("admission queue is full")
+
+// AdmissionConfig configures the admission controller, which bounds the
+// number of requests of each priority class that are in flight at once.
+// Requests beyond the limit wait in a queue for their class. As each class
+// has its own limit, a burst of background requests cannot starve
+// interactive ones.
+type AdmissionConfig struct {
+	// InteractiveConcurrency and BackgroundConcurrency are the maximum
+	// numbers of requests of each class in flight at once. Zero means
+	// unlimited.
+	InteractiveConcurrency int
+	BackgroundConcurrency  int
+
+	// MaxQueueLength is the maximum number of requests of a class waiting
+	// for admission. Further requests fail with ErrAdmissionQueueFull. Zero
+	// means unbounded.
+	MaxQueueLength int
+}
+
+// AdmissionClassStats are the admission statistics of a priority class.
+type AdmissionClassStats struct {
+	Admitted uint64
+	Rejected uint64
+
+	// InFlight and Queued are the numbers of requests currently in flight and
+	// waiting for admission.
+	InFlight int
+	Queued   int
+
+	// TotalQueueTime and MaxQueueTime describe the time admitted requests
+	// spent waiting.
+	TotalQueueTime time.Duration
+	MaxQueueTime   time.Duration
+}
+
+// AverageQueueTime returns the average time admitted requests spent waiting.
+func (s AdmissionClassStats) AverageQueueTime() time.Duration {
+	if s.Admitted == 0 {
+		return 0
+	}
+	return s.TotalQueueTime / time.Duration(s.Admitted)
+}
+
+// AdmissionStats are the statistics of the admission controller, by class.
+type AdmissionStats struct {
+	Interactive AdmissionClassStats
+	Background  AdmissionClassStats
+}
+
+type admissionController struct {
+	maxQueue int
+
+	l       sync.Mutex
+	classes [2]*admissionClass
+}
+
+type admissionClass struct {
+	slots chan struct{}
+	stats AdmissionClassStats
+}
+
+func newAdmissionController(config *AdmissionConfig) *admissionController {
+	newClass := func(concurrency int) *admissionClass {
+		class := &admissionClass{}
+		if concurrency > 0 {
+			class.slots = make(chan struct{}, concurrency)
+		}
+		return class
+	}
+
+	return &admissionController{
+		maxQueue: config.MaxQueueLength,
+		classes: [2]*admissionClass{
+			PriorityInteractive: newClass(config.InteractiveConcurrency),
+			PriorityBackground:  newClass(config.BackgroundConcurrency),
+		},
+	}
+}
+
+// admit blocks until a request of the given priority may proceed, returning a
+// function to be called when the request completes.
+func (a *admissionController) admit(ctx context.Context, priority RequestPriority) (func(), error) {
+	if priority != PriorityBackground {
+		priority = PriorityInteractive
+	}
+	class := a.classes[priority]
+
+	a.l.Lock()
+	if class.slots == nil {
+		class.stats.Admitted++
+		class.stats.InFlight++
+		a.l.Unlock()
+		return a.releaseFunc(class), nil
+	}
+	if a.maxQueue > 0 && class.stats.Queued >= a.maxQueue && len(class.slots) == cap(class.slots) {
+		class.stats.Rejected++
+		a.l.Unlock()
+		return nil, ErrAdmissionQueueFull
+	}
+	class.stats.Queued++
+	a.l.Unlock()
+
+	start := time.Now()
+	select {
+	case class.slots <- struct{}{}:
+	case <-ctx.Done():
+		a.l.Lock()
+		class.stats.Queued--
+		class.stats.Rejected++
+		a.l.Unlock()
+		return nil, ctx.Err()
+	}
+	waited := time.Since(start)
+
+	a.l.Lock()
+	class.stats.Queued--
+	class.stats.Admitted++
+	class.stats.InFlight++
+	class.stats.TotalQueueTime += waited
+	if waited > class.stats.MaxQueueTime {
+		class.stats.MaxQueueTime = waited
+	}
+	a.l.Unlock()
+
+	return a.releaseFunc(class), nil
+}
+
+func (a *admissionController) releaseFunc(class *admissionClass) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.l.Lock()
+			class.stats.InFlight--
+			a.l.Unlock()
+			if class.slots != nil {
+				<-class.slots
+			}
+		})
+	}
+}
+
+func (a *admissionController) stats() AdmissionStats {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	return AdmissionStats{
+		Interactive: a.classes[PriorityInteractive].stats,
+		Background:  a.classes[PriorityBackground].stats,
+	}
+}
+
+// AdmissionStats returns the statistics of the client's admission controller.
+// It returns false if admission control is not enabled.
+func (c *Client) AdmissionStats() (AdmissionStats, bool) {
+	c.modifyLock.RLock()
+	admission := c.admission
+	c.modifyLock.RUnlock()
+
+	if admission == nil {
+		return AdmissionStats{}, false
+	}
+	return admission.stats(), true
+}
+
+// WithPriority returns a copy of the client whose requests are admitted with
+// the given priority. Like WithNamespace, the copy is cheap and shares the
+// client's configuration and admission controller.
+func (c *Client) WithPriority(priority RequestPriority) *Client {
+	c2 := c.shallowCopy()
+	c2.priority = priority
+	return c2
+}
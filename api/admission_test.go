@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClientAdmission(t *testing.T) {
+	unblock := make(chan struct{})
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/block" {
+			<-unblock
+		}
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	config.Admission = &AdmissionConfig{
+		InteractiveConcurrency: 1,
+		BackgroundConcurrency:  1,
+		MaxQueueLength:         1,
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	background := client.WithPriority(PriorityBackground)
+
+	errCh := make(chan error, 2)
+	waitForStats := func(check func(AdmissionClassStats) bool) {
+		t.Helper()
+		for i := 0; i < 100; i++ {
+			stats, _ := client.AdmissionStats()
+			if check(stats.Background) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("timed out waiting for background requests")
+	}
+
+	// Fill the background slot, then its queue
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := background.RawRequest(background.NewRequest("GET", "/block"))
+			errCh <- err
+		}()
+	}
+	waitForStats(func(s AdmissionClassStats) bool { return s.InFlight == 1 && s.Queued == 1 })
+
+	if _, err := background.RawRequest(background.NewRequest("GET", "/")); err != ErrAdmissionQueueFull {
+		t.Fatalf("expected queue full error, got %v", err)
+	}
+
+	// Interactive requests are not held up by background ones
+	if _, err := client.RawRequest(client.NewRequest("GET", "/")); err != nil {
+		t.Fatal(err)
+	}
+
+	close(unblock)
+	for i := 0; i < 2; i++ {
+		if err := <-errCh; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, ok := client.AdmissionStats()
+	if !ok {
+		t.Fatal("expected admission control to be enabled")
+	}
+	if stats.Background.Admitted != 2 || stats.Background.Rejected != 1 || stats.Interactive.Admitted != 1 {
+		t.Fatalf("bad stats: %#v", stats)
+	}
+	if stats.Background.MaxQueueTime == 0 {
+		t.Fatal("expected queue time to be recorded")
+	}
+}
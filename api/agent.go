@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+// Agent is used to perform operations against the control endpoints of a
+// Vault agent. The client's address must point at the agent's listener.
+type Agent struct {
+	c *Client
+}
+
+// Agent is used to return the client for agent-related API calls.
+func (c *Client) Agent() *Agent {
+	return &Agent{c: c}
+}
+
+// The types of cache entries that can be cleared from the agent's cache.
+const (
+	AgentCacheClearTypeRequestPath   = "request_path"
+	AgentCacheClearTypeToken         = "token"
+	AgentCacheClearTypeTokenAccessor = "token_accessor"
+	AgentCacheClearTypeLease         = "lease"
+	AgentCacheClearTypeAll           = "all"
+)
+
+// AgentCacheClearInput is used to select the cache entries to evict.
+type AgentCacheClearInput struct {
+	// Type is one of the AgentCacheClearType constants.
+	Type string `json:"type"`
+
+	// Value is the token, token accessor, lease ID, or request path whose
+	// entries are evicted. It is ignored when clearing all entries.
+	Value string `json:"value,omitempty"`
+
+	// Namespace is the namespace of the request path when clearing by
+	// request path.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// CacheClear evicts entries from the agent's cache.
+func (c *Agent) CacheClear(input *AgentCacheClearInput) error {
+	if input == nil || input.Type == "" {
+		return errors.New("cache clear type must be provided")
+	}
+
+	r := c.c.NewRequest("PUT", consts.AgentPathCacheClear)
+	if err := r.SetJSONBody(input); err != nil {
+		return err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// ClearCache evicts all entries from the agent's cache.
+func (c *Agent) ClearCache() error {
+	return c.CacheClear(&AgentCacheClearInput{Type: AgentCacheClearTypeAll})
+}
+
+// ClearCacheByToken evicts the entries created with, or for, the given token.
+func (c *Agent) ClearCacheByToken(token string) error {
+	return c.CacheClear(&AgentCacheClearInput{Type: AgentCacheClearTypeToken, Value: token})
+}
+
+// ClearCacheByTokenAccessor evicts the entries belonging to the token with
+// the given accessor.
+func (c *Agent) ClearCacheByTokenAccessor(accessor string) error {
+	return c.CacheClear(&AgentCacheClearInput{Type: AgentCacheClearTypeTokenAccessor, Value: accessor})
+}
+
+// ClearCacheByLease evicts the entry holding the given lease.
+func (c *Agent) ClearCacheByLease(leaseID string) error {
+	return c.CacheClear(&AgentCacheClearInput{Type: AgentCacheClearTypeLease, Value: leaseID})
+}
+
+// ClearCacheByRequestPath evicts the entries for requests made under the
+// given path prefix, such as the path of a mount, in the given namespace.
+func (c *Agent) ClearCacheByRequestPath(namespace, path string) error {
+	return c.CacheClear(&AgentCacheClearInput{
+		Type:      AgentCacheClearTypeRequestPath,
+		Value:     path,
+		Namespace: namespace,
+	})
+}
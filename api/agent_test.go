@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAgentCacheClear(t *testing.T) {
+	var got []AgentCacheClearInput
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/agent/v1/cache-clear" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var input AgentCacheClearInput
+		if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		got = append(got, input)
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := client.Agent()
+
+	if err := agent.ClearCacheByLease("secret/foo/lease"); err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.ClearCacheByRequestPath("ns1/", "/v1/kv/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.ClearCache(); err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.CacheClear(&AgentCacheClearInput{}); err == nil {
+		t.Fatal("expected error without a type")
+	}
+
+	expected := []AgentCacheClearInput{
+		{Type: "lease", Value: "secret/foo/lease"},
+		{Type: "request_path", Value: "/v1/kv/", Namespace: "ns1/"},
+		{Type: "all"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("bad requests:\nexpected: %#v\ngot:      %#v", expected, got)
+	}
+}
@@ -1,6 +1,10 @@
 package api
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 // TokenAuth is used to perform token backend operations on Vault
 type TokenAuth struct {
@@ -277,6 +281,66 @@ func (c *TokenAuth) RevokeTree(token string) error {
 	return nil
 }
 
+// LookupInfo looks up the given token, returning its properties.
+func (c *TokenAuth) LookupInfo(token string) (*TokenInfo, error) {
+	return tokenInfo(c.Lookup(token))
+}
+
+// LookupAccessorInfo looks up the token with the given accessor, returning
+// its properties. The token's ID is not included in the result.
+func (c *TokenAuth) LookupAccessorInfo(accessor string) (*TokenInfo, error) {
+	return tokenInfo(c.LookupAccessor(accessor))
+}
+
+// LookupSelfInfo looks up the client's token, returning its properties.
+func (c *TokenAuth) LookupSelfInfo() (*TokenInfo, error) {
+	return tokenInfo(c.LookupSelf())
+}
+
+// CapabilitiesSelf returns the capabilities of the client's token on the
+// given path.
+func (c *TokenAuth) CapabilitiesSelf(path string) ([]string, error) {
+	return c.c.Sys().CapabilitiesSelf(path)
+}
+
+func tokenInfo(secret *Secret, err error) (*TokenInfo, error) {
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result TokenInfo
+	if err := secret.DecodeData(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// TokenInfo holds the properties of a token, as returned by the lookup
+// endpoints.
+type TokenInfo struct {
+	ID               string            `mapstructure:"id"`
+	Accessor         string            `mapstructure:"accessor"`
+	DisplayName      string            `mapstructure:"display_name"`
+	EntityID         string            `mapstructure:"entity_id"`
+	Path             string            `mapstructure:"path"`
+	Type             string            `mapstructure:"type"`
+	Policies         []string          `mapstructure:"policies"`
+	IdentityPolicies []string          `mapstructure:"identity_policies"`
+	Metadata         map[string]string `mapstructure:"meta"`
+	NumUses          int               `mapstructure:"num_uses"`
+	Orphan           bool              `mapstructure:"orphan"`
+	Renewable        bool              `mapstructure:"renewable"`
+	TTL              time.Duration     `mapstructure:"ttl"`
+	CreationTTL      time.Duration     `mapstructure:"creation_ttl"`
+	ExplicitMaxTTL   time.Duration     `mapstructure:"explicit_max_ttl"`
+	Period           time.Duration     `mapstructure:"period"`
+	IssueTime        time.Time         `mapstructure:"issue_time"`
+	ExpireTime       *time.Time        `mapstructure:"expire_time"`
+}
+
 // TokenCreateRequest is the options structure for creating a token.
 type TokenCreateRequest struct {
 	ID              string            `json:"id,omitempty"`
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// TokenRole is a token role, which sets the properties of the tokens created
+// against it with CreateWithRole.
+type TokenRole struct {
+	Name                   string        `mapstructure:"name"`
+	AllowedPolicies        []string      `mapstructure:"allowed_policies"`
+	DisallowedPolicies     []string      `mapstructure:"disallowed_policies"`
+	AllowedPoliciesGlob    []string      `mapstructure:"allowed_policies_glob"`
+	DisallowedPoliciesGlob []string      `mapstructure:"disallowed_policies_glob"`
+	AllowedEntityAliases   []string      `mapstructure:"allowed_entity_aliases"`
+	Orphan                 bool          `mapstructure:"orphan"`
+	Renewable              bool          `mapstructure:"renewable"`
+	PathSuffix             string        `mapstructure:"path_suffix"`
+	BoundCIDRs             []string      `mapstructure:"token_bound_cidrs"`
+	ExplicitMaxTTL         time.Duration `mapstructure:"token_explicit_max_ttl"`
+	Period                 time.Duration `mapstructure:"token_period"`
+	NoDefaultPolicy        bool          `mapstructure:"token_no_default_policy"`
+	NumUses                int           `mapstructure:"token_num_uses"`
+	Type                   string        `mapstructure:"token_type"`
+}
+
+func (r *TokenRole) body() map[string]interface{} {
+	body := map[string]interface{}{
+		"orphan":                  r.Orphan,
+		"renewable":               r.Renewable,
+		"token_no_default_policy": r.NoDefaultPolicy,
+		"token_num_uses":          r.NumUses,
+		"token_explicit_max_ttl":  r.ExplicitMaxTTL.String(),
+		"token_period":            r.Period.String(),
+	}
+	for field, values := range map[string][]string{
+		"allowed_policies":         r.AllowedPolicies,
+		"disallowed_policies":      r.DisallowedPolicies,
+		"allowed_policies_glob":    r.AllowedPoliciesGlob,
+		"disallowed_policies_glob": r.DisallowedPoliciesGlob,
+		"allowed_entity_aliases":   r.AllowedEntityAliases,
+		"token_bound_cidrs":        r.BoundCIDRs,
+	} {
+		if values != nil {
+			body[field] = values
+		}
+	}
+	if r.PathSuffix != "" {
+		body["path_suffix"] = r.PathSuffix
+	}
+	if r.Type != "" {
+		body["token_type"] = r.Type
+	}
+	return body
+}
+
+// CreateRole creates or replaces the token role with the name of the given
+// role.
+func (c *TokenAuth) CreateRole(ctx context.Context, role *TokenRole) error {
+	if role == nil || role.Name == "" {
+		return errors.New("missing role name")
+	}
+	_, err := c.c.Logical().Do(ctx, "POST", "auth/token/roles/"+role.Name, role.body())
+	return err
+}
+
+// ReadRole returns the named token role, or nil if it does not exist.
+func (c *TokenAuth) ReadRole(ctx context.Context, name string) (*TokenRole, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, "auth/token/roles/"+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var role TokenRole
+	if err := secret.DecodeData(&role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// ListRoles returns the names of the token roles.
+func (c *TokenAuth) ListRoles(ctx context.Context) ([]string, error) {
+	secret, err := c.c.Logical().listWithContext(ctx, "auth/token/roles", nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var roles []string
+	if err := mapstructure.Decode(secret.Data["keys"], &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// DeleteRole deletes the named token role.
+func (c *TokenAuth) DeleteRole(ctx context.Context, name string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", "auth/token/roles/"+name, nil)
+	return err
+}
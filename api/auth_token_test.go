@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTokenAuthLookupSelfInfo(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/auth/token/lookup-self" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{
+  "data": {
+    "accessor": "8609694a-cdbc-db9b-d345-e782dbb562ed",
+    "creation_time": 1523979354,
+    "creation_ttl": 2764800,
+    "display_name": "ldap2-tesla",
+    "entity_id": "7d2e3179-f69b-450c-7179-ac8ee8bd8ca9",
+    "expire_time": "2018-05-19T11:35:54.466476215-04:00",
+    "explicit_max_ttl": 0,
+    "id": "cf64a70f-3a12-3f6c-791d-6cef6d390eed",
+    "identity_policies": ["dev-group-policy"],
+    "issue_time": "2018-04-17T11:35:54.466476078-04:00",
+    "meta": {"username": "tesla"},
+    "num_uses": 0,
+    "orphan": true,
+    "path": "auth/ldap2/login/tesla",
+    "policies": ["default", "testgroup2-policy"],
+    "renewable": true,
+    "ttl": 2764790,
+    "type": "service"
+  }
+}`))
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := client.Auth().Token().LookupSelfInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.EntityID != "7d2e3179-f69b-450c-7179-ac8ee8bd8ca9" || !info.Orphan || !info.Renewable {
+		t.Fatalf("unexpected token info: %#v", info)
+	}
+	if info.TTL != 2764790*time.Second || info.CreationTTL != 32*24*time.Hour {
+		t.Fatalf("unexpected TTLs: %v, %v", info.TTL, info.CreationTTL)
+	}
+	if !reflect.DeepEqual(info.Policies, []string{"default", "testgroup2-policy"}) {
+		t.Fatalf("unexpected policies: %v", info.Policies)
+	}
+	if info.Metadata["username"] != "tesla" {
+		t.Fatalf("unexpected metadata: %v", info.Metadata)
+	}
+	if info.ExpireTime == nil || info.ExpireTime.Sub(info.IssueTime).Round(time.Second) != 32*24*time.Hour {
+		t.Fatalf("unexpected expire time: %v", info.ExpireTime)
+	}
+}
+
+func TestTokenAuthRoles(t *testing.T) {
+	var written map[string]interface{}
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method + " " + req.URL.Path {
+		case "POST /v1/auth/token/roles/ci":
+			json.NewDecoder(req.Body).Decode(&written)
+			w.WriteHeader(http.StatusNoContent)
+		case "GET /v1/auth/token/roles/ci":
+			w.Write([]byte(`{"data": {
+				"name": "ci",
+				"allowed_policies": ["deploy"],
+				"orphan": true,
+				"renewable": true,
+				"token_bound_cidrs": ["10.0.0.0/8"],
+				"token_explicit_max_ttl": 0,
+				"token_period": 3600,
+				"token_type": "default-service"
+			}}`))
+		case "GET /v1/auth/token/roles":
+			w.Write([]byte(`{"data": {"keys": ["ci"]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenAuth := client.Auth().Token()
+	ctx := context.Background()
+
+	if err := tokenAuth.CreateRole(ctx, &TokenRole{}); err == nil {
+		t.Fatal("expected missing name error")
+	}
+	err = tokenAuth.CreateRole(ctx, &TokenRole{
+		Name:            "ci",
+		AllowedPolicies: []string{"deploy"},
+		Orphan:          true,
+		Period:          time.Hour,
+		BoundCIDRs:      []string{"10.0.0.0/8"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"allowed_policies":        []interface{}{"deploy"},
+		"token_bound_cidrs":       []interface{}{"10.0.0.0/8"},
+		"orphan":                  true,
+		"renewable":               false,
+		"token_no_default_policy": false,
+		"token_num_uses":          float64(0),
+		"token_explicit_max_ttl":  "0s",
+		"token_period":            "1h0m0s",
+	}
+	if !reflect.DeepEqual(written, expected) {
+		t.Fatalf("unexpected request body %#v", written)
+	}
+
+	role, err := tokenAuth.ReadRole(ctx, "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.Name != "ci" || !role.Orphan || role.Period != time.Hour || role.Type != "default-service" ||
+		!reflect.DeepEqual(role.BoundCIDRs, []string{"10.0.0.0/8"}) {
+		t.Fatalf("unexpected role %#v", role)
+	}
+	if role, err := tokenAuth.ReadRole(ctx, "missing"); err != nil || role != nil {
+		t.Fatalf("expected no role, got %#v and %v", role, err)
+	}
+
+	roles, err := tokenAuth.ListRoles(ctx)
+	if err != nil || !reflect.DeepEqual(roles, []string{"ci"}) {
+		t.Fatalf("unexpected roles %v and %v", roles, err)
+	}
+}
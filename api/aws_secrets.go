@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// AWSSecretsDefaultMountPoint is the default path at which the AWS
+	// secrets engine is mounted.
+	AWSSecretsDefaultMountPoint = "aws"
+
+	// DefaultAWSCredentialsExpiryWindow is how long before their expiry
+	// credentials are refreshed by AWSCredentialsProvider.
+	DefaultAWSCredentialsExpiryWindow = time.Minute
+)
+
+// AWSSecrets is used to perform operations on the AWS secrets engine.
+type AWSSecrets struct {
+	c          *Client
+	MountPoint string
+}
+
+// AWSSecrets returns the client for the AWS secrets engine mounted at the
+// default path.
+func (c *Client) AWSSecrets() *AWSSecrets {
+	return c.AWSSecretsWithMountPoint(AWSSecretsDefaultMountPoint)
+}
+
+// AWSSecretsWithMountPoint returns the client for the AWS secrets engine
+// mounted at the given path.
+func (c *Client) AWSSecretsWithMountPoint(mountPoint string) *AWSSecrets {
+	return &AWSSecrets{
+		c:          c,
+		MountPoint: mountPoint,
+	}
+}
+
+// AWSCredentialsOptions are the optional parameters of GenerateCredentials.
+type AWSCredentialsOptions struct {
+	// STS requests credentials from the sts endpoint rather than the creds
+	// endpoint; this is required for federation tokens.
+	STS bool
+
+	// RoleARN is the ARN of the role to assume, if the Vault role allows
+	// several.
+	RoleARN string
+
+	// RoleSessionName is the session name to use when assuming a role.
+	RoleSessionName string
+
+	// TTL is the requested lifetime of STS credentials.
+	TTL string
+}
+
+// AWSCredentials are credentials generated by the AWS secrets engine.
+// SessionToken is only set for STS credentials.
+type AWSCredentials struct {
+	AccessKey     string
+	SecretKey     string
+	SessionToken  string
+	LeaseID       string
+	LeaseDuration time.Duration
+
+	// Expires is when the lease of the credentials expires, or the zero time
+	// if they have no lease.
+	Expires time.Time
+}
+
+// GenerateCredentials generates credentials using the given role.
+func (c *AWSSecrets) GenerateCredentials(ctx context.Context, role string, opts *AWSCredentialsOptions) (*AWSCredentials, error) {
+	if opts == nil {
+		opts = &AWSCredentialsOptions{}
+	}
+
+	endpoint := "creds"
+	if opts.STS {
+		endpoint = "sts"
+	}
+	data := map[string][]string{}
+	if opts.RoleARN != "" {
+		data["role_arn"] = []string{opts.RoleARN}
+	}
+	if opts.RoleSessionName != "" {
+		data["role_session_name"] = []string{opts.RoleSessionName}
+	}
+	if opts.TTL != "" {
+		data["ttl"] = []string{opts.TTL}
+	}
+
+	secret, err := c.c.Logical().readWithContext(ctx, fmt.Sprintf("%s/%s/%s", c.MountPoint, endpoint, role), data)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	creds := &AWSCredentials{
+		LeaseID:       secret.LeaseID,
+		LeaseDuration: time.Duration(secret.LeaseDuration) * time.Second,
+	}
+	if creds.LeaseDuration > 0 {
+		creds.Expires = time.Now().Add(creds.LeaseDuration)
+	}
+	var ok bool
+	if creds.AccessKey, ok = secret.Data["access_key"].(string); !ok {
+		return nil, errors.New("unexpected type for access_key in response")
+	}
+	if creds.SecretKey, ok = secret.Data["secret_key"].(string); !ok {
+		return nil, errors.New("unexpected type for secret_key in response")
+	}
+	creds.SessionToken, _ = secret.Data["security_token"].(string)
+	return creds, nil
+}
+
+// AWSCredentialsProvider caches credentials generated by the AWS secrets
+// engine, generating new ones shortly before they expire. Its Retrieve method
+// mirrors that of the AWS SDK's credential providers, so adapting it to the
+// SDK takes a few lines in the application:
+//
+//	type vaultCredentials struct{ p *api.AWSCredentialsProvider }
+//
+//	func (v vaultCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+//		creds, err := v.p.Retrieve(ctx)
+//		if err != nil {
+//			return aws.Credentials{}, err
+//		}
+//		return aws.Credentials{
+//			AccessKeyID:     creds.AccessKey,
+//			SecretAccessKey: creds.SecretKey,
+//			SessionToken:    creds.SessionToken,
+//			CanExpire:       !creds.Expires.IsZero(),
+//			Expires:         creds.Expires,
+//			Source:          "Vault",
+//		}, nil
+//	}
+type AWSCredentialsProvider struct {
+	aws     *AWSSecrets
+	role    string
+	options *AWSCredentialsOptions
+
+	// ExpiryWindow is how long before their expiry credentials are
+	// refreshed.
+	ExpiryWindow time.Duration
+
+	l       sync.Mutex
+	current *AWSCredentials
+}
+
+// NewCredentialsProvider returns a provider of credentials generated with the
+// given role.
+func (c *AWSSecrets) NewCredentialsProvider(role string, opts *AWSCredentialsOptions) *AWSCredentialsProvider {
+	return &AWSCredentialsProvider{
+		aws:          c,
+		role:         role,
+		options:      opts,
+		ExpiryWindow: DefaultAWSCredentialsExpiryWindow,
+	}
+}
+
+// Retrieve returns the cached credentials, generating new ones if there are
+// none or they are about to expire.
+func (p *AWSCredentialsProvider) Retrieve(ctx context.Context) (*AWSCredentials, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if p.current != nil && !p.expiredLocked() {
+		return p.current, nil
+	}
+
+	creds, err := p.aws.GenerateCredentials(ctx, p.role, p.options)
+	if err != nil {
+		return nil, err
+	}
+	p.current = creds
+	return creds, nil
+}
+
+// IsExpired returns whether the cached credentials need to be refreshed.
+func (p *AWSCredentialsProvider) IsExpired() bool {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	return p.current == nil || p.expiredLocked()
+}
+
+func (p *AWSCredentialsProvider) expiredLocked() bool {
+	if p.current.Expires.IsZero() {
+		return false
+	}
+	return !time.Now().Add(p.ExpiryWindow).Before(p.current.Expires)
+}
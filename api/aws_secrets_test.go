@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAWSCredentialsProvider(t *testing.T) {
+	var issued int
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/aws/sts/deploy" || req.URL.Query().Get("ttl") != "15m" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		issued++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"lease_id":       "aws/sts/deploy/abc",
+			"lease_duration": 900,
+			"data": map[string]interface{}{
+				"access_key":     fmt.Sprintf("ASIA%d", issued),
+				"secret_key":     "secret",
+				"security_token": "token",
+			},
+		})
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := client.AWSSecrets().NewCredentialsProvider("deploy", &AWSCredentialsOptions{STS: true, TTL: "15m"})
+	if !provider.IsExpired() {
+		t.Fatal("expected a provider without credentials to be expired")
+	}
+
+	creds, err := provider.Retrieve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKey != "ASIA1" || creds.SessionToken != "token" || creds.LeaseDuration != 15*time.Minute {
+		t.Fatalf("bad credentials: %#v", creds)
+	}
+
+	if creds, err = provider.Retrieve(context.Background()); err != nil || creds.AccessKey != "ASIA1" {
+		t.Fatalf("expected cached credentials, got %#v, %v", creds, err)
+	}
+
+	// Credentials within the expiry window are refreshed.
+	provider.ExpiryWindow = 20 * time.Minute
+	if !provider.IsExpired() {
+		t.Fatal("expected credentials to be expired")
+	}
+	if creds, err = provider.Retrieve(context.Background()); err != nil || creds.AccessKey != "ASIA2" {
+		t.Fatalf("expected new credentials, got %#v, %v", creds, err)
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned, without a request being made, for requests to
+// a host whose circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerCoolDown         = 10 * time.Second
+)
+
+// BreakerConfig configures the circuit breaker, which stops the client from
+// sending requests to a host that keeps failing. After FailureThreshold
+// consecutive failures, requests to the host fail fast with ErrCircuitOpen
+// for the CoolDown period. After that a single request is let through: if it
+// succeeds the circuit closes, and otherwise it opens for another CoolDown.
+//
+// Failures are requests that fail with a network error or a 5xx response,
+// such as those of a sealed Vault. As the breaker is consulted before the
+// rate limiter and admission control, requests made while the circuit is
+// open do not use up their budgets.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures after which the
+	// circuit opens. Defaults to 5.
+	FailureThreshold int
+
+	// CoolDown is the time the circuit stays open before a request is let
+	// through. Defaults to 10 seconds.
+	CoolDown time.Duration
+}
+
+type circuitBreaker struct {
+	threshold int
+	coolDown  time.Duration
+
+	l     sync.Mutex
+	hosts map[string]*circuit
+}
+
+type circuit struct {
+	failures int
+	openedAt time.Time
+
+	// trialAt is when the request let through after the cool down was made,
+	// if it is still in flight.
+	trialAt time.Time
+}
+
+func newCircuitBreaker(config *BreakerConfig) *circuitBreaker {
+	b := &circuitBreaker{
+		threshold: config.FailureThreshold,
+		coolDown:  config.CoolDown,
+		hosts:     make(map[string]*circuit),
+	}
+	if b.threshold <= 0 {
+		b.threshold = defaultBreakerFailureThreshold
+	}
+	if b.coolDown <= 0 {
+		b.coolDown = defaultBreakerCoolDown
+	}
+	return b
+}
+
+// allow returns ErrCircuitOpen if requests to the host should fail fast.
+func (b *circuitBreaker) allow(host string) error {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	c, ok := b.hosts[host]
+	if !ok || c.openedAt.IsZero() {
+		return nil
+	}
+
+	now := time.Now()
+	if now.Sub(c.openedAt) < b.coolDown {
+		return ErrCircuitOpen
+	}
+
+	// Half open: let a single request through. A trial that never reported
+	// back, e.g. because it was abandoned before being sent, is replaced
+	// after another cool down.
+	if !c.trialAt.IsZero() && now.Sub(c.trialAt) < b.coolDown {
+		return ErrCircuitOpen
+	}
+	c.trialAt = now
+	return nil
+}
+
+// record records the outcome of a request to the host.
+func (b *circuitBreaker) record(host string, failed bool) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	c, ok := b.hosts[host]
+	if !failed {
+		if ok {
+			delete(b.hosts, host)
+		}
+		return
+	}
+	if !ok {
+		c = &circuit{}
+		b.hosts[host] = c
+	}
+
+	c.failures++
+	c.trialAt = time.Time{}
+	if c.failures >= b.threshold || !c.openedAt.IsZero() {
+		c.openedAt = time.Now()
+	}
+}
+
+// isBreakerFailure returns whether the outcome of a request counts as a
+// failure of the host. Requests cancelled by the caller do not.
+func isBreakerFailure(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil
+	}
+	return resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientBreaker(t *testing.T) {
+	var status, requests int32
+	atomic.StoreInt32(&status, http.StatusServiceUnavailable)
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+		w.Write([]byte(`{"errors": ["Vault is sealed"]}`))
+	}))
+	defer ln.Close()
+
+	config.MaxRetries = 0
+	config.Breaker = &BreakerConfig{
+		FailureThreshold: 3,
+		CoolDown:         50 * time.Millisecond,
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := func() error {
+		_, err := client.Logical().Read("secret/foo")
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := read(); !IsSealed(err) {
+			t.Fatalf("expected sealed error, got %v", err)
+		}
+	}
+	if err := read(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected open circuit, got %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Fatalf("expected requests to fail fast, got %d requests", n)
+	}
+
+	// A failed trial after the cool down opens the circuit again
+	time.Sleep(60 * time.Millisecond)
+	if err := read(); !IsSealed(err) {
+		t.Fatalf("expected trial request, got %v", err)
+	}
+	if err := read(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected open circuit, got %v", err)
+	}
+
+	// A successful trial closes it
+	atomic.StoreInt32(&status, http.StatusOK)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if err := read(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 6 {
+		t.Fatalf("expected 6 requests, got %d", n)
+	}
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	b := newCircuitBreaker(&BreakerConfig{FailureThreshold: 1, CoolDown: 20 * time.Millisecond})
+	b.record("a", true)
+	if b.allow("a") != ErrCircuitOpen || b.allow("b") != nil {
+		t.Fatal("expected only the failing host to be open")
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if err := b.allow("a"); err != nil {
+		t.Fatalf("expected trial to be allowed, got %v", err)
+	}
+	if err := b.allow("a"); err != ErrCircuitOpen {
+		t.Fatalf("expected a single trial, got %v", err)
+	}
+}
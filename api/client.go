@@ -2,8 +2,16 @@ package api
 
 import (
 	"context"
+	"crypto"
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
@@ -21,11 +29,13 @@ import (
 	rootcerts "github.com/hashicorp/go-rootcerts"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/helper/tlsutil"
 	"golang.org/x/net/http2"
 	"golang.org/x/time/rate"
 )
 
 const EnvVaultAddress = "VAULT_ADDR"
+const EnvVaultAddresses = "VAULT_ADDRESSES"
 const EnvVaultAgentAddr = "VAULT_AGENT_ADDR"
 const EnvVaultCACert = "VAULT_CACERT"
 const EnvVaultCAPath = "VAULT_CAPATH"
@@ -36,15 +46,39 @@ const EnvVaultSRVLookup = "VAULT_SRV_LOOKUP"
 const EnvVaultSkipVerify = "VAULT_SKIP_VERIFY"
 const EnvVaultNamespace = "VAULT_NAMESPACE"
 const EnvVaultTLSServerName = "VAULT_TLS_SERVER_NAME"
+const EnvVaultTLSMinVersion = "VAULT_TLS_MIN_VERSION"
+const EnvVaultTLSMaxVersion = "VAULT_TLS_MAX_VERSION"
+const EnvVaultTLSCipherSuites = "VAULT_TLS_CIPHER_SUITES"
 const EnvVaultWrapTTL = "VAULT_WRAP_TTL"
 const EnvVaultMaxRetries = "VAULT_MAX_RETRIES"
 const EnvVaultToken = "VAULT_TOKEN"
 const EnvVaultMFA = "VAULT_MFA"
 const EnvRateLimit = "VAULT_RATE_LIMIT"
+const EnvVaultProxyAddr = "VAULT_PROXY_ADDR"
+const EnvVaultMaxIdleConns = "VAULT_MAX_IDLE_CONNS"
+const EnvVaultMaxIdleConnsPerHost = "VAULT_MAX_IDLE_CONNS_PER_HOST"
+const EnvVaultMaxConnsPerHost = "VAULT_MAX_CONNS_PER_HOST"
+const EnvVaultIdleConnTimeout = "VAULT_IDLE_CONN_TIMEOUT"
+const EnvVaultKeepAlive = "VAULT_KEEP_ALIVE"
+const EnvVaultDisableKeepAlives = "VAULT_DISABLE_KEEP_ALIVES"
 
 // Deprecated values
 const EnvVaultAgentAddress = "VAULT_AGENT_ADDR"
 const EnvVaultInsecure = "VAULT_SKIP_VERIFY"
+const EnvHTTPProxy = "VAULT_HTTP_PROXY"
+
+// EnvLookup returns the value of an environment variable, or the empty
+// string if it is unset.
+type EnvLookup func(key string) string
+
+// PrefixedEnv returns an EnvLookup reading variables with the given prefix,
+// so that applications embedding several differently configured clients can
+// keep their settings apart, e.g. MYAPP_VAULT_ADDR for the prefix "MYAPP_".
+func PrefixedEnv(prefix string) EnvLookup {
+	return func(key string) string {
+		return os.Getenv(prefix + key)
+	}
+}
 
 // WrappingLookupFunc is a function that, given an HTTP verb and a path,
 // returns an optional string duration to be used for response wrapping (e.g.
@@ -63,6 +97,50 @@ type Config struct {
 	// HttpClient.
 	Address string
 
+	// Addresses is an optional list of addresses of the nodes of an HA Vault
+	// cluster. When set, it takes precedence over Address: the client starts
+	// with the first address and, on connection errors or sealed (503)
+	// responses, fails over to the next healthy node as reported by
+	// sys/health, preferring the active node. The client then sticks to that
+	// node until it in turn fails.
+	Addresses []string
+
+	// ProxyURL is the URL of a proxy through which all requests to Vault are
+	// sent, e.g. "http://proxy.example.com:3128" or
+	// "socks5://127.0.0.1:1080". If unset, the standard HTTP_PROXY,
+	// HTTPS_PROXY, and NO_PROXY environment variables are honored.
+	ProxyURL string
+
+	// DialContext, if set, is used by the transport to establish connections
+	// to Vault, e.g. to reach it over an overlay network or through an SSH
+	// jump host. When the address is a unix socket, it is called with the
+	// "unix" network and the socket path. TLS is still negotiated by the
+	// transport on top of the returned connection.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// MaxIdleConns, MaxIdleConnsPerHost, MaxConnsPerHost, and
+	// IdleConnTimeout tune the connection pool of the transport, as the
+	// fields of the same names of http.Transport do. Zero values keep the
+	// settings of the transport.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+
+	// KeepAlive is the TCP keep-alive period of the connections made by the
+	// default dialer. It has no effect when DialContext is set. Zero keeps
+	// the default of 30 seconds, and negative values disable TCP
+	// keep-alives.
+	KeepAlive time.Duration
+
+	// DisableKeepAlives disables HTTP keep-alives, so that a connection is
+	// only used for a single request.
+	DisableKeepAlives bool
+
+	// Namespace is the namespace the client is created with. The
+	// VAULT_NAMESPACE environment variable takes precedence.
+	Namespace string
+
 	// AgentAddress is the address of the local Vault agent. This should be a
 	// complete URL such as "http://vault.example.com".
 	AgentAddress string
@@ -72,8 +150,20 @@ type Config struct {
 	// If you must modify Vault's defaults, it is suggested that you start with
 	// that client and modify as needed rather than start with an empty client
 	// (or http.DefaultClient).
+	//
+	// The TLS, dialer, proxy, and connection pool settings are applied to
+	// the *http.Transport of the client, which may be wrapped by transports
+	// implementing TransportUnwrapper.
 	HttpClient *http.Client
 
+	// WrapTransport, if set, wraps the transport of HttpClient for sending
+	// requests, e.g. with instrumentation or a recorder. Unlike setting a
+	// wrapper as the transport of HttpClient, this leaves the *http.Transport
+	// in place to be configured. The wrapper is created again whenever
+	// HttpClient is replaced.
+	WrapTransport func(http.RoundTripper) http.RoundTripper
+	wrapped       wrappedTransport
+
 	// MaxRetries controls the maximum number of times to retry when a 5xx
 	// error occurs. Set to 0 to disable retrying. Defaults to 2 (for a total
 	// of three tries).
@@ -82,6 +172,19 @@ type Config struct {
 	// Timeout is for setting custom timeout parameter in the HttpClient
 	Timeout time.Duration
 
+	// MaxRetryDuration, if positive, limits the total time spent on a
+	// request: no retry is made if it would start after this long since the
+	// request was first made. Retries are likewise not made past the
+	// deadline of the request's context.
+	MaxRetryDuration time.Duration
+
+	// RetryWrites allows requests other than GET, HEAD, and LIST to be
+	// retried after a transient network error, such as the connection being
+	// reset. As the server may have already handled such a request, this
+	// should only be set if the writes made by the client are idempotent.
+	// Requests failing with any other network error are not retried.
+	RetryWrites bool
+
 	// If there is an error when creating the configuration, this will be the
 	// error
 	Error error
@@ -92,6 +195,10 @@ type Config struct {
 	// The CheckRetry function to use; a default is used if not provided
 	CheckRetry retryablehttp.CheckRetry
 
+	// Logger, if set, receives debug logs of the requests made by the
+	// client, including their retries.
+	Logger retryablehttp.Logger
+
 	// Limiter is the rate limiter used by the client.
 	// If this pointer is nil, then there will be no limit set.
 	// In contrast, if this pointer is set, even to an empty struct,
@@ -99,16 +206,147 @@ type Config struct {
 	// is equivalent blocking all events.
 	Limiter *rate.Limiter
 
+	// NamespaceLimiters holds rate limiters keyed by namespace. Requests made
+	// against a namespace with an entry here wait on that limiter instead of
+	// Limiter, so a noisy namespace cannot exhaust the budget shared by the
+	// others.
+	NamespaceLimiters map[string]*rate.Limiter
+
+	// PathLimiters holds rate limiters keyed by path prefix, such as
+	// "transit/" or "secret/data/", relative to /v1/. Requests to a path
+	// matching a prefix here wait on the limiter of the longest matching
+	// prefix instead of NamespaceLimiters or Limiter, so that expensive
+	// endpoints can be given a tighter budget than the rest.
+	PathLimiters map[string]*rate.Limiter
+
+	// AdaptiveRateLimit, if set, makes the client slow down when the server
+	// rejects requests with rate limit quota errors, by lowering the limit
+	// of the limiter the requests waited on and honoring Retry-After
+	// headers.
+	AdaptiveRateLimit *AdaptiveRateLimitConfig
+
+	// Admission, if set, enables admission control: requests wait in a
+	// queue for their priority class when too many of that class are in
+	// flight. Priorities are set per request or with WithPriority.
+	Admission *AdmissionConfig
+
+	// Breaker, if set, enables a circuit breaker per host: after repeated
+	// failures, requests to the host fail fast with ErrCircuitOpen for a
+	// while rather than adding to the load of a sealed or downed Vault.
+	Breaker *BreakerConfig
+
+	// TokenSources, if set, replace the lookup of VAULT_TOKEN when the client
+	// is created: the sources are consulted in order and the first token
+	// found is used. Include an EnvTokenSource to keep honoring VAULT_TOKEN.
+	TokenSources []TokenSource
+
+	// CloneHeaders causes Clone to copy the client's headers, including the
+	// namespace, along with its policy override flag and wrapping lookup
+	// function.
+	CloneHeaders bool
+
+	// CloneToken causes Clone to copy the client's token.
+	CloneToken bool
+
+	// CloneTLSConfig causes Clone to give the new client its own copy of the
+	// HTTP transport, so that its TLS configuration can be changed without
+	// affecting the original client. This requires the transport of the HTTP
+	// client to be an *http.Transport, wrapped through WrapTransport if
+	// needed; Clone fails for transports wrapped otherwise, as the wrapper
+	// cannot be rebuilt around the copy.
+	CloneTLSConfig bool
+
 	// OutputCurlString causes the actual request to return an error of type
 	// *OutputStringError. Type asserting the error message will allow
 	// fetching a cURL-compatible string for the operation.
 	//
 	// Note: It is not thread-safe to set this and make concurrent requests
-	// with the same client. Cloning a client will not clone this value.
+	// with the same client. Cloning a client will not clone this value. To
+	// output cURL strings for some requests only, use WithOutputCurlString or
+	// set OutputCurlString on the request instead.
 	OutputCurlString bool
 
-	// SRVLookup enables the client to lookup the host through DNS SRV lookup
+	// OutputPolicy causes the actual request to return an error of type
+	// *OutputPolicyError. Type asserting the error message will allow
+	// fetching the HCL policy stanza required to make the request.
+	//
+	// Like OutputCurlString, it is not thread-safe to set this and make
+	// concurrent requests with the same client; use WithOutputPolicy or set
+	// OutputPolicy on the request instead.
+	OutputPolicy bool
+
+	// SRVLookup enables the client to lookup the host through DNS SRV lookup.
+	// The lookup is skipped when the address includes a port. Records are
+	// selected by priority and weight, and cached for SRVCacheTTL.
 	SRVLookup bool
+
+	// SRVCacheTTL is how long SRV lookup results are reused before being
+	// resolved again. Defaults to DefaultSRVCacheTTL.
+	SRVCacheTTL time.Duration
+
+	// AddressResolver, if set, resolves the configured address to the node
+	// that requests are sent to, taking precedence over SRV lookup. If the
+	// address uses the "consul://<service>" form and no resolver is set, a
+	// ConsulResolver with default settings is used.
+	AddressResolver AddressResolver
+
+	// EnableClientCache enables an in-memory cache of Logical reads. Cached
+	// responses are honored for the lease duration (or TTL hint) of the
+	// secret and are invalidated by writes and deletes made to the same path
+	// through this client.
+	EnableClientCache bool
+
+	// ClientCacheTTL is how long a cached read is kept when the response has
+	// no lease duration or TTL hint. Defaults to DefaultClientCacheTTL.
+	ClientCacheTTL time.Duration
+
+	// ClientCacheMaxEntries is the number of reads the cache holds at most,
+	// beyond which the entries closest to expiry are evicted. Defaults to
+	// DefaultClientCacheMaxEntries.
+	ClientCacheMaxEntries int
+
+	// CoalesceReads enables the coalescing of identical GET and LIST
+	// requests: a request made while an identical one, with the same path,
+	// parameters, token, and namespace, is in flight waits for it and gets a
+	// copy of its response rather than being sent. This reduces the load
+	// when many goroutines read the same secret at once, e.g. at startup.
+	// Shared responses are buffered in memory.
+	CoalesceReads bool
+
+	// ReadYourWrites enables read-your-writes consistency; see
+	// Client.SetReadYourWrites.
+	ReadYourWrites bool
+
+	// WarningHandler, if set, is called with the path and warnings of every
+	// successful JSON response that contains warnings, before the response
+	// is returned. It is called synchronously, so it should not block.
+	WarningHandler WarningHandler
+
+	// MaxResponseBodyBytes, if positive, limits the size of the response
+	// bodies read by the client. Reading past the limit fails with
+	// ErrResponseBodyTooLarge, protecting the process from misbehaving
+	// endpoints returning unbounded responses.
+	MaxResponseBodyBytes int64
+
+	// UserAgent is the User-Agent header sent with requests. If unset,
+	// DefaultUserAgent is used. A User-Agent set in the client's headers
+	// takes precedence over both.
+	UserAgent string
+
+	// JSONDecoding controls how response bodies are decoded. Numbers are
+	// always preserved as json.Number, so that large integers are not
+	// mangled into float64.
+	JSONDecoding JSONDecodingOptions
+
+	// envLookup is the function the environment was last read with.
+	envLookup EnvLookup
+
+	// The TLS file paths last given to ConfigureTLS, checked by Validate and
+	// added as the equivalent flags to cURL strings.
+	tlsCACert     string
+	tlsCAPath     string
+	tlsClientCert string
+	tlsClientKey  string
 }
 
 // TLSConfig contains the parameters needed to configure TLS on the HTTP client
@@ -128,12 +366,57 @@ type TLSConfig struct {
 	// ClientKey is the path to the private key for Vault communication
 	ClientKey string
 
+	// CACertBytes is a PEM-encoded CA cert or bundle to use to verify the
+	// Vault server SSL certificate. It takes precedence over CACert and
+	// CAPath.
+	CACertBytes []byte
+
+	// ClientCertPEM and ClientKeyPEM are the PEM-encoded certificate and
+	// private key for Vault communication, for use when they are held in
+	// memory rather than on disk. They cannot be combined with ClientCert
+	// and ClientKey.
+	ClientCertPEM []byte
+	ClientKeyPEM  []byte
+
+	// ClientKeySigner, if set, is the private key for Vault communication
+	// held in an HSM, TPM, or other store that does not expose the key
+	// material. The matching certificate is given with ClientCert or
+	// ClientCertPEM; ClientKey and ClientKeyPEM must be unset.
+	ClientKeySigner crypto.Signer
+
 	// TLSServerName, if set, is used to set the SNI host when connecting via
 	// TLS.
 	TLSServerName string
 
 	// Insecure enables or disables SSL verification
 	Insecure bool
+
+	// MinVersion and MaxVersion bound the TLS versions used to talk to
+	// Vault, given as "tls10", "tls11", "tls12", or "tls13". The minimum
+	// defaults to TLS 1.2.
+	MinVersion string
+	MaxVersion string
+
+	// CipherSuites restricts the cipher suites used for TLS 1.2 and below to
+	// the given names, e.g. "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384". TLS
+	// 1.3 cipher suites are not configurable.
+	CipherSuites []string
+
+	// PinnedCertFingerprints are hex-encoded SHA-256 fingerprints of
+	// certificates, optionally colon-separated. If set, connections are
+	// rejected unless the server's certificate chain includes a certificate
+	// with one of these fingerprints. This applies in addition to the usual
+	// verification, unless Insecure is set.
+	PinnedCertFingerprints []string
+}
+
+// empty returns whether no settings are set.
+func (t *TLSConfig) empty() bool {
+	return t.CACert == "" && t.CAPath == "" && t.ClientCert == "" && t.ClientKey == "" &&
+		len(t.CACertBytes) == 0 && len(t.ClientCertPEM) == 0 && len(t.ClientKeyPEM) == 0 &&
+		t.ClientKeySigner == nil && t.TLSServerName == "" && !t.Insecure &&
+		t.MinVersion == "" && t.MaxVersion == "" && len(t.CipherSuites) == 0 &&
+		len(t.PinnedCertFingerprints) == 0
 }
 
 // DefaultConfig returns a default configuration for the client. It is
@@ -144,6 +427,12 @@ type TLSConfig struct {
 //
 // If an error is encountered, this will return nil.
 func DefaultConfig() *Config {
+	return DefaultConfigWithEnv(os.Getenv)
+}
+
+// DefaultConfigWithEnv is like DefaultConfig, but reads the environment with
+// the given lookup function instead, e.g. PrefixedEnv("MYAPP_").
+func DefaultConfigWithEnv(lookup EnvLookup) *Config {
 	config := &Config{
 		Address:    "https://127.0.0.1:8200",
 		HttpClient: cleanhttp.DefaultPooledClient(),
@@ -160,7 +449,10 @@ func DefaultConfig() *Config {
 		return config
 	}
 
-	if err := config.ReadEnvironment(); err != nil {
+	// Set before reading the environment so that VAULT_MAX_RETRIES applies
+	config.MaxRetries = 2
+
+	if err := config.ReadEnvironmentWithLookup(lookup); err != nil {
 		config.Error = err
 		return config
 	}
@@ -178,23 +470,89 @@ func DefaultConfig() *Config {
 	}
 
 	config.Backoff = retryablehttp.LinearJitterBackoff
-	config.MaxRetries = 2
 
 	return config
 }
 
+// SetTLSConfig replaces the TLS configuration of the HTTP client's transport
+// with a copy of the given one. It is an escape hatch for settings that
+// TLSConfig does not cover; the HTTP client must use an *http.Transport.
+func (c *Config) SetTLSConfig(tlsConfig *tls.Config) error {
+	c.modifyLock.Lock()
+	defer c.modifyLock.Unlock()
+
+	if tlsConfig == nil {
+		return fmt.Errorf("nil TLS config")
+	}
+	if c.HttpClient == nil {
+		c.HttpClient = DefaultConfig().HttpClient
+	}
+	transport, err := findTransport(c.HttpClient.Transport)
+	if err != nil {
+		return errwrap.Wrapf("failed to set TLS config: {{err}}", err)
+	}
+
+	newConfig := tlsConfig.Clone()
+	if len(newConfig.NextProtos) == 0 && transport.TLSClientConfig != nil {
+		// Keep the protocols negotiated by the HTTP/2 transport, if any
+		newConfig.NextProtos = transport.TLSClientConfig.NextProtos
+	}
+	transport.TLSClientConfig = newConfig
+
+	return nil
+}
+
 // ConfigureTLS takes a set of TLS configurations and applies those to the the
 // HTTP client.
 func (c *Config) ConfigureTLS(t *TLSConfig) error {
 	if c.HttpClient == nil {
 		c.HttpClient = DefaultConfig().HttpClient
 	}
-	clientTLSConfig := c.HttpClient.Transport.(*http.Transport).TLSClientConfig
+	transport, err := findTransport(c.HttpClient.Transport)
+	if err != nil {
+		if t.empty() {
+			return nil
+		}
+		return errwrap.Wrapf("failed to configure TLS: {{err}}", err)
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	clientTLSConfig := transport.TLSClientConfig
+
+	c.tlsCACert = t.CACert
+	c.tlsCAPath = t.CAPath
+	c.tlsClientCert = t.ClientCert
+	c.tlsClientKey = t.ClientKey
 
 	var clientCert tls.Certificate
 	foundClientCert := false
 
 	switch {
+	case t.ClientKeySigner != nil:
+		if t.ClientKey != "" || len(t.ClientKeyPEM) > 0 {
+			return fmt.Errorf("a client key cannot be provided along with a client key signer")
+		}
+		certPEM := t.ClientCertPEM
+		if t.ClientCert != "" {
+			if len(certPEM) > 0 {
+				return fmt.Errorf("client cert must be provided either as a file or as PEM bytes, not both")
+			}
+			var err error
+			if certPEM, err = ioutil.ReadFile(t.ClientCert); err != nil {
+				return err
+			}
+		}
+		var err error
+		clientCert, err = signerCertificate(certPEM, t.ClientKeySigner)
+		if err != nil {
+			return err
+		}
+		foundClientCert = true
+	case (t.ClientCert != "" || t.ClientKey != "") && (len(t.ClientCertPEM) > 0 || len(t.ClientKeyPEM) > 0):
+		return fmt.Errorf("client cert and key must be provided either as files or as PEM bytes, not both")
 	case t.ClientCert != "" && t.ClientKey != "":
 		var err error
 		clientCert, err = tls.LoadX509KeyPair(t.ClientCert, t.ClientKey)
@@ -202,11 +560,24 @@ func (c *Config) ConfigureTLS(t *TLSConfig) error {
 			return err
 		}
 		foundClientCert = true
-	case t.ClientCert != "" || t.ClientKey != "":
+	case len(t.ClientCertPEM) > 0 && len(t.ClientKeyPEM) > 0:
+		var err error
+		clientCert, err = tls.X509KeyPair(t.ClientCertPEM, t.ClientKeyPEM)
+		if err != nil {
+			return err
+		}
+		foundClientCert = true
+	case t.ClientCert != "" || t.ClientKey != "", len(t.ClientCertPEM) > 0 || len(t.ClientKeyPEM) > 0:
 		return fmt.Errorf("both client cert and client key must be provided")
 	}
 
-	if t.CACert != "" || t.CAPath != "" {
+	if len(t.CACertBytes) > 0 {
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(t.CACertBytes) {
+			return fmt.Errorf("no valid CA certificates found in CACertBytes")
+		}
+		clientTLSConfig.RootCAs = certPool
+	} else if t.CACert != "" || t.CAPath != "" {
 		rootConfig := &rootcerts.Config{
 			CAFile: t.CACert,
 			CAPath: t.CAPath,
@@ -233,14 +604,74 @@ func (c *Config) ConfigureTLS(t *TLSConfig) error {
 		clientTLSConfig.ServerName = t.TLSServerName
 	}
 
+	if t.MinVersion != "" {
+		minVersion, ok := tlsutil.TLSLookup[t.MinVersion]
+		if !ok {
+			return fmt.Errorf("invalid TLS min version %q", t.MinVersion)
+		}
+		clientTLSConfig.MinVersion = minVersion
+	}
+
+	if t.MaxVersion != "" {
+		maxVersion, ok := tlsutil.TLSLookup[t.MaxVersion]
+		if !ok {
+			return fmt.Errorf("invalid TLS max version %q", t.MaxVersion)
+		}
+		clientTLSConfig.MaxVersion = maxVersion
+	}
+
+	if clientTLSConfig.MaxVersion != 0 && clientTLSConfig.MinVersion > clientTLSConfig.MaxVersion {
+		return fmt.Errorf("TLS min version %q is greater than max version %q", t.MinVersion, t.MaxVersion)
+	}
+
+	if len(t.PinnedCertFingerprints) > 0 {
+		verify, err := pinnedCertVerifier(t.PinnedCertFingerprints)
+		if err != nil {
+			return err
+		}
+		clientTLSConfig.VerifyPeerCertificate = verify
+	}
+
+	if len(t.CipherSuites) > 0 {
+		cipherSuites, err := tlsutil.ParseCiphers(strings.Join(t.CipherSuites, ","))
+		if err != nil {
+			return err
+		}
+		clientTLSConfig.CipherSuites = cipherSuites
+	}
+
 	return nil
 }
 
+// lookupEnv returns the value of the environment variable, read with the
+// function the environment was last read with.
+func (c *Config) lookupEnv(key string) string {
+	if c.envLookup == nil {
+		return os.Getenv(key)
+	}
+	return c.envLookup(key)
+}
+
 // ReadEnvironment reads configuration information from the environment. If
 // there is an error, no configuration value is updated.
 func (c *Config) ReadEnvironment() error {
+	return c.ReadEnvironmentWithLookup(os.Getenv)
+}
+
+// ReadEnvironmentWithPrefix is like ReadEnvironment, but reads the variables
+// with the given prefix, e.g. MYAPP_VAULT_ADDR for the prefix "MYAPP_".
+func (c *Config) ReadEnvironmentWithPrefix(prefix string) error {
+	return c.ReadEnvironmentWithLookup(PrefixedEnv(prefix))
+}
+
+// ReadEnvironmentWithLookup is like ReadEnvironment, but reads the variables
+// with the given lookup function. The function is also used by NewClient to
+// read VAULT_TOKEN and VAULT_NAMESPACE, and by EnvTokenSource.
+func (c *Config) ReadEnvironmentWithLookup(lookup EnvLookup) error {
 	var envAddress string
+	var envAddresses []string
 	var envAgentAddress string
+	var envProxyURL string
 	var envCACert string
 	var envCAPath string
 	var envClientCert string
@@ -248,76 +679,135 @@ func (c *Config) ReadEnvironment() error {
 	var envClientTimeout time.Duration
 	var envInsecure bool
 	var envTLSServerName string
+	var envTLSMinVersion string
+	var envTLSMaxVersion string
+	var envTLSCipherSuites []string
 	var envMaxRetries *uint64
-	var envSRVLookup bool
+	var envSRVLookup *bool
+	var envMaxIdleConns, envMaxIdleConnsPerHost, envMaxConnsPerHost *int
+	var envIdleConnTimeout, envKeepAlive time.Duration
+	var envDisableKeepAlives *bool
 	var limit *rate.Limiter
 
 	// Parse the environment variables
-	if v := os.Getenv(EnvVaultAddress); v != "" {
+	if v := lookup(EnvVaultAddress); v != "" {
 		envAddress = v
 	}
-	if v := os.Getenv(EnvVaultAgentAddr); v != "" {
+	if v := lookup(EnvVaultAddresses); v != "" {
+		envAddresses = strings.Split(v, ",")
+	}
+	if v := lookup(EnvVaultAgentAddr); v != "" {
 		envAgentAddress = v
-	} else if v := os.Getenv(EnvVaultAgentAddress); v != "" {
+	} else if v := lookup(EnvVaultAgentAddress); v != "" {
 		envAgentAddress = v
 	}
-	if v := os.Getenv(EnvVaultMaxRetries); v != "" {
+	if v := lookup(EnvVaultProxyAddr); v != "" {
+		envProxyURL = v
+	} else if v := lookup(EnvHTTPProxy); v != "" {
+		envProxyURL = v
+	}
+	if v := lookup(EnvVaultMaxRetries); v != "" {
 		maxRetries, err := strconv.ParseUint(v, 10, 32)
 		if err != nil {
 			return err
 		}
 		envMaxRetries = &maxRetries
 	}
-	if v := os.Getenv(EnvVaultCACert); v != "" {
+	if v := lookup(EnvVaultCACert); v != "" {
 		envCACert = v
 	}
-	if v := os.Getenv(EnvVaultCAPath); v != "" {
+	if v := lookup(EnvVaultCAPath); v != "" {
 		envCAPath = v
 	}
-	if v := os.Getenv(EnvVaultClientCert); v != "" {
+	if v := lookup(EnvVaultClientCert); v != "" {
 		envClientCert = v
 	}
-	if v := os.Getenv(EnvVaultClientKey); v != "" {
+	if v := lookup(EnvVaultClientKey); v != "" {
 		envClientKey = v
 	}
-	if v := os.Getenv(EnvRateLimit); v != "" {
+	if v := lookup(EnvRateLimit); v != "" {
 		rateLimit, burstLimit, err := parseRateLimit(v)
 		if err != nil {
 			return err
 		}
 		limit = rate.NewLimiter(rate.Limit(rateLimit), burstLimit)
 	}
-	if t := os.Getenv(EnvVaultClientTimeout); t != "" {
+	if t := lookup(EnvVaultClientTimeout); t != "" {
 		clientTimeout, err := parseutil.ParseDurationSecond(t)
 		if err != nil {
 			return fmt.Errorf("could not parse %q", EnvVaultClientTimeout)
 		}
 		envClientTimeout = clientTimeout
 	}
-	if v := os.Getenv(EnvVaultSkipVerify); v != "" {
+	if v := lookup(EnvVaultSkipVerify); v != "" {
 		var err error
 		envInsecure, err = strconv.ParseBool(v)
 		if err != nil {
 			return fmt.Errorf("could not parse VAULT_SKIP_VERIFY")
 		}
-	} else if v := os.Getenv(EnvVaultInsecure); v != "" {
+	} else if v := lookup(EnvVaultInsecure); v != "" {
 		var err error
 		envInsecure, err = strconv.ParseBool(v)
 		if err != nil {
 			return fmt.Errorf("could not parse VAULT_INSECURE")
 		}
 	}
-	if v := os.Getenv(EnvVaultSRVLookup); v != "" {
-		var err error
-		envSRVLookup, err = strconv.ParseBool(v)
+	if v := lookup(EnvVaultSRVLookup); v != "" {
+		srvLookup, err := strconv.ParseBool(v)
 		if err != nil {
 			return fmt.Errorf("could not parse %s", EnvVaultSRVLookup)
 		}
+		envSRVLookup = &srvLookup
 	}
 
-	if v := os.Getenv(EnvVaultTLSServerName); v != "" {
+	for name, dest := range map[string]**int{
+		EnvVaultMaxIdleConns:        &envMaxIdleConns,
+		EnvVaultMaxIdleConnsPerHost: &envMaxIdleConnsPerHost,
+		EnvVaultMaxConnsPerHost:     &envMaxConnsPerHost,
+	} {
+		if v := lookup(name); v != "" {
+			n, err := strconv.ParseUint(v, 10, 31)
+			if err != nil {
+				return fmt.Errorf("could not parse %s", name)
+			}
+			conns := int(n)
+			*dest = &conns
+		}
+	}
+	if v := lookup(EnvVaultIdleConnTimeout); v != "" {
+		timeout, err := parseutil.ParseDurationSecond(v)
+		if err != nil {
+			return fmt.Errorf("could not parse %s", EnvVaultIdleConnTimeout)
+		}
+		envIdleConnTimeout = timeout
+	}
+	if v := lookup(EnvVaultKeepAlive); v != "" {
+		keepAlive, err := parseutil.ParseDurationSecond(v)
+		if err != nil {
+			return fmt.Errorf("could not parse %s", EnvVaultKeepAlive)
+		}
+		envKeepAlive = keepAlive
+	}
+	if v := lookup(EnvVaultDisableKeepAlives); v != "" {
+		disable, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("could not parse %s", EnvVaultDisableKeepAlives)
+		}
+		envDisableKeepAlives = &disable
+	}
+
+	if v := lookup(EnvVaultTLSServerName); v != "" {
 		envTLSServerName = v
 	}
+	if v := lookup(EnvVaultTLSMinVersion); v != "" {
+		envTLSMinVersion = v
+	}
+	if v := lookup(EnvVaultTLSMaxVersion); v != "" {
+		envTLSMaxVersion = v
+	}
+	if v := lookup(EnvVaultTLSCipherSuites); v != "" {
+		envTLSCipherSuites = strings.Split(v, ",")
+	}
 
 	// Configure the HTTP clients TLS configuration.
 	t := &TLSConfig{
@@ -327,12 +817,19 @@ func (c *Config) ReadEnvironment() error {
 		ClientKey:     envClientKey,
 		TLSServerName: envTLSServerName,
 		Insecure:      envInsecure,
+		MinVersion:    envTLSMinVersion,
+		MaxVersion:    envTLSMaxVersion,
+		CipherSuites:  envTLSCipherSuites,
 	}
 
 	c.modifyLock.Lock()
 	defer c.modifyLock.Unlock()
 
-	c.SRVLookup = envSRVLookup
+	c.envLookup = lookup
+
+	if envSRVLookup != nil {
+		c.SRVLookup = *envSRVLookup
+	}
 	c.Limiter = limit
 
 	if err := c.ConfigureTLS(t); err != nil {
@@ -343,10 +840,18 @@ func (c *Config) ReadEnvironment() error {
 		c.Address = envAddress
 	}
 
+	if len(envAddresses) > 0 {
+		c.Addresses = envAddresses
+	}
+
 	if envAgentAddress != "" {
 		c.AgentAddress = envAgentAddress
 	}
 
+	if envProxyURL != "" {
+		c.ProxyURL = envProxyURL
+	}
+
 	if envMaxRetries != nil {
 		c.MaxRetries = int(*envMaxRetries)
 	}
@@ -355,6 +860,213 @@ func (c *Config) ReadEnvironment() error {
 		c.Timeout = envClientTimeout
 	}
 
+	if envMaxIdleConns != nil {
+		c.MaxIdleConns = *envMaxIdleConns
+	}
+	if envMaxIdleConnsPerHost != nil {
+		c.MaxIdleConnsPerHost = *envMaxIdleConnsPerHost
+	}
+	if envMaxConnsPerHost != nil {
+		c.MaxConnsPerHost = *envMaxConnsPerHost
+	}
+	if envIdleConnTimeout != 0 {
+		c.IdleConnTimeout = envIdleConnTimeout
+	}
+	if envKeepAlive != 0 {
+		c.KeepAlive = envKeepAlive
+	}
+	if envDisableKeepAlives != nil {
+		c.DisableKeepAlives = *envDisableKeepAlives
+	}
+
+	return nil
+}
+
+// parseAddress validates a Vault address and returns the URL that requests
+// are sent to. For "unix://" addresses, the path of the socket is returned as
+// well.
+func parseAddress(address string) (*url.URL, string, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch u.Scheme {
+	case "http", "https", "consul":
+	case "unix":
+		// Since the address points to a unix domain socket, the scheme in the
+		// *URL would be set to `unix`. The *URL in the client is expected to
+		// be pointing to the protocol used in the application layer and not to
+		// the transport layer. Hence, setting the fields accordingly.
+		socket := strings.TrimPrefix(address, "unix://")
+		u.Scheme = "http"
+		u.Host = socket
+		u.Path = ""
+		return u, socket, nil
+	case "":
+		// Unset addresses are tolerated so that they can be set later with
+		// SetAddress, as are bare hosts for backwards compatibility.
+	default:
+		return nil, "", fmt.Errorf("unsupported address scheme %q", u.Scheme)
+	}
+
+	return u, "", nil
+}
+
+// configureDialer points the transport's dialer at the given unix socket, or,
+// if socket is empty, at DialContext or a default dialer.
+func (c *Config) configureDialer(socket string) error {
+	transport, err := findTransport(c.HttpClient.Transport)
+	if err != nil {
+		return errwrap.Wrapf("failed to configure dialer: {{err}}", err)
+	}
+
+	switch dial := c.DialContext; {
+	case socket != "" && dial != nil:
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dial(ctx, "unix", socket)
+		}
+	case socket != "":
+		transport.DialContext = func(context.Context, string, string) (net.Conn, error) {
+			return net.Dial("unix", socket)
+		}
+	case dial != nil:
+		transport.DialContext = dial
+	default:
+		keepAlive := c.KeepAlive
+		if keepAlive == 0 {
+			keepAlive = 30 * time.Second
+		}
+		transport.DialContext = (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: keepAlive,
+			DualStack: true,
+		}).DialContext
+	}
+
+	return nil
+}
+
+// poolConfigured returns whether any of the connection pool settings are set.
+func (c *Config) poolConfigured() bool {
+	return c.MaxIdleConns != 0 || c.MaxIdleConnsPerHost != 0 || c.MaxConnsPerHost != 0 ||
+		c.IdleConnTimeout != 0 || c.DisableKeepAlives
+}
+
+// configurePool applies the connection pool settings to the transport.
+func (c *Config) configurePool() error {
+	transport, err := findTransport(c.HttpClient.Transport)
+	if err != nil {
+		return errwrap.Wrapf("failed to configure connection pool: {{err}}", err)
+	}
+
+	if c.MaxIdleConns != 0 {
+		transport.MaxIdleConns = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	if c.MaxConnsPerHost != 0 {
+		transport.MaxConnsPerHost = c.MaxConnsPerHost
+	}
+	if c.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = c.IdleConnTimeout
+	}
+	if c.DisableKeepAlives {
+		transport.DisableKeepAlives = true
+	}
+	return nil
+}
+
+// signerCertificate pairs the PEM-encoded certificate chain with a signer
+// holding the private key of its leaf certificate.
+func signerCertificate(certPEM []byte, signer crypto.Signer) (tls.Certificate, error) {
+	var cert tls.Certificate
+	for {
+		var block *pem.Block
+		block, certPEM = pem.Decode(certPEM)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			cert.Certificate = append(cert.Certificate, block.Bytes)
+		}
+	}
+	if len(cert.Certificate) == 0 {
+		return cert, fmt.Errorf("no client certificate found to use with the client key signer")
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return cert, errwrap.Wrapf("failed to parse client certificate: {{err}}", err)
+	}
+
+	type publicKey interface {
+		Equal(crypto.PublicKey) bool
+	}
+	if pub, ok := signer.Public().(publicKey); ok && !pub.Equal(leaf.PublicKey) {
+		return cert, fmt.Errorf("client key signer does not match the client certificate")
+	}
+
+	cert.Leaf = leaf
+	cert.PrivateKey = signer
+	return cert, nil
+}
+
+// pinnedCertVerifier returns a VerifyPeerCertificate function that accepts a
+// connection only if the server's leaf certificate, or a certificate of a
+// chain verified up to a trusted root, matches one of the given SHA-256
+// fingerprints. Intermediates sent by the server but left out of the
+// verified chains are not considered, since anyone can append them; with
+// verification disabled, only the leaf certificate can match.
+func pinnedCertVerifier(fingerprints []string) (func([][]byte, [][]*x509.Certificate) error, error) {
+	pinned := make(map[string]bool, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		fingerprint = strings.ToLower(strings.Replace(strings.TrimSpace(fingerprint), ":", "", -1))
+		if b, err := hex.DecodeString(fingerprint); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("invalid SHA-256 certificate fingerprint %q", fingerprint)
+		}
+		pinned[fingerprint] = true
+	}
+
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(rawCerts) > 0 {
+			sum := sha256.Sum256(rawCerts[0])
+			if pinned[hex.EncodeToString(sum[:])] {
+				return nil
+			}
+		}
+		for _, chain := range verifiedChains {
+			for _, cert := range chain {
+				sum := sha256.Sum256(cert.Raw)
+				if pinned[hex.EncodeToString(sum[:])] {
+					return nil
+				}
+			}
+		}
+		return errors.New("server certificate chain does not include a pinned certificate")
+	}, nil
+}
+
+// configureProxy points the transport's Proxy function at ProxyURL. HTTP,
+// HTTPS, and SOCKS5 proxies are supported.
+func (c *Config) configureProxy() error {
+	proxyURL, err := url.Parse(c.ProxyURL)
+	if err != nil {
+		return errwrap.Wrapf("failed to parse proxy URL: {{err}}", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	}
+
+	transport, err := findTransport(c.HttpClient.Transport)
+	if err != nil {
+		return errwrap.Wrapf("failed to configure proxy: {{err}}", err)
+	}
+	transport.Proxy = http.ProxyURL(proxyURL)
+
 	return nil
 }
 
@@ -377,12 +1089,83 @@ func parseRateLimit(val string) (rate float64, burst int, err error) {
 type Client struct {
 	modifyLock         sync.RWMutex
 	addr               *url.URL
+	addrs              []*url.URL
 	config             *Config
 	token              string
 	headers            http.Header
 	wrappingLookupFunc WrappingLookupFunc
 	mfaCreds           []string
 	policyOverride     bool
+	cache              *clientCache
+	srv                *srvResolver
+	resolver           AddressResolver
+
+	// socket is the path of the unix socket the client talks to, if any.
+	socket string
+
+	admission        *admissionController
+	breaker          *circuitBreaker
+	reads            *readGroup
+	adaptiveLimiter  *adaptiveRateLimiter
+	priority         RequestPriority
+	deprecations     *deprecationTracker
+	outputCurlString bool
+	outputPolicy     bool
+
+	// noTimeout disables the client's timeout, for long-lived streaming
+	// requests that are cancelled through their context instead.
+	noTimeout bool
+
+	replicationStateStore *replicationStateStore
+
+	// closer is shared by the client and its shallow copies.
+	closer *clientCloser
+}
+
+// ErrClientClosed is returned for requests made with a closed client.
+var ErrClientClosed = errors.New("client is closed")
+
+// LimiterWaitError is returned when a request could not obtain a token from
+// the client's rate limiter, because its context was cancelled or its
+// deadline would pass before a token became available. It matches
+// ErrRateLimited with errors.Is, and unwraps to the limiter's error, which is
+// the context's error for cancelled contexts.
+type LimiterWaitError struct {
+	Err  error
+	Wait time.Duration
+}
+
+func (e *LimiterWaitError) Error() string {
+	return fmt.Sprintf("client rate limiter: %s (waited %s)", e.Err, e.Wait)
+}
+
+// Unwrap returns the limiter's error.
+func (e *LimiterWaitError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrRateLimited.
+func (e *LimiterWaitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// cancelOnCloseBody cancels the context of a request when its response body
+// is closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancelFunc context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancelFunc()
+	return err
+}
+
+// clientCloser signals that a client was closed.
+type clientCloser struct {
+	once   sync.Once
+	doneCh chan struct{}
 }
 
 // NewClient returns a new client for the given configuration.
@@ -392,8 +1175,35 @@ type Client struct {
 //
 // If the environment variable `VAULT_TOKEN` is present, the token will be
 // automatically added to the client. Otherwise, you must manually call
-// `SetToken()`.
+// `SetToken()`. If TokenSources are configured, they are consulted instead.
 func NewClient(c *Config) (*Client, error) {
+	return newClientWithSources(context.Background(), c)
+}
+
+// newClientWithSources creates a client and sets its token from the token
+// sources of the configuration, if any.
+func newClientWithSources(ctx context.Context, c *Config) (*Client, error) {
+	client, err := newClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	client.config.modifyLock.RLock()
+	sources := client.config.TokenSources
+	client.config.modifyLock.RUnlock()
+
+	if len(sources) > 0 {
+		token, err := client.tokenFromSources(ctx, sources)
+		if err != nil {
+			return nil, errwrap.Wrapf("error obtaining token: {{err}}", err)
+		}
+		client.SetToken(token)
+	}
+
+	return client, nil
+}
+
+func newClient(c *Config) (*Client, error) {
 	def := DefaultConfig()
 	if def == nil {
 		return nil, fmt.Errorf("could not create/read default configuration")
@@ -416,46 +1226,98 @@ func NewClient(c *Config) (*Client, error) {
 		c.HttpClient.Transport = def.HttpClient.Transport
 	}
 
+	if c.ProxyURL != "" {
+		if err := c.configureProxy(); err != nil {
+			return nil, err
+		}
+	}
+
+	addrs, err := parseAddresses(c.Addresses)
+	if err != nil {
+		return nil, err
+	}
+
 	address := c.Address
-	if c.AgentAddress != "" {
+	switch {
+	case c.AgentAddress != "":
 		address = c.AgentAddress
+		addrs = nil
+	case len(addrs) > 0:
+		address = addrs[0].String()
 	}
 
-	u, err := url.Parse(address)
+	u, socket, err := parseAddress(address)
 	if err != nil {
 		return nil, err
 	}
 
-	if strings.HasPrefix(address, "unix://") {
-		socket := strings.TrimPrefix(address, "unix://")
-		transport := c.HttpClient.Transport.(*http.Transport)
-		transport.DialContext = func(context.Context, string, string) (net.Conn, error) {
-			return net.Dial("unix", socket)
+	if socket != "" || c.DialContext != nil || c.KeepAlive != 0 {
+		if err := c.configureDialer(socket); err != nil {
+			return nil, err
 		}
+	}
 
-		// Since the address points to a unix domain socket, the scheme in the
-		// *URL would be set to `unix`. The *URL in the client is expected to
-		// be pointing to the protocol used in the application layer and not to
-		// the transport layer. Hence, setting the fields accordingly.
-		u.Scheme = "http"
-		u.Host = socket
-		u.Path = ""
+	if c.poolConfigured() {
+		if err := c.configurePool(); err != nil {
+			return nil, err
+		}
 	}
 
 	client := &Client{
 		addr:    u,
+		socket:  socket,
+		addrs:   addrs,
 		config:  c,
 		headers: make(http.Header),
+		srv:     newSRVResolver(c.SRVCacheTTL),
+
+		deprecations: newDeprecationTracker(),
+		closer:       &clientCloser{doneCh: make(chan struct{})},
+	}
+
+	client.resolver = c.AddressResolver
+	if client.resolver == nil && u.Scheme == "consul" {
+		client.resolver = NewConsulResolver(nil)
+	}
+
+	if c.EnableClientCache {
+		client.cache = newClientCache(c.ClientCacheTTL, c.ClientCacheMaxEntries)
+	}
+
+	if c.ReadYourWrites {
+		client.replicationStateStore = &replicationStateStore{}
+	}
+
+	if c.Admission != nil {
+		client.admission = newAdmissionController(c.Admission)
+	}
+
+	if c.Breaker != nil {
+		client.breaker = newCircuitBreaker(c.Breaker)
+	}
+
+	if c.CoalesceReads {
+		client.reads = newReadGroup()
+	}
+
+	if c.AdaptiveRateLimit != nil {
+		client.adaptiveLimiter = newAdaptiveRateLimiter(c.AdaptiveRateLimit)
 	}
 
 	// Add the VaultRequest SSRF protection header
 	client.headers[consts.RequestHeaderName] = []string{"true"}
 
-	if token := os.Getenv(EnvVaultToken); token != "" {
-		client.token = token
+	if len(c.TokenSources) == 0 {
+		if token := c.lookupEnv(EnvVaultToken); token != "" {
+			client.token = token
+		}
 	}
 
-	if namespace := os.Getenv(EnvVaultNamespace); namespace != "" {
+	namespace := c.Namespace
+	if v := c.lookupEnv(EnvVaultNamespace); v != "" {
+		namespace = v
+	}
+	if namespace != "" {
 		client.setNamespace(namespace)
 	}
 
@@ -463,18 +1325,40 @@ func NewClient(c *Config) (*Client, error) {
 }
 
 // Sets the address of Vault in the client. The format of address should be
-// "<Scheme>://<Host>:<Port>". Setting this on a client will override the
-// value of VAULT_ADDR environment variable.
+// "<Scheme>://<Host>:<Port>", or "unix://<path>" for a unix socket. Setting
+// this on a client will override the value of VAULT_ADDR environment
+// variable. The client's configuration is updated to match, and idle pooled
+// connections to the previous address are closed.
 func (c *Client) SetAddress(addr string) error {
 	c.modifyLock.Lock()
 	defer c.modifyLock.Unlock()
+	c.config.modifyLock.Lock()
+	defer c.config.modifyLock.Unlock()
 
-	parsedAddr, err := url.Parse(addr)
+	parsedAddr, socket, err := parseAddress(addr)
 	if err != nil {
 		return errwrap.Wrapf("failed to set address: {{err}}", err)
 	}
 
+	if socket != "" || c.socket != "" {
+		if err := c.config.configureDialer(socket); err != nil {
+			return errwrap.Wrapf("failed to set address: {{err}}", err)
+		}
+	}
+
+	changed := c.addr == nil || c.addr.String() != parsedAddr.String()
 	c.addr = parsedAddr
+	c.socket = socket
+	c.config.Address = addr
+
+	if c.resolver == nil && parsedAddr.Scheme == "consul" {
+		c.resolver = NewConsulResolver(nil)
+	}
+
+	if changed && c.config.HttpClient != nil {
+		c.config.HttpClient.CloseIdleConnections()
+	}
+
 	return nil
 }
 
@@ -498,6 +1382,37 @@ func (c *Client) SetLimiter(rateLimit float64, burst int) {
 	c.config.Limiter = rate.NewLimiter(rate.Limit(rateLimit), burst)
 }
 
+// SetNamespaceLimiter sets a rate limiter used for requests made against the
+// given namespace in place of the client-wide limiter.
+// This method is thread-safe.
+func (c *Client) SetNamespaceLimiter(namespace string, rateLimit float64, burst int) {
+	c.modifyLock.RLock()
+	c.config.modifyLock.Lock()
+	defer c.config.modifyLock.Unlock()
+	c.modifyLock.RUnlock()
+
+	if c.config.NamespaceLimiters == nil {
+		c.config.NamespaceLimiters = make(map[string]*rate.Limiter)
+	}
+	c.config.NamespaceLimiters[normalizeNamespace(namespace)] = rate.NewLimiter(rate.Limit(rateLimit), burst)
+}
+
+// SetPathLimiter sets a rate limiter used for requests to paths starting
+// with the given prefix, relative to /v1/, in place of the namespace and
+// client-wide limiters.
+// This method is thread-safe.
+func (c *Client) SetPathLimiter(prefix string, rateLimit float64, burst int) {
+	c.modifyLock.RLock()
+	c.config.modifyLock.Lock()
+	defer c.config.modifyLock.Unlock()
+	c.modifyLock.RUnlock()
+
+	if c.config.PathLimiters == nil {
+		c.config.PathLimiters = make(map[string]*rate.Limiter)
+	}
+	c.config.PathLimiters[strings.TrimPrefix(prefix, "/")] = rate.NewLimiter(rate.Limit(rateLimit), burst)
+}
+
 // SetMaxRetries sets the number of retries that will be used in the case of certain errors
 func (c *Client) SetMaxRetries(retries int) {
 	c.modifyLock.RLock()
@@ -546,6 +1461,43 @@ func (c *Client) SetOutputCurlString(curl bool) {
 	c.config.OutputCurlString = curl
 }
 
+// WithOutputCurlString returns a copy of the client whose requests return an
+// *OutputStringError holding the equivalent cURL command instead of being
+// sent. Unlike SetOutputCurlString, this does not affect other users of the
+// client, and LastOutputStringError is not set.
+func (c *Client) WithOutputCurlString() *Client {
+	c2 := c.shallowCopy()
+	c2.outputCurlString = true
+	return c2
+}
+
+func (c *Client) OutputPolicy() bool {
+	c.modifyLock.RLock()
+	c.config.modifyLock.RLock()
+	defer c.config.modifyLock.RUnlock()
+	c.modifyLock.RUnlock()
+
+	return c.config.OutputPolicy
+}
+
+func (c *Client) SetOutputPolicy(isSet bool) {
+	c.modifyLock.RLock()
+	c.config.modifyLock.Lock()
+	defer c.config.modifyLock.Unlock()
+	c.modifyLock.RUnlock()
+
+	c.config.OutputPolicy = isSet
+}
+
+// WithOutputPolicy returns a copy of the client whose requests return an
+// *OutputPolicyError holding the policy required for the request instead of
+// being sent. LastOutputPolicyError is not set.
+func (c *Client) WithOutputPolicy() *Client {
+	c2 := c.shallowCopy()
+	c2.outputPolicy = true
+	return c2
+}
+
 // CurrentWrappingLookupFunc sets a lookup function that returns desired wrap TTLs
 // for a given operation and path
 func (c *Client) CurrentWrappingLookupFunc() WrappingLookupFunc {
@@ -589,6 +1541,109 @@ func (c *Client) setNamespace(namespace string) {
 	c.headers.Set(consts.NamespaceHeaderName, namespace)
 }
 
+// ClearNamespace removes the namespace header if set.
+func (c *Client) ClearNamespace() {
+	c.modifyLock.Lock()
+	defer c.modifyLock.Unlock()
+
+	if c.headers != nil {
+		c.headers.Del(consts.NamespaceHeaderName)
+	}
+}
+
+// Namespace returns the namespace requests are made against, or the empty
+// string if none is set.
+func (c *Client) Namespace() string {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+
+	if c.headers == nil {
+		return ""
+	}
+	return c.headers.Get(consts.NamespaceHeaderName)
+}
+
+// WithNamespace returns a copy of the client that makes requests against the
+// given namespace. Unlike Clone, the copy is cheap: it shares the client's
+// configuration, HTTP client, and cache, and starts with the client's token
+// and headers. Changing the namespace of the copy does not affect the
+// original client, so a single client can serve many namespaces
+// concurrently.
+func (c *Client) WithNamespace(namespace string) *Client {
+	c2 := c.shallowCopy()
+	if namespace == "" {
+		c2.headers.Del(consts.NamespaceHeaderName)
+	} else {
+		c2.setNamespace(namespace)
+	}
+	return c2
+}
+
+// withoutTimeout returns a copy of the client whose requests are not bounded
+// by the client's timeout, for streaming requests.
+func (c *Client) withoutTimeout() *Client {
+	c2 := c.shallowCopy()
+	c2.noTimeout = true
+	return c2
+}
+
+// shallowCopy returns a new client sharing the configuration of this one, with
+// its own copy of the headers.
+func (c *Client) shallowCopy() *Client {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+
+	c2 := &Client{
+		addr:               c.addr,
+		addrs:              c.addrs,
+		config:             c.config,
+		token:              c.token,
+		headers:            make(http.Header, len(c.headers)),
+		wrappingLookupFunc: c.wrappingLookupFunc,
+		mfaCreds:           c.mfaCreds,
+		policyOverride:     c.policyOverride,
+		cache:              c.cache,
+		srv:                c.srv,
+		resolver:           c.resolver,
+		socket:             c.socket,
+		admission:          c.admission,
+		breaker:            c.breaker,
+		reads:              c.reads,
+		adaptiveLimiter:    c.adaptiveLimiter,
+		priority:           c.priority,
+		deprecations:       c.deprecations,
+		outputCurlString:   c.outputCurlString,
+		outputPolicy:       c.outputPolicy,
+		noTimeout:          c.noTimeout,
+
+		replicationStateStore: c.replicationStateStore,
+		closer:                c.closer,
+	}
+	for k, v := range c.headers {
+		c2.headers[k] = append([]string(nil), v...)
+	}
+	return c2
+}
+
+// longestPrefixLimiter returns the limiter of the longest prefix of path in
+// limiters, or nil if there is none.
+func longestPrefixLimiter(limiters map[string]*rate.Limiter, path string) *rate.Limiter {
+	var limiter *rate.Limiter
+	longest := -1
+	for prefix, l := range limiters {
+		if len(prefix) > longest && strings.HasPrefix(path, prefix) {
+			limiter, longest = l, len(prefix)
+		}
+	}
+	return limiter
+}
+
+// normalizeNamespace strips surrounding slashes so that "ns1", "/ns1" and
+// "ns1/" all refer to the same namespace.
+func normalizeNamespace(namespace string) string {
+	return strings.Trim(namespace, "/")
+}
+
 // Token returns the access token being used by this client. It will
 // return the empty string if there is no token set.
 func (c *Client) Token() string {
@@ -661,32 +1716,168 @@ func (c *Client) SetBackoff(backoff retryablehttp.Backoff) {
 	c.config.Backoff = backoff
 }
 
+// Close stops the background goroutines started through the client, such as
+// lifetime watchers, seal state watchers, credential rotators, event
+// subscriptions and log monitors, and closes the idle connections of its
+// HTTP client. Requests made after Close fail with ErrClientClosed. Close
+// affects the copies of the client made with its WithX methods, but not its
+// clones. It is safe to call more than once.
+func (c *Client) Close() error {
+	c.closer.once.Do(func() {
+		close(c.closer.doneCh)
+	})
+
+	c.modifyLock.RLock()
+	c.config.modifyLock.RLock()
+	httpClient := c.config.HttpClient
+	c.config.modifyLock.RUnlock()
+	c.modifyLock.RUnlock()
+
+	if httpClient != nil {
+		httpClient.CloseIdleConnections()
+	}
+	return nil
+}
+
+// closedCh returns a channel that is closed when the client is closed.
+func (c *Client) closedCh() <-chan struct{} {
+	return c.closer.doneCh
+}
+
+// withCloseContext returns a context that is also cancelled when the client
+// is closed, for use by long-running operations.
+func (c *Client) withCloseContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancelFunc := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-c.closedCh():
+			cancelFunc()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancelFunc
+}
+
 // Clone creates a new client with the same configuration. Note that the same
-// underlying http.Client is used; modifying the client from more than one
-// goroutine at once may not be safe, so modify the client as needed and then
-// clone.
+// underlying http.Client is used unless CloneTLSConfig is set; modifying the
+// client from more than one goroutine at once may not be safe, so modify the
+// client as needed and then clone.
 //
-// Also, only the client's config is currently copied; this means items not in
-// the api.Config struct, such as policy override and wrapping function
-// behavior, must currently then be set as desired on the new client.
+// By default only the client's config is copied; the token, headers
+// (including the namespace), policy override, and wrapping function behavior
+// are only carried over when CloneToken and CloneHeaders are set in the
+// config.
 func (c *Client) Clone() (*Client, error) {
 	c.modifyLock.RLock()
 	c.config.modifyLock.RLock()
 	config := c.config
+	addr := c.addr
+	token := c.token
+	headers := c.headers
+	policyOverride := c.policyOverride
+	wrappingLookupFunc := c.wrappingLookupFunc
 	c.modifyLock.RUnlock()
 
 	newConfig := &Config{
 		Address:    config.Address,
+		Addresses:  config.Addresses,
+		ProxyURL:   config.ProxyURL,
 		HttpClient: config.HttpClient,
 		MaxRetries: config.MaxRetries,
 		Timeout:    config.Timeout,
 		Backoff:    config.Backoff,
 		CheckRetry: config.CheckRetry,
 		Limiter:    config.Limiter,
+		SRVLookup:  config.SRVLookup,
+
+		DialContext:           config.DialContext,
+		WrapTransport:         config.WrapTransport,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		KeepAlive:             config.KeepAlive,
+		DisableKeepAlives:     config.DisableKeepAlives,
+		SRVCacheTTL:           config.SRVCacheTTL,
+		AddressResolver:       config.AddressResolver,
+		EnableClientCache:     config.EnableClientCache,
+		ClientCacheTTL:        config.ClientCacheTTL,
+		ClientCacheMaxEntries: config.ClientCacheMaxEntries,
+		CoalesceReads:         config.CoalesceReads,
+		ReadYourWrites:        config.ReadYourWrites,
+		WarningHandler:        config.WarningHandler,
+		MaxResponseBodyBytes:  config.MaxResponseBodyBytes,
+		JSONDecoding:          config.JSONDecoding,
+		UserAgent:             config.UserAgent,
+		Namespace:             config.Namespace,
+		Logger:                config.Logger,
+		MaxRetryDuration:      config.MaxRetryDuration,
+		RetryWrites:           config.RetryWrites,
+		envLookup:             config.envLookup,
+		Admission:             config.Admission,
+		Breaker:               config.Breaker,
+		AdaptiveRateLimit:     config.AdaptiveRateLimit,
+		CloneHeaders:          config.CloneHeaders,
+		CloneToken:            config.CloneToken,
+		CloneTLSConfig:        config.CloneTLSConfig,
+
+		tlsCACert:     config.tlsCACert,
+		tlsCAPath:     config.tlsCAPath,
+		tlsClientCert: config.tlsClientCert,
+		tlsClientKey:  config.tlsClientKey,
+	}
+	if config.NamespaceLimiters != nil {
+		newConfig.NamespaceLimiters = make(map[string]*rate.Limiter, len(config.NamespaceLimiters))
+		for k, v := range config.NamespaceLimiters {
+			newConfig.NamespaceLimiters[k] = v
+		}
+	}
+	if config.PathLimiters != nil {
+		newConfig.PathLimiters = make(map[string]*rate.Limiter, len(config.PathLimiters))
+		for k, v := range config.PathLimiters {
+			newConfig.PathLimiters[k] = v
+		}
+	}
+	if config.CloneTLSConfig && config.HttpClient != nil {
+		transport, ok := config.HttpClient.Transport.(*http.Transport)
+		if !ok {
+			config.modifyLock.RUnlock()
+			if _, err := findTransport(config.HttpClient.Transport); err != nil {
+				return nil, errwrap.Wrapf("cannot clone TLS configuration: {{err}}", err)
+			}
+			return nil, fmt.Errorf("cannot clone TLS configuration of wrapped transport %T; configure the *http.Transport and set WrapTransport to wrap it instead", config.HttpClient.Transport)
+		}
+		httpClient := *config.HttpClient
+		httpClient.Transport = transport.Clone()
+		newConfig.HttpClient = &httpClient
 	}
 	config.modifyLock.RUnlock()
 
-	return NewClient(newConfig)
+	client, err := NewClient(newConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// A client with HA addresses starts with the first one; the clone sticks
+	// to the node this client failed over to instead.
+	if len(client.addrs) > 0 {
+		client.addr = addr
+	}
+
+	if newConfig.CloneToken {
+		client.SetToken(token)
+	}
+	if newConfig.CloneHeaders {
+		newHeaders := make(http.Header, len(headers))
+		for k, v := range headers {
+			newHeaders[k] = append([]string(nil), v...)
+		}
+		client.SetHeaders(newHeaders)
+		client.SetPolicyOverride(policyOverride)
+		client.SetWrappingLookupFunc(wrappingLookupFunc)
+	}
+
+	return client, nil
 }
 
 // SetPolicyOverride sets whether requests should be sent with the policy
@@ -709,16 +1900,29 @@ func (c *Client) NewRequest(method, requestPath string) *Request {
 	mfaCreds := c.mfaCreds
 	wrappingLookupFunc := c.wrappingLookupFunc
 	policyOverride := c.policyOverride
+	srv := c.srv
+	resolver := c.resolver
+	priority := c.priority
+	outputCurlString := c.outputCurlString
+	outputPolicy := c.outputPolicy
+	c.config.modifyLock.RLock()
+	srvLookup := c.config.SRVLookup
+	c.config.modifyLock.RUnlock()
 	c.modifyLock.RUnlock()
 
+	var scheme = addr.Scheme
 	var host = addr.Host
+	var hostHeader = addr.Host
+
+	// Addresses with a resolver are resolved once the request is sent, with
+	// its context.
+	//
 	// if SRV records exist (see https://tools.ietf.org/html/draft-andrews-http-srv-02), lookup the SRV
-	// record and take the highest match; this is not designed for high-availability, just discovery
+	// record and pick one by priority and weight; this is not designed for high-availability, just discovery
 	// Internet Draft specifies that the SRV record is ignored if a port is given
-	if addr.Port() == "" && c.config.SRVLookup {
-		_, addrs, err := net.LookupSRV("http", "tcp", addr.Hostname())
-		if err == nil && len(addrs) > 0 {
-			host = fmt.Sprintf("%s:%d", addrs[0].Target, addrs[0].Port)
+	if resolver == nil && addr.Port() == "" && srvLookup && srv != nil {
+		if srvHost := srv.resolve(addr.Hostname()); srvHost != "" {
+			host = srvHost
 		}
 	}
 
@@ -726,13 +1930,17 @@ func (c *Client) NewRequest(method, requestPath string) *Request {
 		Method: method,
 		URL: &url.URL{
 			User:   addr.User,
-			Scheme: addr.Scheme,
+			Scheme: scheme,
 			Host:   host,
 			Path:   path.Join(addr.Path, requestPath),
 		},
-		Host:        addr.Host,
+		Host:        hostHeader,
 		ClientToken: token,
 		Params:      make(map[string][]string),
+		Priority:    priority,
+
+		OutputCurlString: outputCurlString,
+		OutputPolicy:     outputPolicy,
 	}
 
 	var lookupPath string
@@ -770,23 +1978,106 @@ func (c *Client) RawRequest(r *Request) (*Response, error) {
 // a Vault server not configured with this client. This is an advanced operation
 // that generally won't need to be called externally.
 func (c *Client) RawRequestWithContext(ctx context.Context, r *Request) (*Response, error) {
+	c.modifyLock.RLock()
+	reads := c.reads
+	c.modifyLock.RUnlock()
+
+	if reads != nil && r.coalescable() && !c.OutputCurlString() && !c.OutputPolicy() {
+		return reads.do(ctx, readKey(r), func() (*Response, error) {
+			return c.rawRequestWithContext(ctx, r)
+		})
+	}
+	return c.rawRequestWithContext(ctx, r)
+}
+
+func (c *Client) rawRequestWithContext(ctx context.Context, r *Request) (*Response, error) {
+	start := time.Now()
+
+	select {
+	case <-c.closedCh():
+		return nil, ErrClientClosed
+	default:
+	}
+
 	c.modifyLock.RLock()
 	token := c.token
+	addr := c.addr
+	numAddrs := len(c.addrs)
+	resolver := c.resolver
+	admission := c.admission
+	breaker := c.breaker
+	adaptiveLimiter := c.adaptiveLimiter
+	deprecations := c.deprecations
+	stateStore := c.replicationStateStore
+	noTimeout := c.noTimeout
 
 	c.config.modifyLock.RLock()
 	limiter := c.config.Limiter
+	if len(c.config.NamespaceLimiters) > 0 {
+		if nsLimiter, ok := c.config.NamespaceLimiters[normalizeNamespace(r.Headers.Get(consts.NamespaceHeaderName))]; ok {
+			limiter = nsLimiter
+		}
+	}
+	if pathLimiter := longestPrefixLimiter(c.config.PathLimiters, strings.TrimPrefix(r.URL.Path, "/v1/")); pathLimiter != nil {
+		limiter = pathLimiter
+	}
 	maxRetries := c.config.MaxRetries
 	checkRetry := c.config.CheckRetry
 	backoff := c.config.Backoff
+	maxRetryDuration := c.config.MaxRetryDuration
+	retryWrites := c.config.RetryWrites
+	logger := c.config.Logger
 	httpClient := c.config.HttpClient
+	wrapTransport := c.config.WrapTransport
 	timeout := c.config.Timeout
 	outputCurlString := c.config.OutputCurlString
+	outputPolicy := c.config.OutputPolicy
+	warningHandler := c.config.WarningHandler
+	maxBodyBytes := c.config.MaxResponseBodyBytes
+	jsonDecoding := c.config.JSONDecoding
+	userAgent := c.config.UserAgent
+	curlString := &OutputStringError{
+		ClientCACert: c.config.tlsCACert,
+		ClientCAPath: c.config.tlsCAPath,
+		ClientCert:   c.config.tlsClientCert,
+		ClientKey:    c.config.tlsClientKey,
+	}
+	if transport, err := findTransport(httpClient.Transport); err == nil && transport.TLSClientConfig != nil {
+		curlString.TLSSkipVerify = transport.TLSClientConfig.InsecureSkipVerify
+	}
 	c.config.modifyLock.RUnlock()
+	httpClient = c.config.wrapped.httpClient(httpClient, wrapTransport)
 
 	c.modifyLock.RUnlock()
 
+	if breaker != nil {
+		if err := breaker.allow(r.URL.Host); err != nil {
+			return nil, err
+		}
+	}
+
+	if admission != nil {
+		release, err := admission.admit(ctx, r.Priority)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	var limiterWait time.Duration
+	if adaptiveLimiter != nil {
+		waitStart := time.Now()
+		if err := adaptiveLimiter.wait(ctx, limiter); err != nil {
+			return nil, &LimiterWaitError{Err: err, Wait: time.Since(waitStart)}
+		}
+		limiterWait = time.Since(waitStart)
+	}
 	if limiter != nil {
-		limiter.Wait(ctx)
+		waitStart := time.Now()
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, &LimiterWaitError{Err: err, Wait: limiterWait + time.Since(waitStart)}
+		}
+		limiterWait += time.Since(waitStart)
 	}
 
 	// Sanity check the token before potentially erroring from the API
@@ -797,7 +2088,23 @@ func (c *Client) RawRequestWithContext(ctx context.Context, r *Request) (*Respon
 		return nil, fmt.Errorf("configured Vault token contains non-printable characters and cannot be used")
 	}
 
+	if stateStore != nil {
+		stateStore.requireState(r)
+	}
+
+	requestID := r.Headers.Get(HeaderRequestID)
+	if requestID == "" {
+		requestID = generateRequestID()
+	}
+
+	if resolver != nil && r.URL.Scheme == addr.Scheme && r.URL.Host == addr.Host {
+		if err := resolveRequestAddress(ctx, resolver, addr, r); err != nil {
+			return nil, err
+		}
+	}
+
 	redirectCount := 0
+	failoverCount := 0
 START:
 	req, err := r.toRetryableHTTP()
 	if err != nil {
@@ -807,26 +2114,48 @@ START:
 		return nil, fmt.Errorf("nil request created")
 	}
 
-	if outputCurlString {
-		LastOutputStringError = &OutputStringError{Request: req}
-		return nil, LastOutputStringError
+	if outputCurlString || r.OutputCurlString {
+		curlString.Request = req
+		if outputCurlString {
+			LastOutputStringError = curlString
+		}
+		return nil, curlString
+	}
+
+	if outputPolicy || r.OutputPolicy {
+		policyErr := &OutputPolicyError{
+			method: req.Method,
+			path:   strings.TrimPrefix(req.URL.Path, "/v1"),
+			params: req.URL.Query(),
+		}
+		if outputPolicy {
+			LastOutputPolicyError = policyErr
+		}
+		return nil, policyErr
 	}
 
-	if timeout != 0 {
-		// Note: we purposefully do not call cancel manually. The reason is
-		// when canceled, the request.Body will EOF when reading due to the way
-		// it streams data in. Cancel will still be run when the timeout is
-		// hit, so this doesn't really harm anything.
-		ctx, _ = context.WithTimeout(ctx, timeout)
+	// The timeout applies to each attempt, including its retries. Its context
+	// cannot be cancelled on return, as the response body is streamed in,
+	// so it is cancelled once the body is closed instead.
+	reqCtx, cancelFunc := ctx, context.CancelFunc(func() {})
+	if timeout != 0 && !noTimeout {
+		reqCtx, cancelFunc = context.WithTimeout(ctx, timeout)
+	}
+	req.Request = req.Request.WithContext(reqCtx)
+	req.Header.Set(HeaderRequestID, requestID)
+	if req.Header.Get("User-Agent") == "" {
+		if userAgent == "" {
+			userAgent = DefaultUserAgent()
+		}
+		req.Header.Set("User-Agent", userAgent)
 	}
-	req.Request = req.Request.WithContext(ctx)
 
 	if backoff == nil {
 		backoff = retryablehttp.LinearJitterBackoff
 	}
 
 	if checkRetry == nil {
-		checkRetry = retryablehttp.DefaultRetryPolicy
+		checkRetry = networkErrorRetryPolicy(DefaultRetryPolicy, retryWrites || isIdempotentMethod(r.Method))
 	}
 
 	client := &retryablehttp.Client{
@@ -835,14 +2164,88 @@ START:
 		RetryWaitMax: 1500 * time.Millisecond,
 		RetryMax:     maxRetries,
 		Backoff:      backoff,
-		CheckRetry:   checkRetry,
 		ErrorHandler: retryablehttp.PassthroughErrorHandler,
 	}
 
+	// Count the retries made, for the ResponseError of a failed request, and
+	// stop retrying when the next attempt would start past the deadline of
+	// the request or the retry budget.
+	var retryCount int
+	client.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		retry, err := checkRetry(ctx, resp, err)
+		if !retry || retryCount >= maxRetries {
+			return retry, err
+		}
+
+		next := time.Now().Add(backoff(client.RetryWaitMin, client.RetryWaitMax, retryCount+1, resp))
+		if deadline, ok := ctx.Deadline(); ok && next.After(deadline) {
+			return false, err
+		}
+		if maxRetryDuration > 0 && next.After(start.Add(maxRetryDuration)) {
+			return false, err
+		}
+
+		retryCount++
+		return true, err
+	}
+	if logger != nil {
+		client.Logger = logger
+	}
+
 	var result *Response
 	resp, err := client.Do(req)
 	if resp != nil {
-		result = &Response{Response: resp}
+		resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancelFunc: cancelFunc}
+	} else {
+		cancelFunc()
+	}
+	if breaker != nil {
+		breaker.record(r.URL.Host, isBreakerFailure(ctx, resp, err))
+	}
+	if adaptiveLimiter != nil && resp != nil {
+		adaptiveLimiter.observe(limiter, resp)
+	}
+	if err != nil && resp == nil && resolver != nil {
+		resolver.Invalidate(r.URL)
+	}
+	if failoverCount < numAddrs-1 && c.shouldFailover(r, resp, err) {
+		if next := c.failover(ctx, r.URL); next != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+
+			r.URL.Scheme = next.Scheme
+			r.URL.Host = next.Host
+			r.URL.User = next.User
+			r.Host = next.Host
+
+			if err := r.ResetJSONBody(); err != nil {
+				return nil, err
+			}
+
+			failoverCount++
+			goto START
+		}
+	}
+	if resp != nil {
+		if maxBodyBytes > 0 {
+			resp.Body = newLimitedBody(resp.Body, maxBodyBytes)
+		}
+		result = &Response{
+			Response:   resp,
+			RequestID:  requestID,
+			Duration:   time.Since(start),
+			RetryCount: retryCount,
+			ReceivedAt: time.Now(),
+
+			RateLimiterWait: limiterWait,
+
+			jsonDecoding: jsonDecoding,
+		}
+		result.ClockSkew = clockSkew(resp.Header.Get("Date"), result.ReceivedAt)
+		if id := resp.Header.Get(HeaderRequestID); id != "" {
+			result.RequestID = id
+		}
 	}
 	if err != nil {
 		if strings.Contains(err.Error(), "tls: oversized") {
@@ -876,6 +2279,7 @@ START:
 
 		// Update the request
 		r.URL = respLoc
+		resp.Body.Close()
 
 		// Reset the request body if any
 		if err := r.ResetJSONBody(); err != nil {
@@ -887,7 +2291,24 @@ START:
 		goto START
 	}
 
+	if deprecations != nil {
+		deprecations.record(r.Method, r.URL.Path, result)
+	}
+
+	if stateStore != nil {
+		stateStore.recordState(result)
+	}
+
+	if warningHandler != nil {
+		if err := handleWarnings(warningHandler, r.URL.Path, result); err != nil {
+			return result, err
+		}
+	}
+
 	if err := result.Error(); err != nil {
+		if respErr, ok := err.(*ResponseError); ok {
+			respErr.RetryCount = retryCount
+		}
 		return result, err
 	}
 
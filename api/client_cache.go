@@ -0,0 +1,197 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+)
+
+// DefaultClientCacheTTL is the amount of time a cached read is kept when the
+// response carries neither a lease duration nor a TTL hint.
+const DefaultClientCacheTTL = 30 * time.Second
+
+// DefaultClientCacheMaxEntries is the number of reads the client-side cache
+// holds at most, unless configured otherwise.
+const DefaultClientCacheMaxEntries = 1024
+
+// ClientCacheStats reports the effectiveness of the client-side cache.
+type ClientCacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// HitRate returns the fraction of cacheable reads that were served from the
+// cache, or 0 if no reads have been made.
+func (s ClientCacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// clientCache is a lease-aware cache of read responses, keyed by token,
+// namespace, path, and query parameters. Entries expire according to the
+// lease duration or TTL hint of the cached secret, and are invalidated when a
+// write or delete is made to the same path through the owning client.
+// Expired entries are evicted as they are found, and once the cache is full,
+// the entries closest to expiry make room for new ones.
+type clientCache struct {
+	l          sync.RWMutex
+	entries    map[string]*clientCacheEntry
+	defaultTTL time.Duration
+	maxEntries int
+
+	hits   uint64
+	misses uint64
+}
+
+type clientCacheEntry struct {
+	path    string
+	body    []byte
+	expires time.Time
+}
+
+func newClientCache(defaultTTL time.Duration, maxEntries int) *clientCache {
+	if defaultTTL <= 0 {
+		defaultTTL = DefaultClientCacheTTL
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultClientCacheMaxEntries
+	}
+	return &clientCache{
+		entries:    make(map[string]*clientCacheEntry),
+		defaultTTL: defaultTTL,
+		maxEntries: maxEntries,
+	}
+}
+
+// key builds the cache key for a read. The token is hashed so that it isn't
+// kept around in another place in memory.
+func (c *clientCache) key(r *Request, path string) string {
+	tokenHash := sha256.Sum256([]byte(r.ClientToken))
+	namespace := ""
+	if r.Headers != nil {
+		namespace = normalizeNamespace(r.Headers.Get(consts.NamespaceHeaderName))
+	}
+
+	var params string
+	if len(r.Params) > 0 {
+		params = r.Params.Encode()
+	}
+
+	return strings.Join([]string{hex.EncodeToString(tokenHash[:]), namespace, path, params}, "\x00")
+}
+
+// get returns the cached response body for the key, if any and unexpired.
+func (c *clientCache) get(key string) []byte {
+	c.l.RLock()
+	entry, ok := c.entries[key]
+	c.l.RUnlock()
+
+	if !ok || time.Now().After(entry.expires) {
+		atomic.AddUint64(&c.misses, 1)
+		if ok {
+			c.l.Lock()
+			// The entry may have been replaced meanwhile
+			if c.entries[key] == entry {
+				delete(c.entries, key)
+			}
+			c.l.Unlock()
+		}
+		return nil
+	}
+
+	atomic.AddUint64(&c.hits, 1)
+	return entry.body
+}
+
+// put stores the response body for the key if the secret is cacheable.
+// Responses containing auth or wrapping information are never cached.
+func (c *clientCache) put(key, path string, body []byte) {
+	secret, err := ParseSecret(bytes.NewReader(body))
+	if err != nil || secret == nil || secret.Auth != nil || secret.WrapInfo != nil {
+		return
+	}
+
+	ttl := c.defaultTTL
+	switch {
+	case secret.LeaseDuration > 0:
+		ttl = time.Duration(secret.LeaseDuration) * time.Second
+	case secret.Data != nil && secret.Data["ttl"] != nil:
+		if hint, err := parseutil.ParseDurationSecond(secret.Data["ttl"]); err == nil && hint > 0 {
+			ttl = hint
+		}
+	}
+
+	c.l.Lock()
+	defer c.l.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		c.evict(time.Now())
+	}
+	c.entries[key] = &clientCacheEntry{
+		path:    path,
+		body:    body,
+		expires: time.Now().Add(ttl),
+	}
+}
+
+// invalidate removes all entries for the given path, regardless of the token,
+// namespace, or parameters used to read it.
+func (c *clientCache) invalidate(path string) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	for k, entry := range c.entries {
+		if entry.path == path || time.Now().After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// evict makes room for a new entry, by removing the expired entries, or the
+// one closest to expiry if there are none. The lock must be held.
+func (c *clientCache) evict(now time.Time) {
+	var oldestKey string
+	var oldest *clientCacheEntry
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+			continue
+		}
+		if oldest == nil || entry.expires.Before(oldest.expires) {
+			oldestKey, oldest = k, entry
+		}
+	}
+	if len(c.entries) >= c.maxEntries && oldest != nil {
+		delete(c.entries, oldestKey)
+	}
+}
+
+func (c *clientCache) stats() ClientCacheStats {
+	c.l.RLock()
+	defer c.l.RUnlock()
+
+	return ClientCacheStats{
+		Hits:    atomic.LoadUint64(&c.hits),
+		Misses:  atomic.LoadUint64(&c.misses),
+		Entries: len(c.entries),
+	}
+}
+
+// ClientCacheStats returns hit/miss statistics for the client-side cache. If
+// the cache is not enabled, the zero value is returned.
+func (c *Client) ClientCacheStats() ClientCacheStats {
+	if c.cache == nil {
+		return ClientCacheStats{}
+	}
+	return c.cache.stats()
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientCache(t *testing.T) {
+	var reads uint64
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case "GET":
+			atomic.AddUint64(&reads, 1)
+			w.Write([]byte(`{"lease_duration":300,"data":{"foo":"bar"}}`))
+		default:
+			w.WriteHeader(204)
+		}
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	config.EnableClientCache = true
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("foo")
+
+	for i := 0; i < 3; i++ {
+		secret, err := client.Logical().Read("secret/foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret.Data["foo"] != "bar" {
+			t.Fatalf("bad: %#v", secret.Data)
+		}
+	}
+	if n := atomic.LoadUint64(&reads); n != 1 {
+		t.Fatalf("expected 1 upstream read, got %d", n)
+	}
+
+	stats := client.ClientCacheStats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Fatalf("bad stats: %#v", stats)
+	}
+
+	if _, err := client.Logical().Write("secret/foo", map[string]interface{}{"foo": "baz"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Read("secret/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadUint64(&reads); n != 2 {
+		t.Fatalf("expected write to invalidate cache, got %d upstream reads", n)
+	}
+
+	// A different token must not see the cached entry
+	client.SetToken("bar")
+	if _, err := client.Logical().Read("secret/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadUint64(&reads); n != 3 {
+		t.Fatalf("expected cache miss for a different token, got %d upstream reads", n)
+	}
+}
+
+func TestClientCache_Eviction(t *testing.T) {
+	cache := newClientCache(time.Hour, 2)
+	body := func(ttl int) []byte {
+		return []byte(fmt.Sprintf(`{"lease_duration": %d, "data": {"value": "bar"}}`, ttl))
+	}
+
+	// Expired entries are evicted when they are read
+	cache.put("expired", "secret/expired", body(1))
+	cache.entries["expired"].expires = time.Now().Add(-time.Second)
+	if cache.get("expired") != nil {
+		t.Fatal("expected expired entry to be missed")
+	}
+	if _, ok := cache.entries["expired"]; ok {
+		t.Fatal("expected expired entry to be evicted")
+	}
+
+	// Once full, the entry closest to expiry makes room for new ones
+	cache.put("short", "secret/short", body(10))
+	cache.put("long", "secret/long", body(100))
+	cache.put("new", "secret/new", body(50))
+	if len(cache.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(cache.entries))
+	}
+	if cache.get("short") != nil || cache.get("long") == nil || cache.get("new") == nil {
+		t.Fatal("expected the entry closest to expiry to be evicted")
+	}
+
+	// Replacing an entry does not evict another
+	cache.put("new", "secret/new", body(60))
+	if len(cache.entries) != 2 || cache.get("long") == nil {
+		t.Fatal("expected replaced entry to keep the others")
+	}
+
+	// Expired entries are evicted first
+	cache.entries["long"].expires = time.Now().Add(-time.Second)
+	cache.put("other", "secret/other", body(5))
+	if cache.get("long") != nil || cache.get("new") == nil || cache.get("other") == nil {
+		t.Fatal("expected expired entry to be evicted first")
+	}
+}
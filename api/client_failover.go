@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// healthProbeTimeout bounds each sys/health probe made while failing over.
+const healthProbeTimeout = 5 * time.Second
+
+// parseAddresses parses a list of Vault addresses, as given in
+// Config.Addresses or VAULT_ADDRESSES.
+func parseAddresses(addresses []string) ([]*url.URL, error) {
+	var ret []*url.URL
+	for _, address := range addresses {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		u, socket, err := parseAddress(address)
+		if err != nil {
+			return nil, err
+		}
+		if socket != "" {
+			return nil, fmt.Errorf("unix socket address %q cannot be used as one of multiple addresses", address)
+		}
+		ret = append(ret, u)
+	}
+	return ret, nil
+}
+
+// shouldFailover reports whether a request to one of the configured HA
+// addresses failed in a way that another node may be able to serve: either
+// the connection failed, or the node reported itself as sealed or otherwise
+// unavailable. Requests with a streamed body cannot be sent again, and
+// writes are only sent again if they never reached the node.
+func (c *Client) shouldFailover(r *Request, resp *http.Response, err error) bool {
+	c.modifyLock.RLock()
+	addrs := c.addrs
+	c.modifyLock.RUnlock()
+
+	if len(addrs) < 2 || r.Body != nil {
+		return false
+	}
+
+	switch {
+	case err != nil && resp == nil:
+		if !isIdempotentMethod(r.Method) && !isDialError(err) {
+			return false
+		}
+	case resp != nil && resp.StatusCode == http.StatusServiceUnavailable:
+	default:
+		return false
+	}
+
+	for _, addr := range addrs {
+		if addr.Host == r.URL.Host {
+			return true
+		}
+	}
+	return false
+}
+
+// failover probes the configured addresses other than the one that failed,
+// preferring the active node and falling back to any healthy standby. The
+// chosen address becomes the client's address, including in its config, so
+// that subsequent requests and clones stick to it. It returns nil if no
+// other node is healthy.
+func (c *Client) failover(ctx context.Context, failed *url.URL) *url.URL {
+	c.modifyLock.RLock()
+	addrs := c.addrs
+	c.modifyLock.RUnlock()
+
+	var standby *url.URL
+	for _, addr := range addrs {
+		if addr.Host == failed.Host {
+			continue
+		}
+		active, healthy := c.probeHealth(ctx, addr)
+		if active {
+			standby = addr
+			break
+		}
+		if healthy && standby == nil {
+			standby = addr
+		}
+	}
+
+	if standby == nil {
+		return nil
+	}
+
+	c.modifyLock.Lock()
+	c.config.modifyLock.Lock()
+	c.addr = standby
+	c.config.Address = standby.String()
+	c.config.modifyLock.Unlock()
+	c.modifyLock.Unlock()
+
+	return standby
+}
+
+// probeHealth queries sys/health on the given address. It reports whether the
+// node is the active node, and whether it is able to serve requests at all
+// (active, standby, or performance standby).
+func (c *Client) probeHealth(ctx context.Context, addr *url.URL) (active bool, healthy bool) {
+	r := c.NewRequest("GET", "/v1/sys/health")
+	r.URL.Scheme = addr.Scheme
+	r.URL.Host = addr.Host
+	r.URL.User = addr.User
+	r.Host = addr.Host
+
+	req, err := r.toRetryableHTTP()
+	if err != nil {
+		return false, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	resp, err := c.config.requestHTTPClient().Do(req.Request.WithContext(ctx))
+	if err != nil {
+		return false, false
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, true
+	case http.StatusTooManyRequests, 473:
+		// Standby and performance standby nodes
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
+)
+
+// ClientOption configures a client created with NewClientWithOptions.
+type ClientOption func(*clientOptions) error
+
+type clientOptions struct {
+	config    *Config
+	tlsConfig *TLSConfig
+	token     *string
+	namespace *string
+}
+
+// WithAddress sets the address of Vault. See Config.Address.
+func WithAddress(address string) ClientOption {
+	return func(o *clientOptions) error {
+		o.config.Address = address
+		return nil
+	}
+}
+
+// WithToken sets the token of the client, taking precedence over VAULT_TOKEN
+// and any token sources.
+func WithToken(token string) ClientOption {
+	return func(o *clientOptions) error {
+		o.token = &token
+		return nil
+	}
+}
+
+// WithNamespace sets the namespace of the client, taking precedence over
+// VAULT_NAMESPACE.
+func WithNamespace(namespace string) ClientOption {
+	return func(o *clientOptions) error {
+		o.namespace = &namespace
+		return nil
+	}
+}
+
+// WithTLSConfig configures TLS on the HTTP client. See Config.ConfigureTLS.
+func WithTLSConfig(t *TLSConfig) ClientOption {
+	return func(o *clientOptions) error {
+		o.tlsConfig = t
+		return nil
+	}
+}
+
+// WithLogger sets the logger receiving debug logs of requests. See
+// Config.Logger.
+func WithLogger(logger retryablehttp.Logger) ClientOption {
+	return func(o *clientOptions) error {
+		o.config.Logger = logger
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client to use. See Config.HttpClient.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(o *clientOptions) error {
+		o.config.HttpClient = httpClient
+		return nil
+	}
+}
+
+// WithMaxRetries sets the maximum number of retries. See Config.MaxRetries.
+func WithMaxRetries(maxRetries int) ClientOption {
+	return func(o *clientOptions) error {
+		o.config.MaxRetries = maxRetries
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of requests. See Config.Timeout.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) error {
+		o.config.Timeout = timeout
+		return nil
+	}
+}
+
+// WithTokenSources sets the sources of the client's token. See
+// Config.TokenSources.
+func WithTokenSources(sources ...TokenSource) ClientOption {
+	return func(o *clientOptions) error {
+		o.config.TokenSources = sources
+		return nil
+	}
+}
+
+// WithConfigFunc applies a function to the configuration of the client, for
+// settings without a dedicated option.
+func WithConfigFunc(f func(*Config) error) ClientOption {
+	return func(o *clientOptions) error {
+		return f(o.config)
+	}
+}
+
+// NewClientWithOptions creates a client from a configuration of its own,
+// starting from DefaultConfig and applying the given options in order. As
+// the configuration is not shared, it cannot be modified concurrently by
+// other users. The context is used by token sources.
+func NewClientWithOptions(ctx context.Context, opts ...ClientOption) (*Client, error) {
+	options := &clientOptions{
+		config: DefaultConfig(),
+	}
+	if options.config.Error != nil {
+		return nil, options.config.Error
+	}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+
+	// Applied after all options, as WithHTTPClient replaces the transport
+	if options.tlsConfig != nil {
+		if err := options.config.ConfigureTLS(options.tlsConfig); err != nil {
+			return nil, err
+		}
+	}
+	if options.token != nil {
+		options.config.TokenSources = nil
+	}
+
+	client, err := newClientWithSources(ctx, options.config)
+	if err != nil {
+		return nil, err
+	}
+	if options.token != nil {
+		client.SetToken(*options.token)
+	}
+	if options.namespace != nil {
+		client.SetNamespace(*options.namespace)
+	}
+	return client, nil
+}
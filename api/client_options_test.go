@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Printf(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestNewClientWithOptions(t *testing.T) {
+	var token, namespace string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token = req.Header.Get(consts.AuthHeaderName)
+		namespace = req.Header.Get(consts.NamespaceHeaderName)
+	}))
+	defer ln.Close()
+
+	logger := &testLogger{}
+	client, err := NewClientWithOptions(context.Background(),
+		WithAddress(config.Address),
+		WithToken("options-token"),
+		WithNamespace("ns1"),
+		WithLogger(logger),
+		WithMaxRetries(0),
+		WithTimeout(5*time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Logical().Read("secret/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if token != "options-token" || namespace != "ns1" {
+		t.Fatalf("unexpected token %q and namespace %q", token, namespace)
+	}
+	if client.config.MaxRetries != 0 || client.config.Timeout != 5*time.Second {
+		t.Fatalf("options not applied to the configuration")
+	}
+	if len(logger.lines) == 0 {
+		t.Fatal("expected requests to be logged")
+	}
+
+	_, err = NewClientWithOptions(context.Background(), WithTLSConfig(&TLSConfig{CACert: "/nonexistent/ca.pem"}))
+	if err == nil {
+		t.Fatal("expected error for invalid TLS configuration")
+	}
+}
@@ -2,13 +2,32 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/helper/consts"
+	"golang.org/x/time/rate"
 )
 
 func init() {
@@ -69,6 +88,25 @@ func TestClientSetAddress(t *testing.T) {
 	if client.addr.Host != "172.168.2.1:8300" {
 		t.Fatalf("bad: expected: '172.168.2.1:8300' actual: %q", client.addr.Host)
 	}
+	if client.config.Address != "http://172.168.2.1:8300" {
+		t.Fatalf("bad: config address not updated: %q", client.config.Address)
+	}
+
+	if err := client.SetAddress("unix:///var/run/vault.sock"); err != nil {
+		t.Fatal(err)
+	}
+	if client.addr.Scheme != "http" || client.addr.Host != "/var/run/vault.sock" {
+		t.Fatalf("bad: unix socket address: %q", client.addr.String())
+	}
+
+	for _, addr := range []string{"ftp://172.168.2.1", "172.168.2.1:8300"} {
+		if err := client.SetAddress(addr); err == nil {
+			t.Fatalf("expected error setting address %q", addr)
+		}
+	}
+	if client.addr.Host != "/var/run/vault.sock" {
+		t.Fatalf("bad: address changed after failed set: %q", client.addr.String())
+	}
 }
 
 func TestClientToken(t *testing.T) {
@@ -357,6 +395,55 @@ func TestClone(t *testing.T) {
 	_ = client2
 }
 
+func TestCloneWithOptions(t *testing.T) {
+	config := DefaultConfig()
+	config.CloneHeaders = true
+	config.CloneToken = true
+	config.CloneTLSConfig = true
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("token")
+	client.SetNamespace("ns1")
+	client.AddHeader("X-Custom", "value")
+	client.SetPolicyOverride(true)
+
+	clone, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.Token() != "token" {
+		t.Fatalf("expected token to be cloned, got %q", clone.Token())
+	}
+	if clone.Namespace() != "ns1" || clone.Headers().Get("X-Custom") != "value" {
+		t.Fatalf("expected headers to be cloned, got %v", clone.Headers())
+	}
+	if !clone.policyOverride {
+		t.Fatal("expected policy override to be cloned")
+	}
+
+	clone.SetNamespace("ns2")
+	if client.Namespace() != "ns1" {
+		t.Fatal("expected cloned headers to be independent")
+	}
+
+	clientTransport := client.config.HttpClient.Transport.(*http.Transport)
+	cloneTransport := clone.config.HttpClient.Transport.(*http.Transport)
+	if clientTransport == cloneTransport || clientTransport.TLSClientConfig == cloneTransport.TLSClientConfig {
+		t.Fatal("expected TLS config to be cloned")
+	}
+
+	config.CloneToken = false
+	clone, err = client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.Token() != "" {
+		t.Fatalf("expected token not to be cloned, got %q", clone.Token())
+	}
+}
+
 func TestSetHeadersRaceSafe(t *testing.T) {
 	client, err1 := NewClient(nil)
 	if err1 != nil {
@@ -405,3 +492,806 @@ func TestSetHeadersRaceSafe(t *testing.T) {
 		}
 	}
 }
+
+func TestClientNamespaceLimiter(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.SetLimiter(0.001, 1)
+	client.SetNamespaceLimiter("/tenant1/", 0.001, 1)
+
+	client.SetNamespace("tenant1")
+	if _, err := client.RawRequest(client.NewRequest("GET", "/")); err != nil {
+		t.Fatal(err)
+	}
+
+	if client.config.NamespaceLimiters["tenant1"].Allow() {
+		t.Fatal("expected namespace limiter to have been consumed")
+	}
+	if !client.config.Limiter.Allow() {
+		t.Fatal("expected client-wide limiter to be untouched")
+	}
+
+	clone, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := clone.config.NamespaceLimiters["tenant1"]; !ok {
+		t.Fatal("expected namespace limiters to be cloned")
+	}
+}
+
+func TestClientPathLimiter(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.SetLimiter(0.001, 1)
+	client.SetNamespaceLimiter("tenant1", 0.001, 1)
+	client.SetPathLimiter("transit/", 0.001, 1)
+	client.SetPathLimiter("/transit/encrypt/", 0.001, 1)
+
+	client.SetNamespace("tenant1")
+	if _, err := client.RawRequest(client.NewRequest("PUT", "/v1/transit/encrypt/key")); err != nil {
+		t.Fatal(err)
+	}
+
+	if client.config.PathLimiters["transit/encrypt/"].Allow() {
+		t.Fatal("expected the longest matching path limiter to have been consumed")
+	}
+	if !client.config.PathLimiters["transit/"].Allow() {
+		t.Fatal("expected shorter path limiter to be untouched")
+	}
+	if !client.config.NamespaceLimiters["tenant1"].Allow() || !client.config.Limiter.Allow() {
+		t.Fatal("expected namespace and client-wide limiters to be untouched")
+	}
+
+	clone, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clone.config.PathLimiters) != 2 {
+		t.Fatal("expected path limiters to be cloned")
+	}
+}
+
+func TestClientAddressesFailover(t *testing.T) {
+	// Grab an address with nothing listening on it
+	_, deadLn := testHTTPServer(t, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	deadAddr := "http://" + deadLn.Addr().String()
+	deadLn.Close()
+
+	sealed := func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(503)
+	}
+	sealedConfig, sealedLn := testHTTPServer(t, http.HandlerFunc(sealed))
+	defer sealedLn.Close()
+
+	active := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("active"))
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(active))
+	defer ln.Close()
+	activeAddr := config.Address
+
+	config.MaxRetries = 0
+	config.Addresses = []string{deadAddr, sealedConfig.Address, activeAddr}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Address() != deadAddr {
+		t.Fatalf("expected client to start with the first address, got %q", client.Address())
+	}
+
+	resp, err := client.RawRequest(client.NewRequest("GET", "/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	io.Copy(&buf, resp.Body)
+	if buf.String() != "active" {
+		t.Fatalf("bad: %s", buf.String())
+	}
+
+	if client.Address() != activeAddr {
+		t.Fatalf("expected client to stick to %q, got %q", activeAddr, client.Address())
+	}
+	clone, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.Address() != activeAddr {
+		t.Fatalf("expected clone to use %q, got %q", activeAddr, clone.Address())
+	}
+}
+
+func TestClientAddressesFailover_Writes(t *testing.T) {
+	_, deadLn := testHTTPServer(t, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	deadAddr := "http://" + deadLn.Addr().String()
+	deadLn.Close()
+
+	// Drops the connection after reading the request
+	resetConfig, resetLn := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/sys/health" {
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn.Close()
+	}))
+	defer resetLn.Close()
+
+	var body string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/sys/health" {
+			b, _ := ioutil.ReadAll(req.Body)
+			body = string(b)
+		}
+	}))
+	defer ln.Close()
+	activeAddr := config.Address
+
+	config.MaxRetries = 0
+	config.Addresses = []string{deadAddr, activeAddr}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The connection to the first node could not be made, so the write is
+	// sent to the next one with its body
+	if _, err := client.Logical().Write("secret/foo", map[string]interface{}{"value": "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	if body != `{"value":"bar"}` {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	// The write may have been applied by a node that dropped the connection,
+	// so it is not sent again
+	body = ""
+	config.Addresses = []string{resetConfig.Address, activeAddr}
+	client, err = NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("secret/foo", map[string]interface{}{"value": "bar"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if body != "" || client.Address() != resetConfig.Address {
+		t.Fatalf("expected no failover, got body %q and address %q", body, client.Address())
+	}
+}
+
+func TestClientProxyURL(t *testing.T) {
+	var proxiedHost string
+	proxy := func(w http.ResponseWriter, req *http.Request) {
+		proxiedHost = req.URL.Host
+		w.Write([]byte("proxied"))
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(proxy))
+	defer ln.Close()
+
+	proxyAddr := config.Address
+	config.Address = "http://vault.example.com:8200"
+	config.ProxyURL = proxyAddr
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.RawRequest(client.NewRequest("GET", "/")); err != nil {
+		t.Fatal(err)
+	}
+	if proxiedHost != "vault.example.com:8200" {
+		t.Fatalf("expected request to go through the proxy, got host %q", proxiedHost)
+	}
+
+	config.ProxyURL = "ftp://proxy.example.com"
+	if _, err := NewClient(config); err == nil {
+		t.Fatal("expected error for unsupported proxy scheme")
+	}
+}
+
+func TestClientDialContext(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	target := ln.Addr().String()
+	var dialed []string
+	config.Address = "http://vault.overlay.internal:8200"
+	config.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		var d net.Dialer
+		return d.DialContext(ctx, network, target)
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.RawRequest(client.NewRequest("GET", "/")); err != nil {
+		t.Fatal(err)
+	}
+	if len(dialed) != 1 || dialed[0] != "vault.overlay.internal:8200" {
+		t.Fatalf("expected the custom dialer to be used, got %v", dialed)
+	}
+}
+
+func TestClientConfigureTLSBytes(t *testing.T) {
+	certPEM, err := ioutil.ReadFile("test-fixtures/keys/cert.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM, err := ioutil.ReadFile("test-fixtures/keys/key.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	err = config.ConfigureTLS(&TLSConfig{
+		CACertBytes:   certPEM,
+		ClientCertPEM: certPEM,
+		ClientKeyPEM:  keyPEM,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tlsConfig := config.HttpClient.Transport.(*http.Transport).TLSClientConfig
+	if tlsConfig.RootCAs == nil {
+		t.Fatal("expected root CAs to be set")
+	}
+	if tlsConfig.GetClientCertificate == nil {
+		t.Fatal("expected client certificate to be set")
+	}
+
+	err = config.ConfigureTLS(&TLSConfig{
+		ClientCert:   "test-fixtures/keys/cert.pem",
+		ClientKeyPEM: keyPEM,
+	})
+	if err == nil {
+		t.Fatal("expected error mixing files and PEM bytes")
+	}
+
+	if err := config.SetTLSConfig(&tls.Config{ServerName: "vault.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig = config.HttpClient.Transport.(*http.Transport).TLSClientConfig
+	if tlsConfig.ServerName != "vault.example.com" {
+		t.Fatalf("expected TLS config to be replaced, got server name %q", tlsConfig.ServerName)
+	}
+}
+
+func TestClientConfigureTLSVersions(t *testing.T) {
+	os.Setenv(EnvVaultTLSMinVersion, "tls13")
+	defer os.Setenv(EnvVaultTLSMinVersion, "")
+	os.Setenv(EnvVaultTLSCipherSuites, "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384")
+	defer os.Setenv(EnvVaultTLSCipherSuites, "")
+
+	config := DefaultConfig()
+	if config.Error != nil {
+		t.Fatal(config.Error)
+	}
+
+	tlsConfig := config.HttpClient.Transport.(*http.Transport).TLSClientConfig
+	if tlsConfig.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("expected TLS 1.3 minimum, got %x", tlsConfig.MinVersion)
+	}
+	if len(tlsConfig.CipherSuites) != 1 || tlsConfig.CipherSuites[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384 {
+		t.Fatalf("bad cipher suites: %v", tlsConfig.CipherSuites)
+	}
+
+	if err := config.ConfigureTLS(&TLSConfig{MinVersion: "tls13", MaxVersion: "tls12"}); err == nil {
+		t.Fatal("expected error for min version above max version")
+	}
+	if err := config.ConfigureTLS(&TLSConfig{MinVersion: "ssl3"}); err == nil {
+		t.Fatal("expected error for unknown version")
+	}
+}
+
+func TestClientPinnedCertFingerprints(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	sum := sha256.Sum256(server.Certificate().Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	for _, tc := range []struct {
+		fingerprint string
+		expectErr   bool
+	}{
+		{fingerprint, false},
+		{strings.ToUpper(fingerprint[:2]) + ":" + fingerprint[2:], false},
+		{strings.Repeat("00", sha256.Size), true},
+	} {
+		config := DefaultConfig()
+		config.Address = server.URL
+		config.MaxRetries = 0
+		err := config.ConfigureTLS(&TLSConfig{
+			Insecure:               true,
+			PinnedCertFingerprints: []string{tc.fingerprint},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := NewClient(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.RawRequest(client.NewRequest("GET", "/"))
+		if tc.expectErr != (err != nil) {
+			t.Fatalf("fingerprint %q: expected error: %t, got: %v", tc.fingerprint, tc.expectErr, err)
+		}
+	}
+
+	if err := DefaultConfig().ConfigureTLS(&TLSConfig{PinnedCertFingerprints: []string{"abc"}}); err == nil {
+		t.Fatal("expected error for malformed fingerprint")
+	}
+}
+
+func TestPinnedCertVerifier_CAPin(t *testing.T) {
+	newCert := func(name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(time.Now().UnixNano()),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             time.Now(),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  parent == nil,
+			BasicConstraintsValid: true,
+		}
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, key
+	}
+
+	pinnedCA, pinnedKey := newCert("pinned CA", nil, nil)
+	otherCA, otherKey := newCert("other CA", nil, nil)
+	pinnedLeaf, _ := newCert("pinned leaf", pinnedCA, pinnedKey)
+	otherLeaf, _ := newCert("other leaf", otherCA, otherKey)
+
+	sum := sha256.Sum256(pinnedCA.Raw)
+	verify, err := pinnedCertVerifier([]string{hex.EncodeToString(sum[:])})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The pinned CA appended to an unrelated chain must not match, whether
+	// verification is disabled or the chain verified up to another root
+	raw := [][]byte{otherLeaf.Raw, pinnedCA.Raw}
+	if err := verify(raw, nil); err == nil {
+		t.Fatal("expected error for pinned CA appended without verified chains")
+	}
+	if err := verify(raw, [][]*x509.Certificate{{otherLeaf, otherCA}}); err == nil {
+		t.Fatal("expected error for pinned CA appended to an unrelated chain")
+	}
+
+	if err := verify([][]byte{pinnedLeaf.Raw}, [][]*x509.Certificate{{pinnedLeaf, pinnedCA}}); err != nil {
+		t.Fatalf("expected chain verified up to the pinned CA to match: %v", err)
+	}
+
+	// A pinned leaf matches even without verified chains
+	sum = sha256.Sum256(pinnedLeaf.Raw)
+	verify, err = pinnedCertVerifier([]string{hex.EncodeToString(sum[:])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify([][]byte{pinnedLeaf.Raw, pinnedCA.Raw}, nil); err != nil {
+		t.Fatalf("expected pinned leaf to match: %v", err)
+	}
+	if err := verify([][]byte{otherLeaf.Raw, pinnedLeaf.Raw}, nil); err == nil {
+		t.Fatal("expected error for pinned leaf sent as an intermediate")
+	}
+}
+
+func TestClientConfigureTLSSigner(t *testing.T) {
+	keyPEM, err := ioutil.ReadFile("test-fixtures/keys/key.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(keyPEM)
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	err = config.ConfigureTLS(&TLSConfig{
+		ClientCert:      "test-fixtures/keys/cert.pem",
+		ClientKeySigner: key,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tlsConfig := config.HttpClient.Transport.(*http.Transport).TLSClientConfig
+	cert, err := tlsConfig.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.PrivateKey != key {
+		t.Fatal("expected the signer to be used as the private key")
+	}
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = config.ConfigureTLS(&TLSConfig{
+		ClientCert:      "test-fixtures/keys/cert.pem",
+		ClientKeySigner: otherKey,
+	})
+	if err == nil {
+		t.Fatal("expected error for a signer not matching the certificate")
+	}
+}
+
+func TestClientWithNamespace(t *testing.T) {
+	var namespaces []string
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		namespaces = append(namespaces, req.Header.Get(consts.NamespaceHeaderName))
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetNamespace("parent")
+	client.SetToken("token")
+
+	child := client.WithNamespace("parent/child")
+	if child.Namespace() != "parent/child" || client.Namespace() != "parent" {
+		t.Fatalf("bad namespaces: child %q, parent %q", child.Namespace(), client.Namespace())
+	}
+	if child.Token() != "token" {
+		t.Fatalf("expected token to be carried over, got %q", child.Token())
+	}
+
+	if _, err := child.RawRequest(child.NewRequest("GET", "/")); err != nil {
+		t.Fatal(err)
+	}
+	r := client.NewRequest("GET", "/")
+	r.SetNamespace("other")
+	if _, err := client.RawRequest(r); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.RawRequest(client.NewRequest("GET", "/")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"parent/child", "other", "parent"}
+	if !reflect.DeepEqual(namespaces, expected) {
+		t.Fatalf("expected namespaces %v, got %v", expected, namespaces)
+	}
+
+	client.ClearNamespace()
+	if client.Namespace() != "" {
+		t.Fatalf("expected namespace to be cleared, got %q", client.Namespace())
+	}
+}
+
+func TestClientWithOutputCurlString(t *testing.T) {
+	var requests int
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+	}))
+	defer ln.Close()
+
+	config.HttpClient.Transport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	config.tlsCACert = "/etc/vault/ca.pem"
+	config.tlsClientCert = "/etc/vault/client's.pem"
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("foo")
+
+	LastOutputStringError = nil
+	_, err = client.WithOutputCurlString().Logical().Write("secret/foo", map[string]interface{}{"bar": "baz"})
+	curlErr, ok := err.(*OutputStringError)
+	if !ok {
+		t.Fatalf("expected an *OutputStringError, got: %v", err)
+	}
+	if requests != 0 {
+		t.Fatal("request should not have been sent")
+	}
+	if LastOutputStringError != nil {
+		t.Fatal("LastOutputStringError should not be set by per-request output")
+	}
+
+	expected := fmt.Sprintf(`curl --insecure --cacert '/etc/vault/ca.pem' --cert '/etc/vault/client'"'"'s.pem' -X PUT -H "X-Vault-Request: true" -H "X-Vault-Token: $(vault print token)" -d '{"bar":"baz"}' %s/v1/secret/foo`, config.Address)
+	if curl := curlErr.CurlString(); curl != expected {
+		t.Fatalf("bad curl string\nexpected: %s\n     got: %s", expected, curl)
+	}
+
+	if _, err := client.Logical().Read("secret/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Fatal("the original client should still send requests")
+	}
+}
+
+func TestClientClone_TLSPaths(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer ln.Close()
+
+	config.tlsCACert = "/etc/vault/ca.pem"
+	config.tlsCAPath = "/etc/vault/ca"
+	config.tlsClientCert = "/etc/vault/client.pem"
+	config.tlsClientKey = "/etc/vault/client-key.pem"
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = clone.WithOutputCurlString().Logical().Read("secret/foo")
+	curlErr, ok := err.(*OutputStringError)
+	if !ok {
+		t.Fatalf("expected an *OutputStringError, got: %v", err)
+	}
+	curl := curlErr.CurlString()
+	for _, flag := range []string{
+		"--cacert '/etc/vault/ca.pem'",
+		"--capath '/etc/vault/ca'",
+		"--cert '/etc/vault/client.pem'",
+		"--key '/etc/vault/client-key.pem'",
+	} {
+		if !strings.Contains(curl, flag) {
+			t.Fatalf("expected %s in curl string %s", flag, curl)
+		}
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"lease_duration": 3600, "renewable": true}`))
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := client.WithNamespace("ns1")
+
+	watcher, err := client.NewLifetimeWatcher(&LifetimeWatcherInput{
+		Secret: &Secret{LeaseID: "lease", LeaseDuration: 3600, Renewable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go watcher.Start()
+	topologyCh := client.WatchTopology(context.Background(), time.Hour)
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-watcher.DoneCh():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("lifetime watcher not stopped by Close")
+	}
+	timeout := time.After(5 * time.Second)
+	for open := true; open; {
+		select {
+		case _, open = <-topologyCh:
+		case <-timeout:
+			t.Fatal("topology watch not stopped by Close")
+		}
+	}
+
+	for _, c := range []*Client{client, copied} {
+		if _, err := c.Logical().Read("secret/foo"); err != ErrClientClosed {
+			t.Fatalf("expected ErrClientClosed, got %v", err)
+		}
+	}
+	if _, err := clone.Logical().Read("secret/foo"); err != nil {
+		t.Fatalf("expected clone to remain usable, got %v", err)
+	}
+}
+
+func TestConfigReadEnvironmentWithPrefix(t *testing.T) {
+	for k, v := range map[string]string{
+		"MYAPP_" + EnvVaultAddress:   "https://myapp.example.com:8200",
+		"MYAPP_" + EnvVaultToken:     "myapp-token",
+		"MYAPP_" + EnvVaultNamespace: "myapp",
+		EnvVaultToken:                "other-token",
+	} {
+		defer os.Setenv(k, os.Getenv(k))
+		os.Setenv(k, v)
+	}
+
+	config := DefaultConfigWithEnv(PrefixedEnv("MYAPP_"))
+	if config.Error != nil {
+		t.Fatal(config.Error)
+	}
+	if config.Address != "https://myapp.example.com:8200" {
+		t.Fatalf("unexpected address %q", config.Address)
+	}
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Token() != "myapp-token" {
+		t.Fatalf("unexpected token %q", client.Token())
+	}
+	if ns := client.Headers().Get(consts.NamespaceHeaderName); ns != "myapp" {
+		t.Fatalf("unexpected namespace %q", ns)
+	}
+
+	config = DefaultConfig()
+	if err := config.ReadEnvironmentWithPrefix("OTHERAPP_"); err != nil {
+		t.Fatal(err)
+	}
+	client, err = NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Token() != "" {
+		t.Fatalf("expected no token for an unused prefix, got %q", client.Token())
+	}
+}
+
+func TestClientLimiterWait(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer ln.Close()
+
+	config.Limiter = rate.NewLimiter(20, 1)
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.RawRequest(client.NewRequest("GET", "/v1/secret/foo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if i == 1 && resp.RateLimiterWait <= 0 {
+			t.Fatal("expected the second request to wait for the limiter")
+		}
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	cancelFunc()
+	_, err = client.RawRequestWithContext(ctx, client.NewRequest("GET", "/v1/secret/foo"))
+	var waitErr *LimiterWaitError
+	if !errors.As(err, &waitErr) {
+		t.Fatalf("expected a limiter wait error, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) || !IsRateLimited(err) {
+		t.Fatalf("unexpected classification of %v", err)
+	}
+}
+
+func TestClientRetryBudget(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ln.Close()
+
+	config.MaxRetries = 10
+	config.Timeout = 10 * time.Second
+	config.Backoff = func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		return 50 * time.Millisecond
+	}
+	config.MaxRetryDuration = 120 * time.Millisecond
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Logical().Read("secret/foo")
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("expected a response error, got %v", err)
+	}
+	if respErr.RetryCount != 2 {
+		t.Fatalf("expected retries to stop at the budget, got %d", respErr.RetryCount)
+	}
+
+	// Retries are not made past the deadline of the context either
+	client.config.MaxRetryDuration = 0
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelFunc()
+	resp, err := client.RawRequestWithContext(ctx, client.NewRequest("GET", "/v1/secret/foo"))
+	if !errors.As(err, &respErr) || respErr.RetryCount != 0 {
+		t.Fatalf("expected no retries past the deadline, got %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestClientConnectionPool(t *testing.T) {
+	env := map[string]string{
+		EnvVaultMaxIdleConns:        "10",
+		EnvVaultMaxIdleConnsPerHost: "5",
+		EnvVaultMaxConnsPerHost:     "20",
+		EnvVaultIdleConnTimeout:     "45s",
+		EnvVaultDisableKeepAlives:   "true",
+	}
+	config := DefaultConfigWithEnv(func(key string) string { return env[key] })
+	if config.Error != nil {
+		t.Fatal(config.Error)
+	}
+	config.MaxIdleConns = 50
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := client.config.HttpClient.Transport.(*http.Transport)
+	if transport.MaxIdleConns != 50 || transport.MaxIdleConnsPerHost != 5 || transport.MaxConnsPerHost != 20 {
+		t.Fatalf("unexpected connection limits %d, %d, %d", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost)
+	}
+	if transport.IdleConnTimeout != 45*time.Second || !transport.DisableKeepAlives {
+		t.Fatalf("unexpected keep-alive settings %s, %t", transport.IdleConnTimeout, transport.DisableKeepAlives)
+	}
+
+	env = map[string]string{EnvVaultMaxConnsPerHost: "-1"}
+	if config := DefaultConfigWithEnv(func(key string) string { return env[key] }); config.Error == nil {
+		t.Fatal("expected error for negative connection limit")
+	}
+
+	config = DefaultConfig()
+	config.HttpClient = &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+	config.MaxConnsPerHost = 1
+	if _, err := NewClient(config); err == nil {
+		t.Fatal("expected error for pool settings on a custom transport")
+	}
+}
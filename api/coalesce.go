@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// readGroup coalesces identical reads that are in flight at the same time
+// into a single request, whose response is shared by all of the callers.
+type readGroup struct {
+	l     sync.Mutex
+	calls map[string]*readCall
+}
+
+type readCall struct {
+	done chan struct{}
+
+	resp *Response
+	body []byte
+	err  error
+
+	// canceled is set if the call failed because the context of the caller
+	// that made it was done, in which case the waiters make their own.
+	canceled bool
+}
+
+func newReadGroup() *readGroup {
+	return &readGroup{
+		calls: make(map[string]*readCall),
+	}
+}
+
+// coalescable returns whether the request is a read that may share the
+// response of an identical one.
+func (r *Request) coalescable() bool {
+	if r.Method != http.MethodGet && r.Method != "LIST" {
+		return false
+	}
+	if r.Obj != nil || r.BodyBytes != nil || r.Body != nil {
+		return false
+	}
+	return !r.OutputCurlString && !r.OutputPolicy
+}
+
+// readKey identifies the requests that can share a response: those made
+// with the same method, URL, token, and headers, which include the
+// namespace. The token is hashed so that it isn't kept around in another
+// place in memory.
+func readKey(r *Request) string {
+	tokenHash := sha256.Sum256([]byte(r.ClientToken))
+
+	var headers bytes.Buffer
+	r.Headers.Write(&headers)
+
+	return strings.Join([]string{
+		r.Method,
+		r.URL.Host,
+		r.URL.Path,
+		r.Params.Encode(),
+		hex.EncodeToString(tokenHash[:]),
+		r.WrapTTL,
+		strings.Join(r.MFAHeaderVals, ","),
+		headers.String(),
+	}, "\x00")
+}
+
+// do makes the request with fn, unless an identical one is already in
+// flight, in which case its response is waited for. Each caller gets its
+// own copy of the response, with the body buffered in memory. The request
+// runs under the context of the caller that made it; if that context ends
+// first, the waiters retry rather than fail with the error of another
+// caller.
+func (g *readGroup) do(ctx context.Context, key string, fn func() (*Response, error)) (*Response, error) {
+	for {
+		g.l.Lock()
+		call, ok := g.calls[key]
+		if !ok {
+			call = &readCall{done: make(chan struct{})}
+			g.calls[key] = call
+		}
+		g.l.Unlock()
+
+		if !ok {
+			return g.call(ctx, key, call, fn)
+		}
+
+		select {
+		case <-call.done:
+			if !call.canceled {
+				return call.response()
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// call makes the request of a call with fn, and shares its response with
+// the waiters.
+func (g *readGroup) call(ctx context.Context, key string, call *readCall, fn func() (*Response, error)) (*Response, error) {
+	call.resp, call.err = fn()
+	call.canceled = call.err != nil && ctx.Err() != nil
+	if call.resp != nil {
+		body, err := ioutil.ReadAll(call.resp.Body)
+		call.resp.Body.Close()
+		call.body = body
+		if err != nil && call.err == nil {
+			call.err = err
+		}
+	}
+
+	g.l.Lock()
+	delete(g.calls, key)
+	g.l.Unlock()
+	close(call.done)
+
+	return call.response()
+}
+
+// response returns a copy of the response of the call.
+func (c *readCall) response() (*Response, error) {
+	if c.resp == nil {
+		return nil, c.err
+	}
+
+	httpResp := *c.resp.Response
+	httpResp.Body = ioutil.NopCloser(bytes.NewReader(c.body))
+	resp := *c.resp
+	resp.Response = &httpResp
+	return &resp, c.err
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientCoalesceReads(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Write([]byte(`{"data": {"value": "bar"}}`))
+	}))
+	defer ln.Close()
+
+	config.CoalesceReads = true
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(client *Client, results chan<- *Secret, wg *sync.WaitGroup) {
+		defer wg.Done()
+		secret, err := client.Logical().Read("secret/foo")
+		if err != nil {
+			t.Error(err)
+		}
+		results <- secret
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan *Secret, 6)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go read(client, results, &wg)
+	}
+
+	// Reads in another namespace are not coalesced with the others
+	wg.Add(1)
+	go read(client.WithNamespace("ns1"), results, &wg)
+
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for secret := range results {
+		if secret == nil || secret.Data["value"] != "bar" {
+			t.Fatalf("unexpected secret %#v", secret)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+
+	// Reads made after the shared one completed are sent again
+	if _, err := client.Logical().Read("secret/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Fatalf("expected 3 requests, got %d", n)
+	}
+}
+
+func TestClientCoalesceReads_LeaderCanceled(t *testing.T) {
+	var requests int32
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// Hold the first request until its caller gives up
+			<-req.Context().Done()
+			return
+		}
+		w.Write([]byte(`{"data": {"value": "bar"}}`))
+	}))
+	defer ln.Close()
+
+	config.CoalesceReads = true
+	config.MaxRetries = 0
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := client.Logical().readWithContext(ctx, "secret/foo", nil)
+		leaderErr <- err
+	}()
+	for atomic.LoadInt32(&requests) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	waiterResult := make(chan *Secret, 1)
+	go func() {
+		secret, err := client.Logical().readWithContext(context.Background(), "secret/foo", nil)
+		if err != nil {
+			t.Error(err)
+		}
+		waiterResult <- secret
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	if err := <-leaderErr; err == nil {
+		t.Fatal("expected error for the canceled caller")
+	}
+	select {
+	case secret := <-waiterResult:
+		if secret == nil || secret.Data["value"] != "bar" {
+			t.Fatalf("unexpected secret %#v", secret)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the waiter")
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+}
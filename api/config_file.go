@@ -0,0 +1,250 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/sdk/helper/hclutil"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultConfigFilePath is the default path of the client configuration
+	// file, shared with the Vault CLI.
+	DefaultConfigFilePath = "~/.vault"
+
+	// EnvVaultConfigPath overrides the path of the configuration file.
+	EnvVaultConfigPath = "VAULT_CONFIG_PATH"
+)
+
+// ConfigFile is the contents of a client configuration file. The file is
+// written in HCL or JSON, and is a superset of the Vault CLI configuration
+// file, which only supports token_helper.
+type ConfigFile struct {
+	TokenHelper   string         `hcl:"token_helper"`
+	Address       string         `hcl:"address"`
+	AgentAddress  string         `hcl:"agent_address"`
+	Namespace     string         `hcl:"namespace"`
+	MaxRetries    *int           `hcl:"max_retries"`
+	ClientTimeout string         `hcl:"client_timeout"`
+	RateLimit     string         `hcl:"rate_limit"`
+	SRVLookup     *bool          `hcl:"srv_lookup"`
+	TLS           *ConfigFileTLS `hcl:"tls"`
+}
+
+// ConfigFileTLS is the tls block of a configuration file.
+type ConfigFileTLS struct {
+	CACert        string `hcl:"ca_cert"`
+	CAPath        string `hcl:"ca_path"`
+	ClientCert    string `hcl:"client_cert"`
+	ClientKey     string `hcl:"client_key"`
+	TLSServerName string `hcl:"tls_server_name"`
+	SkipVerify    bool   `hcl:"tls_skip_verify"`
+	MinVersion    string `hcl:"tls_min_version"`
+	MaxVersion    string `hcl:"tls_max_version"`
+}
+
+// ParseConfigFile parses the contents of a configuration file.
+func ParseConfigFile(contents string) (*ConfigFile, error) {
+	root, err := hcl.Parse(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse config; does not contain a root object")
+	}
+
+	valid := []string{
+		"token_helper",
+		"address",
+		"agent_address",
+		"namespace",
+		"max_retries",
+		"client_timeout",
+		"rate_limit",
+		"srv_lookup",
+		"tls",
+	}
+	if err := hclutil.CheckHCLKeys(list, valid); err != nil {
+		return nil, err
+	}
+	if tls := list.Filter("tls"); len(tls.Items) > 0 {
+		validTLS := []string{
+			"ca_cert",
+			"ca_path",
+			"client_cert",
+			"client_key",
+			"tls_server_name",
+			"tls_skip_verify",
+			"tls_min_version",
+			"tls_max_version",
+		}
+		for _, item := range tls.Items {
+			if err := hclutil.CheckHCLKeys(item.Val, validTLS); err != nil {
+				return nil, errwrap.Wrapf("tls: {{err}}", err)
+			}
+		}
+	}
+
+	var c ConfigFile
+	if err := hcl.DecodeObject(&c, list); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// LoadConfig returns a configuration built from the configuration file at
+// the given path, or if empty, at VAULT_CONFIG_PATH or ~/.vault. A missing
+// file is not an error. Settings are taken from, in order of precedence:
+//
+//  1. Environment variables, such as VAULT_ADDR.
+//  2. The configuration file.
+//  3. The defaults of DefaultConfig.
+//
+// If the file sets a token helper, the returned configuration consults
+// VAULT_TOKEN and then the helper for the token of the client.
+func LoadConfig(path string) (*Config, error) {
+	return LoadConfigWithEnv(path, os.Getenv)
+}
+
+// LoadConfigWithEnv is like LoadConfig, but reads the environment with the
+// given lookup function instead, e.g. PrefixedEnv("MYAPP_").
+func LoadConfigWithEnv(path string, lookup EnvLookup) (*Config, error) {
+	if path == "" {
+		path = DefaultConfigFilePath
+		if v := lookup(EnvVaultConfigPath); v != "" {
+			path = v
+		}
+	}
+	path, err := expandHomeDir(path)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error expanding config path %q: {{err}}", path), err)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	file, err := ParseConfigFile(string(contents))
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error parsing config file at %q: {{err}}", path), err)
+	}
+
+	config := DefaultConfigWithEnv(lookup)
+	if config.Error != nil {
+		return nil, config.Error
+	}
+	if err := file.apply(config); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error applying config file at %q: {{err}}", path), err)
+	}
+	return config, nil
+}
+
+// apply sets the values of the file on the configuration, except where
+// they are overridden by the environment.
+func (f *ConfigFile) apply(c *Config) error {
+	unset := func(names ...string) bool {
+		for _, name := range names {
+			if c.lookupEnv(name) != "" {
+				return false
+			}
+		}
+		return true
+	}
+
+	if f.Address != "" && unset(EnvVaultAddress) {
+		c.Address = f.Address
+	}
+	if f.AgentAddress != "" && unset(EnvVaultAgentAddr) {
+		c.AgentAddress = f.AgentAddress
+	}
+	if f.Namespace != "" {
+		c.Namespace = f.Namespace
+	}
+	if f.MaxRetries != nil && unset(EnvVaultMaxRetries) {
+		c.MaxRetries = *f.MaxRetries
+	}
+	if f.ClientTimeout != "" && unset(EnvVaultClientTimeout) {
+		timeout, err := parseutil.ParseDurationSecond(f.ClientTimeout)
+		if err != nil {
+			return errwrap.Wrapf("error parsing client_timeout: {{err}}", err)
+		}
+		c.Timeout = timeout
+	}
+	if f.RateLimit != "" && unset(EnvRateLimit) {
+		rateLimit, burstLimit, err := parseRateLimit(f.RateLimit)
+		if err != nil {
+			return fmt.Errorf("error parsing rate_limit %q", f.RateLimit)
+		}
+		c.Limiter = rate.NewLimiter(rate.Limit(rateLimit), burstLimit)
+	}
+	if f.SRVLookup != nil && unset(EnvVaultSRVLookup) {
+		c.SRVLookup = *f.SRVLookup
+	}
+
+	if f.TLS != nil {
+		t := &TLSConfig{
+			CACert:        envOr(c, EnvVaultCACert, f.TLS.CACert),
+			CAPath:        envOr(c, EnvVaultCAPath, f.TLS.CAPath),
+			ClientCert:    envOr(c, EnvVaultClientCert, f.TLS.ClientCert),
+			ClientKey:     envOr(c, EnvVaultClientKey, f.TLS.ClientKey),
+			TLSServerName: envOr(c, EnvVaultTLSServerName, f.TLS.TLSServerName),
+			Insecure:      f.TLS.SkipVerify,
+			MinVersion:    envOr(c, EnvVaultTLSMinVersion, f.TLS.MinVersion),
+			MaxVersion:    envOr(c, EnvVaultTLSMaxVersion, f.TLS.MaxVersion),
+		}
+		if v := c.lookupEnv(EnvVaultTLSCipherSuites); v != "" {
+			t.CipherSuites = strings.Split(v, ",")
+		}
+		if v := c.lookupEnv(EnvVaultSkipVerify); v != "" {
+			// Already validated by ReadEnvironment
+			t.Insecure, _ = strconv.ParseBool(v)
+		}
+		if err := c.ConfigureTLS(t); err != nil {
+			return err
+		}
+	}
+
+	if f.TokenHelper != "" {
+		helperPath, err := filepath.Abs(f.TokenHelper)
+		if err != nil {
+			return err
+		}
+		c.TokenSources = []TokenSource{
+			&EnvTokenSource{},
+			&TokenHelperSource{Helper: &ExternalTokenHelper{BinaryPath: helperPath}},
+		}
+	}
+
+	return nil
+}
+
+// envOr returns the value of the environment variable as read by the
+// configuration, or def if it is unset.
+func envOr(c *Config, name, def string) string {
+	if v := c.lookupEnv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func expandHomeDir(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.hcl")
+	contents := `
+token_helper = "/usr/local/bin/vault-helper"
+address = "https://vault.example.com:8200"
+namespace = "ns1"
+max_retries = 5
+client_timeout = "90s"
+rate_limit = "10:20"
+
+tls {
+	tls_server_name = "vault.internal"
+	tls_skip_verify = true
+}
+`
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Setenv(EnvVaultMaxRetries, os.Getenv(EnvVaultMaxRetries))
+	os.Setenv(EnvVaultMaxRetries, "1")
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Address != "https://vault.example.com:8200" || config.Namespace != "ns1" {
+		t.Fatalf("unexpected config %#v", config)
+	}
+	if config.MaxRetries != 1 {
+		t.Fatalf("expected environment to take precedence, got %d retries", config.MaxRetries)
+	}
+	if config.Timeout != 90*time.Second {
+		t.Fatalf("unexpected timeout %s", config.Timeout)
+	}
+	if config.Limiter == nil || config.Limiter.Limit() != 10 || config.Limiter.Burst() != 20 {
+		t.Fatalf("unexpected limiter %#v", config.Limiter)
+	}
+	tlsConfig := config.HttpClient.Transport.(*http.Transport).TLSClientConfig
+	if tlsConfig.ServerName != "vault.internal" || !tlsConfig.InsecureSkipVerify {
+		t.Fatalf("unexpected TLS config %#v", tlsConfig)
+	}
+	if len(config.TokenSources) != 2 {
+		t.Fatalf("expected environment and token helper sources, got %#v", config.TokenSources)
+	}
+	helper := config.TokenSources[1].(*TokenHelperSource).Helper.(*ExternalTokenHelper)
+	if helper.BinaryPath != "/usr/local/bin/vault-helper" {
+		t.Fatalf("unexpected token helper %q", helper.BinaryPath)
+	}
+
+	config.TokenSources = nil
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns := client.Headers().Get(consts.NamespaceHeaderName); ns != "ns1" {
+		t.Fatalf("unexpected namespace %q", ns)
+	}
+
+	config, err = LoadConfig(filepath.Join(dir, "missing.hcl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Address != DefaultConfig().Address {
+		t.Fatalf("expected default address, got %q", config.Address)
+	}
+
+	if _, err := ParseConfigFile(`unknown = "value"`); err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+}
+
+func TestLoadConfigWithEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.hcl")
+	contents := `
+token_helper = "/usr/local/bin/vault-helper"
+address = "https://vault.example.com:8200"
+max_retries = 5
+`
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unprefixed variables are ignored
+	defer os.Setenv(EnvVaultMaxRetries, os.Getenv(EnvVaultMaxRetries))
+	os.Setenv(EnvVaultMaxRetries, "1")
+
+	env := map[string]string{
+		EnvVaultConfigPath: path,
+		EnvVaultAddress:    "https://vault.internal:8200",
+		EnvVaultToken:      "env-token",
+	}
+	config, err := LoadConfigWithEnv("", func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Address != "https://vault.internal:8200" {
+		t.Fatalf("expected environment to take precedence, got %q", config.Address)
+	}
+	if config.MaxRetries != 5 {
+		t.Fatalf("expected %d retries from the file, got %d", 5, config.MaxRetries)
+	}
+
+	// The token source of the file reads the environment the same way
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Token() != "env-token" {
+		t.Fatalf("expected token from environment, got %q", client.Token())
+	}
+}
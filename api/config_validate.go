@@ -0,0 +1,164 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"golang.org/x/time/rate"
+)
+
+// ConfigError is a problem with a single field of a Config, as returned by
+// Config.Validate.
+type ConfigError struct {
+	Field string
+	Err   error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// WrappedErrors implements errwrap.Wrapper.
+func (e *ConfigError) WrappedErrors() []error {
+	return []error{e.Err}
+}
+
+// Validate checks the configuration for problems that would otherwise only
+// surface when the client is created or makes its first request: addresses
+// that cannot be parsed, conflicting fields, TLS files that no longer exist,
+// rate limiters that block every request, and negative durations and limits.
+// All problems found are returned together as a *multierror.Error of
+// *ConfigError.
+func (c *Config) Validate() error {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+
+	var result *multierror.Error
+	add := func(field string, err error) {
+		result = multierror.Append(result, &ConfigError{Field: field, Err: err})
+	}
+
+	if c.Error != nil {
+		add("Error", c.Error)
+	}
+
+	if c.Address != "" {
+		if _, _, err := parseAddress(c.Address); err != nil {
+			add("Address", err)
+		}
+	}
+	if _, err := parseAddresses(c.Addresses); err != nil {
+		add("Addresses", err)
+	}
+	if c.AgentAddress != "" {
+		if _, _, err := parseAddress(c.AgentAddress); err != nil {
+			add("AgentAddress", err)
+		}
+		if len(c.Addresses) > 0 {
+			add("AgentAddress", fmt.Errorf("cannot be combined with Addresses, as requests are always sent to the agent"))
+		}
+	}
+	if c.ProxyURL != "" {
+		if proxyURL, err := url.Parse(c.ProxyURL); err != nil {
+			add("ProxyURL", err)
+		} else if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" && proxyURL.Scheme != "socks5" {
+			add("ProxyURL", fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme))
+		}
+	}
+
+	for field, path := range map[string]string{
+		"TLSConfig.CACert":     c.tlsCACert,
+		"TLSConfig.CAPath":     c.tlsCAPath,
+		"TLSConfig.ClientCert": c.tlsClientCert,
+		"TLSConfig.ClientKey":  c.tlsClientKey,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			add(field, errwrap.Wrapf(fmt.Sprintf("cannot read %q: {{err}}", path), err))
+		}
+	}
+
+	if err := validateLimiter(c.Limiter); err != nil {
+		add("Limiter", err)
+	}
+	for namespace, limiter := range c.NamespaceLimiters {
+		if err := validateLimiter(limiter); err != nil {
+			add(fmt.Sprintf("NamespaceLimiters[%q]", namespace), err)
+		}
+	}
+	for prefix, limiter := range c.PathLimiters {
+		if err := validateLimiter(limiter); err != nil {
+			add(fmt.Sprintf("PathLimiters[%q]", prefix), err)
+		}
+	}
+
+	if c.Timeout < 0 {
+		add("Timeout", fmt.Errorf("cannot be negative, got %s", c.Timeout))
+	}
+	if c.MaxRetries < 0 {
+		add("MaxRetries", fmt.Errorf("cannot be negative, got %d", c.MaxRetries))
+	}
+	if c.SRVCacheTTL < 0 {
+		add("SRVCacheTTL", fmt.Errorf("cannot be negative, got %s", c.SRVCacheTTL))
+	}
+	if c.ClientCacheTTL < 0 {
+		add("ClientCacheTTL", fmt.Errorf("cannot be negative, got %s", c.ClientCacheTTL))
+	}
+	if c.MaxIdleConns < 0 {
+		add("MaxIdleConns", fmt.Errorf("cannot be negative, got %d", c.MaxIdleConns))
+	}
+	if c.MaxIdleConnsPerHost < 0 {
+		add("MaxIdleConnsPerHost", fmt.Errorf("cannot be negative, got %d", c.MaxIdleConnsPerHost))
+	}
+	if c.MaxConnsPerHost < 0 {
+		add("MaxConnsPerHost", fmt.Errorf("cannot be negative, got %d", c.MaxConnsPerHost))
+	}
+	if c.IdleConnTimeout < 0 {
+		add("IdleConnTimeout", fmt.Errorf("cannot be negative, got %s", c.IdleConnTimeout))
+	}
+	if c.MaxResponseBodyBytes < 0 {
+		add("MaxResponseBodyBytes", fmt.Errorf("cannot be negative, got %d", c.MaxResponseBodyBytes))
+	}
+	if c.Admission != nil {
+		if c.Admission.InteractiveConcurrency < 0 || c.Admission.BackgroundConcurrency < 0 || c.Admission.MaxQueueLength < 0 {
+			add("Admission", fmt.Errorf("concurrency and queue length cannot be negative"))
+		}
+	}
+	if c.Breaker != nil {
+		if c.Breaker.FailureThreshold < 0 || c.Breaker.CoolDown < 0 {
+			add("Breaker", fmt.Errorf("failure threshold and cool down cannot be negative"))
+		}
+	}
+	if c.AdaptiveRateLimit != nil {
+		if c.AdaptiveRateLimit.MinLimit < 0 || c.AdaptiveRateLimit.RecoveryInterval < 0 {
+			add("AdaptiveRateLimit", fmt.Errorf("minimum limit and recovery interval cannot be negative"))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// validateLimiter returns an error for limiters that would block every
+// request.
+func validateLimiter(limiter *rate.Limiter) error {
+	if limiter == nil || limiter.Limit() == rate.Inf {
+		return nil
+	}
+	if limiter.Limit() <= 0 {
+		return fmt.Errorf("rate must be positive, got %v", limiter.Limit())
+	}
+	if limiter.Burst() <= 0 {
+		return fmt.Errorf("burst must be positive, got %d", limiter.Burst())
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"golang.org/x/time/rate"
+)
+
+func TestConfigValidate(t *testing.T) {
+	config := DefaultConfig()
+	if err := config.Validate(); err != nil {
+		t.Fatalf("expected default config to be valid, got %v", err)
+	}
+
+	config.Address = "ftp://vault.example.com"
+	config.Addresses = []string{"https://vault1.example.com"}
+	config.AgentAddress = "http://127.0.0.1:8100"
+	config.Timeout = -1
+	config.MaxRetries = -1
+	config.Limiter = rate.NewLimiter(10, 0)
+	config.NamespaceLimiters = map[string]*rate.Limiter{
+		"ns1/": rate.NewLimiter(rate.Inf, 0),
+	}
+	config.tlsCACert = "/nonexistent/ca.pem"
+
+	err := config.Validate()
+	var merr *multierror.Error
+	if !errors.As(err, &merr) {
+		t.Fatalf("expected a multierror, got %v", err)
+	}
+
+	var fields []string
+	for _, e := range merr.Errors {
+		var configErr *ConfigError
+		if !errors.As(e, &configErr) {
+			t.Fatalf("expected a config error, got %T", e)
+		}
+		fields = append(fields, configErr.Field)
+	}
+	sort.Strings(fields)
+
+	expected := []string{"Address", "AgentAddress", "Limiter", "MaxRetries", "TLSConfig.CACert", "Timeout"}
+	if len(fields) != len(expected) {
+		t.Fatalf("expected errors for %v, got %v", expected, fields)
+	}
+	for i := range expected {
+		if fields[i] != expected[i] {
+			t.Fatalf("expected errors for %v, got %v", expected, fields)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// ErrHandoffTampered is returned by ReceiveSecret when the wrapping token
+// given was not created by HandoffSecret, e.g. because it wraps the response
+// of another endpoint, so the data it holds cannot be trusted.
+var ErrHandoffTampered = errors.New("wrapping token was not created by a secret handoff")
+
+// handoffCreationPath is the creation path of the wrapping tokens created by
+// HandoffSecret.
+const handoffCreationPath = "sys/wrapping/wrap"
+
+// Cubbyhole is used to work with secrets in a cubbyhole secrets engine,
+// which is private to the client's token.
+type Cubbyhole struct {
+	c         *Client
+	mountPath string
+}
+
+// Cubbyhole returns the client for the cubbyhole secrets engine mounted at
+// the given path, usually "cubbyhole".
+func (c *Client) Cubbyhole(mountPath string) *Cubbyhole {
+	return &Cubbyhole{
+		c:         c,
+		mountPath: strings.Trim(mountPath, "/"),
+	}
+}
+
+// Get returns the data of the secret at the given path, or ErrSecretNotFound
+// if there is none.
+func (c *Cubbyhole) Get(ctx context.Context, secretPath string) (map[string]interface{}, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, c.path(secretPath), nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, ErrSecretNotFound
+	}
+	return secret.Data, nil
+}
+
+// Put writes the secret at the given path, replacing any existing data.
+func (c *Cubbyhole) Put(ctx context.Context, secretPath string, data map[string]interface{}) error {
+	_, err := c.c.Logical().Do(ctx, "PUT", c.path(secretPath), data)
+	return err
+}
+
+// Delete deletes the secret at the given path.
+func (c *Cubbyhole) Delete(ctx context.Context, secretPath string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", c.path(secretPath), nil)
+	return err
+}
+
+func (c *Cubbyhole) path(secretPath string) string {
+	return c.mountPath + "/" + strings.TrimPrefix(secretPath, "/")
+}
+
+// HandoffSecret stores data in the cubbyhole of a new single-use wrapping
+// token valid for ttl, and returns the token. The token can be passed to
+// another process, which gets the data with ReceiveSecret; since the data
+// can only be read once, a token intercepted and used on the way makes the
+// legitimate receiver fail rather than silently share the secret.
+func (c *Client) HandoffSecret(ctx context.Context, data map[string]interface{}, ttl time.Duration) (string, error) {
+	r := c.NewRequest("PUT", "/v1/sys/wrapping/wrap")
+	if ttl > 0 {
+		r.WrapTTL = ttl.String()
+	}
+	if r.WrapTTL == "" {
+		r.WrapTTL = DefaultWrappingTTL
+	}
+	if err := r.SetJSONBody(data); err != nil {
+		return "", err
+	}
+
+	resp, err := c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := resp.parseSecret()
+	if err != nil {
+		return "", err
+	}
+	if secret == nil || secret.WrapInfo == nil || secret.WrapInfo.Token == "" {
+		return "", fmt.Errorf("no wrapping token returned")
+	}
+	return secret.WrapInfo.Token, nil
+}
+
+// ReceiveSecret returns the data stored by HandoffSecret in the cubbyhole of
+// the given wrapping token, which can no longer be used afterwards. The
+// token is checked to have been created by a handoff first, and
+// ErrHandoffTampered is returned if it was not.
+func (c *Client) ReceiveSecret(ctx context.Context, wrappingToken string) (map[string]interface{}, error) {
+	lookup, err := c.wrappingRequest(ctx, "/v1/sys/wrapping/lookup", wrappingToken)
+	if err != nil {
+		return nil, errwrap.Wrapf("error looking up wrapping token: {{err}}", err)
+	}
+	if lookup == nil || lookup.Data == nil {
+		return nil, fmt.Errorf("no data returned looking up wrapping token")
+	}
+	if creationPath, _ := lookup.Data["creation_path"].(string); creationPath != handoffCreationPath {
+		return nil, ErrHandoffTampered
+	}
+
+	secret, err := c.wrappingRequest(ctx, "/v1/sys/wrapping/unwrap", wrappingToken)
+	if err != nil {
+		return nil, errwrap.Wrapf("error unwrapping token: {{err}}", err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no data returned unwrapping token")
+	}
+	return secret.Data, nil
+}
+
+// wrappingRequest makes a request to a wrapping endpoint authenticated with
+// the wrapping token itself, so that it works regardless of the token of the
+// client.
+func (c *Client) wrappingRequest(ctx context.Context, requestPath, wrappingToken string) (*Secret, error) {
+	r := c.NewRequest("PUT", requestPath)
+	r.ClientToken = wrappingToken
+
+	resp, err := c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.parseSecret()
+}
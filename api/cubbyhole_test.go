@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCubbyhole(t *testing.T) {
+	var l sync.Mutex
+	secrets := make(map[string]map[string]interface{})
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		l.Lock()
+		defer l.Unlock()
+		switch req.Method {
+		case "GET":
+			data, ok := secrets[req.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+		case "PUT":
+			var data map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&data)
+			secrets[req.URL.Path] = data
+			w.WriteHeader(http.StatusNoContent)
+		case "DELETE":
+			delete(secrets, req.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cubbyhole := client.Cubbyhole("cubbyhole/")
+	ctx := context.Background()
+
+	if err := cubbyhole.Put(ctx, "foo", map[string]interface{}{"a": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := cubbyhole.Get(ctx, "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["a"] != "b" || secrets["/v1/cubbyhole/foo"] == nil {
+		t.Fatalf("unexpected data %#v", data)
+	}
+	if err := cubbyhole.Delete(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cubbyhole.Get(ctx, "foo"); err != ErrSecretNotFound {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestClientHandoffSecret(t *testing.T) {
+	var l sync.Mutex
+	wrapped := make(map[string]map[string]interface{})
+	creationPath := "sys/wrapping/wrap"
+	var wrapTTL string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		l.Lock()
+		defer l.Unlock()
+		token := req.Header.Get("X-Vault-Token")
+		switch req.URL.Path {
+		case "/v1/sys/wrapping/wrap":
+			wrapTTL = req.Header.Get("X-Vault-Wrap-TTL")
+			var data map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&data)
+			wrapped["s.wrapping"] = data
+			w.Write([]byte(`{"wrap_info": {"token": "s.wrapping", "ttl": 60, "creation_path": "sys/wrapping/wrap"}}`))
+		case "/v1/sys/wrapping/lookup":
+			if wrapped[token] == nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors": ["wrapping token is not valid or does not exist"]}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"creation_path": creationPath, "creation_ttl": 60},
+			})
+		case "/v1/sys/wrapping/unwrap":
+			data := wrapped[token]
+			if data == nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors": ["wrapping token is not valid or does not exist"]}`))
+				return
+			}
+			delete(wrapped, token)
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	token, err := client.HandoffSecret(ctx, map[string]interface{}{"password": "hunter2"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "s.wrapping" || wrapTTL != "1m0s" {
+		t.Fatalf("unexpected token %q wrapped with TTL %q", token, wrapTTL)
+	}
+
+	data, err := client.ReceiveSecret(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["password"] != "hunter2" {
+		t.Fatalf("unexpected data %#v", data)
+	}
+
+	// The data can only be received once
+	if _, err := client.ReceiveSecret(ctx, token); err == nil {
+		t.Fatal("expected error receiving the secret twice")
+	}
+
+	// Tokens wrapping the response of other endpoints are rejected
+	if _, err := client.HandoffSecret(ctx, map[string]interface{}{"a": "b"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	creationPath = "auth/token/create"
+	if _, err := client.ReceiveSecret(ctx, token); err != ErrHandoffTampered {
+		t.Fatalf("expected tampering error, got %v", err)
+	}
+	if wrapped[token] == nil {
+		t.Fatal("expected the tampered token not to be unwrapped")
+	}
+}
@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DatabaseDefaultMountPoint is the default path at which the database
+	// secrets engine is mounted.
+	DatabaseDefaultMountPoint = "database"
+)
+
+var (
+	ErrDatabaseCredentialRotatorMissingInput = errors.New("missing input")
+	ErrDatabaseCredentialRotatorMissingRole  = errors.New("missing role")
+
+	// DefaultDatabaseCredentialRotatorRetryInterval is the default time to
+	// wait before retrying to fetch credentials.
+	DefaultDatabaseCredentialRotatorRetryInterval = 10 * time.Second
+)
+
+// Database is used to perform operations on the database secrets engine.
+type Database struct {
+	c          *Client
+	MountPoint string
+}
+
+// Database returns the client for the database secrets engine mounted at the
+// default path.
+func (c *Client) Database() *Database {
+	return c.DatabaseWithMountPoint(DatabaseDefaultMountPoint)
+}
+
+// DatabaseWithMountPoint returns the client for the database secrets engine
+// mounted at the given path.
+func (c *Client) DatabaseWithMountPoint(mountPoint string) *Database {
+	return &Database{
+		c:          c,
+		MountPoint: mountPoint,
+	}
+}
+
+// DatabaseCredentials are dynamic credentials generated by the database
+// secrets engine.
+type DatabaseCredentials struct {
+	Username      string
+	Password      string
+	LeaseID       string
+	LeaseDuration time.Duration
+	Renewable     bool
+
+	// Secret is the response the credentials were read from, which can be
+	// used to manage the lease.
+	Secret *Secret
+}
+
+// GetCredentials generates credentials using the given role.
+func (c *Database) GetCredentials(ctx context.Context, role string) (*DatabaseCredentials, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, fmt.Sprintf("%s/creds/%s", c.MountPoint, role), nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	creds := &DatabaseCredentials{
+		LeaseID:       secret.LeaseID,
+		LeaseDuration: time.Duration(secret.LeaseDuration) * time.Second,
+		Renewable:     secret.Renewable,
+		Secret:        secret,
+	}
+	var ok bool
+	if creds.Username, ok = secret.Data["username"].(string); !ok {
+		return nil, errors.New("unexpected type for username in response")
+	}
+	if creds.Password, ok = secret.Data["password"].(string); !ok {
+		return nil, errors.New("unexpected type for password in response")
+	}
+	return creds, nil
+}
+
+// DatabaseCredentialRotator keeps database credentials valid: it renews
+// their lease for as long as possible, then fetches new credentials and
+// hands them to the application, which can rebuild its connection pools.
+//
+//	rotator, err := client.Database().NewCredentialRotator(&DatabaseCredentialRotatorInput{
+//		Role: "readonly",
+//		OnRotate: func(creds *DatabaseCredentials) {
+//			pool.Reconnect(creds.Username, creds.Password)
+//		},
+//	})
+//	go rotator.Start()
+//	defer rotator.Stop()
+type DatabaseCredentialRotator struct {
+	l sync.Mutex
+
+	database      *Database
+	role          string
+	current       *DatabaseCredentials
+	onRotate      func(*DatabaseCredentials)
+	onError       func(error)
+	retryInterval time.Duration
+	doneCh        chan error
+
+	stopped bool
+	stopCh  chan struct{}
+}
+
+// DatabaseCredentialRotatorInput is used as input to NewCredentialRotator.
+type DatabaseCredentialRotatorInput struct {
+	// Role is the role to generate credentials with.
+	Role string
+
+	// Credentials are the current credentials, if any. If not provided,
+	// credentials are fetched as soon as the rotator starts.
+	Credentials *DatabaseCredentials
+
+	// OnRotate is called with each set of new credentials, including the
+	// first if Credentials is not provided.
+	OnRotate func(*DatabaseCredentials)
+
+	// OnError is called with errors encountered while renewing or fetching
+	// credentials, which are otherwise retried.
+	OnError func(error)
+
+	// RetryInterval is the time to wait before retrying to fetch
+	// credentials.
+	RetryInterval time.Duration
+}
+
+// NewCredentialRotator creates a new credential rotator from the given input.
+func (c *Database) NewCredentialRotator(i *DatabaseCredentialRotatorInput) (*DatabaseCredentialRotator, error) {
+	if i == nil {
+		return nil, ErrDatabaseCredentialRotatorMissingInput
+	}
+	if i.Role == "" {
+		return nil, ErrDatabaseCredentialRotatorMissingRole
+	}
+
+	retryInterval := i.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = DefaultDatabaseCredentialRotatorRetryInterval
+	}
+
+	return &DatabaseCredentialRotator{
+		database:      c,
+		role:          i.Role,
+		current:       i.Credentials,
+		onRotate:      i.OnRotate,
+		onError:       i.OnError,
+		retryInterval: retryInterval,
+		doneCh:        make(chan error, 1),
+		stopCh:        make(chan struct{}),
+	}, nil
+}
+
+// DoneCh returns the channel where the rotator will publish when it stops.
+func (r *DatabaseCredentialRotator) DoneCh() <-chan error {
+	return r.doneCh
+}
+
+// Credentials returns the current credentials.
+func (r *DatabaseCredentialRotator) Credentials() *DatabaseCredentials {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	return r.current
+}
+
+// Stop stops the rotator.
+func (r *DatabaseCredentialRotator) Stop() {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if !r.stopped {
+		close(r.stopCh)
+		r.stopped = true
+	}
+}
+
+// Start rotates the credentials until the rotator is stopped. It blocks, so
+// it should usually be run in a goroutine.
+func (r *DatabaseCredentialRotator) Start() {
+	r.doneCh <- r.doRotate()
+}
+
+func (r *DatabaseCredentialRotator) doRotate() error {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	go func() {
+		select {
+		case <-r.stopCh:
+			cancelFunc()
+		case <-r.database.c.closedCh():
+			r.Stop()
+			cancelFunc()
+		case <-ctx.Done():
+		}
+	}()
+
+	creds := r.Credentials()
+	for {
+		if creds == nil {
+			var err error
+			creds, err = r.database.GetCredentials(ctx, r.role)
+			if err != nil {
+				r.reportError(err)
+				select {
+				case <-r.stopCh:
+					return nil
+				case <-time.After(r.retryInterval):
+					continue
+				}
+			}
+
+			r.l.Lock()
+			r.current = creds
+			r.l.Unlock()
+			if r.onRotate != nil {
+				r.onRotate(creds)
+			}
+		}
+
+		// Credentials without a lease never need rotating.
+		if creds.LeaseDuration == 0 {
+			<-r.stopCh
+			return nil
+		}
+
+		watcher, err := r.database.c.NewLifetimeWatcher(&LifetimeWatcherInput{
+			Secret: creds.Secret,
+		})
+		if err != nil {
+			return err
+		}
+		go watcher.Start()
+
+		select {
+		case <-r.stopCh:
+			watcher.Stop()
+			return nil
+		case err := <-watcher.DoneCh():
+			if err != nil {
+				r.reportError(err)
+			}
+		}
+
+		// The lease can no longer be extended, so new credentials are needed.
+		creds = nil
+	}
+}
+
+func (r *DatabaseCredentialRotator) reportError(err error) {
+	if r.onError != nil {
+		r.onError(err)
+	}
+}
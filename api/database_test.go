@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDatabaseCredentialRotator(t *testing.T) {
+	var issued int32
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/database/creds/readonly" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := atomic.AddInt32(&issued, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"lease_id":       fmt.Sprintf("database/creds/readonly/%d", n),
+			"lease_duration": 1,
+			"renewable":      false,
+			"data": map[string]interface{}{
+				"username": fmt.Sprintf("v-user-%d", n),
+				"password": "secret",
+			},
+		})
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := client.Database().GetCredentials(context.Background(), "readonly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Username != "v-user-1" || creds.Password != "secret" || creds.LeaseDuration != time.Second {
+		t.Fatalf("bad credentials: %#v", creds)
+	}
+
+	rotated := make(chan *DatabaseCredentials, 10)
+	rotator, err := client.Database().NewCredentialRotator(&DatabaseCredentialRotatorInput{
+		Role:        "readonly",
+		Credentials: creds,
+		OnRotate: func(creds *DatabaseCredentials) {
+			rotated <- creds
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go rotator.Start()
+	defer rotator.Stop()
+
+	select {
+	case creds := <-rotated:
+		if creds.Username != "v-user-2" {
+			t.Fatalf("expected new credentials, got %#v", creds)
+		}
+		if rotator.Credentials() != creds {
+			t.Fatal("expected the rotator to return the new credentials")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("credentials were not rotated")
+	}
+
+	rotator.Stop()
+	select {
+	case err := <-rotator.DoneCh():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("rotator did not stop")
+	}
+}
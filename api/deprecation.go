@@ -0,0 +1,165 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResponseDeprecation describes the deprecation notices attached to a
+// response, from the Deprecation and Sunset headers and from warnings in the
+// Warning header.
+type ResponseDeprecation struct {
+	// Deprecated is true if the server marked the endpoint as deprecated.
+	Deprecated bool
+
+	// Date is when the endpoint was or will be deprecated, if given.
+	Date *time.Time
+
+	// Sunset is when the endpoint is expected to stop responding, if given.
+	Sunset *time.Time
+
+	// Link is the value of the Link header, which may point at
+	// documentation about the deprecation.
+	Link string
+
+	// Warnings are the warning texts from the Warning header.
+	Warnings []string
+}
+
+// warningRegexp matches a warning-value of the Warning header (RFC 7234),
+// capturing the quoted warning text.
+var warningRegexp = regexp.MustCompile(`\d{3} \S+ "((?:[^"\\]|\\.)*)"`)
+
+// Deprecations returns the deprecation notices attached to the response, or
+// nil if there are none.
+func (r *Response) Deprecations() *ResponseDeprecation {
+	if r == nil || r.Response == nil {
+		return nil
+	}
+	return parseDeprecation(r.Header)
+}
+
+func parseDeprecation(header http.Header) *ResponseDeprecation {
+	var d ResponseDeprecation
+	found := false
+
+	if v := strings.TrimSpace(header.Get("Deprecation")); v != "" && v != "false" {
+		found = true
+		d.Deprecated = true
+		d.Date = parseDeprecationDate(v)
+	}
+
+	if v := strings.TrimSpace(header.Get("Sunset")); v != "" {
+		found = true
+		if t, err := http.ParseTime(v); err == nil {
+			d.Sunset = &t
+		}
+	}
+
+	for _, v := range header["Warning"] {
+		for _, match := range warningRegexp.FindAllStringSubmatch(v, -1) {
+			found = true
+			d.Warnings = append(d.Warnings, strings.Replace(match[1], `\"`, `"`, -1))
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	d.Link = header.Get("Link")
+	return &d
+}
+
+// parseDeprecationDate parses the value of a Deprecation header, which is
+// either "true", an "@"-prefixed Unix timestamp, or an HTTP date.
+func parseDeprecationDate(v string) *time.Time {
+	if strings.HasPrefix(v, "@") {
+		if secs, err := strconv.ParseInt(v[1:], 10, 64); err == nil {
+			t := time.Unix(secs, 0).UTC()
+			return &t
+		}
+		return nil
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return &t
+	}
+	return nil
+}
+
+// DeprecationReportEntry summarizes the deprecation notices received for an
+// endpoint.
+type DeprecationReportEntry struct {
+	Method      string
+	Path        string
+	Count       int
+	LastSeen    time.Time
+	Deprecation *ResponseDeprecation
+}
+
+// deprecationTracker aggregates the deprecation notices received by a client.
+type deprecationTracker struct {
+	l       sync.Mutex
+	entries map[string]*DeprecationReportEntry
+}
+
+func newDeprecationTracker() *deprecationTracker {
+	return &deprecationTracker{
+		entries: make(map[string]*DeprecationReportEntry),
+	}
+}
+
+func (t *deprecationTracker) record(method, path string, resp *Response) {
+	d := resp.Deprecations()
+	if d == nil {
+		return
+	}
+
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	key := method + " " + path
+	entry, ok := t.entries[key]
+	if !ok {
+		entry = &DeprecationReportEntry{
+			Method: method,
+			Path:   path,
+		}
+		t.entries[key] = entry
+	}
+	entry.Count++
+	entry.LastSeen = time.Now()
+	entry.Deprecation = d
+}
+
+// DeprecationReport returns the endpoints for which this client, and any
+// copies made with WithNamespace or WithPriority, received deprecation
+// notices, ordered by path and method.
+func (c *Client) DeprecationReport() []DeprecationReportEntry {
+	c.modifyLock.RLock()
+	tracker := c.deprecations
+	c.modifyLock.RUnlock()
+
+	if tracker == nil {
+		return nil
+	}
+
+	tracker.l.Lock()
+	defer tracker.l.Unlock()
+
+	report := make([]DeprecationReportEntry, 0, len(tracker.entries))
+	for _, entry := range tracker.entries {
+		report = append(report, *entry)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Path != report[j].Path {
+			return report[i].Path < report[j].Path
+		}
+		return report[i].Method < report[j].Method
+	})
+	return report
+}
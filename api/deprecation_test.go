@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDeprecation(t *testing.T) {
+	header := http.Header{}
+	if d := parseDeprecation(header); d != nil {
+		t.Fatalf("expected no deprecation, got %#v", d)
+	}
+
+	header.Set("Deprecation", "@1688169599")
+	header.Set("Sunset", "Wed, 11 Nov 2026 23:59:59 GMT")
+	header.Set("Link", `<https://example.com/docs>; rel="deprecation"`)
+	header.Add("Warning", `299 - "endpoint is deprecated", 299 vault "use \"v2\" instead"`)
+
+	d := parseDeprecation(header)
+	if d == nil || !d.Deprecated {
+		t.Fatalf("expected deprecation, got %#v", d)
+	}
+	if d.Date == nil || !d.Date.Equal(time.Unix(1688169599, 0)) {
+		t.Fatalf("bad deprecation date: %v", d.Date)
+	}
+	if d.Sunset == nil || d.Sunset.Year() != 2026 {
+		t.Fatalf("bad sunset: %v", d.Sunset)
+	}
+	expected := []string{"endpoint is deprecated", `use "v2" instead`}
+	if !reflect.DeepEqual(d.Warnings, expected) {
+		t.Fatalf("expected warnings %q, got %q", expected, d.Warnings)
+	}
+}
+
+func TestClientDeprecationReport(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/old" {
+			w.Header().Set("Deprecation", "true")
+		}
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"/v1/old", "/v1/new", "/v1/old"} {
+		if _, err := client.RawRequest(client.NewRequest("GET", p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := client.DeprecationReport()
+	if len(report) != 1 || report[0].Path != "/v1/old" || report[0].Count != 2 {
+		t.Fatalf("bad report: %#v", report)
+	}
+}
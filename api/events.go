@@ -0,0 +1,179 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+)
+
+const (
+	// eventsReconnectMin and eventsReconnectMax bound the backoff used when
+	// the event stream is interrupted and the client reconnects.
+	eventsReconnectMin = 500 * time.Millisecond
+	eventsReconnectMax = 30 * time.Second
+
+	// DefaultEventsBuffer is the size of the buffered channel on which
+	// subscribed events are delivered.
+	DefaultEventsBuffer = 64
+)
+
+// Events is used to subscribe to event notifications published by Vault.
+type Events struct {
+	c *Client
+}
+
+// Events is used to return the client for event subscription API calls.
+func (c *Client) Events() *Events {
+	return &Events{c: c}
+}
+
+// Event is a single event notification received from the server's event
+// stream.
+type Event struct {
+	ID        string                 `json:"id"`
+	EventType string                 `json:"event_type"`
+	Namespace string                 `json:"namespace"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// Subscribe opens a streaming connection to the server's event endpoint and
+// returns a channel on which decoded events matching the given event types
+// are delivered. If no event types are given, all events visible to the
+// client's token are delivered.
+//
+// If the stream is interrupted the client transparently reconnects, resuming
+// from the last event received. The channel is closed once the context is
+// cancelled or the client is closed.
+func (e *Events) Subscribe(ctx context.Context, eventTypes ...string) (<-chan *Event, error) {
+	ctx, cancelFunc := e.c.withCloseContext(ctx)
+	resp, err := e.subscribe(ctx, eventTypes, "")
+	if err != nil {
+		cancelFunc()
+		return nil, err
+	}
+
+	eventCh := make(chan *Event, DefaultEventsBuffer)
+
+	go func() {
+		defer cancelFunc()
+		defer close(eventCh)
+
+		var lastID string
+		wait := eventsReconnectMin
+		for {
+			if resp != nil {
+				id, err := readEventStream(ctx, resp, eventCh)
+				resp.Body.Close()
+				if id != "" {
+					lastID = id
+					wait = eventsReconnectMin
+				}
+				if err == nil {
+					wait = eventsReconnectMin
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+
+			wait *= 2
+			if wait > eventsReconnectMax {
+				wait = eventsReconnectMax
+			}
+
+			// Errors here are retried on the next pass of the loop; the
+			// subscription only ends when the caller cancels the context.
+			resp, _ = e.subscribe(ctx, eventTypes, lastID)
+		}
+	}()
+
+	return eventCh, nil
+}
+
+func (e *Events) subscribe(ctx context.Context, eventTypes []string, lastID string) (*Response, error) {
+	r := e.c.NewRequest("GET", "/v1/sys/events/subscribe")
+	r.Headers.Set("Accept", "text/event-stream")
+	if lastID != "" {
+		r.Headers.Set("Last-Event-ID", lastID)
+	}
+	for _, eventType := range eventTypes {
+		r.Params.Add("event_type", eventType)
+	}
+
+	// The stream stays open until ctx is cancelled, so the client's timeout
+	// would only cut it off and lose events while reconnecting
+	resp, err := e.c.withoutTimeout().RawRequestWithContext(ctx, r)
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// readEventStream reads server-sent events from the response body, decoding
+// each into an Event and delivering it on eventCh. It returns the ID of the
+// last event delivered.
+func readEventStream(ctx context.Context, resp *Response, eventCh chan<- *Event) (string, error) {
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lastID, id string
+	var data strings.Builder
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			// A blank line dispatches the event accumulated so far
+			if data.Len() == 0 {
+				id = ""
+				continue
+			}
+
+			var event Event
+			if err := jsonutil.DecodeJSON([]byte(data.String()), &event); err != nil {
+				return lastID, errwrap.Wrapf("error decoding event: {{err}}", err)
+			}
+			data.Reset()
+			if event.ID == "" {
+				event.ID = id
+			}
+			id = ""
+
+			select {
+			case eventCh <- &event:
+			case <-ctx.Done():
+				return lastID, ctx.Err()
+			}
+			if event.ID != "" {
+				lastID = event.ID
+			}
+
+		case strings.HasPrefix(line, ":"):
+			// Comment, used by servers as a keep-alive
+
+		case strings.HasPrefix(line, "id:"):
+			id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	return lastID, scanner.Err()
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventsSubscribe_resume(t *testing.T) {
+	var l sync.Mutex
+	var seenLastIDs []string
+
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		l.Lock()
+		seenLastIDs = append(seenLastIDs, req.Header.Get("Last-Event-ID"))
+		attempt := len(seenLastIDs)
+		l.Unlock()
+
+		if got := req.URL.Query()["event_type"]; len(got) != 1 || got[0] != "kv-v2/data-write" {
+			t.Errorf("bad event types: %v", got)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, ": keep-alive\n\n")
+		fmt.Fprintf(w, "id: %d\ndata: {\"event_type\":\"kv-v2/data-write\",\"data\":{\"path\":\"secret/data/%d\"}}\n\n", attempt, attempt)
+	}
+
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eventCh, err := client.Events().Subscribe(ctx, "kv-v2/data-write")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		select {
+		case event := <-eventCh:
+			if event.ID != fmt.Sprintf("%d", i) {
+				t.Fatalf("bad event ID: %q", event.ID)
+			}
+			if event.Data["path"] != fmt.Sprintf("secret/data/%d", i) {
+				t.Fatalf("bad event data: %#v", event.Data)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+
+	cancel()
+	for range eventCh {
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	if seenLastIDs[0] != "" || seenLastIDs[1] != "1" {
+		t.Fatalf("bad Last-Event-ID headers: %v", seenLastIDs)
+	}
+}
+
+func TestEventsSubscribe_noTimeout(t *testing.T) {
+	var l sync.Mutex
+	connections := 0
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		l.Lock()
+		connections++
+		l.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		// Send an event after the client's timeout has passed
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprintf(w, "id: 1\ndata: {\"event_type\":\"kv-v2/data-write\"}\n\n")
+		w.(http.Flusher).Flush()
+		<-req.Context().Done()
+	}
+
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	config.Timeout = 50 * time.Millisecond
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eventCh, err := client.Events().Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-eventCh:
+		if event.ID != "1" {
+			t.Fatalf("bad event ID: %q", event.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	if connections != 1 {
+		t.Fatalf("expected the stream to outlive the client timeout, got %d connections", connections)
+	}
+}
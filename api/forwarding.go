@@ -0,0 +1,56 @@
+package api
+
+import "net/http"
+
+const (
+	// HeaderForward asks a standby to forward the request to the active
+	// node rather than serve it itself.
+	HeaderForward = "X-Vault-Forward"
+
+	// HeaderInconsistent controls what a performance standby does when it
+	// has not caught up with a replication state required by the request:
+	// fail with a 412 (the default), or forward the request to the active
+	// node.
+	HeaderInconsistent = "X-Vault-Inconsistent"
+
+	forwardActiveNode        = "active-node"
+	inconsistentForwardValue = "forward-active-node"
+)
+
+// SetForwardToActive makes standbys forward the request to the active node,
+// so that it is always served with the active node's view of the data.
+func (r *Request) SetForwardToActive() {
+	if r.Headers == nil {
+		r.Headers = make(http.Header)
+	}
+	r.Headers.Set(HeaderForward, forwardActiveNode)
+}
+
+// SetInconsistentForward makes a performance standby that has not caught up
+// with the replication states required by the request forward it to the
+// active node instead of failing it. See Client.SetReadYourWrites.
+func (r *Request) SetInconsistentForward() {
+	if r.Headers == nil {
+		r.Headers = make(http.Header)
+	}
+	r.Headers.Set(HeaderInconsistent, inconsistentForwardValue)
+}
+
+// WithForwardToActive returns a copy of the client whose requests are
+// forwarded to the active node by standbys, for instance to send writes
+// directly to the active node. The original client is not affected.
+func (c *Client) WithForwardToActive() *Client {
+	c2 := c.shallowCopy()
+	c2.headers.Set(HeaderForward, forwardActiveNode)
+	return c2
+}
+
+// WithInconsistentForward returns a copy of the client whose requests are
+// forwarded to the active node by performance standbys that have not caught
+// up with the replication states they require, rather than failed. The
+// original client is not affected.
+func (c *Client) WithInconsistentForward() *Client {
+	c2 := c.shallowCopy()
+	c2.headers.Set(HeaderInconsistent, inconsistentForwardValue)
+	return c2
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClientForwarding(t *testing.T) {
+	var headers http.Header
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		headers = req.Header
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.WithForwardToActive().Logical().Write("secret/foo", nil); err != nil {
+		t.Fatal(err)
+	}
+	if headers.Get(HeaderForward) != "active-node" || headers.Get(HeaderInconsistent) != "" {
+		t.Fatalf("unexpected headers %v", headers)
+	}
+
+	if _, err := client.WithInconsistentForward().Logical().Write("secret/foo", nil); err != nil {
+		t.Fatal(err)
+	}
+	if headers.Get(HeaderForward) != "" || headers.Get(HeaderInconsistent) != "forward-active-node" {
+		t.Fatalf("unexpected headers %v", headers)
+	}
+
+	r := client.NewRequest("GET", "/v1/secret/foo")
+	r.SetForwardToActive()
+	r.SetInconsistentForward()
+	resp, err := client.RawRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if headers.Get(HeaderForward) != "active-node" || headers.Get(HeaderInconsistent) != "forward-active-node" {
+		t.Fatalf("unexpected headers %v", headers)
+	}
+
+	if _, err := client.Logical().Write("secret/foo", nil); err != nil {
+		t.Fatal(err)
+	}
+	if headers.Get(HeaderForward) != "" || headers.Get(HeaderInconsistent) != "" {
+		t.Fatalf("original client should not forward, got headers %v", headers)
+	}
+}
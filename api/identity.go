@@ -0,0 +1,356 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Identity is used to manage the entities, groups and aliases of the
+// identity secrets engine.
+type Identity struct {
+	c *Client
+}
+
+// Identity returns the client for the identity secrets engine.
+func (c *Client) Identity() *Identity {
+	return &Identity{c: c}
+}
+
+// Entity is an identity entity, which ties together the aliases a client
+// authenticates with on different auth mounts.
+type Entity struct {
+	ID                string            `mapstructure:"id"`
+	Name              string            `mapstructure:"name"`
+	NamespaceID       string            `mapstructure:"namespace_id"`
+	Policies          []string          `mapstructure:"policies"`
+	Metadata          map[string]string `mapstructure:"metadata"`
+	Disabled          bool              `mapstructure:"disabled"`
+	Aliases           []*EntityAlias    `mapstructure:"aliases"`
+	DirectGroupIDs    []string          `mapstructure:"direct_group_ids"`
+	GroupIDs          []string          `mapstructure:"group_ids"`
+	InheritedGroupIDs []string          `mapstructure:"inherited_group_ids"`
+	CreationTime      time.Time         `mapstructure:"creation_time"`
+	LastUpdateTime    time.Time         `mapstructure:"last_update_time"`
+}
+
+// EntityInput are the parameters for creating or updating an entity. On
+// update, the policies and metadata replace the existing ones.
+type EntityInput struct {
+	Name     string
+	Policies []string
+	Metadata map[string]string
+	Disabled bool
+}
+
+func (i *EntityInput) body() map[string]interface{} {
+	body := map[string]interface{}{
+		"disabled": i.Disabled,
+	}
+	if i.Name != "" {
+		body["name"] = i.Name
+	}
+	if i.Policies != nil {
+		body["policies"] = i.Policies
+	}
+	if i.Metadata != nil {
+		body["metadata"] = i.Metadata
+	}
+	return body
+}
+
+// EntityAlias maps an identity of an auth mount, such as a user name, to an
+// entity, given by its canonical ID.
+type EntityAlias struct {
+	ID             string            `mapstructure:"id"`
+	Name           string            `mapstructure:"name"`
+	CanonicalID    string            `mapstructure:"canonical_id"`
+	MountAccessor  string            `mapstructure:"mount_accessor"`
+	MountPath      string            `mapstructure:"mount_path"`
+	MountType      string            `mapstructure:"mount_type"`
+	Metadata       map[string]string `mapstructure:"metadata"`
+	CustomMetadata map[string]string `mapstructure:"custom_metadata"`
+	Local          bool              `mapstructure:"local"`
+	CreationTime   time.Time         `mapstructure:"creation_time"`
+	LastUpdateTime time.Time         `mapstructure:"last_update_time"`
+}
+
+// EntityAliasInput are the parameters for creating or updating an entity
+// alias. MountAccessor is the accessor of the auth mount the name belongs to.
+type EntityAliasInput struct {
+	Name           string
+	CanonicalID    string
+	MountAccessor  string
+	CustomMetadata map[string]string
+}
+
+func (i *EntityAliasInput) body() map[string]interface{} {
+	body := map[string]interface{}{}
+	if i.Name != "" {
+		body["name"] = i.Name
+	}
+	if i.CanonicalID != "" {
+		body["canonical_id"] = i.CanonicalID
+	}
+	if i.MountAccessor != "" {
+		body["mount_accessor"] = i.MountAccessor
+	}
+	if i.CustomMetadata != nil {
+		body["custom_metadata"] = i.CustomMetadata
+	}
+	return body
+}
+
+// Group is an identity group. Internal groups list their members, while the
+// membership of external groups is managed by an auth mount through their
+// alias.
+type Group struct {
+	ID              string                 `mapstructure:"id"`
+	Name            string                 `mapstructure:"name"`
+	Type            string                 `mapstructure:"type"`
+	NamespaceID     string                 `mapstructure:"namespace_id"`
+	Policies        []string               `mapstructure:"policies"`
+	Metadata        map[string]string      `mapstructure:"metadata"`
+	MemberEntityIDs []string               `mapstructure:"member_entity_ids"`
+	MemberGroupIDs  []string               `mapstructure:"member_group_ids"`
+	ParentGroupIDs  []string               `mapstructure:"parent_group_ids"`
+	Alias           map[string]interface{} `mapstructure:"alias"`
+	CreationTime    time.Time              `mapstructure:"creation_time"`
+	LastUpdateTime  time.Time              `mapstructure:"last_update_time"`
+}
+
+// GroupInput are the parameters for creating or updating a group. Type is
+// "internal" or "external", and can only be set on creation. Members can
+// only be set on internal groups.
+type GroupInput struct {
+	Name            string
+	Type            string
+	Policies        []string
+	Metadata        map[string]string
+	MemberEntityIDs []string
+	MemberGroupIDs  []string
+}
+
+func (i *GroupInput) body() map[string]interface{} {
+	body := map[string]interface{}{}
+	if i.Name != "" {
+		body["name"] = i.Name
+	}
+	if i.Type != "" {
+		body["type"] = i.Type
+	}
+	if i.Policies != nil {
+		body["policies"] = i.Policies
+	}
+	if i.Metadata != nil {
+		body["metadata"] = i.Metadata
+	}
+	if i.MemberEntityIDs != nil {
+		body["member_entity_ids"] = i.MemberEntityIDs
+	}
+	if i.MemberGroupIDs != nil {
+		body["member_group_ids"] = i.MemberGroupIDs
+	}
+	return body
+}
+
+// EntityLookupInput are the criteria for looking up an entity. Exactly one
+// of ID, Name, AliasID, or AliasName with AliasMountAccessor must be given.
+type EntityLookupInput struct {
+	ID                 string
+	Name               string
+	AliasID            string
+	AliasName          string
+	AliasMountAccessor string
+}
+
+// CreateEntity creates an entity and returns its ID.
+func (c *Identity) CreateEntity(ctx context.Context, input *EntityInput) (string, error) {
+	return c.create(ctx, "identity/entity", input.body())
+}
+
+// ReadEntity returns the entity with the given ID, or nil if there is none.
+func (c *Identity) ReadEntity(ctx context.Context, id string) (*Entity, error) {
+	var entity Entity
+	if ok, err := c.read(ctx, "identity/entity/id/"+id, &entity); err != nil || !ok {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// ReadEntityByName returns the entity with the given name, or nil if there
+// is none.
+func (c *Identity) ReadEntityByName(ctx context.Context, name string) (*Entity, error) {
+	var entity Entity
+	if ok, err := c.read(ctx, "identity/entity/name/"+name, &entity); err != nil || !ok {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// UpdateEntity updates the entity with the given ID.
+func (c *Identity) UpdateEntity(ctx context.Context, id string, input *EntityInput) error {
+	_, err := c.c.Logical().Do(ctx, "POST", "identity/entity/id/"+id, input.body())
+	return err
+}
+
+// DeleteEntity deletes the entity with the given ID, along with its aliases.
+func (c *Identity) DeleteEntity(ctx context.Context, id string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", "identity/entity/id/"+id, nil)
+	return err
+}
+
+// ListEntities returns the IDs of the entities.
+func (c *Identity) ListEntities(ctx context.Context) ([]string, error) {
+	return c.list(ctx, "identity/entity/id")
+}
+
+// LookupEntity returns the entity matching the input, or nil if there is
+// none.
+func (c *Identity) LookupEntity(ctx context.Context, input *EntityLookupInput) (*Entity, error) {
+	body := map[string]interface{}{}
+	for field, value := range map[string]string{
+		"id":                   input.ID,
+		"name":                 input.Name,
+		"alias_id":             input.AliasID,
+		"alias_name":           input.AliasName,
+		"alias_mount_accessor": input.AliasMountAccessor,
+	} {
+		if value != "" {
+			body[field] = value
+		}
+	}
+
+	secret, err := c.c.Logical().Do(ctx, "POST", "identity/lookup/entity", body)
+	if err != nil {
+		return nil, err
+	}
+	var entity Entity
+	if ok, err := decodeIdentity(secret, &entity); err != nil || !ok {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// CreateEntityAlias creates an entity alias and returns its ID.
+func (c *Identity) CreateEntityAlias(ctx context.Context, input *EntityAliasInput) (string, error) {
+	return c.create(ctx, "identity/entity-alias", input.body())
+}
+
+// ReadEntityAlias returns the entity alias with the given ID, or nil if
+// there is none.
+func (c *Identity) ReadEntityAlias(ctx context.Context, id string) (*EntityAlias, error) {
+	var alias EntityAlias
+	if ok, err := c.read(ctx, "identity/entity-alias/id/"+id, &alias); err != nil || !ok {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// UpdateEntityAlias updates the entity alias with the given ID.
+func (c *Identity) UpdateEntityAlias(ctx context.Context, id string, input *EntityAliasInput) error {
+	_, err := c.c.Logical().Do(ctx, "POST", "identity/entity-alias/id/"+id, input.body())
+	return err
+}
+
+// DeleteEntityAlias deletes the entity alias with the given ID.
+func (c *Identity) DeleteEntityAlias(ctx context.Context, id string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", "identity/entity-alias/id/"+id, nil)
+	return err
+}
+
+// ListEntityAliases returns the IDs of the entity aliases.
+func (c *Identity) ListEntityAliases(ctx context.Context) ([]string, error) {
+	return c.list(ctx, "identity/entity-alias/id")
+}
+
+// CreateGroup creates a group and returns its ID.
+func (c *Identity) CreateGroup(ctx context.Context, input *GroupInput) (string, error) {
+	return c.create(ctx, "identity/group", input.body())
+}
+
+// ReadGroup returns the group with the given ID, or nil if there is none.
+func (c *Identity) ReadGroup(ctx context.Context, id string) (*Group, error) {
+	var group Group
+	if ok, err := c.read(ctx, "identity/group/id/"+id, &group); err != nil || !ok {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// ReadGroupByName returns the group with the given name, or nil if there is
+// none.
+func (c *Identity) ReadGroupByName(ctx context.Context, name string) (*Group, error) {
+	var group Group
+	if ok, err := c.read(ctx, "identity/group/name/"+name, &group); err != nil || !ok {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// UpdateGroup updates the group with the given ID.
+func (c *Identity) UpdateGroup(ctx context.Context, id string, input *GroupInput) error {
+	_, err := c.c.Logical().Do(ctx, "POST", "identity/group/id/"+id, input.body())
+	return err
+}
+
+// DeleteGroup deletes the group with the given ID.
+func (c *Identity) DeleteGroup(ctx context.Context, id string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", "identity/group/id/"+id, nil)
+	return err
+}
+
+// ListGroups returns the IDs of the groups.
+func (c *Identity) ListGroups(ctx context.Context) ([]string, error) {
+	return c.list(ctx, "identity/group/id")
+}
+
+func (c *Identity) create(ctx context.Context, path string, body map[string]interface{}) (string, error) {
+	secret, err := c.c.Logical().Do(ctx, "POST", path, body)
+	if err != nil {
+		return "", err
+	}
+	if secret == nil || secret.Data == nil {
+		return "", fmt.Errorf("no ID returned creating %q", path)
+	}
+	id, ok := secret.Data["id"].(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected type for id in response")
+	}
+	return id, nil
+}
+
+// read decodes the data at the given path into out, returning false if there
+// is none.
+func (c *Identity) read(ctx context.Context, path string, out interface{}) (bool, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, path, nil)
+	if err != nil {
+		return false, err
+	}
+	return decodeIdentity(secret, out)
+}
+
+func (c *Identity) list(ctx context.Context, path string) ([]string, error) {
+	secret, err := c.c.Logical().listWithContext(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var keys []string
+	if err := mapstructure.Decode(secret.Data["keys"], &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func decodeIdentity(secret *Secret, out interface{}) (bool, error) {
+	if secret == nil || secret.Data == nil {
+		return false, nil
+	}
+	return true, secret.DecodeData(out)
+}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	jose "gopkg.in/square/go-jose.v2"
+	squarejwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+// ErrOIDCKeyNotFound is returned by ValidateOIDCToken when the token was not
+// signed by any of the keys published by the server.
+var ErrOIDCKeyNotFound = errors.New("token signing key not found in the published key set")
+
+// OIDCKey is a named key used to sign the identity tokens of the roles
+// referencing it.
+type OIDCKey struct {
+	Algorithm        string        `mapstructure:"algorithm"`
+	RotationPeriod   time.Duration `mapstructure:"rotation_period"`
+	VerificationTTL  time.Duration `mapstructure:"verification_ttl"`
+	AllowedClientIDs []string      `mapstructure:"allowed_client_ids"`
+}
+
+// OIDCKeyInput are the parameters for creating or updating a key. Zero
+// values are left to the server's defaults.
+type OIDCKeyInput struct {
+	Algorithm        string
+	RotationPeriod   time.Duration
+	VerificationTTL  time.Duration
+	AllowedClientIDs []string
+}
+
+func (i *OIDCKeyInput) body() map[string]interface{} {
+	body := map[string]interface{}{}
+	if i.Algorithm != "" {
+		body["algorithm"] = i.Algorithm
+	}
+	if i.RotationPeriod > 0 {
+		body["rotation_period"] = i.RotationPeriod.String()
+	}
+	if i.VerificationTTL > 0 {
+		body["verification_ttl"] = i.VerificationTTL.String()
+	}
+	if i.AllowedClientIDs != nil {
+		body["allowed_client_ids"] = i.AllowedClientIDs
+	}
+	return body
+}
+
+// OIDCRole is a role for which identity tokens are generated. ClientID is
+// set by the server and is the audience of the tokens.
+type OIDCRole struct {
+	Key      string        `mapstructure:"key"`
+	Template string        `mapstructure:"template"`
+	TTL      time.Duration `mapstructure:"ttl"`
+	ClientID string        `mapstructure:"client_id"`
+}
+
+// OIDCRoleInput are the parameters for creating or updating a role. Key is
+// required; Template is a JSON template of additional claims.
+type OIDCRoleInput struct {
+	Key      string
+	Template string
+	TTL      time.Duration
+}
+
+func (i *OIDCRoleInput) body() map[string]interface{} {
+	body := map[string]interface{}{
+		"key": i.Key,
+	}
+	if i.Template != "" {
+		body["template"] = i.Template
+	}
+	if i.TTL > 0 {
+		body["ttl"] = i.TTL.String()
+	}
+	return body
+}
+
+// OIDCToken is an identity token generated for the client's entity.
+type OIDCToken struct {
+	Token    string        `mapstructure:"token"`
+	ClientID string        `mapstructure:"client_id"`
+	TTL      time.Duration `mapstructure:"ttl"`
+}
+
+// OIDCIDToken holds the claims of an identity token validated by
+// ValidateOIDCToken. Claims holds all of them, including custom claims added
+// by the role's template.
+type OIDCIDToken struct {
+	Issuer   string
+	Subject  string
+	Audience []string
+	IssuedAt time.Time
+	Expiry   time.Time
+	Claims   map[string]interface{}
+}
+
+// OIDCValidateInput are the expectations a token must meet to be valid.
+// Issuer defaults to the issuer published by the server; the audience is
+// not checked if empty.
+type OIDCValidateInput struct {
+	Issuer   string
+	Audience string
+}
+
+// CreateOIDCKey creates or updates the named key.
+func (c *Identity) CreateOIDCKey(ctx context.Context, name string, input *OIDCKeyInput) error {
+	_, err := c.c.Logical().Do(ctx, "POST", "identity/oidc/key/"+name, input.body())
+	return err
+}
+
+// ReadOIDCKey returns the named key, or nil if it does not exist.
+func (c *Identity) ReadOIDCKey(ctx context.Context, name string) (*OIDCKey, error) {
+	var key OIDCKey
+	if ok, err := c.read(ctx, "identity/oidc/key/"+name, &key); err != nil || !ok {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListOIDCKeys returns the names of the keys.
+func (c *Identity) ListOIDCKeys(ctx context.Context) ([]string, error) {
+	return c.list(ctx, "identity/oidc/key")
+}
+
+// DeleteOIDCKey deletes the named key, which must not be referenced by any
+// role.
+func (c *Identity) DeleteOIDCKey(ctx context.Context, name string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", "identity/oidc/key/"+name, nil)
+	return err
+}
+
+// RotateOIDCKey creates a new version of the named key. The previous
+// version remains published for verificationTTL, or the key's verification
+// TTL if zero.
+func (c *Identity) RotateOIDCKey(ctx context.Context, name string, verificationTTL time.Duration) error {
+	body := map[string]interface{}{}
+	if verificationTTL > 0 {
+		body["verification_ttl"] = verificationTTL.String()
+	}
+	_, err := c.c.Logical().Do(ctx, "POST", "identity/oidc/key/"+name+"/rotate", body)
+	return err
+}
+
+// CreateOIDCRole creates or updates the named role.
+func (c *Identity) CreateOIDCRole(ctx context.Context, name string, input *OIDCRoleInput) error {
+	_, err := c.c.Logical().Do(ctx, "POST", "identity/oidc/role/"+name, input.body())
+	return err
+}
+
+// ReadOIDCRole returns the named role, or nil if it does not exist.
+func (c *Identity) ReadOIDCRole(ctx context.Context, name string) (*OIDCRole, error) {
+	var role OIDCRole
+	if ok, err := c.read(ctx, "identity/oidc/role/"+name, &role); err != nil || !ok {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// ListOIDCRoles returns the names of the roles.
+func (c *Identity) ListOIDCRoles(ctx context.Context) ([]string, error) {
+	return c.list(ctx, "identity/oidc/role")
+}
+
+// DeleteOIDCRole deletes the named role.
+func (c *Identity) DeleteOIDCRole(ctx context.Context, name string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", "identity/oidc/role/"+name, nil)
+	return err
+}
+
+// GenerateOIDCToken generates an identity token of the named role for the
+// entity of the client's token.
+func (c *Identity) GenerateOIDCToken(ctx context.Context, role string) (*OIDCToken, error) {
+	var token OIDCToken
+	ok, err := c.read(ctx, "identity/oidc/token/"+role, &token)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || token.Token == "" {
+		return nil, fmt.Errorf("no token returned for role %q", role)
+	}
+	return &token, nil
+}
+
+// IntrospectOIDCToken asks the server whether the given identity token is
+// active, i.e. validly signed, unexpired, and issued to an entity that is
+// still enabled. If clientID is set, the token must also have been issued
+// for it. Inactive tokens are reported with the server's reason as error.
+func (c *Identity) IntrospectOIDCToken(ctx context.Context, token, clientID string) (bool, error) {
+	body := map[string]interface{}{
+		"token": token,
+	}
+	if clientID != "" {
+		body["client_id"] = clientID
+	}
+
+	r := c.c.NewRequest("POST", "/v1/identity/oidc/introspect")
+	if err := r.SetJSONBody(body); err != nil {
+		return false, err
+	}
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var result struct {
+		Active bool   `json:"active"`
+		Error  string `json:"error"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return false, err
+	}
+	if !result.Active && result.Error != "" {
+		return false, errors.New(result.Error)
+	}
+	return result.Active, nil
+}
+
+// OIDCKeySet returns the public keys currently published by the server to
+// verify identity tokens.
+func (c *Identity) OIDCKeySet(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	r := c.c.NewRequest("GET", "/v1/identity/oidc/.well-known/keys")
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var keySet jose.JSONWebKeySet
+	if err := resp.DecodeJSON(&keySet); err != nil {
+		return nil, errwrap.Wrapf("error decoding key set: {{err}}", err)
+	}
+	return &keySet, nil
+}
+
+// ValidateOIDCToken validates an identity token issued by the server
+// locally: its signature is checked against the published keys, then its
+// expiry and the expectations of the input. Unlike IntrospectOIDCToken, it
+// does not detect tokens of entities disabled since they were issued.
+func (c *Identity) ValidateOIDCToken(ctx context.Context, token string, input *OIDCValidateInput) (*OIDCIDToken, error) {
+	if input == nil {
+		input = &OIDCValidateInput{}
+	}
+
+	parsed, err := squarejwt.ParseSigned(token)
+	if err != nil {
+		return nil, errwrap.Wrapf("error parsing token: {{err}}", err)
+	}
+	keySet, err := c.OIDCKeySet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := keySet.Keys
+	if len(parsed.Headers) > 0 && parsed.Headers[0].KeyID != "" {
+		keys = keySet.Key(parsed.Headers[0].KeyID)
+	}
+
+	var claims squarejwt.Claims
+	var allClaims map[string]interface{}
+	verified := false
+	for _, key := range keys {
+		if err := parsed.Claims(key, &claims, &allClaims); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrOIDCKeyNotFound
+	}
+
+	issuer := input.Issuer
+	if issuer == "" {
+		if issuer, err = c.oidcIssuer(ctx); err != nil {
+			return nil, err
+		}
+	}
+	expected := squarejwt.Expected{
+		Issuer: issuer,
+		Time:   time.Now(),
+	}
+	if input.Audience != "" {
+		expected.Audience = squarejwt.Audience{input.Audience}
+	}
+	if err := claims.ValidateWithLeeway(expected, squarejwt.DefaultLeeway); err != nil {
+		return nil, errwrap.Wrapf("invalid token: {{err}}", err)
+	}
+
+	result := &OIDCIDToken{
+		Issuer:   claims.Issuer,
+		Subject:  claims.Subject,
+		Audience: []string(claims.Audience),
+		Claims:   allClaims,
+	}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = claims.IssuedAt.Time()
+	}
+	if claims.Expiry != nil {
+		result.Expiry = claims.Expiry.Time()
+	}
+	return result, nil
+}
+
+// oidcIssuer returns the issuer of the tokens of the server, as published in
+// its discovery document.
+func (c *Identity) oidcIssuer(ctx context.Context) (string, error) {
+	r := c.c.NewRequest("GET", "/v1/identity/oidc/.well-known/openid-configuration")
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var discovery struct {
+		Issuer string `json:"issuer"`
+	}
+	if err := resp.DecodeJSON(&discovery); err != nil {
+		return "", errwrap.Wrapf("error decoding discovery document: {{err}}", err)
+	}
+	if discovery.Issuer == "" {
+		return "", fmt.Errorf("no issuer found in discovery document")
+	}
+	return discovery.Issuer, nil
+}
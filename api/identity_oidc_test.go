@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+	squarejwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestIdentityOIDC(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithHeader("kid", "k1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const issuer = "https://vault.example.com/v1/identity/oidc"
+	sign := func(claims squarejwt.Claims) string {
+		token, err := squarejwt.Signed(signer).Claims(claims).Claims(map[string]interface{}{"team": "ops"}).CompactSerialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	now := time.Now()
+	validToken := sign(squarejwt.Claims{
+		Issuer:   issuer,
+		Subject:  "entity-id",
+		Audience: squarejwt.Audience{"client-id"},
+		IssuedAt: squarejwt.NewNumericDate(now),
+		Expiry:   squarejwt.NewNumericDate(now.Add(time.Hour)),
+	})
+
+	var introspected map[string]interface{}
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/identity/oidc/.well-known/keys":
+			json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+				{Key: key.Public(), KeyID: "k1", Algorithm: "RS256", Use: "sig"},
+			}})
+		case "/v1/identity/oidc/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]interface{}{"issuer": issuer})
+		case "/v1/identity/oidc/token/app":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"token": validToken, "client_id": "client-id", "ttl": 3600},
+			})
+		case "/v1/identity/oidc/role/app":
+			w.Write([]byte(`{"data": {"key": "named", "template": "", "ttl": 3600, "client_id": "client-id"}}`))
+		case "/v1/identity/oidc/introspect":
+			json.NewDecoder(req.Body).Decode(&introspected)
+			if introspected["token"] == validToken {
+				w.Write([]byte(`{"active": true}`))
+				return
+			}
+			w.Write([]byte(`{"active": false, "error": "token is expired"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := client.Identity()
+	ctx := context.Background()
+
+	role, err := identity.ReadOIDCRole(ctx, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.Key != "named" || role.TTL != time.Hour || role.ClientID != "client-id" {
+		t.Fatalf("unexpected role %#v", role)
+	}
+
+	token, err := identity.GenerateOIDCToken(ctx, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != validToken || token.TTL != time.Hour {
+		t.Fatalf("unexpected token %#v", token)
+	}
+
+	idToken, err := identity.ValidateOIDCToken(ctx, token.Token, &OIDCValidateInput{Audience: "client-id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idToken.Subject != "entity-id" || idToken.Issuer != issuer || idToken.Claims["team"] != "ops" || idToken.Expiry.IsZero() {
+		t.Fatalf("unexpected ID token %#v", idToken)
+	}
+
+	if _, err := identity.ValidateOIDCToken(ctx, token.Token, &OIDCValidateInput{Audience: "other"}); err == nil {
+		t.Fatal("expected audience mismatch error")
+	}
+	expiredToken := sign(squarejwt.Claims{
+		Issuer: issuer,
+		Expiry: squarejwt.NewNumericDate(now.Add(-time.Hour)),
+	})
+	if _, err := identity.ValidateOIDCToken(ctx, expiredToken, nil); err == nil {
+		t.Fatal("expected expiry error")
+	}
+
+	// Tokens signed by other keys are rejected
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSigner, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: otherKey}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forgedToken, err := squarejwt.Signed(otherSigner).Claims(squarejwt.Claims{Issuer: issuer}).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := identity.ValidateOIDCToken(ctx, forgedToken, nil); err != ErrOIDCKeyNotFound {
+		t.Fatalf("expected key not found error, got %v", err)
+	}
+
+	active, err := identity.IntrospectOIDCToken(ctx, validToken, "client-id")
+	if err != nil || !active || introspected["client_id"] != "client-id" {
+		t.Fatalf("expected active token, got %v and %v", active, err)
+	}
+	if active, err := identity.IntrospectOIDCToken(ctx, expiredToken, ""); active || err == nil || err.Error() != "token is expired" {
+		t.Fatalf("expected inactive token, got %v and %v", active, err)
+	}
+}
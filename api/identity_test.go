@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestIdentity(t *testing.T) {
+	var requests []string
+	var bodies []map[string]interface{}
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		bodies = append(bodies, body)
+
+		switch req.Method + " " + req.URL.Path {
+		case "POST /v1/identity/entity", "POST /v1/identity/entity-alias", "POST /v1/identity/group":
+			w.Write([]byte(`{"data": {"id": "abc"}}`))
+		case "GET /v1/identity/entity/id/abc", "POST /v1/identity/lookup/entity":
+			if req.Method == "POST" && body["alias_name"] != "alice" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Write([]byte(`{"data": {
+				"id": "abc",
+				"name": "alice",
+				"policies": ["default"],
+				"metadata": {"team": "ops"},
+				"creation_time": "2020-01-02T03:04:05Z",
+				"aliases": [{"id": "def", "name": "alice", "canonical_id": "abc", "mount_accessor": "auth_userpass_1", "mount_type": "userpass"}]
+			}}`))
+		case "GET /v1/identity/group/name/admins":
+			w.Write([]byte(`{"data": {"id": "ghi", "name": "admins", "type": "internal", "member_entity_ids": ["abc"]}}`))
+		case "GET /v1/identity/entity/id":
+			w.Write([]byte(`{"data": {"keys": ["abc"]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := client.Identity()
+	ctx := context.Background()
+
+	id, err := identity.CreateEntity(ctx, &EntityInput{Name: "alice", Policies: []string{"default"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "abc" {
+		t.Fatalf("unexpected ID %q", id)
+	}
+	expected := map[string]interface{}{"name": "alice", "policies": []interface{}{"default"}, "disabled": false}
+	if !reflect.DeepEqual(bodies[0], expected) {
+		t.Fatalf("unexpected request body %#v", bodies[0])
+	}
+
+	entity, err := identity.ReadEntity(ctx, "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity.Name != "alice" || entity.Metadata["team"] != "ops" || entity.CreationTime.IsZero() ||
+		len(entity.Aliases) != 1 || entity.Aliases[0].MountAccessor != "auth_userpass_1" {
+		t.Fatalf("unexpected entity %#v", entity)
+	}
+	if entity, err := identity.ReadEntity(ctx, "missing"); err != nil || entity != nil {
+		t.Fatalf("expected no entity, got %#v and %v", entity, err)
+	}
+
+	if _, err := identity.CreateEntityAlias(ctx, &EntityAliasInput{Name: "alice", CanonicalID: "abc", MountAccessor: "auth_userpass_1"}); err != nil {
+		t.Fatal(err)
+	}
+	entity, err = identity.LookupEntity(ctx, &EntityLookupInput{AliasName: "alice", AliasMountAccessor: "auth_userpass_1"})
+	if err != nil || entity == nil || entity.ID != "abc" {
+		t.Fatalf("unexpected lookup result %#v and %v", entity, err)
+	}
+	if entity, err := identity.LookupEntity(ctx, &EntityLookupInput{Name: "bob"}); err != nil || entity != nil {
+		t.Fatalf("expected no entity, got %#v and %v", entity, err)
+	}
+
+	if _, err := identity.CreateGroup(ctx, &GroupInput{Name: "admins", MemberEntityIDs: []string{"abc"}}); err != nil {
+		t.Fatal(err)
+	}
+	group, err := identity.ReadGroupByName(ctx, "admins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.ID != "ghi" || !reflect.DeepEqual(group.MemberEntityIDs, []string{"abc"}) {
+		t.Fatalf("unexpected group %#v", group)
+	}
+
+	ids, err := identity.ListEntities(ctx)
+	if err != nil || !reflect.DeepEqual(ids, []string{"abc"}) {
+		t.Fatalf("unexpected entities %v and %v", ids, err)
+	}
+	if err := identity.DeleteEntity(ctx, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if requests[len(requests)-1] != "DELETE /v1/identity/entity/id/abc" {
+		t.Fatalf("unexpected request %q", requests[len(requests)-1])
+	}
+}
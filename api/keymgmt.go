@@ -0,0 +1,297 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// KeyManagement is used to work with the key management secrets engine,
+// which creates keys in Vault and distributes them to external KMS
+// providers.
+type KeyManagement struct {
+	c         *Client
+	mountPath string
+}
+
+// KeyManagement returns the client for the key management secrets engine
+// mounted at the given path, usually "keymgmt".
+func (c *Client) KeyManagement(mountPath string) *KeyManagement {
+	return &KeyManagement{
+		c:         c,
+		mountPath: strings.Trim(mountPath, "/"),
+	}
+}
+
+// KeyManagementKey is a key of the engine.
+type KeyManagementKey struct {
+	Name              string `mapstructure:"name"`
+	Type              string `mapstructure:"type"`
+	DeletionAllowed   bool   `mapstructure:"deletion_allowed"`
+	LatestVersion     int    `mapstructure:"latest_version"`
+	MinEnabledVersion int    `mapstructure:"min_enabled_version"`
+
+	// Versions are the versions of the key, oldest first.
+	Versions []*KeyManagementKeyVersion `mapstructure:"-"`
+}
+
+// KeyManagementKeyVersion is a version of a key. PublicKey is only set for
+// asymmetric keys.
+type KeyManagementKeyVersion struct {
+	Version      int       `mapstructure:"-"`
+	CreationTime time.Time `mapstructure:"creation_time"`
+	PublicKey    string    `mapstructure:"public_key"`
+}
+
+// KeyManagementKeyUpdateInput are the parameters for updating a key. Nil
+// fields are left unchanged.
+type KeyManagementKeyUpdateInput struct {
+	MinEnabledVersion *int
+	DeletionAllowed   *bool
+}
+
+// KeyManagementKMS is a KMS provider keys are distributed to. KeyCollection
+// is the provider specific container of the keys, e.g. the name of an Azure
+// key vault.
+type KeyManagementKMS struct {
+	Name          string `mapstructure:"name"`
+	Provider      string `mapstructure:"provider"`
+	KeyCollection string `mapstructure:"key_collection"`
+}
+
+// KeyManagementKMSInput are the parameters for creating or updating a KMS
+// provider. Credentials are provider specific, and are not returned when
+// the provider is read.
+type KeyManagementKMSInput struct {
+	Provider      string
+	KeyCollection string
+	Credentials   map[string]string
+}
+
+// KeyManagementDistribution describes a key distributed to a KMS provider.
+// Purpose lists the operations the key may be used for, e.g. "encrypt" or
+// "sign", and Protection is "hsm" or "software".
+type KeyManagementDistribution struct {
+	Name       string   `mapstructure:"name"`
+	Purpose    []string `mapstructure:"purpose"`
+	Protection string   `mapstructure:"protection"`
+}
+
+// CreateKey creates a key of the given type, e.g. "aes256-gcm96" or
+// "rsa-2048".
+func (c *KeyManagement) CreateKey(ctx context.Context, name, keyType string) error {
+	body := map[string]interface{}{}
+	if keyType != "" {
+		body["type"] = keyType
+	}
+	_, err := c.c.Logical().Do(ctx, "POST", c.path("key", name), body)
+	return err
+}
+
+// ReadKey returns the named key with its versions, or nil if it does not
+// exist.
+func (c *KeyManagement) ReadKey(ctx context.Context, name string) (*KeyManagementKey, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, c.path("key", name), nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var key KeyManagementKey
+	if err := secret.DecodeData(&key); err != nil {
+		return nil, err
+	}
+
+	versions, _ := secret.Data["versions"].(map[string]interface{})
+	for v, raw := range versions {
+		number, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key version %q", v)
+		}
+		version := &KeyManagementKeyVersion{Version: number}
+		if err := decodeKeyManagementVersion(raw, version); err != nil {
+			return nil, err
+		}
+		key.Versions = append(key.Versions, version)
+	}
+	sort.Slice(key.Versions, func(i, j int) bool {
+		return key.Versions[i].Version < key.Versions[j].Version
+	})
+	return &key, nil
+}
+
+// ListKeyVersions returns the versions of the named key, oldest first.
+func (c *KeyManagement) ListKeyVersions(ctx context.Context, name string) ([]*KeyManagementKeyVersion, error) {
+	key, err := c.ReadKey(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrSecretNotFound
+	}
+	return key.Versions, nil
+}
+
+// ListKeys returns the names of the keys.
+func (c *KeyManagement) ListKeys(ctx context.Context) ([]string, error) {
+	return c.list(ctx, c.path("key"))
+}
+
+// UpdateKey updates the named key.
+func (c *KeyManagement) UpdateKey(ctx context.Context, name string, input *KeyManagementKeyUpdateInput) error {
+	body := map[string]interface{}{}
+	if input.MinEnabledVersion != nil {
+		body["min_enabled_version"] = *input.MinEnabledVersion
+	}
+	if input.DeletionAllowed != nil {
+		body["deletion_allowed"] = *input.DeletionAllowed
+	}
+	_, err := c.c.Logical().Do(ctx, "POST", c.path("key", name), body)
+	return err
+}
+
+// RotateKey creates a new version of the named key, which is also
+// distributed to the KMS providers the key was distributed to.
+func (c *KeyManagement) RotateKey(ctx context.Context, name string) error {
+	_, err := c.c.Logical().Do(ctx, "POST", c.path("key", name, "rotate"), nil)
+	return err
+}
+
+// DeleteKey deletes the named key. Keys can only be deleted once deletion
+// has been allowed, and after they were removed from all KMS providers.
+func (c *KeyManagement) DeleteKey(ctx context.Context, name string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", c.path("key", name), nil)
+	return err
+}
+
+// CreateKMS creates or updates the named KMS provider.
+func (c *KeyManagement) CreateKMS(ctx context.Context, name string, input *KeyManagementKMSInput) error {
+	body := map[string]interface{}{
+		"provider":       input.Provider,
+		"key_collection": input.KeyCollection,
+	}
+	if input.Credentials != nil {
+		body["credentials"] = input.Credentials
+	}
+	_, err := c.c.Logical().Do(ctx, "POST", c.path("kms", name), body)
+	return err
+}
+
+// ReadKMS returns the named KMS provider, or nil if it does not exist.
+func (c *KeyManagement) ReadKMS(ctx context.Context, name string) (*KeyManagementKMS, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, c.path("kms", name), nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var kms KeyManagementKMS
+	if err := secret.DecodeData(&kms); err != nil {
+		return nil, err
+	}
+	return &kms, nil
+}
+
+// ListKMS returns the names of the KMS providers.
+func (c *KeyManagement) ListKMS(ctx context.Context) ([]string, error) {
+	return c.list(ctx, c.path("kms"))
+}
+
+// DeleteKMS deletes the named KMS provider, which must have no keys
+// distributed to it.
+func (c *KeyManagement) DeleteKMS(ctx context.Context, name string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", c.path("kms", name), nil)
+	return err
+}
+
+// DistributeKey distributes the named key to the named KMS provider, for
+// the given purposes and protection.
+func (c *KeyManagement) DistributeKey(ctx context.Context, kms, key string, purpose []string, protection string) error {
+	body := map[string]interface{}{
+		"purpose": strings.Join(purpose, ","),
+	}
+	if protection != "" {
+		body["protection"] = protection
+	}
+	_, err := c.c.Logical().Do(ctx, "POST", c.path("kms", kms, "key", key), body)
+	return err
+}
+
+// ReadDistribution returns the distribution of the named key to the named
+// KMS provider, or nil if the key was not distributed to it.
+func (c *KeyManagement) ReadDistribution(ctx context.Context, kms, key string) (*KeyManagementDistribution, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, c.path("kms", kms, "key", key), nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var distribution KeyManagementDistribution
+	if err := secret.DecodeData(&distribution); err != nil {
+		return nil, err
+	}
+	if len(distribution.Purpose) == 1 {
+		distribution.Purpose = strings.Split(distribution.Purpose[0], ",")
+	}
+	return &distribution, nil
+}
+
+// ListKMSKeys returns the names of the keys distributed to the named KMS
+// provider.
+func (c *KeyManagement) ListKMSKeys(ctx context.Context, kms string) ([]string, error) {
+	return c.list(ctx, c.path("kms", kms, "key"))
+}
+
+// ListKeyKMS returns the names of the KMS providers the named key was
+// distributed to.
+func (c *KeyManagement) ListKeyKMS(ctx context.Context, key string) ([]string, error) {
+	return c.list(ctx, c.path("key", key, "kms"))
+}
+
+// RemoveKey removes the named key from the named KMS provider, destroying
+// it there.
+func (c *KeyManagement) RemoveKey(ctx context.Context, kms, key string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", c.path("kms", kms, "key", key), nil)
+	return err
+}
+
+func (c *KeyManagement) path(elems ...string) string {
+	return c.mountPath + "/" + strings.Join(elems, "/")
+}
+
+func (c *KeyManagement) list(ctx context.Context, path string) ([]string, error) {
+	secret, err := c.c.Logical().listWithContext(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var keys []string
+	if err := mapstructure.Decode(secret.Data["keys"], &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// decodeKeyManagementVersion decodes a key version, which older servers
+// return as its creation time alone.
+func decodeKeyManagementVersion(raw interface{}, version *KeyManagementKeyVersion) error {
+	data, ok := raw.(map[string]interface{})
+	if !ok {
+		data = map[string]interface{}{"creation_time": raw}
+	}
+	return (&Secret{Data: data}).DecodeData(version)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestKeyManagement(t *testing.T) {
+	bodies := make(map[string]map[string]interface{})
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost || req.Method == http.MethodPut {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			bodies[req.Method+" "+req.URL.Path] = body
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		switch {
+		case req.URL.Query().Get("list") == "true":
+			switch req.URL.Path {
+			case "/v1/keymgmt/key":
+				w.Write([]byte(`{"data": {"keys": ["app-key"]}}`))
+			case "/v1/keymgmt/kms/azure/key":
+				w.Write([]byte(`{"data": {"keys": ["app-key"]}}`))
+			case "/v1/keymgmt/key/app-key/kms":
+				w.Write([]byte(`{"data": {"keys": ["azure"]}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case req.URL.Path == "/v1/keymgmt/key/app-key":
+			w.Write([]byte(`{"data": {
+				"name": "app-key",
+				"type": "rsa-2048",
+				"deletion_allowed": false,
+				"latest_version": 2,
+				"min_enabled_version": 1,
+				"versions": {
+					"2": {"creation_time": "2021-02-01T00:00:00Z", "public_key": "key-2"},
+					"1": "2021-01-01T00:00:00Z"
+				}
+			}}`))
+		case req.URL.Path == "/v1/keymgmt/kms/azure":
+			w.Write([]byte(`{"data": {"name": "azure", "provider": "azurekeyvault", "key_collection": "vault-keys"}}`))
+		case req.URL.Path == "/v1/keymgmt/kms/azure/key/app-key":
+			w.Write([]byte(`{"data": {"name": "app-key", "purpose": "encrypt,decrypt", "protection": "hsm"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	km := client.KeyManagement("keymgmt/")
+
+	if err := km.CreateKey(ctx, "app-key", "rsa-2048"); err != nil {
+		t.Fatal(err)
+	}
+	if bodies["POST /v1/keymgmt/key/app-key"]["type"] != "rsa-2048" {
+		t.Fatalf("unexpected create body %#v", bodies)
+	}
+
+	key, err := km.ReadKey(ctx, "app-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Type != "rsa-2048" || key.LatestVersion != 2 || len(key.Versions) != 2 {
+		t.Fatalf("unexpected key %#v", key)
+	}
+	if key.Versions[0].Version != 1 || key.Versions[0].CreationTime.Month() != 1 || key.Versions[1].PublicKey != "key-2" {
+		t.Fatalf("unexpected versions %#v %#v", key.Versions[0], key.Versions[1])
+	}
+
+	if _, err := km.ListKeyVersions(ctx, "missing"); err != ErrSecretNotFound {
+		t.Fatalf("expected ErrSecretNotFound, got %v", err)
+	}
+
+	minVersion := 2
+	if err := km.UpdateKey(ctx, "app-key", &KeyManagementKeyUpdateInput{MinEnabledVersion: &minVersion}); err != nil {
+		t.Fatal(err)
+	}
+	if body := bodies["POST /v1/keymgmt/key/app-key"]; body["min_enabled_version"] != float64(2) || body["deletion_allowed"] != nil {
+		t.Fatalf("unexpected update body %#v", body)
+	}
+
+	if err := km.RotateKey(ctx, "app-key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bodies["POST /v1/keymgmt/key/app-key/rotate"]; !ok {
+		t.Fatal("expected key to be rotated")
+	}
+
+	err = km.CreateKMS(ctx, "azure", &KeyManagementKMSInput{
+		Provider:      "azurekeyvault",
+		KeyCollection: "vault-keys",
+		Credentials:   map[string]string{"client_id": "id", "client_secret": "secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := bodies["POST /v1/keymgmt/kms/azure"]; body["provider"] != "azurekeyvault" || body["credentials"].(map[string]interface{})["client_id"] != "id" {
+		t.Fatalf("unexpected kms body %#v", body)
+	}
+
+	kms, err := km.ReadKMS(ctx, "azure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kms.Provider != "azurekeyvault" || kms.KeyCollection != "vault-keys" {
+		t.Fatalf("unexpected kms %#v", kms)
+	}
+
+	if err := km.DistributeKey(ctx, "azure", "app-key", []string{"encrypt", "decrypt"}, "hsm"); err != nil {
+		t.Fatal(err)
+	}
+	if body := bodies["POST /v1/keymgmt/kms/azure/key/app-key"]; body["purpose"] != "encrypt,decrypt" || body["protection"] != "hsm" {
+		t.Fatalf("unexpected distribution body %#v", body)
+	}
+
+	distribution, err := km.ReadDistribution(ctx, "azure", "app-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(distribution.Purpose) != 2 || distribution.Purpose[1] != "decrypt" || distribution.Protection != "hsm" {
+		t.Fatalf("unexpected distribution %#v", distribution)
+	}
+
+	for _, list := range []func(context.Context) ([]string, error){
+		km.ListKeys,
+		func(ctx context.Context) ([]string, error) { return km.ListKMSKeys(ctx, "azure") },
+		func(ctx context.Context) ([]string, error) { return km.ListKeyKMS(ctx, "app-key") },
+	} {
+		names, err := list(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != 1 {
+			t.Fatalf("unexpected names %v", names)
+		}
+	}
+}
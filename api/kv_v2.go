@@ -0,0 +1,371 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+)
+
+var (
+	// ErrSecretNotFound is returned when reading a KV secret that does not
+	// exist.
+	ErrSecretNotFound = errors.New("secret not found")
+
+	// ErrCASConflict matches response errors for KV version 2 writes whose
+	// check-and-set version did not match the current version of the
+	// secret, i.e. which lost a race with another writer.
+	ErrCASConflict = errors.New("check-and-set parameter did not match the current version")
+)
+
+// KVv2 is used to work with secrets in a KV version 2 secrets engine. Paths
+// are given relative to the mount, without the data/ or metadata/ prefixes
+// of the engine's endpoints.
+type KVv2 struct {
+	c         *Client
+	mountPath string
+}
+
+// KVv2 returns the client for the KV version 2 secrets engine mounted at the
+// given path, such as "secret".
+func (c *Client) KVv2(mountPath string) *KVv2 {
+	return &KVv2{
+		c:         c,
+		mountPath: strings.Trim(mountPath, "/"),
+	}
+}
+
+// KVSecret is a version of a KV secret.
+type KVSecret struct {
+	// Data is the data of the secret. It is nil if the version was deleted
+	// or destroyed.
+	Data map[string]interface{}
+
+	// VersionMetadata describes the version.
+	VersionMetadata *KVVersionMetadata
+
+	// CustomMetadata is the custom metadata of the secret, if returned by
+	// the server.
+	CustomMetadata map[string]interface{}
+
+	// Raw is the response the secret was parsed from.
+	Raw *Secret
+}
+
+// KVVersionMetadata describes a version of a KV secret. DeletionTime is zero
+// unless the version was deleted, or is set to be deleted at that time.
+type KVVersionMetadata struct {
+	Version      int
+	CreatedTime  time.Time
+	DeletionTime time.Time
+	Destroyed    bool
+}
+
+// KVOption configures a write to a KV secret.
+type KVOption func(*kvOptions)
+
+type kvOptions struct {
+	cas *int
+}
+
+// WithCheckAndSet makes a write succeed only if the current version of the
+// secret is the given one, with zero meaning that the secret must not exist
+// yet. Otherwise, the write fails with an error matching ErrCASConflict.
+func WithCheckAndSet(version int) KVOption {
+	return func(o *kvOptions) {
+		o.cas = &version
+	}
+}
+
+// Get returns the latest version of the secret at the given path, or
+// ErrSecretNotFound if there is none.
+func (kv *KVv2) Get(ctx context.Context, secretPath string) (*KVSecret, error) {
+	return kv.get(ctx, secretPath, nil)
+}
+
+// GetVersion returns the given version of the secret at the given path, or
+// ErrSecretNotFound if there is none.
+func (kv *KVv2) GetVersion(ctx context.Context, secretPath string, version int) (*KVSecret, error) {
+	return kv.get(ctx, secretPath, map[string][]string{
+		"version": {fmt.Sprint(version)},
+	})
+}
+
+func (kv *KVv2) get(ctx context.Context, secretPath string, data map[string][]string) (*KVSecret, error) {
+	secret, err := kv.c.Logical().readWithContext(ctx, kv.path("data", secretPath), data)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, ErrSecretNotFound
+	}
+
+	result := &KVSecret{Raw: secret}
+	if d, ok := secret.Data["data"].(map[string]interface{}); ok {
+		result.Data = d
+	}
+	if m, ok := secret.Data["metadata"].(map[string]interface{}); ok {
+		if result.VersionMetadata, err = parseKVVersionMetadata(m); err != nil {
+			return nil, err
+		}
+		if custom, ok := m["custom_metadata"].(map[string]interface{}); ok {
+			result.CustomMetadata = custom
+		}
+	}
+	return result, nil
+}
+
+// Put writes a new version of the secret at the given path.
+func (kv *KVv2) Put(ctx context.Context, secretPath string, data map[string]interface{}, opts ...KVOption) (*KVSecret, error) {
+	var o kvOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	body := map[string]interface{}{
+		"data": data,
+	}
+	if o.cas != nil {
+		body["options"] = map[string]interface{}{
+			"cas": *o.cas,
+		}
+	}
+
+	secret, err := kv.c.Logical().Do(ctx, "PUT", kv.path("data", secretPath), body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no version metadata returned writing %q", secretPath)
+	}
+
+	metadata, err := parseKVVersionMetadata(secret.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &KVSecret{
+		Data:            data,
+		VersionMetadata: metadata,
+		Raw:             secret,
+	}, nil
+}
+
+// GetSubkeys returns the structure of the latest version of the secret at
+// the given path without its values: nested objects are returned as maps,
+// and every other value as nil. Objects deeper than depth are returned as
+// nil too, with zero meaning no limit. It returns ErrSecretNotFound if there
+// is no such secret.
+func (kv *KVv2) GetSubkeys(ctx context.Context, secretPath string, depth int) (map[string]interface{}, error) {
+	secret, err := kv.c.Logical().readWithContext(ctx, kv.path("subkeys", secretPath), map[string][]string{
+		"depth": {strconv.Itoa(depth)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, ErrSecretNotFound
+	}
+
+	subkeys, ok := secret.Data["subkeys"].(map[string]interface{})
+	if !ok {
+		// Deleted and destroyed versions have no subkeys
+		return nil, ErrSecretNotFound
+	}
+	return subkeys, nil
+}
+
+// path returns the path of the given endpoint of the engine for the secret.
+func (kv *KVv2) path(endpoint, secretPath string) string {
+	return fmt.Sprintf("%s/%s/%s", kv.mountPath, endpoint, strings.TrimPrefix(secretPath, "/"))
+}
+
+// IsCASConflict returns whether err is a response error for a KV version 2
+// write whose check-and-set version did not match.
+func IsCASConflict(err error) bool {
+	return isResponseError(err, ErrCASConflict)
+}
+
+func parseKVVersionMetadata(m map[string]interface{}) (*KVVersionMetadata, error) {
+	var metadata KVVersionMetadata
+	var err error
+	if v, ok := m["version"]; ok {
+		if metadata.Version, err = toInt(v); err != nil {
+			return nil, err
+		}
+	}
+	if metadata.CreatedTime, err = parseKVTime(m["created_time"]); err != nil {
+		return nil, err
+	}
+	if metadata.DeletionTime, err = parseKVTime(m["deletion_time"]); err != nil {
+		return nil, err
+	}
+	metadata.Destroyed, _ = m["destroyed"].(bool)
+	return &metadata, nil
+}
+
+// parseKVTime parses a time returned by the engine, which is empty for unset
+// times.
+func parseKVTime(value interface{}) (time.Time, error) {
+	s, _ := value.(string)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// KVMetadata is the metadata of a KV version 2 secret, which applies to all
+// of its versions.
+type KVMetadata struct {
+	CASRequired        bool
+	CreatedTime        time.Time
+	CurrentVersion     int
+	CustomMetadata     map[string]interface{}
+	DeleteVersionAfter time.Duration
+	MaxVersions        int
+	OldestVersion      int
+	UpdatedTime        time.Time
+
+	// Versions holds the metadata of the versions of the secret, by
+	// version number.
+	Versions map[int]KVVersionMetadata
+
+	// Raw is the response the metadata was parsed from.
+	Raw *Secret
+}
+
+// KVMetadataPutInput are the parameters for writing the metadata of a
+// secret. Zero values reset the settings to the defaults of the mount.
+type KVMetadataPutInput struct {
+	MaxVersions        int
+	CASRequired        bool
+	DeleteVersionAfter time.Duration
+	CustomMetadata     map[string]interface{}
+}
+
+// KVMetadataPatchInput are the parameters for updating the metadata of a
+// secret. Nil fields are left unchanged. CustomMetadata is merged into the
+// existing custom metadata, with keys set to nil being removed.
+type KVMetadataPatchInput struct {
+	MaxVersions        *int
+	CASRequired        *bool
+	DeleteVersionAfter *time.Duration
+	CustomMetadata     map[string]interface{}
+}
+
+// GetMetadata returns the metadata of the secret at the given path, or
+// ErrSecretNotFound if there is none.
+func (kv *KVv2) GetMetadata(ctx context.Context, secretPath string) (*KVMetadata, error) {
+	secret, err := kv.c.Logical().readWithContext(ctx, kv.path("metadata", secretPath), nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, ErrSecretNotFound
+	}
+	return parseKVMetadata(secret)
+}
+
+// PutMetadata writes the metadata of the secret at the given path, creating
+// it if needed.
+func (kv *KVv2) PutMetadata(ctx context.Context, secretPath string, input KVMetadataPutInput) error {
+	customMetadata := input.CustomMetadata
+	if customMetadata == nil {
+		customMetadata = map[string]interface{}{}
+	}
+	body := map[string]interface{}{
+		"max_versions":         input.MaxVersions,
+		"cas_required":         input.CASRequired,
+		"delete_version_after": input.DeleteVersionAfter.String(),
+		"custom_metadata":      customMetadata,
+	}
+
+	_, err := kv.c.Logical().Do(ctx, "PUT", kv.path("metadata", secretPath), body)
+	return err
+}
+
+// PatchMetadata updates the metadata of the secret at the given path, only
+// sending the fields that are set.
+func (kv *KVv2) PatchMetadata(ctx context.Context, secretPath string, input KVMetadataPatchInput) error {
+	body := map[string]interface{}{}
+	if input.MaxVersions != nil {
+		body["max_versions"] = *input.MaxVersions
+	}
+	if input.CASRequired != nil {
+		body["cas_required"] = *input.CASRequired
+	}
+	if input.DeleteVersionAfter != nil {
+		body["delete_version_after"] = input.DeleteVersionAfter.String()
+	}
+	if input.CustomMetadata != nil {
+		body["custom_metadata"] = input.CustomMetadata
+	}
+
+	_, err := kv.c.Logical().Do(ctx, "PATCH", kv.path("metadata", secretPath), body)
+	return err
+}
+
+// DeleteMetadata deletes the metadata and all versions of the secret at the
+// given path.
+func (kv *KVv2) DeleteMetadata(ctx context.Context, secretPath string) error {
+	_, err := kv.c.Logical().Do(ctx, "DELETE", kv.path("metadata", secretPath), nil)
+	return err
+}
+
+func parseKVMetadata(secret *Secret) (*KVMetadata, error) {
+	d := secret.Data
+	metadata := &KVMetadata{
+		Raw:      secret,
+		Versions: make(map[int]KVVersionMetadata),
+	}
+
+	var err error
+	metadata.CASRequired, _ = d["cas_required"].(bool)
+	if metadata.CreatedTime, err = parseKVTime(d["created_time"]); err != nil {
+		return nil, err
+	}
+	if metadata.UpdatedTime, err = parseKVTime(d["updated_time"]); err != nil {
+		return nil, err
+	}
+	for field, dest := range map[string]*int{
+		"current_version": &metadata.CurrentVersion,
+		"max_versions":    &metadata.MaxVersions,
+		"oldest_version":  &metadata.OldestVersion,
+	} {
+		if v, ok := d[field]; ok {
+			if *dest, err = toInt(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if v, ok := d["delete_version_after"]; ok {
+		if metadata.DeleteVersionAfter, err = parseutil.ParseDurationSecond(v); err != nil {
+			return nil, err
+		}
+	}
+	if custom, ok := d["custom_metadata"].(map[string]interface{}); ok {
+		metadata.CustomMetadata = custom
+	}
+
+	versions, _ := d["versions"].(map[string]interface{})
+	for k, v := range versions {
+		version, err := strconv.Atoi(k)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected version %q in metadata", k)
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected type %T for metadata of version %d", v, version)
+		}
+		versionMetadata, err := parseKVVersionMetadata(m)
+		if err != nil {
+			return nil, err
+		}
+		versionMetadata.Version = version
+		metadata.Versions[version] = *versionMetadata
+	}
+	return metadata, nil
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testKVv2Handler is a minimal KV version 2 engine mounted at secret/.
+type testKVv2Handler struct {
+	l        sync.Mutex
+	versions map[string][]map[string]interface{}
+	custom   map[string]map[string]interface{}
+}
+
+func newTestKVv2Handler() *testKVv2Handler {
+	return &testKVv2Handler{versions: make(map[string][]map[string]interface{})}
+}
+
+func (h *testKVv2Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	const metadataPrefix = "/v1/secret/metadata"
+	if strings.HasPrefix(req.URL.Path, metadataPrefix) {
+		h.serveMetadata(w, req, strings.TrimPrefix(req.URL.Path[len(metadataPrefix):], "/"))
+		return
+	}
+
+	const prefix = "/v1/secret/data/"
+	if len(req.URL.Path) <= len(prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	path := req.URL.Path[len(prefix):]
+	versions := h.versions[path]
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC).Format(time.RFC3339Nano)
+
+	switch req.Method {
+	case "GET":
+		if len(versions) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": versions[len(versions)-1],
+				"metadata": map[string]interface{}{
+					"version":         len(versions),
+					"created_time":    created,
+					"deletion_time":   "",
+					"destroyed":       false,
+					"custom_metadata": map[string]interface{}{"owner": "ops"},
+				},
+			},
+		})
+	case "PUT", "POST":
+		var body struct {
+			Data    map[string]interface{} `json:"data"`
+			Options map[string]interface{} `json:"options"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		if cas, ok := body.Options["cas"].(float64); ok && int(cas) != len(versions) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors": ["check-and-set parameter did not match the current version"]}`))
+			return
+		}
+		h.versions[path] = append(versions, body.Data)
+		fmt.Fprintf(w, `{"data": {"version": %d, "created_time": %q, "deletion_time": "", "destroyed": false}}`, len(versions)+1, created)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// serveMetadata handles LIST requests on directories, and PATCH requests
+// setting custom metadata, which is recorded in the custom field of the
+// handler.
+func (h *testKVv2Handler) serveMetadata(w http.ResponseWriter, req *http.Request, dir string) {
+	switch {
+	case req.Method == "GET" && req.URL.Query().Get("list") == "true":
+		// The client drops the trailing slash of directories
+		if dir != "" && !strings.HasSuffix(dir, "/") {
+			dir += "/"
+		}
+		seen := make(map[string]bool)
+		keys := []string{}
+		for path := range h.versions {
+			if !strings.HasPrefix(path, dir) {
+				continue
+			}
+			key := path[len(dir):]
+			if i := strings.Index(key, "/"); i >= 0 {
+				key = key[:i+1]
+			}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		sort.Strings(keys)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"keys": keys},
+		})
+	case req.Method == "PATCH":
+		var body struct {
+			CustomMetadata map[string]interface{} `json:"custom_metadata"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		if h.custom == nil {
+			h.custom = make(map[string]map[string]interface{})
+		}
+		h.custom[dir] = body.CustomMetadata
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestKVv2_CheckAndSet(t *testing.T) {
+	config, ln := testHTTPServer(t, newTestKVv2Handler())
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv := client.KVv2("secret")
+	ctx := context.Background()
+
+	if _, err := kv.Get(ctx, "foo"); err != ErrSecretNotFound {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	written, err := kv.Put(ctx, "foo", map[string]interface{}{"value": "a"}, WithCheckAndSet(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written.VersionMetadata.Version != 1 || written.VersionMetadata.CreatedTime.IsZero() {
+		t.Fatalf("unexpected version metadata %#v", written.VersionMetadata)
+	}
+
+	// A writer that missed the first write loses the race
+	_, err = kv.Put(ctx, "foo", map[string]interface{}{"value": "b"}, WithCheckAndSet(0))
+	if !errors.Is(err, ErrCASConflict) || !IsCASConflict(err) {
+		t.Fatalf("expected CAS conflict, got %v", err)
+	}
+
+	if _, err := kv.Put(ctx, "foo", map[string]interface{}{"value": "c"}, WithCheckAndSet(1)); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := kv.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["value"] != "c" || secret.VersionMetadata.Version != 2 || secret.CustomMetadata["owner"] != "ops" {
+		t.Fatalf("unexpected secret %#v", secret)
+	}
+}
+
+func TestKVv2_Metadata(t *testing.T) {
+	var written []map[string]interface{}
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/secret/metadata/foo" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch req.Method {
+		case "GET":
+			w.Write([]byte(`{"data": {
+				"cas_required": true,
+				"created_time": "2020-01-02T03:04:05Z",
+				"current_version": 2,
+				"custom_metadata": {"owner": "ops"},
+				"delete_version_after": "1h0m0s",
+				"max_versions": 5,
+				"oldest_version": 1,
+				"updated_time": "2020-01-03T03:04:05Z",
+				"versions": {
+					"1": {"created_time": "2020-01-02T03:04:05Z", "deletion_time": "", "destroyed": true},
+					"2": {"created_time": "2020-01-03T03:04:05Z", "deletion_time": "", "destroyed": false}
+				}
+			}}`))
+		case "PUT", "PATCH":
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			written = append(written, body)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv := client.KVv2("secret")
+	ctx := context.Background()
+
+	metadata, err := kv.GetMetadata(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !metadata.CASRequired || metadata.CurrentVersion != 2 || metadata.MaxVersions != 5 ||
+		metadata.OldestVersion != 1 || metadata.DeleteVersionAfter != time.Hour ||
+		metadata.CustomMetadata["owner"] != "ops" || metadata.UpdatedTime.IsZero() {
+		t.Fatalf("unexpected metadata %#v", metadata)
+	}
+	if len(metadata.Versions) != 2 || !metadata.Versions[1].Destroyed || metadata.Versions[2].Version != 2 {
+		t.Fatalf("unexpected versions %#v", metadata.Versions)
+	}
+	if _, err := kv.GetMetadata(ctx, "bar"); err != ErrSecretNotFound {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	if err := kv.PutMetadata(ctx, "foo", KVMetadataPutInput{MaxVersions: 3}); err != nil {
+		t.Fatal(err)
+	}
+	casRequired := false
+	if err := kv.PatchMetadata(ctx, "foo", KVMetadataPatchInput{
+		CASRequired:    &casRequired,
+		CustomMetadata: map[string]interface{}{"owner": nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]interface{}{
+		{
+			"max_versions":         float64(3),
+			"cas_required":         false,
+			"delete_version_after": "0s",
+			"custom_metadata":      map[string]interface{}{},
+		},
+		{
+			"cas_required":    false,
+			"custom_metadata": map[string]interface{}{"owner": nil},
+		},
+	}
+	if !reflect.DeepEqual(written, expected) {
+		t.Fatalf("unexpected writes %#v", written)
+	}
+}
+
+func TestKVv2_GetSubkeys(t *testing.T) {
+	var depth string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/secret/subkeys/foo":
+			depth = req.URL.Query().Get("depth")
+			w.Write([]byte(`{"data": {"subkeys": {"a": null, "b": {"c": null}}, "metadata": {"version": 1}}}`))
+		case "/v1/secret/subkeys/deleted":
+			w.Write([]byte(`{"data": {"subkeys": null, "metadata": {"version": 1}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv := client.KVv2("secret")
+
+	subkeys, err := kv.GetSubkeys(context.Background(), "foo", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"a": nil,
+		"b": map[string]interface{}{"c": nil},
+	}
+	if !reflect.DeepEqual(subkeys, expected) || depth != "2" {
+		t.Fatalf("unexpected subkeys %#v with depth %q", subkeys, depth)
+	}
+
+	for _, path := range []string{"deleted", "missing"} {
+		if _, err := kv.GetSubkeys(context.Background(), path, 0); err != ErrSecretNotFound {
+			t.Fatalf("expected not found error for %q, got %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// DefaultKVWalkConcurrency is the number of requests KVWalk performs at once
+// unless overridden with KVWalkConcurrency.
+const DefaultKVWalkConcurrency = 8
+
+// KVWalkFunc is called by KVWalk for every secret found, with its path
+// relative to the mount. Secrets whose latest version was deleted or
+// destroyed are visited with nil Data. Returning an error stops the walk.
+type KVWalkFunc func(secretPath string, secret *KVSecret) error
+
+// KVWalkOption configures a call to KVWalk.
+type KVWalkOption func(*kvWalkOptions)
+
+type kvWalkOptions struct {
+	concurrency int
+}
+
+// KVWalkConcurrency sets the number of LIST and read requests performed at
+// once.
+func KVWalkConcurrency(n int) KVWalkOption {
+	return func(o *kvWalkOptions) {
+		o.concurrency = n
+	}
+}
+
+// KVWalk recursively lists the KV version 2 secrets engine mounted at mount,
+// starting from the directory prefix, and calls fn with the latest version
+// of every secret found. Requests are made concurrently, but fn is never
+// called concurrently. The walk stops at the first error, which is returned.
+func (c *Client) KVWalk(ctx context.Context, mount, prefix string, fn KVWalkFunc, opts ...KVWalkOption) error {
+	options := &kvWalkOptions{
+		concurrency: DefaultKVWalkConcurrency,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.concurrency <= 0 {
+		options.concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	w := &kvWalker{
+		kv:     c.KVv2(mount),
+		fn:     fn,
+		cancel: cancel,
+		queue:  []kvWalkItem{{path: prefix, dir: true}},
+		seen:   map[string]bool{prefix: true},
+	}
+	w.cond = sync.NewCond(&w.l)
+
+	var wg sync.WaitGroup
+	for i := 0; i < options.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return w.err
+}
+
+// kvWalkItem is a directory to list or a secret to read during a walk.
+type kvWalkItem struct {
+	path string
+	dir  bool
+}
+
+// kvWalker holds the state of a call to KVWalk. A fixed pool of workers
+// takes items from the queue, with directories adding their entries back to
+// it, until the queue is empty and no worker is busy.
+type kvWalker struct {
+	kv     *KVv2
+	fn     KVWalkFunc
+	cancel context.CancelFunc
+
+	l      sync.Mutex
+	cond   *sync.Cond
+	queue  []kvWalkItem
+	seen   map[string]bool
+	active int
+	err    error
+
+	// fnLock serializes the calls to fn.
+	fnLock sync.Mutex
+}
+
+func (w *kvWalker) work(ctx context.Context) {
+	for {
+		w.l.Lock()
+		for len(w.queue) == 0 && w.active > 0 && w.err == nil {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 || w.err != nil {
+			w.l.Unlock()
+			w.cond.Broadcast()
+			return
+		}
+		item := w.queue[0]
+		w.queue = w.queue[1:]
+		w.active++
+		w.l.Unlock()
+
+		if item.dir {
+			w.list(ctx, item.path)
+		} else {
+			w.visit(ctx, item.path)
+		}
+
+		w.l.Lock()
+		w.active--
+		w.l.Unlock()
+		w.cond.Broadcast()
+	}
+}
+
+func (w *kvWalker) list(ctx context.Context, dir string) {
+	secret, err := w.kv.c.Logical().listWithContext(ctx, w.kv.path("metadata", dir), nil)
+	if err != nil {
+		w.fail(errwrap.Wrapf(fmt.Sprintf("error listing %q: {{err}}", dir), err))
+		return
+	}
+	if secret == nil {
+		return
+	}
+
+	keys, _ := secret.Data["keys"].([]interface{})
+	items := make([]kvWalkItem, 0, len(keys))
+	for _, k := range keys {
+		key, ok := k.(string)
+		if !ok {
+			w.fail(fmt.Errorf("unexpected key %v listing %q", k, dir))
+			return
+		}
+		// Such keys would make the directory list itself again
+		if key == "" || strings.HasPrefix(key, "/") {
+			continue
+		}
+		items = append(items, kvWalkItem{
+			path: dir + key,
+			dir:  strings.HasSuffix(key, "/"),
+		})
+	}
+
+	w.l.Lock()
+	defer w.l.Unlock()
+	for _, item := range items {
+		if item.dir {
+			if w.seen[item.path] {
+				continue
+			}
+			w.seen[item.path] = true
+		}
+		w.queue = append(w.queue, item)
+	}
+}
+
+func (w *kvWalker) visit(ctx context.Context, secretPath string) {
+	secret, err := w.kv.Get(ctx, secretPath)
+	switch {
+	case err == ErrSecretNotFound:
+		// Removed since it was listed
+		return
+	case err != nil:
+		w.fail(errwrap.Wrapf(fmt.Sprintf("error reading %q: {{err}}", secretPath), err))
+		return
+	}
+
+	w.fnLock.Lock()
+	defer w.fnLock.Unlock()
+	if w.stopped() {
+		return
+	}
+	if err := w.fn(secretPath, secret); err != nil {
+		w.fail(err)
+	}
+}
+
+func (w *kvWalker) stopped() bool {
+	w.l.Lock()
+	defer w.l.Unlock()
+	return w.err != nil
+}
+
+// fail stops the walk with err, unless it was already stopped.
+func (w *kvWalker) fail(err error) {
+	w.l.Lock()
+	defer w.l.Unlock()
+	if w.err == nil {
+		w.err = err
+		w.cancel()
+	}
+}
+
+// KVSnapshot is the JSON document written by KVExport and read by KVImport.
+// Secrets are keyed by their path relative to the mount.
+type KVSnapshot struct {
+	Secrets map[string]*KVSnapshotSecret `json:"secrets"`
+}
+
+// KVSnapshotSecret is the latest version of a secret in a KVSnapshot.
+type KVSnapshotSecret struct {
+	Data           map[string]interface{} `json:"data"`
+	CustomMetadata map[string]interface{} `json:"custom_metadata,omitempty"`
+}
+
+// KVExport writes a KVSnapshot of the latest version of every secret under
+// prefix in the KV version 2 secrets engine mounted at mount. Secrets whose
+// latest version was deleted or destroyed are left out.
+func (c *Client) KVExport(ctx context.Context, mount, prefix string, w io.Writer, opts ...KVWalkOption) error {
+	snapshot := &KVSnapshot{
+		Secrets: make(map[string]*KVSnapshotSecret),
+	}
+	err := c.KVWalk(ctx, mount, prefix, func(secretPath string, secret *KVSecret) error {
+		if secret.Data != nil {
+			snapshot.Secrets[secretPath] = &KVSnapshotSecret{
+				Data:           secret.Data,
+				CustomMetadata: secret.CustomMetadata,
+			}
+		}
+		return nil
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snapshot)
+}
+
+// KVImport reads a KVSnapshot and writes its secrets, as new versions, to
+// the KV version 2 secrets engine mounted at mount. Every secret is
+// attempted; the returned error combines the errors of all failed writes.
+func (c *Client) KVImport(ctx context.Context, mount string, r io.Reader) error {
+	var snapshot KVSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return errwrap.Wrapf("error decoding snapshot: {{err}}", err)
+	}
+
+	paths := make([]string, 0, len(snapshot.Secrets))
+	for secretPath := range snapshot.Secrets {
+		paths = append(paths, secretPath)
+	}
+	sort.Strings(paths)
+
+	kv := c.KVv2(mount)
+	var errs *multierror.Error
+	for _, secretPath := range paths {
+		if err := ctx.Err(); err != nil {
+			return multierror.Append(errs, err)
+		}
+
+		secret := snapshot.Secrets[secretPath]
+		if secret == nil {
+			continue
+		}
+		if _, err := kv.Put(ctx, secretPath, secret.Data); err != nil {
+			errs = multierror.Append(errs, errwrap.Wrapf(fmt.Sprintf("error writing %q: {{err}}", secretPath), err))
+			continue
+		}
+		if len(secret.CustomMetadata) > 0 {
+			err := kv.PatchMetadata(ctx, secretPath, KVMetadataPatchInput{CustomMetadata: secret.CustomMetadata})
+			if err != nil {
+				errs = multierror.Append(errs, errwrap.Wrapf(fmt.Sprintf("error writing metadata of %q: {{err}}", secretPath), err))
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClientKVWalk(t *testing.T) {
+	handler := newTestKVv2Handler()
+	for _, path := range []string{"a", "dir/b", "dir/sub/c", "other/d"} {
+		handler.versions[path] = []map[string]interface{}{{"path": path}}
+	}
+	config, ln := testHTTPServer(t, handler)
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	visited := make(map[string]interface{})
+	err = client.KVWalk(context.Background(), "secret", "/dir/", func(secretPath string, secret *KVSecret) error {
+		visited[secretPath] = secret.Data["path"]
+		return nil
+	}, KVWalkConcurrency(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"dir/b":     "dir/b",
+		"dir/sub/c": "dir/sub/c",
+	}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Fatalf("unexpected secrets visited: %#v", visited)
+	}
+
+	// An error returned by the callback stops the walk
+	stop := errors.New("stop")
+	var calls int
+	err = client.KVWalk(context.Background(), "secret", "", func(string, *KVSecret) error {
+		calls++
+		return stop
+	}, KVWalkConcurrency(1))
+	if err != stop || calls != 1 {
+		t.Fatalf("expected the walk to stop after one call, got %d calls and error %v", calls, err)
+	}
+
+	// Walking a missing directory visits nothing
+	err = client.KVWalk(context.Background(), "secret", "missing", func(string, *KVSecret) error {
+		t.Fatal("unexpected call")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientKVWalk_Bounded(t *testing.T) {
+	var l sync.Mutex
+	var inFlight, maxInFlight, lists int
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		l.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		if req.URL.Query().Get("list") == "true" {
+			lists++
+		}
+		l.Unlock()
+		defer func() {
+			l.Lock()
+			inFlight--
+			l.Unlock()
+		}()
+		time.Sleep(5 * time.Millisecond)
+
+		switch req.URL.Path {
+		case "/v1/secret/metadata":
+			// Keys that would make the root list itself again are skipped
+			w.Write([]byte(`{"data": {"keys": ["", "/", "a/", "a/"]}}`))
+		case "/v1/secret/metadata/a":
+			keys := make([]string, 20)
+			for i := range keys {
+				keys[i] = fmt.Sprintf("s%d", i)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"keys": keys},
+			})
+		default:
+			w.Write([]byte(`{"data": {"data": {}, "metadata": {"version": 1}}}`))
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var visited int
+	err = client.KVWalk(context.Background(), "secret", "/", func(string, *KVSecret) error {
+		visited++
+		return nil
+	}, KVWalkConcurrency(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if visited != 20 || lists != 2 {
+		t.Fatalf("expected 20 secrets visited with 2 lists, got %d and %d", visited, lists)
+	}
+	if maxInFlight > 3 {
+		t.Fatalf("expected at most 3 requests at once, got %d", maxInFlight)
+	}
+}
+
+func TestClientKVExportImport(t *testing.T) {
+	snapshot := `{
+		"secrets": {
+			"app/db": {"data": {"password": "hunter2"}, "custom_metadata": {"owner": "ops"}},
+			"app/api": {"data": {"key": "abc"}}
+		}
+	}`
+
+	handler := newTestKVv2Handler()
+	config, ln := testHTTPServer(t, handler)
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.KVImport(context.Background(), "secret", strings.NewReader(snapshot)); err != nil {
+		t.Fatal(err)
+	}
+	if len(handler.versions) != 2 || handler.versions["app/db"][0]["password"] != "hunter2" {
+		t.Fatalf("unexpected secrets imported: %#v", handler.versions)
+	}
+	if handler.custom["app/db"]["owner"] != "ops" || handler.custom["app/api"] != nil {
+		t.Fatalf("unexpected custom metadata imported: %#v", handler.custom)
+	}
+
+	var buf bytes.Buffer
+	if err := client.KVExport(context.Background(), "secret", "app", &buf); err != nil {
+		t.Fatal(err)
+	}
+	var exported KVSnapshot
+	if err := json.Unmarshal(buf.Bytes(), &exported); err != nil {
+		t.Fatal(err)
+	}
+	if len(exported.Secrets) != 2 || exported.Secrets["app/api"].Data["key"] != "abc" {
+		t.Fatalf("unexpected snapshot exported: %s", buf.String())
+	}
+
+	if err := client.KVImport(context.Background(), "secret", strings.NewReader("{")); err == nil {
+		t.Fatal("expected error decoding invalid snapshot")
+	}
+}
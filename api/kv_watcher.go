@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrKVWatcherMissingInput = errors.New("missing input")
+	ErrKVWatcherMissingPaths = errors.New("missing paths")
+
+	// DefaultKVWatcherPollInterval is the default time between two checks of
+	// the watched secrets.
+	DefaultKVWatcherPollInterval = 30 * time.Second
+)
+
+// kvWatcherEventTypes are the events that make a KVWatcher check its secrets
+// straight away.
+var kvWatcherEventTypes = []string{"kv-v2/*"}
+
+// KVWatcher is a process which watches KV version 2 secrets for changes,
+// delivering a KVChange on a channel for every new version seen.
+//
+//	watcher, err := client.KVv2("secret").NewWatcher(&KVWatcherInput{
+//		Paths: []string{"app/config"},
+//	})
+//	go watcher.Start()
+//	defer watcher.Stop()
+//
+//	for change := range watcher.ChangeCh() {
+//		// Reload the configuration
+//	}
+//
+// The secrets are polled, and if UseEvents is set also checked whenever the
+// server publishes a KV event. Failed checks are retried at the next poll.
+type KVWatcher struct {
+	l sync.Mutex
+
+	kv           *KVv2
+	paths        []string
+	pollInterval time.Duration
+	useEvents    bool
+	current      map[string]*KVSecret
+	changeCh     chan *KVChange
+
+	stopped bool
+	stopCh  chan struct{}
+}
+
+// KVWatcherInput is used as input to NewWatcher.
+type KVWatcherInput struct {
+	// Paths are the paths of the secrets to watch, relative to the mount.
+	Paths []string
+
+	// PollInterval is the time between two checks of the secrets.
+	PollInterval time.Duration
+
+	// UseEvents makes the watcher also check the secrets whenever the server
+	// publishes a KV event. Servers without the event API are only polled.
+	UseEvents bool
+}
+
+// KVChange describes a new version of a watched secret.
+type KVChange struct {
+	Path string
+
+	// OldVersion and NewVersion are the versions of the secret before and
+	// after the change, with zero meaning that the secret did not exist.
+	OldVersion int
+	NewVersion int
+
+	// Added, Removed and Modified are the keys of the data of the secret
+	// that were added, removed and changed, sorted.
+	Added    []string
+	Removed  []string
+	Modified []string
+
+	// Old and New are the secret before and after the change, nil if it did
+	// not exist. The Data of New is nil if the new version was deleted.
+	Old *KVSecret
+	New *KVSecret
+}
+
+// NewWatcher creates a new watcher for the secrets given in the input. The
+// versions current when the watcher starts are the baseline against which
+// changes are reported.
+func (kv *KVv2) NewWatcher(i *KVWatcherInput) (*KVWatcher, error) {
+	if i == nil {
+		return nil, ErrKVWatcherMissingInput
+	}
+	if len(i.Paths) == 0 {
+		return nil, ErrKVWatcherMissingPaths
+	}
+
+	pollInterval := i.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultKVWatcherPollInterval
+	}
+
+	return &KVWatcher{
+		kv:           kv,
+		paths:        append([]string(nil), i.Paths...),
+		pollInterval: pollInterval,
+		useEvents:    i.UseEvents,
+		changeCh:     make(chan *KVChange, len(i.Paths)),
+		stopCh:       make(chan struct{}),
+	}, nil
+}
+
+// ChangeCh returns the channel on which changes are delivered. It is closed
+// once the watcher stops.
+func (w *KVWatcher) ChangeCh() <-chan *KVChange {
+	return w.changeCh
+}
+
+// Stop stops the watcher.
+func (w *KVWatcher) Stop() {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	if !w.stopped {
+		close(w.stopCh)
+		w.stopped = true
+	}
+}
+
+// Start watches the secrets until the watcher is stopped. It blocks, so it
+// should usually be run in a goroutine.
+func (w *KVWatcher) Start() {
+	defer close(w.changeCh)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	go func() {
+		select {
+		case <-w.stopCh:
+			cancelFunc()
+		case <-ctx.Done():
+		}
+	}()
+
+	var eventCh <-chan *Event
+	if w.useEvents {
+		// Without the event API, the secrets are only polled
+		eventCh, _ = w.kv.c.Events().Subscribe(ctx, kvWatcherEventTypes...)
+	}
+
+	w.current = make(map[string]*KVSecret, len(w.paths))
+	w.check(ctx, false)
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case _, ok := <-eventCh:
+			if !ok {
+				eventCh = nil
+				continue
+			}
+		}
+		if !w.check(ctx, true) {
+			return
+		}
+	}
+}
+
+// check reads the watched secrets, delivering a change for every new version
+// if notify is set. It returns false if the watcher was stopped meanwhile.
+func (w *KVWatcher) check(ctx context.Context, notify bool) bool {
+	for _, path := range w.paths {
+		secret, err := w.kv.Get(ctx, path)
+		switch {
+		case err == ErrSecretNotFound:
+			secret = nil
+		case err != nil:
+			// Keep the last version seen; the next check retries
+			continue
+		}
+
+		old, seen := w.current[path]
+		if seen && kvSecretVersion(old) == kvSecretVersion(secret) {
+			continue
+		}
+		w.current[path] = secret
+		if !notify {
+			continue
+		}
+
+		select {
+		case w.changeCh <- diffKVSecrets(path, old, secret):
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return ctx.Err() == nil
+}
+
+func kvSecretVersion(secret *KVSecret) int {
+	if secret == nil || secret.VersionMetadata == nil {
+		return 0
+	}
+	return secret.VersionMetadata.Version
+}
+
+// diffKVSecrets compares the data of two versions of a secret.
+func diffKVSecrets(path string, old, new *KVSecret) *KVChange {
+	change := &KVChange{
+		Path:       path,
+		OldVersion: kvSecretVersion(old),
+		NewVersion: kvSecretVersion(new),
+		Old:        old,
+		New:        new,
+	}
+
+	var oldData, newData map[string]interface{}
+	if old != nil {
+		oldData = old.Data
+	}
+	if new != nil {
+		newData = new.Data
+	}
+	for k, v := range newData {
+		oldValue, ok := oldData[k]
+		switch {
+		case !ok:
+			change.Added = append(change.Added, k)
+		case !reflect.DeepEqual(oldValue, v):
+			change.Modified = append(change.Modified, k)
+		}
+	}
+	for k := range oldData {
+		if _, ok := newData[k]; !ok {
+			change.Removed = append(change.Removed, k)
+		}
+	}
+	sort.Strings(change.Added)
+	sort.Strings(change.Removed)
+	sort.Strings(change.Modified)
+	return change
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestKVWatcher(t *testing.T) {
+	handler := newTestKVv2Handler()
+	handler.versions["app/config"] = []map[string]interface{}{{"a": "1", "b": "2"}}
+	config, ln := testHTTPServer(t, handler)
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv := client.KVv2("secret")
+
+	if _, err := kv.NewWatcher(&KVWatcherInput{}); err != ErrKVWatcherMissingPaths {
+		t.Fatalf("expected missing paths error, got %v", err)
+	}
+	watcher, err := kv.NewWatcher(&KVWatcherInput{
+		Paths:        []string{"app/config", "app/new"},
+		PollInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go watcher.Start()
+	defer watcher.Stop()
+
+	// Let the watcher read the baseline before changing the secrets
+	time.Sleep(50 * time.Millisecond)
+	if _, err := kv.Put(context.Background(), "app/config", map[string]interface{}{"a": "1", "b": "3", "c": "4"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case change := <-watcher.ChangeCh():
+		if change.Path != "app/config" || change.OldVersion != 1 || change.NewVersion != 2 {
+			t.Fatalf("unexpected change %#v", change)
+		}
+		if !reflect.DeepEqual(change.Added, []string{"c"}) || !reflect.DeepEqual(change.Modified, []string{"b"}) || change.Removed != nil {
+			t.Fatalf("unexpected diff %#v", change)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+
+	if _, err := kv.Put(context.Background(), "app/new", map[string]interface{}{"d": "5"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case change := <-watcher.ChangeCh():
+		if change.Path != "app/new" || change.OldVersion != 0 || change.NewVersion != 1 || change.Old != nil {
+			t.Fatalf("unexpected change %#v", change)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+
+	watcher.Stop()
+	select {
+	case _, ok := <-watcher.ChangeCh():
+		if ok {
+			t.Fatal("unexpected change after stopping")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the watcher to stop")
+	}
+}
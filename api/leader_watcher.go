@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultLeaderWatcherInterval is the default time between two checks of the
+// leader by a LeaderWatcher.
+const DefaultLeaderWatcherInterval = 10 * time.Second
+
+// LeaderWatcher is a process which follows the active node of an HA cluster,
+// pointing the client at it whenever leadership changes, so that requests
+// are not forwarded by, or fail on, standby nodes.
+//
+//	watcher := client.Sys().NewLeaderWatcher(nil)
+//	go watcher.Start()
+//	defer watcher.Stop()
+//
+// The new addresses are also delivered on ChangeCh, dropping those the
+// caller was not ready to receive.
+type LeaderWatcher struct {
+	l sync.Mutex
+
+	sys      *Sys
+	interval time.Duration
+	changeCh chan string
+
+	stopped bool
+	stopCh  chan struct{}
+}
+
+// LeaderWatcherInput is used as input to NewLeaderWatcher.
+type LeaderWatcherInput struct {
+	// Interval is the time between two checks of the leader.
+	Interval time.Duration
+}
+
+// NewLeaderWatcher creates a new leader watcher. A nil input uses the
+// defaults.
+func (c *Sys) NewLeaderWatcher(i *LeaderWatcherInput) *LeaderWatcher {
+	interval := DefaultLeaderWatcherInterval
+	if i != nil && i.Interval > 0 {
+		interval = i.Interval
+	}
+
+	return &LeaderWatcher{
+		sys:      c,
+		interval: interval,
+		changeCh: make(chan string, 1),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// ChangeCh returns the channel on which the addresses of new leaders are
+// delivered.
+func (w *LeaderWatcher) ChangeCh() <-chan string {
+	return w.changeCh
+}
+
+// Stop stops the watcher.
+func (w *LeaderWatcher) Stop() {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	if !w.stopped {
+		close(w.stopCh)
+		w.stopped = true
+	}
+}
+
+// Start follows the leader until the watcher is stopped or the client is
+// closed. It blocks, so it should usually be run in a goroutine.
+func (w *LeaderWatcher) Start() {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	go func() {
+		select {
+		case <-w.stopCh:
+			cancelFunc()
+		case <-w.sys.c.closedCh():
+			w.Stop()
+			cancelFunc()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check points the client at the current leader, if it is known and not the
+// current address already. Errors are retried at the next check.
+func (w *LeaderWatcher) check(ctx context.Context) {
+	leader, err := w.sys.leaderWithContext(ctx)
+	if err != nil || !leader.HAEnabled || leader.IsSelf || leader.LeaderAddress == "" {
+		return
+	}
+	if leader.LeaderAddress == w.sys.c.Address() {
+		return
+	}
+	if err := w.sys.c.SetAddress(leader.LeaderAddress); err != nil {
+		return
+	}
+
+	select {
+	case w.changeCh <- leader.LeaderAddress:
+	default:
+	}
+}
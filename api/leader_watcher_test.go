@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLeaderWatcher(t *testing.T) {
+	activeConfig, activeLn := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"ha_enabled": true, "is_self": true}`))
+	}))
+	defer activeLn.Close()
+
+	standbyConfig, standbyLn := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/sys/leader" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"ha_enabled": true, "is_self": false, "leader_address": %q}`, activeConfig.Address)
+	}))
+	defer standbyLn.Close()
+
+	client, err := NewClient(standbyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watcher := client.Sys().NewLeaderWatcher(&LeaderWatcherInput{Interval: 10 * time.Millisecond})
+	go watcher.Start()
+	defer watcher.Stop()
+
+	select {
+	case addr := <-watcher.ChangeCh():
+		if addr != activeConfig.Address {
+			t.Fatalf("expected %q, got %q", activeConfig.Address, addr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for leader change")
+	}
+	if client.Address() != activeConfig.Address {
+		t.Fatalf("expected client address %q, got %q", activeConfig.Address, client.Address())
+	}
+
+	// The active node reports itself, which must not trigger further changes
+	select {
+	case addr := <-watcher.ChangeCh():
+		t.Fatalf("unexpected change to %q", addr)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestLeaderWatcher_ClientClosed(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"ha_enabled": true, "is_self": true}`))
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watcher := client.Sys().NewLeaderWatcher(&LeaderWatcherInput{Interval: 10 * time.Millisecond})
+	done := make(chan struct{})
+	go func() {
+		watcher.Start()
+		close(done)
+	}()
+
+	client.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the watcher to stop")
+	}
+}
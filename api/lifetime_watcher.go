@@ -265,6 +265,8 @@ func (r *LifetimeWatcher) doRenew() error {
 		select {
 		case <-r.stopCh:
 			return nil
+		case <-r.client.closedCh():
+			return nil
 		default:
 		}
 
@@ -340,6 +342,8 @@ func (r *LifetimeWatcher) doRenew() error {
 		select {
 		case <-r.stopCh:
 			return nil
+		case <-r.client.closedCh():
+			return nil
 		case <-time.After(sleepDuration):
 			continue
 		}
@@ -5,8 +5,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
 	"net/url"
 	"os"
+	"strings"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
@@ -51,6 +54,13 @@ func (c *Logical) Read(path string) (*Secret, error) {
 }
 
 func (c *Logical) ReadWithData(path string, data map[string][]string) (*Secret, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	return c.readWithContext(ctx, path, data)
+}
+
+func (c *Logical) readWithContext(ctx context.Context, path string, data map[string][]string) (*Secret, error) {
 	r := c.c.NewRequest("GET", "/v1/"+path)
 
 	var values url.Values
@@ -67,14 +77,20 @@ func (c *Logical) ReadWithData(path string, data map[string][]string) (*Secret,
 		r.Params = values
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
-	defer cancelFunc()
+	var cacheKey string
+	if c.c.cache != nil && !r.OutputCurlString && !r.OutputPolicy && !c.c.OutputCurlString() && !c.c.OutputPolicy() {
+		cacheKey = c.c.cache.key(r, path)
+		if body := c.c.cache.get(cacheKey); body != nil {
+			return ParseSecret(bytes.NewReader(body))
+		}
+	}
+
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
 	if resp != nil && resp.StatusCode == 404 {
-		secret, parseErr := ParseSecret(resp.Body)
+		secret, parseErr := resp.parseSecret()
 		switch parseErr {
 		case nil:
 		case io.EOF:
@@ -91,24 +107,45 @@ func (c *Logical) ReadWithData(path string, data map[string][]string) (*Secret,
 		return nil, err
 	}
 
-	return ParseSecret(resp.Body)
+	if cacheKey != "" {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		c.c.cache.put(cacheKey, path, body)
+		secret, err := ParseSecret(bytes.NewReader(body))
+		if secret != nil {
+			secret.ResponseMetadata = resp.metadata()
+		}
+		return secret, err
+	}
+
+	return resp.parseSecret()
 }
 
 func (c *Logical) List(path string) (*Secret, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	return c.listWithContext(ctx, path, nil)
+}
+
+func (c *Logical) listWithContext(ctx context.Context, path string, params url.Values) (*Secret, error) {
 	r := c.c.NewRequest("LIST", "/v1/"+path)
 	// Set this for broader compatibility, but we use LIST above to be able to
 	// handle the wrapping lookup function
 	r.Method = "GET"
+	for k, v := range params {
+		r.Params[k] = v
+	}
 	r.Params.Set("list", "true")
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
-	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
 	if resp != nil && resp.StatusCode == 404 {
-		secret, parseErr := ParseSecret(resp.Body)
+		secret, parseErr := resp.parseSecret()
 		switch parseErr {
 		case nil:
 		case io.EOF:
@@ -125,7 +162,7 @@ func (c *Logical) List(path string) (*Secret, error) {
 		return nil, err
 	}
 
-	return ParseSecret(resp.Body)
+	return resp.parseSecret()
 }
 
 func (c *Logical) Write(path string, data map[string]interface{}) (*Secret, error) {
@@ -145,6 +182,10 @@ func (c *Logical) WriteBytes(path string, data []byte) (*Secret, error) {
 }
 
 func (c *Logical) write(path string, request *Request) (*Secret, error) {
+	if c.c.cache != nil {
+		defer c.c.cache.invalidate(path)
+	}
+
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, request)
@@ -152,7 +193,7 @@ func (c *Logical) write(path string, request *Request) (*Secret, error) {
 		defer resp.Body.Close()
 	}
 	if resp != nil && resp.StatusCode == 404 {
-		secret, parseErr := ParseSecret(resp.Body)
+		secret, parseErr := resp.parseSecret()
 		switch parseErr {
 		case nil:
 		case io.EOF:
@@ -168,7 +209,7 @@ func (c *Logical) write(path string, request *Request) (*Secret, error) {
 		return nil, err
 	}
 
-	return ParseSecret(resp.Body)
+	return resp.parseSecret()
 }
 
 func (c *Logical) Delete(path string) (*Secret, error) {
@@ -177,6 +218,9 @@ func (c *Logical) Delete(path string) (*Secret, error) {
 
 func (c *Logical) DeleteWithData(path string, data map[string][]string) (*Secret, error) {
 	r := c.c.NewRequest("DELETE", "/v1/"+path)
+	if c.c.cache != nil {
+		defer c.c.cache.invalidate(path)
+	}
 
 	var values url.Values
 	for k, v := range data {
@@ -199,7 +243,7 @@ func (c *Logical) DeleteWithData(path string, data map[string][]string) (*Secret
 		defer resp.Body.Close()
 	}
 	if resp != nil && resp.StatusCode == 404 {
-		secret, parseErr := ParseSecret(resp.Body)
+		secret, parseErr := resp.parseSecret()
 		switch parseErr {
 		case nil:
 		case io.EOF:
@@ -215,7 +259,7 @@ func (c *Logical) DeleteWithData(path string, data map[string][]string) (*Secret
 		return nil, err
 	}
 
-	return ParseSecret(resp.Body)
+	return resp.parseSecret()
 }
 
 func (c *Logical) Unwrap(wrappingToken string) (*Secret, error) {
@@ -248,11 +292,11 @@ func (c *Logical) Unwrap(wrappingToken string) (*Secret, error) {
 		if resp == nil {
 			return nil, nil
 		}
-		return ParseSecret(resp.Body)
+		return resp.parseSecret()
 	}
 
 	// In the 404 case this may actually be a wrapped 404 error
-	secret, parseErr := ParseSecret(resp.Body)
+	secret, parseErr := resp.parseSecret()
 	switch parseErr {
 	case nil:
 	case io.EOF:
@@ -294,3 +338,106 @@ func (c *Logical) Unwrap(wrappingToken string) (*Secret, error) {
 
 	return wrappedSecret, nil
 }
+
+// Patch applies a JSON merge patch (RFC 7386) to the data at the given path,
+// as supported by the data endpoints of KV version 2 mounts. Fields set to
+// nil are removed.
+func (c *Logical) Patch(path string, data map[string]interface{}) (*Secret, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	return c.Do(ctx, "PATCH", path, data)
+}
+
+// Exists returns whether there is a value at the given path, by making a HEAD
+// request to it. Only endpoints that handle HEAD requests, such as
+// sys/health, report existence this way; for others, use Read.
+func (c *Logical) Exists(path string) (bool, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	resp, err := c.c.RawRequestWithContext(ctx, c.c.NewRequest("HEAD", "/v1/"+path))
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return false, nil
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Do makes a request with an arbitrary method to the given path. For GET,
+// HEAD, LIST, and DELETE requests, data is sent as query parameters;
+// otherwise it is sent as the JSON body, with the content type of a merge
+// patch for PATCH requests. LIST requests are sent as GET requests with the
+// list parameter set, for broader compatibility.
+func (c *Logical) Do(ctx context.Context, method, path string, data map[string]interface{}) (*Secret, error) {
+	method = strings.ToUpper(method)
+	if method == "LIST" {
+		params := make(url.Values, len(data))
+		for k, v := range data {
+			params.Set(k, fmt.Sprint(v))
+		}
+		return c.listWithContext(ctx, path, params)
+	}
+
+	r := c.c.NewRequest(method, "/v1/"+path)
+	switch method {
+	case "GET", "HEAD", "DELETE":
+		for k, v := range data {
+			r.Params.Set(k, fmt.Sprint(v))
+		}
+	default:
+		if data != nil {
+			if err := r.SetJSONBody(data); err != nil {
+				return nil, err
+			}
+		}
+		if method == "PATCH" {
+			r.Headers = setHeader(r.Headers, "Content-Type", "application/merge-patch+json")
+		}
+	}
+
+	if method != "GET" && method != "HEAD" && c.c.cache != nil {
+		defer c.c.cache.invalidate(path)
+	}
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if resp != nil && resp.StatusCode == 404 {
+		secret, parseErr := resp.parseSecret()
+		switch parseErr {
+		case nil:
+		case io.EOF:
+			return nil, nil
+		default:
+			return nil, err
+		}
+		if secret != nil && (len(secret.Warnings) > 0 || len(secret.Data) > 0) {
+			return secret, nil
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if method == "HEAD" {
+		return nil, nil
+	}
+
+	return resp.parseSecret()
+}
+
+// setHeader sets a header, allocating the headers if needed.
+func setHeader(headers http.Header, key, value string) http.Header {
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Set(key, value)
+	return headers
+}
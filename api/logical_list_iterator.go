@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+// DefaultListPageSize is the number of keys requested per page by
+// ListIterator unless overridden with ListPageSize.
+const DefaultListPageSize = 1000
+
+// ListIteratorOption configures a ListIterator.
+type ListIteratorOption func(*ListIterator)
+
+// ListPageSize sets the number of keys requested per page.
+func ListPageSize(n int) ListIteratorOption {
+	return func(it *ListIterator) {
+		it.pageSize = n
+	}
+}
+
+// ListIterator enumerates the keys under a path one page at a time, using the
+// "after" and "limit" parameters of LIST requests. Endpoints which do not
+// support pagination return all of their keys in the first page.
+//
+//	it := client.Logical().ListIterator(ctx, "secret/metadata")
+//	for it.Next() {
+//		for _, key := range it.Keys() {
+//			...
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ListIterator struct {
+	c        *Logical
+	ctx      context.Context
+	path     string
+	pageSize int
+
+	after string
+	keys  []string
+	done  bool
+	err   error
+}
+
+// ListIterator returns an iterator over the keys under the given path.
+func (c *Logical) ListIterator(ctx context.Context, path string, opts ...ListIteratorOption) *ListIterator {
+	it := &ListIterator{
+		c:        c,
+		ctx:      ctx,
+		path:     path,
+		pageSize: DefaultListPageSize,
+	}
+	for _, opt := range opts {
+		opt(it)
+	}
+	return it
+}
+
+// Next fetches the next page of keys, returning false when there are no more
+// keys or an error occurred.
+func (it *ListIterator) Next() bool {
+	it.keys = nil
+	if it.done {
+		return false
+	}
+
+	params := make(url.Values)
+	if it.pageSize > 0 {
+		params.Set("limit", strconv.Itoa(it.pageSize))
+	}
+	if it.after != "" {
+		params.Set("after", it.after)
+	}
+
+	secret, err := it.c.listWithContext(it.ctx, it.path, params)
+	if err != nil {
+		it.err = err
+		it.done = true
+		return false
+	}
+	if secret == nil || secret.Data == nil {
+		it.done = true
+		return false
+	}
+
+	rawKeys, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		if secret.Data["keys"] != nil {
+			it.err = errors.New("unexpected type for keys in list response")
+		}
+		it.done = true
+		return false
+	}
+
+	// Servers which ignore the pagination parameters return every key on
+	// each request, so drop keys already seen and stop once a page is not
+	// full.
+	keys := make([]string, 0, len(rawKeys))
+	for _, raw := range rawKeys {
+		key, ok := raw.(string)
+		if !ok {
+			it.err = errors.New("unexpected type for key in list response")
+			it.done = true
+			return false
+		}
+		if it.after != "" && key <= it.after {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if it.pageSize <= 0 || len(rawKeys) != it.pageSize || len(keys) == 0 {
+		it.done = true
+	}
+	if len(keys) == 0 {
+		return false
+	}
+
+	it.keys = keys
+	it.after = keys[len(keys)-1]
+	return true
+}
+
+// Keys returns the keys of the current page.
+func (it *ListIterator) Keys() []string {
+	return it.keys
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (it *ListIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+)
+
+// MergePatchOption configures a call to JSONMergePatch.
+type MergePatchOption func(*mergePatchOptions)
+
+type mergePatchOptions struct {
+	cas *int
+}
+
+// MergePatchCAS makes the patch conditional on the current version of a KV
+// version 2 secret being the given one, as with the cas option of a write.
+func MergePatchCAS(version int) MergePatchOption {
+	return func(o *mergePatchOptions) {
+		o.cas = &version
+	}
+}
+
+// JSONMergePatch applies a JSON merge patch (RFC 7386) to the data at the
+// given path: fields of the patch set to nil are removed from the data, and
+// objects are merged recursively. For KV version 2 data endpoints, the patch
+// is given as the request body would be, i.e. with the fields to change
+// under "data".
+//
+// If the mount does not support PATCH requests, the patch is applied by
+// reading the data, merging the patch into it, and writing the result. For
+// KV version 2 secrets, the write is made with the version read as its
+// check-and-set version, so that it fails rather than overwrite a concurrent
+// change.
+func (c *Logical) JSONMergePatch(ctx context.Context, path string, data map[string]interface{}, opts ...MergePatchOption) (*Secret, error) {
+	var o mergePatchOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	body := data
+	if o.cas != nil {
+		body = mergePatch(data, map[string]interface{}{
+			"options": map[string]interface{}{"cas": *o.cas},
+		}).(map[string]interface{})
+	}
+
+	secret, err := c.Do(ctx, "PATCH", path, body)
+	var respErr *ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusMethodNotAllowed {
+		return c.readModifyWrite(ctx, path, data, o.cas)
+	}
+	return secret, err
+}
+
+// readModifyWrite applies a merge patch by reading the data at the path and
+// writing it back patched.
+func (c *Logical) readModifyWrite(ctx context.Context, path string, patch map[string]interface{}, cas *int) (*Secret, error) {
+	current, err := c.readWithContext(ctx, path, nil)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error reading %q to patch it: {{err}}", path), err)
+	}
+	if current == nil {
+		return nil, fmt.Errorf("no value found at %q to patch", path)
+	}
+
+	kvData, isKVv2 := current.Data["data"].(map[string]interface{})
+	metadata, _ := current.Data["metadata"].(map[string]interface{})
+	if !isKVv2 || metadata == nil {
+		if cas != nil {
+			return nil, fmt.Errorf("check-and-set is only supported for KV version 2 secrets, and %q is not one", path)
+		}
+		return c.Do(ctx, "PUT", path, mergePatch(current.Data, patch).(map[string]interface{}))
+	}
+
+	version, err := parseutil.ParseInt(metadata["version"])
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error parsing version of %q: {{err}}", path), err)
+	}
+	if cas != nil && int64(*cas) != version {
+		return nil, &casMismatchError{path: path, cas: *cas, version: version}
+	}
+
+	patchData, ok := patch["data"]
+	if !ok {
+		patchData = map[string]interface{}{}
+	}
+	return c.Do(ctx, "PUT", path, map[string]interface{}{
+		"data":    mergePatch(kvData, patchData),
+		"options": map[string]interface{}{"cas": version},
+	})
+}
+
+// casMismatchError is returned when the check-and-set version of a patch
+// applied by reading and writing the data does not match the version read.
+// Like the server's response to a mismatched write, it matches
+// ErrCASConflict.
+type casMismatchError struct {
+	path    string
+	cas     int
+	version int64
+}
+
+func (e *casMismatchError) Error() string {
+	return fmt.Sprintf("check-and-set version %d does not match the current version %d of %q", e.cas, e.version, e.path)
+}
+
+func (e *casMismatchError) Is(target error) bool {
+	return target == ErrCASConflict
+}
+
+// mergePatch returns the result of applying a JSON merge patch to target,
+// without modifying either of them.
+func mergePatch(target, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetMap, _ := target.(map[string]interface{})
+	result := make(map[string]interface{}, len(targetMap)+len(patchMap))
+	for k, v := range targetMap {
+		result[k] = v
+	}
+	for k, v := range patchMap {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+		result[k] = mergePatch(result[k], v)
+	}
+	return result
+}
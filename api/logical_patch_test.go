@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]interface{}{
+		"a": "b",
+		"c": map[string]interface{}{"d": "e", "f": "g"},
+	}
+	patch := map[string]interface{}{
+		"a": "z",
+		"c": map[string]interface{}{"f": nil},
+		"h": []interface{}{"i"},
+	}
+	expected := map[string]interface{}{
+		"a": "z",
+		"c": map[string]interface{}{"d": "e"},
+		"h": []interface{}{"i"},
+	}
+	if actual := mergePatch(target, patch); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected result %#v", actual)
+	}
+	if target["a"] != "b" || len(target["c"].(map[string]interface{})) != 2 {
+		t.Fatalf("target was modified: %#v", target)
+	}
+}
+
+func TestLogicalJSONMergePatch(t *testing.T) {
+	var patchSupported bool
+	var methods []string
+	var written map[string]interface{}
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		methods = append(methods, req.Method)
+		switch req.Method {
+		case "PATCH":
+			if !patchSupported {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				w.Write([]byte(`{"errors": ["unsupported operation"]}`))
+				return
+			}
+			if req.Header.Get("Content-Type") != "application/merge-patch+json" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewDecoder(req.Body).Decode(&written)
+		case "GET":
+			w.Write([]byte(`{"data": {"data": {"a": "b", "c": "d"}, "metadata": {"version": 3}}}`))
+			return
+		case "PUT":
+			json.NewDecoder(req.Body).Decode(&written)
+		}
+		w.Write([]byte(`{"data": {"version": 4}}`))
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch := map[string]interface{}{
+		"data": map[string]interface{}{"a": "z", "c": nil},
+	}
+
+	// The read-modify-write fallback writes with the version read as CAS
+	secret, err := client.Logical().JSONMergePatch(context.Background(), "secret/data/foo", patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["version"] == nil {
+		t.Fatalf("unexpected secret %#v", secret)
+	}
+	expected := map[string]interface{}{
+		"data":    map[string]interface{}{"a": "z"},
+		"options": map[string]interface{}{"cas": float64(3)},
+	}
+	if !reflect.DeepEqual(methods, []string{"PATCH", "GET", "PUT"}) || !reflect.DeepEqual(written, expected) {
+		t.Fatalf("unexpected requests %v writing %#v", methods, written)
+	}
+
+	methods = nil
+	if _, err := client.Logical().JSONMergePatch(context.Background(), "secret/data/foo", patch, MergePatchCAS(2)); !IsCASConflict(err) {
+		t.Fatalf("expected CAS conflict, got %v", err)
+	}
+	if !reflect.DeepEqual(methods, []string{"PATCH", "GET"}) {
+		t.Fatalf("expected no write on CAS mismatch, got %v", methods)
+	}
+
+	// When supported, the patch is sent as is, with the CAS option
+	patchSupported = true
+	methods, written = nil, nil
+	if _, err := client.Logical().JSONMergePatch(context.Background(), "secret/data/foo", patch, MergePatchCAS(3)); err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string]interface{}{
+		"data":    map[string]interface{}{"a": "z", "c": nil},
+		"options": map[string]interface{}{"cas": float64(3)},
+	}
+	if !reflect.DeepEqual(methods, []string{"PATCH"}) || !reflect.DeepEqual(written, expected) {
+		t.Fatalf("unexpected requests %v writing %#v", methods, written)
+	}
+}
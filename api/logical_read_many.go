@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// DefaultReadManyConcurrency is the number of reads ReadMany performs at once
+// unless overridden with ReadManyConcurrency.
+const DefaultReadManyConcurrency = 8
+
+// ReadManyResult is the outcome of reading one of the paths given to
+// ReadMany. Secret is nil if the path does not exist or the read failed.
+type ReadManyResult struct {
+	Path   string
+	Secret *Secret
+	Err    error
+}
+
+// ReadManyOption configures a call to ReadMany.
+type ReadManyOption func(*readManyOptions)
+
+type readManyOptions struct {
+	concurrency int
+	data        map[string][]string
+}
+
+// ReadManyConcurrency sets the number of reads performed at once.
+func ReadManyConcurrency(n int) ReadManyOption {
+	return func(o *readManyOptions) {
+		o.concurrency = n
+	}
+}
+
+// ReadManyData sets query parameters sent with every read, as with
+// ReadWithData.
+func ReadManyData(data map[string][]string) ReadManyOption {
+	return func(o *readManyOptions) {
+		o.data = data
+	}
+}
+
+// ReadMany reads the given paths concurrently using a bounded pool of
+// workers. Requests go through the client as usual, so its rate limiter and
+// admission control apply. The results are returned in the order of the
+// paths; the returned error combines the errors of all failed reads and is
+// nil if every read succeeded.
+func (c *Logical) ReadMany(ctx context.Context, paths []string, opts ...ReadManyOption) ([]*ReadManyResult, error) {
+	options := &readManyOptions{
+		concurrency: DefaultReadManyConcurrency,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.concurrency <= 0 || options.concurrency > len(paths) {
+		options.concurrency = len(paths)
+	}
+
+	results := make([]*ReadManyResult, len(paths))
+	work := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < options.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range work {
+				result := &ReadManyResult{Path: paths[idx]}
+				if err := ctx.Err(); err != nil {
+					result.Err = err
+				} else {
+					result.Secret, result.Err = c.readWithContext(ctx, paths[idx], options.data)
+				}
+				results[idx] = result
+			}
+		}()
+	}
+	for i := range paths {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	var errs *multierror.Error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = multierror.Append(errs, errwrap.Wrapf("error reading "+result.Path+": {{err}}", result.Err))
+		}
+	}
+	return results, errs.ErrorOrNil()
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLogicalReadMany(t *testing.T) {
+	var l sync.Mutex
+	var inFlight, maxInFlight int
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		l.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		l.Unlock()
+		defer func() {
+			l.Lock()
+			inFlight--
+			l.Unlock()
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/missing"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasSuffix(req.URL.Path, "/forbidden"):
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+		default:
+			w.Write([]byte(`{"data":{"path":"` + req.URL.Path + `"}}`))
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{"secret/a", "secret/b", "secret/missing", "secret/c", "secret/forbidden", "secret/d"}
+	results, err := client.Logical().ReadMany(context.Background(), paths, ReadManyConcurrency(2))
+	if err == nil || !strings.Contains(err.Error(), "secret/forbidden") {
+		t.Fatalf("expected an error for the forbidden path, got: %v", err)
+	}
+	if maxInFlight > 2 {
+		t.Fatalf("expected at most 2 concurrent reads, got %d", maxInFlight)
+	}
+	if len(results) != len(paths) {
+		t.Fatalf("expected %d results, got %d", len(paths), len(results))
+	}
+
+	for i, result := range results {
+		if result.Path != paths[i] {
+			t.Fatalf("result %d: expected path %q, got %q", i, paths[i], result.Path)
+		}
+		switch result.Path {
+		case "secret/missing":
+			if result.Secret != nil || result.Err != nil {
+				t.Fatalf("expected no secret and no error for a missing path, got %#v", result)
+			}
+		case "secret/forbidden":
+			if result.Err == nil {
+				t.Fatal("expected an error for the forbidden path")
+			}
+		default:
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if result.Secret.Data["path"] != "/v1/"+result.Path {
+				t.Fatalf("result %d: got data for %v", i, result.Secret.Data["path"])
+			}
+		}
+	}
+}
+
+func TestLogicalListIterator(t *testing.T) {
+	var allKeys []string
+	for i := 0; i < 25; i++ {
+		allKeys = append(allKeys, fmt.Sprintf("key%02d", i))
+	}
+
+	for _, paginated := range []bool{true, false} {
+		t.Run(fmt.Sprintf("paginated=%t", paginated), func(t *testing.T) {
+			var requests int
+			config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requests++
+				keys := allKeys
+				if paginated {
+					after := req.URL.Query().Get("after")
+					start := sort.SearchStrings(keys, after)
+					if start < len(keys) && keys[start] == after {
+						start++
+					}
+					keys = keys[start:]
+					if limit, _ := strconv.Atoi(req.URL.Query().Get("limit")); limit > 0 && limit < len(keys) {
+						keys = keys[:limit]
+					}
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"data": map[string]interface{}{"keys": keys},
+				})
+			}))
+			defer ln.Close()
+
+			client, err := NewClient(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			var pages int
+			it := client.Logical().ListIterator(context.Background(), "secret/metadata", ListPageSize(10))
+			for it.Next() {
+				pages++
+				got = append(got, it.Keys()...)
+			}
+			if err := it.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, allKeys) {
+				t.Fatalf("expected %v, got %v", allKeys, got)
+			}
+
+			expectedPages, expectedRequests := 1, 1
+			if paginated {
+				expectedPages, expectedRequests = 3, 3
+			}
+			if pages != expectedPages || requests != expectedRequests {
+				t.Fatalf("expected %d pages in %d requests, got %d in %d", expectedPages, expectedRequests, pages, requests)
+			}
+		})
+	}
+}
+
+func TestLogicalMethods(t *testing.T) {
+	type request struct {
+		method      string
+		path        string
+		query       string
+		contentType string
+		body        string
+	}
+	var requests []request
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		requests = append(requests, request{req.Method, req.URL.Path, req.URL.RawQuery, req.Header.Get("Content-Type"), string(body)})
+		if strings.HasSuffix(req.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Method != "HEAD" {
+			w.Write([]byte(`{"data": {"value": "bar"}}`))
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := client.Logical().Patch("secret/data/foo", map[string]interface{}{
+		"data": map[string]interface{}{"value": "bar"},
+	})
+	if err != nil || secret.Data["value"] != "bar" {
+		t.Fatalf("unexpected patch result %#v, %v", secret, err)
+	}
+
+	for _, tc := range []struct {
+		path   string
+		exists bool
+	}{
+		{"sys/health", true},
+		{"secret/missing", false},
+	} {
+		exists, err := client.Logical().Exists(tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists != tc.exists {
+			t.Fatalf("%s: expected exists to be %t", tc.path, tc.exists)
+		}
+	}
+
+	if _, err := client.Logical().Do(context.Background(), "list", "secret/metadata", map[string]interface{}{"after": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Do(context.Background(), "POST", "secret/foo", map[string]interface{}{"value": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []request{
+		{"PATCH", "/v1/secret/data/foo", "", "application/merge-patch+json", `{"data":{"value":"bar"}}`},
+		{"HEAD", "/v1/sys/health", "", "", ""},
+		{"HEAD", "/v1/secret/missing", "", "", ""},
+		{"GET", "/v1/secret/metadata", "after=a&list=true", "", ""},
+		{"POST", "/v1/secret/foo", "", "", `{"value":1}`},
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Fatalf("unexpected requests:\n%#v", requests)
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrOutputPolicyRequest = "output a policy, please"
+)
+
+var (
+	LastOutputPolicyError *OutputPolicyError
+)
+
+// sudoPaths are the paths which require the sudo capability in addition to
+// the capabilities implied by the request method.
+var sudoPaths = []*regexp.Regexp{
+	regexp.MustCompile(`^auth/token/accessors/?$`),
+	regexp.MustCompile(`^auth/token/revoke-orphan$`),
+	regexp.MustCompile(`^auth/token/tidy$`),
+	regexp.MustCompile(`^sys/audit(/.+)?$`),
+	regexp.MustCompile(`^sys/auth/.+$`),
+	regexp.MustCompile(`^sys/config/auditing/request-headers(/.+)?$`),
+	regexp.MustCompile(`^sys/config/cors$`),
+	regexp.MustCompile(`^sys/config/ui/headers(/.*)?$`),
+	regexp.MustCompile(`^sys/leases/lookup(/.*)?$`),
+	regexp.MustCompile(`^sys/leases/revoke-(force|prefix)/.+$`),
+	regexp.MustCompile(`^sys/plugins/catalog/.+$`),
+	regexp.MustCompile(`^sys/plugins/reload/backend$`),
+	regexp.MustCompile(`^sys/raw(/.*)?$`),
+	regexp.MustCompile(`^sys/remount$`),
+	regexp.MustCompile(`^sys/revoke-(force|prefix)/.+$`),
+	regexp.MustCompile(`^sys/rotate$`),
+}
+
+// OutputPolicyError is returned instead of sending a request when policy
+// output is requested. Its HCLString method returns the policy stanza
+// granting the capabilities the request needs.
+type OutputPolicyError struct {
+	method         string
+	path           string
+	params         url.Values
+	finalHCLString string
+}
+
+func (d *OutputPolicyError) Error() string {
+	if d.finalHCLString == "" {
+		p, err := d.buildSamplePolicy()
+		if err != nil {
+			return err.Error()
+		}
+		d.finalHCLString = p
+	}
+
+	return ErrOutputPolicyRequest
+}
+
+// HCLString returns the policy stanza required for the request, e.g.
+//
+//	path "secret/data/foo" {
+//	  capabilities = ["read"]
+//	}
+func (d *OutputPolicyError) HCLString() (string, error) {
+	if d.finalHCLString == "" {
+		p, err := d.buildSamplePolicy()
+		if err != nil {
+			return "", err
+		}
+		d.finalHCLString = p
+	}
+	return d.finalHCLString, nil
+}
+
+// buildSamplePolicy builds a policy document from the request.
+func (d *OutputPolicyError) buildSamplePolicy() (string, error) {
+	operation := d.method
+	// List is sent as a GET with a list parameter, so check for it to
+	// determine the intended operation
+	if list := d.params.Get("list"); list != "" {
+		isList, err := strconv.ParseBool(list)
+		if err != nil {
+			return "", fmt.Errorf("the value of the list url param is not a bool: %v", err)
+		}
+		if isList {
+			operation = "LIST"
+		}
+	}
+
+	var capabilities []string
+	switch operation {
+	case http.MethodGet, "":
+		capabilities = append(capabilities, "read")
+	case http.MethodPost, http.MethodPut:
+		capabilities = append(capabilities, "create", "update")
+	case http.MethodPatch:
+		capabilities = append(capabilities, "patch")
+	case http.MethodDelete:
+		capabilities = append(capabilities, "delete")
+	case "LIST":
+		capabilities = append(capabilities, "list")
+	default:
+		return "", fmt.Errorf("unable to determine capabilities for method %q", operation)
+	}
+
+	path, err := url.PathUnescape(d.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to unescape request URL characters in %q: %v", d.path, err)
+	}
+	path = strings.TrimPrefix(path, "/")
+
+	if isSudoPath(path) {
+		capabilities = append(capabilities, "sudo")
+	}
+
+	return formatOutputPolicy(path, capabilities), nil
+}
+
+func isSudoPath(path string) bool {
+	for _, re := range sudoPaths {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatOutputPolicy(path string, capabilities []string) string {
+	capStr := strings.Join(capabilities, `", "`)
+	return fmt.Sprintf(
+		`path "%s" {
+  capabilities = ["%s"]
+}
+`, path, capStr)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestBuildSamplePolicy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      *OutputPolicyError
+		expected string
+		err      bool
+	}{
+		{
+			"read",
+			&OutputPolicyError{method: http.MethodGet, path: "/secret/data/foo"},
+			formatOutputPolicy("secret/data/foo", []string{"read"}),
+			false,
+		},
+		{
+			"write",
+			&OutputPolicyError{method: http.MethodPut, path: "/secret/data/foo"},
+			formatOutputPolicy("secret/data/foo", []string{"create", "update"}),
+			false,
+		},
+		{
+			"list",
+			&OutputPolicyError{method: http.MethodGet, path: "/secret/metadata/", params: url.Values{"list": []string{"true"}}},
+			formatOutputPolicy("secret/metadata/", []string{"list"}),
+			false,
+		},
+		{
+			"sudo",
+			&OutputPolicyError{method: http.MethodDelete, path: "/sys/auth/userpass"},
+			formatOutputPolicy("sys/auth/userpass", []string{"delete", "sudo"}),
+			false,
+		},
+		{
+			"escaped",
+			&OutputPolicyError{method: http.MethodGet, path: "/secret/data/foo%20bar"},
+			formatOutputPolicy("secret/data/foo bar", []string{"read"}),
+			false,
+		},
+		{
+			"bad list param",
+			&OutputPolicyError{method: http.MethodGet, path: "/secret/metadata/", params: url.Values{"list": []string{"maybe"}}},
+			"",
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := tc.req.HCLString()
+			if tc.err != (err != nil) {
+				t.Fatalf("expected error: %t, got: %v", tc.err, err)
+			}
+			if policy != tc.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s", tc.expected, policy)
+			}
+		})
+	}
+}
+
+func TestClientOutputPolicy(t *testing.T) {
+	var requests int
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	LastOutputPolicyError = nil
+	_, err = client.WithOutputPolicy().Logical().List("secret/metadata")
+	policyErr, ok := err.(*OutputPolicyError)
+	if !ok {
+		t.Fatalf("expected an *OutputPolicyError, got: %v", err)
+	}
+	if LastOutputPolicyError != nil {
+		t.Fatal("LastOutputPolicyError should not be set by per-request output")
+	}
+	policy, err := policyErr.HCLString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "path \"secret/metadata\" {\n  capabilities = [\"list\"]\n}\n"; policy != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, policy)
+	}
+
+	client.SetOutputPolicy(true)
+	if _, err := client.Logical().Write("secret/data/foo", nil); err != LastOutputPolicyError || err == nil {
+		t.Fatalf("expected LastOutputPolicyError to be returned, got: %v", err)
+	}
+	if requests != 0 {
+		t.Fatal("no requests should have been sent")
+	}
+}
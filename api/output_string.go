@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	retryablehttp "github.com/hashicorp/go-retryablehttp"
@@ -15,8 +16,18 @@ var (
 	LastOutputStringError *OutputStringError
 )
 
+// OutputStringError is returned instead of sending a request when cURL
+// output is requested. Its CurlString method returns the equivalent cURL
+// command, including the TLS flags matching the client's configuration. The
+// token is replaced with a call to "vault print token".
 type OutputStringError struct {
 	*retryablehttp.Request
+	TLSSkipVerify bool
+	ClientCACert  string
+	ClientCAPath  string
+	ClientCert    string
+	ClientKey     string
+
 	parsingError     error
 	parsedCurlString string
 }
@@ -41,11 +52,31 @@ func (d *OutputStringError) parseRequest() {
 
 	// Build cURL string
 	d.parsedCurlString = "curl "
+	if d.TLSSkipVerify {
+		d.parsedCurlString += "--insecure "
+	}
+	if d.ClientCACert != "" {
+		d.parsedCurlString = fmt.Sprintf("%s--cacert '%s' ", d.parsedCurlString, escapeSingleQuotes(d.ClientCACert))
+	}
+	if d.ClientCAPath != "" {
+		d.parsedCurlString = fmt.Sprintf("%s--capath '%s' ", d.parsedCurlString, escapeSingleQuotes(d.ClientCAPath))
+	}
+	if d.ClientCert != "" {
+		d.parsedCurlString = fmt.Sprintf("%s--cert '%s' ", d.parsedCurlString, escapeSingleQuotes(d.ClientCert))
+	}
+	if d.ClientKey != "" {
+		d.parsedCurlString = fmt.Sprintf("%s--key '%s' ", d.parsedCurlString, escapeSingleQuotes(d.ClientKey))
+	}
 	if d.Request.Method != "GET" {
 		d.parsedCurlString = fmt.Sprintf("%s-X %s ", d.parsedCurlString, d.Request.Method)
 	}
-	for k, v := range d.Request.Header {
-		for _, h := range v {
+	keys := make([]string, 0, len(d.Request.Header))
+	for k := range d.Request.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, h := range d.Request.Header[k] {
 			if strings.ToLower(k) == "x-vault-token" {
 				h = `$(vault print token)`
 			}
@@ -56,13 +87,17 @@ func (d *OutputStringError) parseRequest() {
 	if len(body) > 0 {
 		// We need to escape single quotes since that's what we're using to
 		// quote the body
-		escapedBody := strings.Replace(string(body), "'", "'\"'\"'", -1)
-		d.parsedCurlString = fmt.Sprintf("%s-d '%s' ", d.parsedCurlString, escapedBody)
+		d.parsedCurlString = fmt.Sprintf("%s-d '%s' ", d.parsedCurlString, escapeSingleQuotes(string(body)))
 	}
 
 	d.parsedCurlString = fmt.Sprintf("%s%s", d.parsedCurlString, d.Request.URL.String())
 }
 
+// escapeSingleQuotes escapes a string for use within single quotes in a shell.
+func escapeSingleQuotes(s string) string {
+	return strings.Replace(s, "'", "'\"'\"'", -1)
+}
+
 func (d *OutputStringError) CurlString() string {
 	if d.parsedCurlString == "" {
 		d.parseRequest()
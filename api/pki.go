@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// PKIDefaultMountPoint is the default path at which the PKI secrets
+	// engine is mounted.
+	PKIDefaultMountPoint = "pki"
+)
+
+// PKI is used to perform operations on the PKI secrets engine.
+type PKI struct {
+	c          *Client
+	MountPoint string
+}
+
+// PKI returns the client for the PKI secrets engine mounted at the default
+// path.
+func (c *Client) PKI() *PKI {
+	return c.PKIWithMountPoint(PKIDefaultMountPoint)
+}
+
+// PKIWithMountPoint returns the client for the PKI secrets engine mounted at
+// the given path.
+func (c *Client) PKIWithMountPoint(mountPoint string) *PKI {
+	return &PKI{
+		c:          c,
+		MountPoint: mountPoint,
+	}
+}
+
+// PKIIssueInput are the parameters for issuing or signing a certificate.
+type PKIIssueInput struct {
+	CommonName        string
+	AltNames          []string
+	IPSANs            []string
+	URISANs           []string
+	TTL               string
+	Format            string
+	PrivateKeyFormat  string
+	ExcludeCNFromSANs bool
+}
+
+func (i *PKIIssueInput) body() map[string]interface{} {
+	body := map[string]interface{}{}
+	if i == nil {
+		return body
+	}
+	if i.CommonName != "" {
+		body["common_name"] = i.CommonName
+	}
+	if len(i.AltNames) > 0 {
+		body["alt_names"] = strings.Join(i.AltNames, ",")
+	}
+	if len(i.IPSANs) > 0 {
+		body["ip_sans"] = strings.Join(i.IPSANs, ",")
+	}
+	if len(i.URISANs) > 0 {
+		body["uri_sans"] = strings.Join(i.URISANs, ",")
+	}
+	if i.TTL != "" {
+		body["ttl"] = i.TTL
+	}
+	if i.Format != "" {
+		body["format"] = i.Format
+	}
+	if i.PrivateKeyFormat != "" {
+		body["private_key_format"] = i.PrivateKeyFormat
+	}
+	if i.ExcludeCNFromSANs {
+		body["exclude_cn_from_sans"] = true
+	}
+	return body
+}
+
+// PKICertificate is a certificate issued or signed by the PKI secrets engine.
+// PrivateKey is only set for issued certificates.
+type PKICertificate struct {
+	Certificate    string   `mapstructure:"certificate"`
+	IssuingCA      string   `mapstructure:"issuing_ca"`
+	CAChain        []string `mapstructure:"ca_chain"`
+	PrivateKey     string   `mapstructure:"private_key"`
+	PrivateKeyType string   `mapstructure:"private_key_type"`
+	SerialNumber   string   `mapstructure:"serial_number"`
+	Expiration     int64    `mapstructure:"expiration"`
+}
+
+// X509Certificate parses the certificate.
+func (p *PKICertificate) X509Certificate() (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(p.Certificate))
+	if block == nil {
+		return nil, errors.New("certificate is not PEM encoded")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// TLSCertificate returns the certificate, its chain and private key for use
+// in a tls.Config.
+func (p *PKICertificate) TLSCertificate() (tls.Certificate, error) {
+	certPEM := p.Certificate
+	for _, ca := range p.CAChain {
+		certPEM += "\n" + ca
+	}
+	if len(p.CAChain) == 0 && p.IssuingCA != "" {
+		certPEM += "\n" + p.IssuingCA
+	}
+	return tls.X509KeyPair([]byte(certPEM), []byte(p.PrivateKey))
+}
+
+// ExpiresAt returns the expiration time of the certificate.
+func (p *PKICertificate) ExpiresAt() time.Time {
+	return time.Unix(p.Expiration, 0)
+}
+
+// Issue issues a new certificate and private key using the given role.
+func (c *PKI) Issue(role string, input *PKIIssueInput) (*PKICertificate, error) {
+	return c.issue(fmt.Sprintf("issue/%s", role), input.body())
+}
+
+// SignCSR signs the PEM encoded certificate signing request using the given
+// role.
+func (c *PKI) SignCSR(role string, csr string, input *PKIIssueInput) (*PKICertificate, error) {
+	body := input.body()
+	body["csr"] = csr
+	return c.issue(fmt.Sprintf("sign/%s", role), body)
+}
+
+// ReadCAChain returns the PEM encoded CA chain of the mount.
+func (c *PKI) ReadCAChain() (string, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/%s/ca_chain", c.MountPoint))
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	chain, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(chain), nil
+}
+
+func (c *PKI) issue(path string, body map[string]interface{}) (*PKICertificate, error) {
+	r := c.c.NewRequest("POST", fmt.Sprintf("/v1/%s/%s", c.MountPoint, path))
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var cert PKICertificate
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           &cert,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(secret.Data); err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"crypto/x509"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCertificateRenewerMissingInput = errors.New("missing input")
+	ErrCertificateRenewerMissingRole  = errors.New("missing role")
+
+	// DefaultCertificateRenewerRetryInterval is the default time to wait
+	// before retrying a failed re-issue.
+	DefaultCertificateRenewerRetryInterval = 30 * time.Second
+)
+
+// CertificateRenewer is a process which re-issues a certificate before it
+// expires, delivering each new certificate on a channel.
+//
+//	renewer, err := client.PKI().NewCertificateRenewer(&CertificateRenewerInput{
+//		Role:  "web",
+//		Input: &PKIIssueInput{CommonName: "app.example.com"},
+//	})
+//	go renewer.Start()
+//	defer renewer.Stop()
+//
+//	for {
+//		select {
+//		case err := <-renewer.DoneCh():
+//			log.Fatal(err)
+//		case cert := <-renewer.RenewCh():
+//			// Load the new certificate
+//		}
+//	}
+//
+// `DoneCh` will return if the certificate could not be re-issued before the
+// current one expired.
+type CertificateRenewer struct {
+	l sync.Mutex
+
+	pki           *PKI
+	role          string
+	input         *PKIIssueInput
+	current       *PKICertificate
+	renewFraction float64
+	retryInterval time.Duration
+	random        *rand.Rand
+	doneCh        chan error
+	renewCh       chan *PKICertificate
+
+	stopped bool
+	stopCh  chan struct{}
+}
+
+// CertificateRenewerInput is used as input to NewCertificateRenewer.
+type CertificateRenewerInput struct {
+	// Role is the role to issue certificates with.
+	Role string
+
+	// Input are the parameters of the certificates to issue.
+	Input *PKIIssueInput
+
+	// Certificate is the current certificate, if any. If not provided, a
+	// certificate is issued as soon as the renewer starts.
+	Certificate *PKICertificate
+
+	// RenewFraction is the fraction of the certificate's lifetime after which
+	// it is re-issued. Defaults to 2/3. Some jitter is added so that many
+	// clients do not hit Vault simultaneously.
+	RenewFraction float64
+
+	// RetryInterval is the time to wait before retrying a failed re-issue.
+	RetryInterval time.Duration
+
+	// Rand is the randomizer to use for underlying randomization. If not
+	// provided, one will be generated and seeded automatically.
+	Rand *rand.Rand
+}
+
+// NewCertificateRenewer creates a new certificate renewer from the given
+// input.
+func (c *PKI) NewCertificateRenewer(i *CertificateRenewerInput) (*CertificateRenewer, error) {
+	if i == nil {
+		return nil, ErrCertificateRenewerMissingInput
+	}
+	if i.Role == "" {
+		return nil, ErrCertificateRenewerMissingRole
+	}
+
+	renewFraction := i.RenewFraction
+	if renewFraction <= 0 || renewFraction >= 1 {
+		renewFraction = 2.0 / 3.0
+	}
+
+	retryInterval := i.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = DefaultCertificateRenewerRetryInterval
+	}
+
+	random := i.Rand
+	if random == nil {
+		random = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	}
+
+	return &CertificateRenewer{
+		pki:           c,
+		role:          i.Role,
+		input:         i.Input,
+		current:       i.Certificate,
+		renewFraction: renewFraction,
+		retryInterval: retryInterval,
+		random:        random,
+		doneCh:        make(chan error, 1),
+		renewCh:       make(chan *PKICertificate, 1),
+		stopCh:        make(chan struct{}),
+	}, nil
+}
+
+// DoneCh returns the channel where the renewer will publish when it stops.
+// If the certificate expired before it could be re-issued, this will be the
+// last error encountered.
+func (r *CertificateRenewer) DoneCh() <-chan error {
+	return r.doneCh
+}
+
+// RenewCh returns the channel on which newly issued certificates are
+// delivered.
+func (r *CertificateRenewer) RenewCh() <-chan *PKICertificate {
+	return r.renewCh
+}
+
+// Stop stops the renewer.
+func (r *CertificateRenewer) Stop() {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if !r.stopped {
+		close(r.stopCh)
+		r.stopped = true
+	}
+}
+
+// Start re-issues the certificate until the renewer is stopped or a
+// certificate expires before it could be re-issued. It blocks, so it should
+// usually be run in a goroutine.
+func (r *CertificateRenewer) Start() {
+	r.doneCh <- r.doRenew()
+}
+
+func (r *CertificateRenewer) doRenew() error {
+	var notBefore, notAfter time.Time
+	if r.current != nil {
+		cert, err := r.current.X509Certificate()
+		if err != nil {
+			return err
+		}
+		notBefore, notAfter = cert.NotBefore, cert.NotAfter
+	}
+
+	for {
+		var wait time.Duration
+		if r.current != nil {
+			lifetime := notAfter.Sub(notBefore)
+			// Renew after the configured fraction of the lifetime, minus up
+			// to a tenth of the lifetime of jitter.
+			renewAt := notBefore.Add(time.Duration(float64(lifetime)*r.renewFraction - float64(lifetime)*0.1*r.random.Float64()))
+			wait = time.Until(renewAt)
+		}
+
+		if wait > 0 {
+			select {
+			case <-r.stopCh:
+				return nil
+			case <-time.After(wait):
+			}
+		}
+
+		for {
+			select {
+			case <-r.stopCh:
+				return nil
+			default:
+			}
+
+			cert, err := r.pki.Issue(r.role, r.input)
+			if err == nil {
+				var x509Cert *x509.Certificate
+				if x509Cert, err = cert.X509Certificate(); err == nil {
+					r.current = cert
+					notBefore, notAfter = x509Cert.NotBefore, x509Cert.NotAfter
+					break
+				}
+			}
+
+			// Give up once the current certificate has expired, or straight
+			// away if there is none; otherwise retry, making a last attempt
+			// just before expiry.
+			if r.current == nil || !time.Now().Before(notAfter) {
+				return err
+			}
+			retry := r.retryInterval
+			if remaining := time.Until(notAfter); retry > remaining {
+				retry = remaining
+			}
+
+			select {
+			case <-r.stopCh:
+				return nil
+			case <-time.After(retry):
+			}
+		}
+
+		select {
+		case r.renewCh <- r.current:
+		case <-r.stopCh:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testPKIServer(t *testing.T, lifetime time.Duration, issued *int32) (*Config, func()) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/pki/ca_chain":
+			w.Write([]byte("-----BEGIN CERTIFICATE-----\n"))
+		case "/v1/pki/issue/web":
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			if body["alt_names"] != "a.example.com,b.example.com" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			n := atomic.AddInt32(issued, 1)
+			now := time.Now()
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(int64(n)),
+				Subject:      pkix.Name{CommonName: body["common_name"].(string)},
+				NotBefore:    now,
+				NotAfter:     now.Add(lifetime),
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"certificate":      string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+					"private_key":      string(keyPEM),
+					"private_key_type": "ec",
+					"serial_number":    template.SerialNumber.String(),
+					"expiration":       template.NotAfter.Unix(),
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return config, func() { ln.Close() }
+}
+
+func TestPKIIssue(t *testing.T) {
+	var issued int32
+	config, closer := testPKIServer(t, time.Hour, &issued)
+	defer closer()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := client.PKI().Issue("web", &PKIIssueInput{
+		CommonName: "app.example.com",
+		AltNames:   []string{"a.example.com", "b.example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.PrivateKeyType != "ec" || cert.SerialNumber != "1" || cert.ExpiresAt().Before(time.Now()) {
+		t.Fatalf("bad certificate: %#v", cert)
+	}
+	tlsCert, err := cert.TLSCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tlsCert.Certificate) != 1 {
+		t.Fatalf("expected 1 certificate in the chain, got %d", len(tlsCert.Certificate))
+	}
+
+	chain, err := client.PKI().ReadCAChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain != "-----BEGIN CERTIFICATE-----\n" {
+		t.Fatalf("bad CA chain: %q", chain)
+	}
+}
+
+func TestPKICertificateRenewer(t *testing.T) {
+	var issued int32
+	config, closer := testPKIServer(t, 2*time.Second, &issued)
+	defer closer()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renewer, err := client.PKI().NewCertificateRenewer(&CertificateRenewerInput{
+		Role: "web",
+		Input: &PKIIssueInput{
+			CommonName: "app.example.com",
+			AltNames:   []string{"a.example.com", "b.example.com"},
+		},
+		RenewFraction: 0.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go renewer.Start()
+	defer renewer.Stop()
+
+	var serials []string
+	timeout := time.After(5 * time.Second)
+	for len(serials) < 3 {
+		select {
+		case err := <-renewer.DoneCh():
+			t.Fatalf("renewer stopped: %v", err)
+		case cert := <-renewer.RenewCh():
+			serials = append(serials, cert.SerialNumber)
+		case <-timeout:
+			t.Fatalf("timed out waiting for renewals, got %v", serials)
+		}
+	}
+	if serials[0] != "1" || serials[1] != "2" || serials[2] != "3" {
+		t.Fatalf("expected certificates 1 to 3, got %v", serials)
+	}
+
+	renewer.Stop()
+	select {
+	case err := <-renewer.DoneCh():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("renewer did not stop")
+	}
+}
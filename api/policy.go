@@ -0,0 +1,286 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/sdk/helper/hclutil"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+)
+
+// Policy is the structured representation of an ACL policy returned by
+// ParsePolicy.
+type Policy struct {
+	Name  string
+	Paths []*PolicyPath
+	Raw   string
+}
+
+// PolicyPath holds the rules of a single path stanza of a policy. Path has
+// any leading slash and trailing glob removed; IsPrefix records whether the
+// glob was present.
+type PolicyPath struct {
+	Path                string
+	IsPrefix            bool
+	HasSegmentWildcards bool
+	Templated           bool
+	Capabilities        []string
+	AllowedParameters   map[string][]interface{}
+	DeniedParameters    map[string][]interface{}
+	RequiredParameters  []string
+	MinWrappingTTL      time.Duration
+	MaxWrappingTTL      time.Duration
+	MFAMethods          []string
+	ControlGroup        *PolicyControlGroup
+}
+
+// PolicyControlGroup is the control group configuration of a path.
+type PolicyControlGroup struct {
+	TTL     time.Duration
+	Factors []*PolicyControlGroupFactor
+}
+
+// PolicyControlGroupFactor is a single factor of a control group.
+type PolicyControlGroupFactor struct {
+	Name              string
+	GroupIDs          []string
+	GroupNames        []string
+	ApprovalsRequired int
+}
+
+type policyHCL struct {
+	Name string `hcl:"name"`
+}
+
+type policyPathHCL struct {
+	Policy             string                   `hcl:"policy"`
+	Capabilities       []string                 `hcl:"capabilities"`
+	MinWrappingTTL     interface{}              `hcl:"min_wrapping_ttl"`
+	MaxWrappingTTL     interface{}              `hcl:"max_wrapping_ttl"`
+	AllowedParameters  map[string][]interface{} `hcl:"allowed_parameters"`
+	DeniedParameters   map[string][]interface{} `hcl:"denied_parameters"`
+	RequiredParameters []string                 `hcl:"required_parameters"`
+	MFAMethods         []string                 `hcl:"mfa_methods"`
+	ControlGroup       *policyControlGroupHCL   `hcl:"control_group"`
+	Comment            string                   `hcl:"comment"`
+}
+
+type policyControlGroupHCL struct {
+	TTL     interface{}                             `hcl:"ttl"`
+	Factors map[string]*policyControlGroupFactorHCL `hcl:"factor"`
+}
+
+type policyControlGroupFactorHCL struct {
+	Identity *struct {
+		GroupIDs          []string `hcl:"group_ids"`
+		GroupNames        []string `hcl:"group_names"`
+		ApprovalsRequired int      `hcl:"approvals"`
+	} `hcl:"identity"`
+}
+
+var (
+	policyCapabilities = map[string]bool{
+		"deny":   true,
+		"create": true,
+		"read":   true,
+		"update": true,
+		"delete": true,
+		"list":   true,
+		"sudo":   true,
+	}
+
+	// policyOldStyle maps the deprecated "policy" values to capabilities.
+	policyOldStyle = map[string][]string{
+		"deny":  {"deny"},
+		"read":  {"read", "list"},
+		"write": {"create", "read", "update", "delete", "list"},
+		"sudo":  {"create", "read", "update", "delete", "list", "sudo"},
+	}
+)
+
+// ParsePolicy parses and validates an ACL policy written in HCL, applying
+// the same checks the server does when the policy is written, without
+// requiring a round trip. Templated paths are only checked for balanced
+// delimiters, as they can only be resolved against an entity on the server.
+func ParsePolicy(rules string) (*Policy, error) {
+	root, err := hcl.Parse(rules)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to parse policy: {{err}}", err)
+	}
+
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse policy: does not contain a root object")
+	}
+
+	if err := hclutil.CheckHCLKeys(list, []string{"name", "path"}); err != nil {
+		return nil, errwrap.Wrapf("failed to parse policy: {{err}}", err)
+	}
+
+	var p policyHCL
+	if err := hcl.DecodeObject(&p, list); err != nil {
+		return nil, errwrap.Wrapf("failed to parse policy: {{err}}", err)
+	}
+
+	policy := &Policy{
+		Name: p.Name,
+		Raw:  rules,
+	}
+	for _, item := range list.Filter("path").Items {
+		path, err := parsePolicyPath(item)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to parse policy: {{err}}", err)
+		}
+		policy.Paths = append(policy.Paths, path)
+	}
+
+	return policy, nil
+}
+
+func parsePolicyPath(item *ast.ObjectItem) (*PolicyPath, error) {
+	key := "path"
+	if len(item.Keys) > 0 {
+		key = item.Keys[0].Token.Value().(string)
+	}
+
+	valid := []string{
+		"comment",
+		"policy",
+		"capabilities",
+		"allowed_parameters",
+		"denied_parameters",
+		"required_parameters",
+		"min_wrapping_ttl",
+		"max_wrapping_ttl",
+		"mfa_methods",
+		"control_group",
+	}
+	if err := hclutil.CheckHCLKeys(item.Val, valid); err != nil {
+		return nil, multierror.Prefix(err, fmt.Sprintf("path %q:", key))
+	}
+
+	var raw policyPathHCL
+	if err := hcl.DecodeObject(&raw, item.Val); err != nil {
+		return nil, multierror.Prefix(err, fmt.Sprintf("path %q:", key))
+	}
+
+	pp := &PolicyPath{
+		Path:               strings.TrimPrefix(key, "/"),
+		RequiredParameters: raw.RequiredParameters,
+		MFAMethods:         raw.MFAMethods,
+	}
+
+	if strings.Count(pp.Path, "{{") != strings.Count(pp.Path, "}}") {
+		return nil, fmt.Errorf("path %q: unbalanced templating characters", key)
+	}
+	pp.Templated = strings.Contains(pp.Path, "{{")
+
+	if strings.Contains(pp.Path, "+*") {
+		return nil, fmt.Errorf("path %q: invalid use of wildcards ('+*' is forbidden)", pp.Path)
+	}
+	if pp.Path == "+" || strings.Contains(pp.Path, "/+") || strings.HasPrefix(pp.Path, "+/") {
+		pp.HasSegmentWildcards = true
+	}
+	if strings.HasSuffix(pp.Path, "*") && !pp.HasSegmentWildcards {
+		pp.Path = strings.TrimSuffix(pp.Path, "*")
+		pp.IsPrefix = true
+	}
+
+	if raw.Policy != "" {
+		caps, ok := policyOldStyle[raw.Policy]
+		if !ok {
+			return nil, fmt.Errorf("path %q: invalid policy %q", key, raw.Policy)
+		}
+		raw.Capabilities = append(raw.Capabilities, caps...)
+	}
+	for _, c := range raw.Capabilities {
+		if !policyCapabilities[c] {
+			return nil, fmt.Errorf("path %q: invalid capability %q", key, c)
+		}
+		if c == "deny" {
+			// Deny overrides any other capability
+			pp.Capabilities = []string{"deny"}
+			return pp, nil
+		}
+		pp.Capabilities = append(pp.Capabilities, c)
+	}
+
+	if raw.AllowedParameters != nil {
+		pp.AllowedParameters = make(map[string][]interface{}, len(raw.AllowedParameters))
+		for k, v := range raw.AllowedParameters {
+			pp.AllowedParameters[strings.ToLower(k)] = v
+		}
+	}
+	if raw.DeniedParameters != nil {
+		pp.DeniedParameters = make(map[string][]interface{}, len(raw.DeniedParameters))
+		for k, v := range raw.DeniedParameters {
+			pp.DeniedParameters[strings.ToLower(k)] = v
+		}
+	}
+
+	if raw.MinWrappingTTL != nil {
+		dur, err := parseutil.ParseDurationSecond(raw.MinWrappingTTL)
+		if err != nil {
+			return nil, errwrap.Wrapf("error parsing min_wrapping_ttl: {{err}}", err)
+		}
+		pp.MinWrappingTTL = dur
+	}
+	if raw.MaxWrappingTTL != nil {
+		dur, err := parseutil.ParseDurationSecond(raw.MaxWrappingTTL)
+		if err != nil {
+			return nil, errwrap.Wrapf("error parsing max_wrapping_ttl: {{err}}", err)
+		}
+		pp.MaxWrappingTTL = dur
+	}
+	if pp.MinWrappingTTL != 0 && pp.MaxWrappingTTL != 0 && pp.MaxWrappingTTL < pp.MinWrappingTTL {
+		return nil, errors.New("max_wrapping_ttl cannot be less than min_wrapping_ttl")
+	}
+
+	if raw.ControlGroup != nil {
+		cg, err := parsePolicyControlGroup(raw.ControlGroup)
+		if err != nil {
+			return nil, multierror.Prefix(err, fmt.Sprintf("path %q:", key))
+		}
+		pp.ControlGroup = cg
+	}
+
+	return pp, nil
+}
+
+func parsePolicyControlGroup(raw *policyControlGroupHCL) (*PolicyControlGroup, error) {
+	cg := &PolicyControlGroup{}
+	if raw.TTL != nil {
+		dur, err := parseutil.ParseDurationSecond(raw.TTL)
+		if err != nil {
+			return nil, errwrap.Wrapf("error parsing control group max ttl: {{err}}", err)
+		}
+		cg.TTL = dur
+	}
+
+	for name, factor := range raw.Factors {
+		if factor.Identity == nil {
+			return nil, errors.New("no control_group factor provided")
+		}
+		if factor.Identity.ApprovalsRequired <= 0 ||
+			(len(factor.Identity.GroupIDs) == 0 && len(factor.Identity.GroupNames) == 0) {
+			return nil, errors.New("must provide more than one identity group and approvals > 0")
+		}
+		cg.Factors = append(cg.Factors, &PolicyControlGroupFactor{
+			Name:              name,
+			GroupIDs:          factor.Identity.GroupIDs,
+			GroupNames:        factor.Identity.GroupNames,
+			ApprovalsRequired: factor.Identity.ApprovalsRequired,
+		})
+	}
+	if len(cg.Factors) == 0 {
+		return nil, errors.New("no control group factors provided")
+	}
+
+	return cg, nil
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy(`
+name = "dev"
+
+path "secret/*" {
+	capabilities = ["create", "read"]
+	allowed_parameters = {
+		"Foo" = ["bar"]
+	}
+	min_wrapping_ttl = "1m"
+	max_wrapping_ttl = 3600
+}
+
+path "/sys/+/config" {
+	policy = "read"
+}
+
+path "transit/keys/{{identity.entity.id}}" {
+	capabilities = ["read", "deny"]
+	control_group = {
+		ttl = "4h"
+		factor "ops" {
+			identity {
+				group_names = ["ops"]
+				approvals = 1
+			}
+		}
+	}
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Name != "dev" || len(policy.Paths) != 3 {
+		t.Fatalf("unexpected policy: %#v", policy)
+	}
+
+	expected := &PolicyPath{
+		Path:              "secret/",
+		IsPrefix:          true,
+		Capabilities:      []string{"create", "read"},
+		AllowedParameters: map[string][]interface{}{"foo": {"bar"}},
+		MinWrappingTTL:    time.Minute,
+		MaxWrappingTTL:    time.Hour,
+	}
+	if !reflect.DeepEqual(policy.Paths[0], expected) {
+		t.Fatalf("expected %#v, got %#v", expected, policy.Paths[0])
+	}
+
+	expected = &PolicyPath{
+		Path:                "sys/+/config",
+		HasSegmentWildcards: true,
+		Capabilities:        []string{"read", "list"},
+	}
+	if !reflect.DeepEqual(policy.Paths[1], expected) {
+		t.Fatalf("expected %#v, got %#v", expected, policy.Paths[1])
+	}
+
+	if p := policy.Paths[2]; !p.Templated || !reflect.DeepEqual(p.Capabilities, []string{"deny"}) {
+		t.Fatalf("unexpected path: %#v", p)
+	}
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	cases := map[string]string{
+		`path "secret/*" { capabilities = ["write"] }`:                                        "invalid capability",
+		`path "secret/*" { capabilties = ["read"] }`:                                          "invalid key",
+		`path "secret/+*" { capabilities = ["read"] }`:                                        "invalid use of wildcards",
+		`path "secret/{{identity.entity.id" { capabilities = ["read"] }`:                      "unbalanced templating",
+		`path "secret/*" { min_wrapping_ttl = "1h", max_wrapping_ttl = "1m" }`:                "cannot be less than",
+		`path "secret/*" { control_group = { factor "ops" { identity { approvals = 1 } } } }`: "identity group",
+		`paths "secret/*" {}`: "invalid key",
+		`path "secret/*" {`:   "failed to parse policy",
+	}
+
+	for rules, expected := range cases {
+		_, err := ParsePolicy(rules)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected error containing %q, got %v", rules, expected, err)
+		}
+	}
+}
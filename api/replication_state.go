@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
+)
+
+// HeaderIndex is the header through which Vault reports the replication
+// state of a write, and through which clients require a node to have reached
+// that state before serving a request.
+const HeaderIndex = "X-Vault-Index"
+
+// ReplicationState is the state of a cluster's write-ahead log, as reported
+// in the X-Vault-Index header.
+type ReplicationState struct {
+	ClusterID       string
+	LocalIndex      uint64
+	ReplicatedIndex uint64
+}
+
+// ParseReplicationState parses an X-Vault-Index header value. The HMAC
+// included in the value is not verified, as its key is only known to the
+// server.
+func ParseReplicationState(raw string) (*ReplicationState, error) {
+	cooked, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	s := string(cooked)
+
+	lastIndex := strings.LastIndexByte(s, ':')
+	if lastIndex == -1 {
+		return nil, fmt.Errorf("invalid full state header format")
+	}
+
+	pieces := strings.Split(s[:lastIndex], ":")
+	if len(pieces) != 4 || pieces[0] != "v1" || pieces[1] == "" {
+		return nil, fmt.Errorf("invalid state header format")
+	}
+	localIndex, err := strconv.ParseUint(pieces[2], 10, 64)
+	if err != nil {
+		return nil, errwrap.Wrapf("invalid local index in state header: {{err}}", err)
+	}
+	replicatedIndex, err := strconv.ParseUint(pieces[3], 10, 64)
+	if err != nil {
+		return nil, errwrap.Wrapf("invalid replicated index in state header: {{err}}", err)
+	}
+
+	return &ReplicationState{
+		ClusterID:       pieces[1],
+		LocalIndex:      localIndex,
+		ReplicatedIndex: replicatedIndex,
+	}, nil
+}
+
+// MergeReplicationStates adds the state new to the states old, keeping a
+// single state per cluster: the most recent one. Unparseable states are
+// dropped, unless new itself cannot be parsed, in which case it replaces old.
+func MergeReplicationStates(old []string, new string) []string {
+	newState, err := ParseReplicationState(new)
+	if err != nil {
+		return []string{new}
+	}
+
+	ret := make([]string, 0, len(old)+1)
+	for _, o := range old {
+		oldState, err := ParseReplicationState(o)
+		switch {
+		case err != nil:
+		case oldState.ClusterID != newState.ClusterID:
+			ret = append(ret, o)
+		case oldState.LocalIndex > newState.LocalIndex ||
+			(oldState.LocalIndex == newState.LocalIndex && oldState.ReplicatedIndex > newState.ReplicatedIndex):
+			// The old state is more recent; keep it instead
+			new = o
+		}
+	}
+	return append(ret, new)
+}
+
+// replicationStateStore tracks the replication states returned by the
+// server, so that subsequent requests can require them and read the client's
+// own writes even when served by a performance standby.
+type replicationStateStore struct {
+	l     sync.RWMutex
+	store []string
+}
+
+// recordState records the state of the given response, if any.
+func (s *replicationStateStore) recordState(resp *Response) {
+	if resp == nil {
+		return
+	}
+	newState := resp.Header.Get(HeaderIndex)
+	if newState == "" {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+	s.store = MergeReplicationStates(s.store, newState)
+}
+
+// requireState adds the recorded states to the given request.
+func (s *replicationStateStore) requireState(r *Request) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if len(s.store) == 0 {
+		return
+	}
+	if r.Headers == nil {
+		r.Headers = make(http.Header)
+	}
+	r.Headers.Del(HeaderIndex)
+	for _, state := range s.store {
+		r.Headers.Add(HeaderIndex, state)
+	}
+}
+
+func (s *replicationStateStore) states() []string {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return append([]string(nil), s.store...)
+}
+
+// ReadYourWrites returns whether the client tracks the replication state of
+// its requests to read its own writes.
+func (c *Client) ReadYourWrites() bool {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+
+	return c.replicationStateStore != nil
+}
+
+// SetReadYourWrites enables or disables read-your-writes consistency. When
+// enabled, the client records the X-Vault-Index header of each response and
+// sends the recorded states with subsequent requests, so that a performance
+// standby only serves them once it has caught up with the client's writes.
+// Until then the standby responds with a 412, which the default retry policy
+// retries. Clients copied with the WithX methods share the recorded states.
+func (c *Client) SetReadYourWrites(val bool) {
+	c.modifyLock.Lock()
+	defer c.modifyLock.Unlock()
+
+	switch {
+	case val && c.replicationStateStore == nil:
+		c.replicationStateStore = &replicationStateStore{}
+	case !val:
+		c.replicationStateStore = nil
+	}
+}
+
+// DefaultRetryPolicy is the default CheckRetry of the client. On top of the
+// retries of retryablehttp.DefaultRetryPolicy, it retries 412 responses,
+// which a performance standby returns when it has not yet caught up with a
+// replication state required by the request.
+func DefaultRetryPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	retry, err := retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+	if err != nil || retry {
+		return retry, err
+	}
+	if resp != nil && resp.StatusCode == http.StatusPreconditionFailed {
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testReplicationState(clusterID string, local, replicated uint64) string {
+	raw := fmt.Sprintf("v1:%s:%d:%d:hmac", clusterID, local, replicated)
+	return base64.StdEncoding.EncodeToString([]byte(raw))
+}
+
+func TestMergeReplicationStates(t *testing.T) {
+	a1 := testReplicationState("a", 1, 1)
+	a2 := testReplicationState("a", 2, 1)
+	b1 := testReplicationState("b", 1, 0)
+
+	cases := []struct {
+		old      []string
+		new      string
+		expected []string
+	}{
+		{nil, a1, []string{a1}},
+		{[]string{a1}, a2, []string{a2}},
+		{[]string{a2}, a1, []string{a2}},
+		{[]string{a1}, b1, []string{a1, b1}},
+		{[]string{a1, b1}, a2, []string{b1, a2}},
+		{[]string{"garbage"}, a1, []string{a1}},
+		{[]string{a1}, "garbage", []string{"garbage"}},
+	}
+	for i, tc := range cases {
+		if got := MergeReplicationStates(tc.old, tc.new); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%d: expected %v, got %v", i, tc.expected, got)
+		}
+	}
+}
+
+func TestClientReadYourWrites(t *testing.T) {
+	state := testReplicationState("a", 5, 0)
+
+	var attempts int
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case "PUT":
+			w.Header().Set(HeaderIndex, state)
+			w.WriteHeader(http.StatusNoContent)
+		case "GET":
+			if got := req.Header.Get(HeaderIndex); got != state {
+				t.Errorf("expected index header %q, got %q", state, got)
+			}
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			w.Write([]byte(`{"data": {"value": "bar"}}`))
+		}
+	}))
+	defer ln.Close()
+
+	config.ReadYourWrites = true
+	config.Backoff = func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		return time.Millisecond
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Logical().Write("secret/foo", map[string]interface{}{"value": "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	if states := client.replicationStateStore.states(); !reflect.DeepEqual(states, []string{state}) {
+		t.Fatalf("unexpected recorded states %v", states)
+	}
+
+	secret, err := client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["value"] != "bar" || attempts != 2 {
+		t.Fatalf("unexpected result %#v after %d attempts", secret, attempts)
+	}
+
+	client.SetReadYourWrites(false)
+	if client.ReadYourWrites() {
+		t.Fatal("expected read-your-writes to be disabled")
+	}
+}
@@ -38,6 +38,32 @@ type Request struct {
 	// EGPs). If set, the override flag will take effect for all policies
 	// evaluated during the request.
 	PolicyOverride bool
+
+	// Priority is the admission class of the request, used when admission
+	// control is enabled on the client.
+	Priority RequestPriority
+
+	// OutputCurlString causes the request to return an *OutputStringError
+	// holding the equivalent cURL command instead of being sent.
+	OutputCurlString bool
+
+	// OutputPolicy causes the request to return an *OutputPolicyError
+	// holding the policy required for the request instead of being sent.
+	OutputPolicy bool
+}
+
+// SetNamespace makes this request against the given namespace, overriding
+// the namespace of the client that created it. An empty namespace makes the
+// request against the root namespace.
+func (r *Request) SetNamespace(namespace string) {
+	if r.Headers == nil {
+		r.Headers = make(http.Header)
+	}
+	if namespace == "" {
+		r.Headers.Del(consts.NamespaceHeaderName)
+		return
+	}
+	r.Headers.Set(consts.NamespaceHeaderName, namespace)
 }
 
 // SetJSONBody is used to set a request body that is a JSON-encoded value.
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+)
+
+const (
+	// EnvConsulHTTPAddr and EnvConsulHTTPToken are the standard Consul
+	// environment variables, honored by the Consul resolver.
+	EnvConsulHTTPAddr  = "CONSUL_HTTP_ADDR"
+	EnvConsulHTTPToken = "CONSUL_HTTP_TOKEN"
+
+	// DefaultConsulResolverTTL is how long a resolved node is reused before
+	// Consul is queried again.
+	DefaultConsulResolverTTL = 30 * time.Second
+)
+
+// ConsulResolverConfig is used to configure a ConsulResolver.
+type ConsulResolverConfig struct {
+	// Address is the address of the Consul HTTP API. Defaults to the
+	// CONSUL_HTTP_ADDR environment variable, or http://127.0.0.1:8500.
+	Address string
+
+	// Token is the Consul ACL token. Defaults to CONSUL_HTTP_TOKEN.
+	Token string
+
+	// Tag is the service tag used to select nodes. Vault registers its active
+	// node with the "active" tag, which is the default.
+	Tag string
+
+	// Scheme is the scheme used to talk to the resolved Vault node. Defaults
+	// to "https".
+	Scheme string
+
+	// TTL is how long a resolved node is reused. Defaults to
+	// DefaultConsulResolverTTL.
+	TTL time.Duration
+
+	// HttpClient is the client used to talk to Consul.
+	HttpClient *http.Client
+}
+
+// ConsulResolver is an AddressResolver that resolves addresses of the form
+// "consul://<service>" to a Vault node registered in Consul's catalog whose
+// health checks are passing.
+type ConsulResolver struct {
+	config *ConsulResolverConfig
+
+	l        sync.Mutex
+	resolved *url.URL
+	expires  time.Time
+}
+
+var _ AddressResolver = (*ConsulResolver)(nil)
+
+// NewConsulResolver returns a new ConsulResolver. The configuration may be
+// nil, in which case defaults are used.
+func NewConsulResolver(config *ConsulResolverConfig) *ConsulResolver {
+	c := &ConsulResolverConfig{}
+	if config != nil {
+		*c = *config
+	}
+
+	if c.Address == "" {
+		c.Address = os.Getenv(EnvConsulHTTPAddr)
+	}
+	if c.Address == "" {
+		c.Address = "http://127.0.0.1:8500"
+	}
+	if u, err := url.Parse(c.Address); err != nil || u.Host == "" {
+		// CONSUL_HTTP_ADDR is commonly given without a scheme
+		c.Address = "http://" + c.Address
+	}
+	if c.Token == "" {
+		c.Token = os.Getenv(EnvConsulHTTPToken)
+	}
+	if c.Tag == "" {
+		c.Tag = "active"
+	}
+	if c.Scheme == "" {
+		c.Scheme = "https"
+	}
+	if c.TTL <= 0 {
+		c.TTL = DefaultConsulResolverTTL
+	}
+	if c.HttpClient == nil {
+		c.HttpClient = cleanhttp.DefaultPooledClient()
+	}
+
+	return &ConsulResolver{config: c}
+}
+
+type consulServiceEntry struct {
+	Node struct {
+		Address string
+	}
+	Service struct {
+		Address string
+		Port    int
+	}
+}
+
+// Resolve returns the address of a healthy node of the service named by the
+// host portion of addr.
+func (r *ConsulResolver) Resolve(ctx context.Context, addr *url.URL) (*url.URL, error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if r.resolved != nil && time.Now().Before(r.expires) {
+		return r.resolved, nil
+	}
+
+	service := addr.Hostname()
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v1/health/service/%s", r.config.Address, url.PathEscape(service)), nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Set("passing", "true")
+	q.Set("tag", r.config.Tag)
+	req.URL.RawQuery = q.Encode()
+	if r.config.Token != "" {
+		req.Header.Set("X-Consul-Token", r.config.Token)
+	}
+
+	resp, err := r.config.HttpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d querying Consul for service %q", resp.StatusCode, service)
+	}
+
+	var entries []consulServiceEntry
+	if err := (&Response{Response: resp}).DecodeJSON(&entries); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no healthy %q nodes of service %q registered in Consul", r.config.Tag, service)
+	}
+
+	host := entries[0].Service.Address
+	if host == "" {
+		host = entries[0].Node.Address
+	}
+
+	r.resolved = &url.URL{
+		Scheme: r.config.Scheme,
+		Host:   net.JoinHostPort(host, strconv.Itoa(entries[0].Service.Port)),
+		Path:   addr.Path,
+	}
+	r.expires = time.Now().Add(r.config.TTL)
+
+	return r.resolved, nil
+}
+
+// Invalidate drops the cached node so that the next request re-resolves it.
+func (r *ConsulResolver) Invalidate(resolved *url.URL) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if r.resolved != nil && resolved != nil && r.resolved.Host == resolved.Host {
+		r.resolved = nil
+	}
+}
@@ -2,24 +2,217 @@ package api
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 )
 
+var (
+	// ErrPermissionDenied matches response errors for requests the token is
+	// not allowed to make.
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrSealed matches response errors for requests made while Vault is
+	// sealed.
+	ErrSealed = consts.ErrSealed
+
+	// ErrRateLimited matches response errors for requests rejected by a rate
+	// limit quota.
+	ErrRateLimited = errors.New("rate limit exceeded")
+
+	// ErrResponseBodyTooLarge is returned when reading a response body larger
+	// than Config.MaxResponseBodyBytes.
+	ErrResponseBodyTooLarge = errors.New("response body exceeds the maximum allowed size")
+)
+
+// HeaderRequestID is the header carrying the ID of a request. The client
+// generates one for each request that does not already have one.
+const HeaderRequestID = "X-Vault-Request-Id"
+
 // Response is a raw response that wraps an HTTP response.
 type Response struct {
 	*http.Response
+
+	// RequestID is the ID of the request, as returned by the server in the
+	// X-Vault-Request-Id header, or else as sent by the client.
+	RequestID string
+
+	// Duration is the total time taken by the request, including retries
+	// and redirects.
+	Duration time.Duration
+
+	// RetryCount is the number of times the request was retried.
+	RetryCount int
+
+	// RateLimiterWait is the time the request waited for the client's rate
+	// limiter before being sent, which is included in Duration.
+	RateLimiterWait time.Duration
+
+	// ReceivedAt is the local time at which the response was received.
+	ReceivedAt time.Time
+
+	// ClockSkew is how far the server's clock, as given by the Date header,
+	// is ahead of the local clock. It is zero if the header is missing or
+	// the difference is within the header's one second resolution.
+	ClockSkew time.Duration
+
+	jsonDecoding JSONDecodingOptions
+}
+
+// JSONDecodingOptions control how response bodies are decoded.
+type JSONDecodingOptions struct {
+	// DisallowUnknownFields causes DecodeJSON, and so the typed helpers using
+	// it, to fail when the response contains a field that the target type
+	// does not declare. This is useful to detect API drift in tests, but
+	// should not be enabled against servers newer than the client.
+	DisallowUnknownFields bool
+
+	// Streaming causes secrets to be decoded directly from the response
+	// body, rather than from a copy buffered in memory first.
+	Streaming bool
+}
+
+// ResponseMetadata describes the response a secret was parsed from.
+type ResponseMetadata struct {
+	RequestID  string
+	Duration   time.Duration
+	RetryCount int
+	ReceivedAt time.Time
+	ClockSkew  time.Duration
+
+	RateLimiterWait time.Duration
+}
+
+func (r *Response) metadata() *ResponseMetadata {
+	return &ResponseMetadata{
+		RequestID:  r.RequestID,
+		Duration:   r.Duration,
+		RetryCount: r.RetryCount,
+		ReceivedAt: r.ReceivedAt,
+		ClockSkew:  r.ClockSkew,
+
+		RateLimiterWait: r.RateLimiterWait,
+	}
+}
+
+// SentAt returns the local time at which the request was first sent. As the
+// server handled the request after this, TTLs counted from it end no later
+// than they do on the server.
+func (m *ResponseMetadata) SentAt() time.Time {
+	return m.ReceivedAt.Add(-m.Duration)
+}
+
+// LocalTime converts a time reported by the server to the local clock.
+func (m *ResponseMetadata) LocalTime(serverTime time.Time) time.Time {
+	return serverTime.Add(-m.ClockSkew)
+}
+
+// clockSkew returns how far the time in the given Date header is ahead of
+// receivedAt.
+func clockSkew(date string, receivedAt time.Time) time.Duration {
+	if date == "" {
+		return 0
+	}
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return 0
+	}
+
+	// The header is truncated to the second, so assume the middle of it
+	skew := serverTime.Add(500 * time.Millisecond).Sub(receivedAt)
+	if skew > -time.Second && skew < time.Second {
+		return 0
+	}
+	return skew
+}
+
+// parseSecret parses the secret in the response body, attaching the
+// metadata of the response to it.
+func (r *Response) parseSecret() (*Secret, error) {
+	var secret *Secret
+	var err error
+	if r.jsonDecoding.Streaming {
+		secret, err = parseSecretStream(r.Body)
+	} else {
+		secret, err = ParseSecret(r.Body)
+	}
+	if secret != nil {
+		secret.ResponseMetadata = r.metadata()
+	}
+	return secret, err
+}
+
+// limitedBody is a response body that fails once more than a given number of
+// bytes are read from it. Unlike io.LimitReader, which would silently truncate
+// the body, this reports the overflow so that it is not mistaken for a
+// malformed response.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func newLimitedBody(body io.ReadCloser, max int64) *limitedBody {
+	return &limitedBody{
+		ReadCloser: body,
+		remaining:  max,
+	}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrResponseBodyTooLarge
+	}
+
+	// Read one byte past the limit to detect overflow
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrResponseBodyTooLarge
+	}
+	return n, err
+}
+
+// generateRequestID returns a random UUID to identify a request.
+func generateRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:16])
 }
 
 // DecodeJSON will decode the response body to a JSON structure. This
 // will consume the response body, but will not close it. Close must
 // still be called.
 func (r *Response) DecodeJSON(out interface{}) error {
-	return jsonutil.DecodeJSONFromReader(r.Body, out)
+	if !r.jsonDecoding.DisallowUnknownFields {
+		return jsonutil.DecodeJSONFromReader(r.Body, out)
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	return dec.Decode(out)
+}
+
+// isHealthPath reports whether the request path is sys/health. Only the suffix
+// is matched, as the address may carry a path prefix, such as when Vault is
+// behind a reverse proxy.
+func isHealthPath(path string) bool {
+	return strings.HasSuffix(path, "/v1/sys/health")
 }
 
 // Error returns an error response if there is one. If there is an error,
@@ -27,8 +220,11 @@ func (r *Response) DecodeJSON(out interface{}) error {
 // body must still be closed manually.
 func (r *Response) Error() error {
 	// 200 to 399 are okay status codes. 429 is the code for health status of
-	// standby nodes.
-	if (r.StatusCode >= 200 && r.StatusCode < 400) || r.StatusCode == 429 {
+	// standby nodes; elsewhere it means the request was rate limited.
+	if r.StatusCode >= 200 && r.StatusCode < 400 {
+		return nil
+	}
+	if r.StatusCode == 429 && r.Request != nil && isHealthPath(r.Request.URL.Path) {
 		return nil
 	}
 
@@ -47,6 +243,8 @@ func (r *Response) Error() error {
 		HTTPMethod: r.Request.Method,
 		URL:        r.Request.URL.String(),
 		StatusCode: r.StatusCode,
+		Namespace:  r.Request.Header.Get(consts.NamespaceHeaderName),
+		Header:     r.Header,
 	}
 
 	// Decode the error response if we can. Note that we wrap the bodyBuf
@@ -92,6 +290,77 @@ type ResponseError struct {
 
 	// Errors are the underlying errors returned by Vault.
 	Errors []string
+
+	// Namespace is the namespace the request was made against, if any.
+	Namespace string
+
+	// RetryCount is the number of times the request was retried before
+	// this response was returned.
+	RetryCount int
+
+	// Header holds the headers of the response.
+	Header http.Header
+}
+
+// Is allows the sentinel errors ErrPermissionDenied, ErrSealed,
+// ErrRateLimited and ErrCASConflict to be matched with errors.Is.
+func (r *ResponseError) Is(target error) bool {
+	switch target {
+	case ErrPermissionDenied:
+		return r.StatusCode == http.StatusForbidden
+	case ErrSealed:
+		if r.StatusCode != http.StatusServiceUnavailable {
+			return false
+		}
+		for _, e := range r.Errors {
+			if strings.Contains(e, consts.ErrSealed.Error()) {
+				return true
+			}
+		}
+	case ErrRateLimited:
+		return r.StatusCode == http.StatusTooManyRequests
+	case ErrCASConflict:
+		if r.StatusCode != http.StatusBadRequest {
+			return false
+		}
+		for _, e := range r.Errors {
+			if strings.Contains(e, ErrCASConflict.Error()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsPermissionDenied returns whether err is a response error for a request
+// the token is not allowed to make.
+func IsPermissionDenied(err error) bool {
+	return isResponseError(err, ErrPermissionDenied)
+}
+
+// IsSealed returns whether err is a response error for a request made while
+// Vault is sealed.
+func IsSealed(err error) bool {
+	return isResponseError(err, ErrSealed)
+}
+
+// IsRateLimited returns whether err is a response error for a request
+// rejected by a rate limit quota, or a LimiterWaitError for a request that
+// the client's own rate limiter did not let through.
+func IsRateLimited(err error) bool {
+	return isResponseError(err, ErrRateLimited)
+}
+
+func isResponseError(err, target error) bool {
+	if errors.Is(err, target) {
+		return true
+	}
+
+	// Errors wrapped with errwrap cannot be unwrapped by errors.Is
+	if respErr, ok := errwrap.GetType(err, &ResponseError{}).(*ResponseError); ok {
+		return respErr.Is(target)
+	}
+	return false
 }
 
 // Error returns a human-readable error string for the response error.
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+func TestResponseError_Sentinels(t *testing.T) {
+	var status int
+	var body string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer ln.Close()
+
+	config.Backoff = func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		return time.Millisecond
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetNamespace("ns1")
+
+	cases := []struct {
+		status          int
+		body            string
+		denied          bool
+		sealed          bool
+		limited         bool
+		expectedRetries int
+	}{
+		{403, `{"errors": ["permission denied"]}`, true, false, false, 0},
+		{503, `{"errors": ["Vault is sealed"]}`, false, true, false, 2},
+		{503, `{"errors": ["other failure"]}`, false, false, false, 2},
+		{429, `{"errors": ["request path \"secret/foo\": rate limit quota exceeded"]}`, false, false, true, 0},
+		{404, `{"errors": []}`, false, false, false, 0},
+	}
+	for _, tc := range cases {
+		status, body = tc.status, tc.body
+		_, err := client.Logical().Write("secret/foo", nil)
+		if err == nil {
+			t.Fatalf("%d: expected error", tc.status)
+		}
+
+		var respErr *ResponseError
+		if !errors.As(err, &respErr) {
+			t.Fatalf("%d: expected a response error, got %T", tc.status, err)
+		}
+		if respErr.Namespace != "ns1" || respErr.RetryCount != tc.expectedRetries {
+			t.Fatalf("%d: unexpected response error %#v", tc.status, respErr)
+		}
+
+		wrapped := errwrap.Wrapf("wrapped: {{err}}", err)
+		for _, e := range []error{err, wrapped} {
+			if IsPermissionDenied(e) != tc.denied || IsSealed(e) != tc.sealed || IsRateLimited(e) != tc.limited {
+				t.Fatalf("%d: unexpected classification of %v", tc.status, e)
+			}
+		}
+		if errors.Is(err, ErrPermissionDenied) != tc.denied {
+			t.Fatalf("%d: unexpected errors.Is result", tc.status)
+		}
+	}
+}
+
+func TestResponseMetadata(t *testing.T) {
+	var requestIDs []string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestIDs = append(requestIDs, req.Header.Get(HeaderRequestID))
+		if len(requestIDs) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"request_id": "server-id", "data": {"value": "bar"}, "warnings": ["deprecated"]}`))
+	}))
+	defer ln.Close()
+
+	config.Backoff = func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		return time.Millisecond
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := secret.ResponseMetadata
+	if metadata == nil {
+		t.Fatal("expected response metadata")
+	}
+	if len(requestIDs) != 2 || requestIDs[0] == "" || requestIDs[0] != requestIDs[1] {
+		t.Fatalf("expected the same request ID on each attempt, got %v", requestIDs)
+	}
+	if metadata.RequestID != requestIDs[0] || metadata.RetryCount != 1 || metadata.Duration <= 0 {
+		t.Fatalf("unexpected metadata %#v", metadata)
+	}
+	if secret.RequestID != "server-id" || len(secret.Warnings) != 1 {
+		t.Fatalf("unexpected secret %#v", secret)
+	}
+}
+
+func TestResponse_MaxBodyBytes(t *testing.T) {
+	var body string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer ln.Close()
+
+	config.MaxResponseBodyBytes = 64
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body = `{"data": {"value": "bar"}}`
+	secret, err := client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["value"] != "bar" {
+		t.Fatalf("unexpected secret %#v", secret)
+	}
+
+	body = `{"data": {"value": "` + strings.Repeat("a", 1024) + `"}}`
+	_, err = client.Logical().Read("secret/foo")
+	if !errors.Is(err, ErrResponseBodyTooLarge) {
+		t.Fatalf("expected body size error, got %v", err)
+	}
+}
+
+func TestResponse_JSONDecoding(t *testing.T) {
+	var body string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer ln.Close()
+
+	config.JSONDecoding = JSONDecodingOptions{
+		DisallowUnknownFields: true,
+		Streaming:             true,
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body = `{"data": {"value": 9007199254740993}}`
+	secret, err := client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := secret.Data["value"].(json.Number); !ok || n.String() != "9007199254740993" {
+		t.Fatalf("expected number to be preserved, got %#v", secret.Data["value"])
+	}
+
+	body = ``
+	secret, err = client.Logical().Read("secret/foo")
+	if err != nil || secret != nil {
+		t.Fatalf("expected no secret for an empty body, got %#v, %v", secret, err)
+	}
+
+	body = `{"initialized": true, "unexpected": true}`
+	if _, err := client.Sys().InitStatus(); err == nil {
+		t.Fatal("expected unknown field to be rejected")
+	}
+}
+
+func TestResponse_ClockSkew(t *testing.T) {
+	skew := time.Hour
+	var body string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Date", time.Now().Add(skew).UTC().Format(http.TimeFormat))
+		w.Write([]byte(body))
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverExpire := time.Now().Add(skew + 10*time.Minute).UTC()
+	body = `{"data": {"ttl": 600, "expire_time": "` + serverExpire.Format(time.RFC3339Nano) + `"}}`
+	before := time.Now()
+	secret, err := client.Logical().Read("auth/token/lookup-self")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := secret.ResponseMetadata.ClockSkew - skew; d < -2*time.Second || d > 2*time.Second {
+		t.Fatalf("unexpected clock skew %s", secret.ResponseMetadata.ClockSkew)
+	}
+	expireTime, err := secret.TokenExpireTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := expireTime.Sub(before.Add(10 * time.Minute)); d < -2*time.Second || d > 2*time.Second {
+		t.Fatalf("expire time %s not corrected for skew", expireTime)
+	}
+
+	body = `{"lease_duration": 60, "data": {}}`
+	secret, err = client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaseExpire := secret.LeaseExpireTime()
+	if leaseExpire.Before(before.Add(time.Minute)) || leaseExpire.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("unexpected lease expire time %s", leaseExpire)
+	}
+}
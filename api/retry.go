@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
+)
+
+// IsTransientNetworkError returns whether err is a network error that is
+// likely to go away when the request is retried: the connection being reset
+// or closed before the response was complete, or a temporary DNS failure.
+// Such errors may happen after the server received the request, so retrying
+// them is only safe for idempotent requests.
+func IsTransientNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary
+	}
+	return false
+}
+
+// isIdempotentMethod returns whether requests with the given method can be
+// repeated without changing their effect, so may be retried after a network
+// error.
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, "LIST":
+		return true
+	}
+	return false
+}
+
+// isDialError returns whether err happened while connecting to the server,
+// in which case the request was never sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// networkErrorRetryPolicy wraps a CheckRetry so that requests failing with a
+// network error are only retried if the error is transient and retryErrors
+// is set. Failures to connect are retried regardless of the method, since
+// the server never saw the request. Responses are left to the wrapped
+// policy.
+func networkErrorRetryPolicy(checkRetry retryablehttp.CheckRetry, retryErrors bool) retryablehttp.CheckRetry {
+	return func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		retry, checkErr := checkRetry(ctx, resp, err)
+		if !retry || err == nil || isDialError(err) {
+			return retry, checkErr
+		}
+		return retryErrors && IsTransientNetworkError(err), checkErr
+	}
+}
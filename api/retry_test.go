@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsTransientNetworkError(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, false},
+		{&net.DNSError{Err: "server misbehaving", IsTemporary: true}, true},
+		{&net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		{errors.New("x509: certificate signed by unknown authority"), false},
+	}
+	for _, tc := range cases {
+		if actual := IsTransientNetworkError(tc.err); actual != tc.expected {
+			t.Fatalf("%v: expected %t, got %t", tc.err, tc.expected, actual)
+		}
+	}
+}
+
+func TestClientRetryNetworkErrors(t *testing.T) {
+	var attempts int32
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}))
+	defer ln.Close()
+
+	config.MaxRetries = 2
+	config.Backoff = func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		return time.Millisecond
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		method      string
+		retryWrites bool
+		expected    int32
+	}{
+		{"GET", false, 3},
+		{"LIST", false, 3},
+		{"PUT", false, 1},
+		{"PUT", true, 3},
+	}
+	for _, tc := range cases {
+		atomic.StoreInt32(&attempts, 0)
+		client.config.RetryWrites = tc.retryWrites
+		_, err := client.RawRequest(client.NewRequest(tc.method, "/v1/secret/foo"))
+		if err == nil {
+			t.Fatalf("%s: expected error", tc.method)
+		}
+		if n := atomic.LoadInt32(&attempts); n != tc.expected {
+			t.Fatalf("%s (retry writes %t): expected %d attempts, got %d", tc.method, tc.retryWrites, tc.expected, n)
+		}
+	}
+}
+
+func TestClientRetryDialErrors(t *testing.T) {
+	var dials int32
+	config := DefaultConfig()
+	config.Address = "http://127.0.0.1:8200"
+	config.MaxRetries = 2
+	config.Backoff = func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		return time.Millisecond
+	}
+	config.HttpClient.Transport.(*http.Transport).DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		atomic.AddInt32(&dials, 1)
+		return nil, &net.OpError{Op: "dial", Net: network, Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The request never reached the server, so writes are retried too
+	for _, method := range []string{"GET", "PUT", "POST", "DELETE"} {
+		atomic.StoreInt32(&dials, 0)
+		if _, err := client.RawRequest(client.NewRequest(method, "/v1/secret/foo")); err == nil {
+			t.Fatalf("%s: expected error", method)
+		}
+		if n := atomic.LoadInt32(&dials); n != 3 {
+			t.Fatalf("%s: expected 3 dials, got %d", method, n)
+		}
+	}
+}
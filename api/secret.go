@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"time"
@@ -37,6 +38,11 @@ type Secret struct {
 	// cubbyhole of the given token (which has a TTL of the given number of
 	// seconds)
 	WrapInfo *SecretWrapInfo `json:"wrap_info,omitempty"`
+
+	// ResponseMetadata describes the response the secret was read from. It
+	// is set by the Logical methods, unless the secret was served from the
+	// client cache.
+	ResponseMetadata *ResponseMetadata `json:"-"`
 }
 
 // TokenID returns the standardized token ID (token) for the given secret.
@@ -272,6 +278,57 @@ func (s *Secret) TokenTTL() (time.Duration, error) {
 	return ttl, nil
 }
 
+// TokenExpireTime returns the local time at which the token expires,
+// corrected for the skew between the local and server clocks. It returns the
+// zero time for tokens that do not expire.
+func (s *Secret) TokenExpireTime() (time.Time, error) {
+	if s == nil {
+		return time.Time{}, nil
+	}
+
+	// Token lookups report the absolute expiry time on the server
+	if s.Auth == nil && s.Data != nil && s.Data["expire_time"] != nil {
+		raw, ok := s.Data["expire_time"].(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("unexpected type %T for expire_time", s.Data["expire_time"])
+		}
+		expireTime, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return time.Time{}, errwrap.Wrapf("error parsing expire_time: {{err}}", err)
+		}
+		if s.ResponseMetadata != nil {
+			expireTime = s.ResponseMetadata.LocalTime(expireTime)
+		}
+		return expireTime, nil
+	}
+
+	ttl, err := s.TokenTTL()
+	if err != nil || ttl == 0 {
+		return time.Time{}, err
+	}
+	return s.issueTime().Add(ttl), nil
+}
+
+// LeaseExpireTime returns the local time at which the lease of the secret
+// expires, or the zero time if the secret has no lease duration.
+func (s *Secret) LeaseExpireTime() time.Time {
+	if s == nil || s.LeaseDuration <= 0 {
+		return time.Time{}
+	}
+	return s.issueTime().Add(time.Duration(s.LeaseDuration) * time.Second)
+}
+
+// issueTime returns the local time from which the TTLs of the secret are
+// counted. Relative TTLs are unaffected by clock skew, so this is when the
+// request was sent, falling back to the current time for secrets that were
+// not read from a response.
+func (s *Secret) issueTime() time.Time {
+	if s.ResponseMetadata == nil || s.ResponseMetadata.ReceivedAt.IsZero() {
+		return time.Now()
+	}
+	return s.ResponseMetadata.SentAt()
+}
+
 // SecretWrapInfo contains wrapping information if we have it. If what is
 // contained is an authentication token, the accessor for the token will be
 // available in WrappedAccessor.
@@ -297,6 +354,10 @@ type SecretAuth struct {
 
 	LeaseDuration int  `json:"lease_duration"`
 	Renewable     bool `json:"renewable"`
+
+	// MFARequirement is set when the login must be completed by validating
+	// MFA, in which case no token is issued until then.
+	MFARequirement *MFARequirement `json:"mfa_requirement"`
 }
 
 // ParseSecret is used to parse a secret value from JSON from an io.Reader.
@@ -320,3 +381,20 @@ func ParseSecret(r io.Reader) (*Secret, error) {
 
 	return &secret, nil
 }
+
+// parseSecretStream is like ParseSecret but decodes the secret directly from
+// the reader, without buffering it.
+func parseSecretStream(r io.Reader) (*Secret, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var secret Secret
+	if err := dec.Decode(&secret); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &secret, nil
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/mitchellh/mapstructure"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// DecodeData decodes the secret's data into v, which must be a pointer to a
+// struct or map. Struct fields are matched using mapstructure tags. The
+// secret's data is unwrapped first if it is a KV version 2 response, so the
+// same struct can be used with both versions of the KV secrets engine.
+//
+// Besides the conversions performed by mapstructure, numbers are decoded
+// from their JSON representation, time.Duration fields accept Vault's TTL
+// formats ("30m", "3600" or a number of seconds), and time.Time fields accept
+// RFC 3339 timestamps.
+func (s *Secret) DecodeData(v interface{}) error {
+	if s == nil {
+		return errors.New("cannot decode data of nil secret")
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			decodeDurationHook,
+			decodeTimeHook,
+		),
+		WeaklyTypedInput: true,
+		Result:           v,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(kvV2Data(s.Data)); err != nil {
+		return errwrap.Wrapf("error decoding secret data: {{err}}", err)
+	}
+	return nil
+}
+
+// kvV2Data returns the inner data of a KV version 2 read response, or data
+// unchanged if it does not look like one.
+func kvV2Data(data map[string]interface{}) map[string]interface{} {
+	if len(data) != 2 {
+		return data
+	}
+	if _, ok := data["metadata"].(map[string]interface{}); !ok {
+		return data
+	}
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		return inner
+	}
+	return data
+}
+
+func decodeDurationHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if to != durationType || from == durationType {
+		return data, nil
+	}
+	return parseutil.ParseDurationSecond(data)
+}
+
+func decodeTimeHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if to != timeType || from.Kind() != reflect.String {
+		return data, nil
+	}
+	return time.Parse(time.RFC3339Nano, reflect.ValueOf(data).String())
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecretDecodeData(t *testing.T) {
+	type credentials struct {
+		Username  string        `mapstructure:"username"`
+		Port      int           `mapstructure:"port"`
+		Ratio     float64       `mapstructure:"ratio"`
+		TTL       time.Duration `mapstructure:"ttl"`
+		MaxTTL    time.Duration `mapstructure:"max_ttl"`
+		ExpiresAt time.Time     `mapstructure:"expires_at"`
+	}
+
+	expected := credentials{
+		Username:  "admin",
+		Port:      5432,
+		Ratio:     0.5,
+		TTL:       30 * time.Minute,
+		MaxTTL:    time.Hour,
+		ExpiresAt: time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	data := `{"username":"admin","port":5432,"ratio":0.5,"ttl":"30m","max_ttl":3600,"expires_at":"2020-04-01T12:00:00Z"}`
+	for name, body := range map[string]string{
+		"kv v1": `{"data":` + data + `}`,
+		"kv v2": `{"data":{"data":` + data + `,"metadata":{"version":1}}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			secret, err := ParseSecret(strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got credentials
+			if err := secret.DecodeData(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != expected {
+				t.Fatalf("expected %#v, got %#v", expected, got)
+			}
+		})
+	}
+
+	secret, err := ParseSecret(strings.NewReader(`{"data":{"ttl":"forever"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got credentials
+	if err := secret.DecodeData(&got); err == nil {
+		t.Fatal("expected an error decoding an invalid TTL")
+	}
+}
@@ -0,0 +1,168 @@
+package spiffe
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// FileSource is an X509Source that reads the SVID and trust bundle from PEM
+// files, as written by a SPIFFE helper process. The files are reloaded when
+// their modification times change, so rotations performed by the helper are
+// picked up automatically.
+type FileSource struct {
+	CertFile   string
+	KeyFile    string
+	BundleFile string
+
+	l        sync.Mutex
+	loadedAt [3]time.Time
+	chain    []*x509.Certificate
+	key      crypto.Signer
+	bundle   []*x509.Certificate
+}
+
+var _ X509Source = (*FileSource)(nil)
+
+// NewFileSource returns a FileSource reading from the given files. The files
+// are loaded immediately so that configuration errors surface early.
+func NewFileSource(certFile, keyFile, bundleFile string) (*FileSource, error) {
+	s := &FileSource{
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		BundleFile: bundleFile,
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// X509SVID returns the current SVID.
+func (s *FileSource) X509SVID() ([]*x509.Certificate, crypto.Signer, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if err := s.reloadLocked(); err != nil {
+		return nil, nil, err
+	}
+	return s.chain, s.key, nil
+}
+
+// X509Bundle returns the current trust bundle.
+func (s *FileSource) X509Bundle() ([]*x509.Certificate, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if err := s.reloadLocked(); err != nil {
+		return nil, err
+	}
+	return s.bundle, nil
+}
+
+func (s *FileSource) reload() error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.reloadLocked()
+}
+
+// reloadLocked re-reads the files if any of them changed since they were last
+// loaded.
+func (s *FileSource) reloadLocked() error {
+	var modTimes [3]time.Time
+	for i, file := range []string{s.CertFile, s.KeyFile, s.BundleFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	if s.chain != nil && modTimes == s.loadedAt {
+		return nil
+	}
+
+	chain, err := readCertificates(s.CertFile)
+	if err != nil {
+		return err
+	}
+	bundle, err := readCertificates(s.BundleFile)
+	if err != nil {
+		return err
+	}
+	key, err := readKey(s.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	s.chain, s.key, s.bundle = chain, key, bundle
+	s.loadedAt = modTimes
+	return nil
+}
+
+func readCertificates(file string) ([]*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("failed to parse certificate in %q: {{err}}", file), err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found in %q", file)
+	}
+	return certs, nil
+}
+
+func readKey(file string) (crypto.Signer, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %q", file)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to parse private key in %q: {{err}}", file), err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key cannot be used for signing")
+	}
+	return signer, nil
+}
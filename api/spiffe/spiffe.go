@@ -0,0 +1,167 @@
+// Package spiffe configures the Vault API client to authenticate to Vault
+// with a SPIFFE X.509 SVID, and optionally to verify that the Vault server
+// presents an SVID with an expected SPIFFE ID.
+//
+// SVIDs are obtained from an X509Source. FileSource reads them from the
+// files maintained by a SPIFFE helper process; a source backed by the SPIFFE
+// Workload API (for instance go-spiffe's workloadapi.X509Source) can be
+// adapted by implementing the two methods of X509Source.
+package spiffe
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// X509Source provides the current X.509 SVID of the workload and the trust
+// bundle used to verify the Vault server. Implementations must be safe for
+// concurrent use; they are consulted on every TLS handshake, so rotated SVIDs
+// are picked up without reconfiguring the client.
+type X509Source interface {
+	// X509SVID returns the workload's certificate chain, leaf first, and the
+	// private key of the leaf.
+	X509SVID() ([]*x509.Certificate, crypto.Signer, error)
+
+	// X509Bundle returns the CA certificates trusted to issue the server's
+	// certificate.
+	X509Bundle() ([]*x509.Certificate, error)
+}
+
+// Config is used to configure TLS with ConfigureTLS.
+type Config struct {
+	// Source provides the SVID and trust bundle.
+	Source X509Source
+
+	// ServerID, if set, is the SPIFFE ID the Vault server's certificate must
+	// carry, e.g. "spiffe://example.org/vault". If unset, the server
+	// certificate is verified against the bundle and the server name as
+	// usual.
+	ServerID string
+}
+
+// ConfigureTLS sets up the HTTP client of the Vault API configuration to
+// present the workload's SVID as its client certificate. When a ServerID is
+// given, the server is authenticated by its SPIFFE ID against the source's
+// trust bundle instead of by hostname, as SVIDs do not usually carry DNS
+// names.
+func ConfigureTLS(config *api.Config, spiffeConfig *Config) error {
+	if spiffeConfig == nil || spiffeConfig.Source == nil {
+		return errors.New("a SPIFFE X.509 source is required")
+	}
+	if config.HttpClient == nil {
+		config.HttpClient = api.DefaultConfig().HttpClient
+	}
+	transport, ok := config.HttpClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("SPIFFE TLS can only be configured on an *http.Transport, got %T", config.HttpClient.Transport)
+	}
+
+	source := spiffeConfig.Source
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig.Clone()
+	}
+
+	tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		chain, key, err := source.X509SVID()
+		if err != nil {
+			return nil, err
+		}
+		cert := &tls.Certificate{
+			PrivateKey: key,
+			Leaf:       chain[0],
+		}
+		for _, c := range chain {
+			cert.Certificate = append(cert.Certificate, c.Raw)
+		}
+		return cert, nil
+	}
+
+	if spiffeConfig.ServerID != "" {
+		serverID := spiffeConfig.ServerID
+		// Hostname verification is replaced by SPIFFE ID verification below
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyServer(rawCerts, source, serverID)
+		}
+	} else {
+		bundle, err := source.X509Bundle()
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		for _, c := range bundle {
+			pool.AddCert(c)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return config.SetTLSConfig(tlsConfig)
+}
+
+// verifyServer verifies the server's certificate chain against the source's
+// current trust bundle and checks that the leaf carries the expected SPIFFE
+// ID.
+func verifyServer(rawCerts [][]byte, source X509Source, serverID string) error {
+	if len(rawCerts) == 0 {
+		return errors.New("server presented no certificates")
+	}
+
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+
+	bundle, err := source.X509Bundle()
+	if err != nil {
+		return err
+	}
+	roots := x509.NewCertPool()
+	for _, c := range bundle {
+		roots.AddCert(c)
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   time.Now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return err
+	}
+
+	id, err := SPIFFEID(certs[0])
+	if err != nil {
+		return err
+	}
+	if id != serverID {
+		return fmt.Errorf("unexpected server SPIFFE ID %q, expected %q", id, serverID)
+	}
+
+	return nil
+}
+
+// SPIFFEID returns the SPIFFE ID carried by an SVID, which is its single URI
+// SAN with the "spiffe" scheme.
+func SPIFFEID(cert *x509.Certificate) (string, error) {
+	if len(cert.URIs) != 1 || cert.URIs[0].Scheme != "spiffe" {
+		return "", errors.New("certificate does not carry exactly one SPIFFE ID")
+	}
+	return cert.URIs[0].String(), nil
+}
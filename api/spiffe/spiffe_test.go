@@ -0,0 +1,138 @@
+package spiffe
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func testSVID(t *testing.T, id string, usage x509.ExtKeyUsage, ca *x509.Certificate, caKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: id},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	if id == "" {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		template.ExtKeyUsage = nil
+		ca, caKey = template, key
+	} else {
+		u, _ := url.Parse(id)
+		template.URIs = []*url.URL{u}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func writePEM(t *testing.T, file, blockType string, der []byte) {
+	t.Helper()
+	if err := ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigureTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spiffe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, caKey := testSVID(t, "", 0, nil, nil)
+	serverCert, serverKey := testSVID(t, "spiffe://example.org/vault", x509.ExtKeyUsageServerAuth, ca, caKey)
+	clientCert, clientKey := testSVID(t, "spiffe://example.org/app", x509.ExtKeyUsageClientAuth, ca, caKey)
+
+	certFile := filepath.Join(dir, "svid.pem")
+	keyFile := filepath.Join(dir, "svid_key.pem")
+	bundleFile := filepath.Join(dir, "bundle.pem")
+	writePEM(t, certFile, "CERTIFICATE", clientCert.Raw)
+	keyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	writePEM(t, bundleFile, "CERTIFICATE", ca.Raw)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	var peerID string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		peerID, _ = SPIFFEID(req.TLS.PeerCertificates[0])
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{serverCert.Raw},
+			PrivateKey:  serverKey,
+		}},
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	source, err := NewFileSource(certFile, keyFile, bundleFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		serverID  string
+		expectErr bool
+	}{
+		{"spiffe://example.org/vault", false},
+		{"spiffe://example.org/other", true},
+	} {
+		config := api.DefaultConfig()
+		config.Address = server.URL
+		config.MaxRetries = 0
+		if err := ConfigureTLS(config, &Config{Source: source, ServerID: tc.serverID}); err != nil {
+			t.Fatal(err)
+		}
+		client, err := api.NewClient(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.RawRequest(client.NewRequest("GET", "/v1/sys/health"))
+		if tc.expectErr != (err != nil) {
+			t.Fatalf("server ID %q: expected error: %t, got: %v", tc.serverID, tc.expectErr, err)
+		}
+		if !tc.expectErr && peerID != "spiffe://example.org/app" {
+			t.Fatalf("expected the client SVID to be presented, got %q", peerID)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// AddressResolver resolves the configured Vault address to the address of a
+// concrete node that requests should be sent to. It allows service discovery
+// mechanisms beyond DNS SRV records to be plugged into the client.
+type AddressResolver interface {
+	// Resolve returns the address requests to addr should be sent to.
+	// Implementations are expected to cache results; Resolve is called for
+	// every request, with a context bounded by the request's. If it fails,
+	// the request fails with its error.
+	Resolve(ctx context.Context, addr *url.URL) (*url.URL, error)
+
+	// Invalidate is called when a request to a previously resolved address
+	// could not be completed, so that the next call to Resolve re-resolves.
+	Invalidate(resolved *url.URL)
+}
+
+// addressResolveTimeout bounds each call to an AddressResolver, so that a
+// slow lookup does not hold up requests indefinitely.
+const addressResolveTimeout = 10 * time.Second
+
+// resolveRequestAddress points the request, made to the client's address
+// addr, at the node the resolver resolves addr to.
+func resolveRequestAddress(ctx context.Context, resolver AddressResolver, addr *url.URL, r *Request) error {
+	ctx, cancel := context.WithTimeout(ctx, addressResolveTimeout)
+	defer cancel()
+
+	resolved, err := resolver.Resolve(ctx, addr)
+	if err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("error resolving address %q: {{err}}", addr.String()), err)
+	}
+	r.URL.Scheme = resolved.Scheme
+	r.URL.Host = resolved.Host
+	r.Host = resolved.Host
+	return nil
+}
+
+// DefaultSRVCacheTTL is how long the results of an SRV lookup are reused
+// before the records are resolved again. The Go resolver does not expose
+// record TTLs, so a fixed interval is used.
+const DefaultSRVCacheTTL = 60 * time.Second
+
+// lookupSRV is swapped out in tests.
+var lookupSRV = net.LookupSRV
+
+// srvResolver resolves and caches SRV records for the client's address so
+// that requests don't block on DNS each time they are made.
+type srvResolver struct {
+	l       sync.Mutex
+	ttl     time.Duration
+	random  *rand.Rand
+	entries map[string]*srvEntry
+}
+
+type srvEntry struct {
+	records []*net.SRV
+	expires time.Time
+}
+
+func newSRVResolver(ttl time.Duration) *srvResolver {
+	if ttl <= 0 {
+		ttl = DefaultSRVCacheTTL
+	}
+	return &srvResolver{
+		ttl:     ttl,
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		entries: make(map[string]*srvEntry),
+	}
+}
+
+// resolve returns the host:port to use for the given hostname, or the empty
+// string if no SRV records exist. Failed lookups are cached as well, so an
+// absent record doesn't cost a DNS round trip on every request.
+func (s *srvResolver) resolve(hostname string) string {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	entry, ok := s.entries[hostname]
+	if !ok || time.Now().After(entry.expires) {
+		_, records, err := lookupSRV("http", "tcp", hostname)
+		if err != nil {
+			records = nil
+		}
+		entry = &srvEntry{
+			records: records,
+			expires: time.Now().Add(s.ttl),
+		}
+		s.entries[hostname] = entry
+	}
+
+	record := selectSRV(entry.records, s.random)
+	if record == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", record.Target, record.Port)
+}
+
+// selectSRV picks a record per RFC 2782: only records with the lowest
+// priority value are considered, and among those a record is chosen at
+// random with probability proportional to its weight.
+func selectSRV(records []*net.SRV, random *rand.Rand) *net.SRV {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var candidates []*net.SRV
+	for _, record := range records {
+		switch {
+		case len(candidates) == 0 || record.Priority < candidates[0].Priority:
+			candidates = []*net.SRV{record}
+		case record.Priority == candidates[0].Priority:
+			candidates = append(candidates, record)
+		}
+	}
+
+	total := 0
+	for _, record := range candidates {
+		total += int(record.Weight)
+	}
+	if total == 0 {
+		return candidates[random.Intn(len(candidates))]
+	}
+
+	n := random.Intn(total)
+	for _, record := range candidates {
+		n -= int(record.Weight)
+		if n < 0 {
+			return record
+		}
+	}
+	return candidates[len(candidates)-1]
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelectSRV(t *testing.T) {
+	records := []*net.SRV{
+		{Target: "backup.", Port: 8200, Priority: 20, Weight: 100},
+		{Target: "a.", Port: 8200, Priority: 10, Weight: 0},
+		{Target: "b.", Port: 8200, Priority: 10, Weight: 1},
+	}
+
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		if got := selectSRV(records, random); got.Target != "b." {
+			t.Fatalf("expected only the weighted lowest-priority record, got %q", got.Target)
+		}
+	}
+
+	if selectSRV(nil, random) != nil {
+		t.Fatal("expected nil for no records")
+	}
+}
+
+func TestSRVResolverCaches(t *testing.T) {
+	lookups := 0
+	oldLookup := lookupSRV
+	defer func() { lookupSRV = oldLookup }()
+	lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		lookups++
+		return "", []*net.SRV{{Target: "vault.example.com.", Port: 8200, Priority: 1, Weight: 1}}, nil
+	}
+
+	resolver := newSRVResolver(0)
+	for i := 0; i < 3; i++ {
+		if host := resolver.resolve("vault.example.com"); host != "vault.example.com.:8200" {
+			t.Fatalf("bad host: %q", host)
+		}
+	}
+	if lookups != 1 {
+		t.Fatalf("expected a single lookup, got %d", lookups)
+	}
+}
+
+func TestConsulResolver(t *testing.T) {
+	vault := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("active"))
+	}
+	vaultConfig, vaultLn := testHTTPServer(t, http.HandlerFunc(vault))
+	defer vaultLn.Close()
+	vaultHost, vaultPort, _ := net.SplitHostPort(vaultLn.Addr().String())
+
+	consul := func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/health/service/vault" || req.URL.Query().Get("tag") != "active" {
+			t.Errorf("unexpected consul request: %s", req.URL)
+		}
+		fmt.Fprintf(w, `[{"Node":{"Address":"10.0.0.1"},"Service":{"Address":%q,"Port":%s}}]`, vaultHost, vaultPort)
+	}
+	consulConfig, consulLn := testHTTPServer(t, http.HandlerFunc(consul))
+	defer consulLn.Close()
+
+	vaultConfig.Address = "consul://vault"
+	vaultConfig.AddressResolver = NewConsulResolver(&ConsulResolverConfig{
+		Address: consulConfig.Address,
+		Scheme:  "http",
+	})
+	client, err := NewClient(vaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.RawRequest(client.NewRequest("GET", "/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	io.Copy(&buf, resp.Body)
+	if buf.String() != "active" {
+		t.Fatalf("bad: %s", buf.String())
+	}
+}
+
+func TestConsulResolver_Error(t *testing.T) {
+	consul := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[]`))
+	}
+	consulConfig, consulLn := testHTTPServer(t, http.HandlerFunc(consul))
+	defer consulLn.Close()
+
+	config := DefaultConfig()
+	config.Address = "consul://vault"
+	config.AddressResolver = NewConsulResolver(&ConsulResolverConfig{
+		Address: consulConfig.Address,
+	})
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.RawRequest(client.NewRequest("GET", "/"))
+	if err == nil || !strings.Contains(err.Error(), "no healthy") {
+		t.Fatalf("expected resolution error, got %v", err)
+	}
+}
+
+func TestConsulResolver_Timeout(t *testing.T) {
+	consul := func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}
+	consulConfig, consulLn := testHTTPServer(t, http.HandlerFunc(consul))
+	defer consulLn.Close()
+
+	config := DefaultConfig()
+	config.Address = "consul://vault"
+	config.AddressResolver = NewConsulResolver(&ConsulResolverConfig{
+		Address: consulConfig.Address,
+	})
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The lookup is bounded by the request's context
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.RawRequestWithContext(ctx, client.NewRequest("GET", "/"))
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+}
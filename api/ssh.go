@@ -2,7 +2,15 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
 // SSH is used to return a client to invoke operations on SSH backend.
@@ -60,3 +68,152 @@ func (c *SSH) SignKey(role string, data map[string]interface{}) (*Secret, error)
 
 	return ParseSecret(resp.Body)
 }
+
+// SSHSignOptions are the optional parameters of SignPublicKey.
+type SSHSignOptions struct {
+	ValidPrincipals []string
+	CertType        string
+	KeyID           string
+	TTL             string
+	CriticalOptions map[string]string
+	Extensions      map[string]string
+}
+
+// SSHSignedKey is a public key signed by the SSH secrets engine.
+type SSHSignedKey struct {
+	// SignedKey is the certificate in authorized_keys format, as returned by
+	// Vault.
+	SignedKey    string
+	SerialNumber string
+
+	// Certificate is the parsed certificate.
+	Certificate *ssh.Certificate
+}
+
+// SSHOTPCredential is a one-time password issued by the SSH secrets engine.
+type SSHOTPCredential struct {
+	Key      string `mapstructure:"key"`
+	KeyType  string `mapstructure:"key_type"`
+	Username string `mapstructure:"username"`
+	IP       string `mapstructure:"ip"`
+	Port     int    `mapstructure:"port"`
+}
+
+// SignPublicKey signs the given public key, in authorized_keys format, and
+// returns the parsed certificate.
+func (c *SSH) SignPublicKey(role string, publicKey []byte, opts *SSHSignOptions) (*SSHSignedKey, error) {
+	data := map[string]interface{}{
+		"public_key": string(publicKey),
+	}
+	if opts != nil {
+		if len(opts.ValidPrincipals) > 0 {
+			data["valid_principals"] = strings.Join(opts.ValidPrincipals, ",")
+		}
+		if opts.CertType != "" {
+			data["cert_type"] = opts.CertType
+		}
+		if opts.KeyID != "" {
+			data["key_id"] = opts.KeyID
+		}
+		if opts.TTL != "" {
+			data["ttl"] = opts.TTL
+		}
+		if len(opts.CriticalOptions) > 0 {
+			data["critical_options"] = opts.CriticalOptions
+		}
+		if len(opts.Extensions) > 0 {
+			data["extensions"] = opts.Extensions
+		}
+	}
+
+	secret, err := c.SignKey(role, data)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	signedKey, ok := secret.Data["signed_key"].(string)
+	if !ok {
+		return nil, errors.New("unexpected type for signed_key in response")
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(signedKey))
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to parse signed key: {{err}}", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("signed key is not a certificate")
+	}
+
+	result := &SSHSignedKey{
+		SignedKey:   signedKey,
+		Certificate: cert,
+	}
+	result.SerialNumber, _ = secret.Data["serial_number"].(string)
+	return result, nil
+}
+
+// OTPCredential requests a one-time password to log into the given IP
+// address using a role in OTP mode.
+func (c *SSH) OTPCredential(role, ip string) (*SSHOTPCredential, error) {
+	secret, err := c.Credential(role, map[string]interface{}{
+		"ip": ip,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var cred SSHOTPCredential
+	if err := secret.DecodeData(&cred); err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// ReadCAPublicKey returns the public key of the CA used to sign keys, in
+// authorized_keys format. It can be added to TrustedUserCAKeys on hosts.
+func (c *SSH) ReadCAPublicKey() (string, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/%s/public_key", c.MountPoint))
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	key, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(key)), nil
+}
+
+// AgentKey returns the signed key paired with its private key, for adding to
+// an ssh-agent. The lifetime of the key in the agent matches the validity of
+// the certificate.
+func (k *SSHSignedKey) AgentKey(privateKey interface{}) agent.AddedKey {
+	added := agent.AddedKey{
+		PrivateKey:  privateKey,
+		Certificate: k.Certificate,
+		Comment:     k.Certificate.KeyId,
+	}
+	if k.Certificate.ValidBefore != ssh.CertTimeInfinity {
+		if lifetime := time.Until(time.Unix(int64(k.Certificate.ValidBefore), 0)); lifetime > 0 {
+			added.LifetimeSecs = uint32(lifetime.Seconds())
+		}
+	}
+	return added
+}
+
+// WriteCertificate writes the signed key next to the private key at the
+// given path, using the "-cert.pub" suffix that ssh and ssh-add look for.
+func (k *SSHSignedKey) WriteCertificate(privateKeyPath string) error {
+	return ioutil.WriteFile(privateKeyPath+"-cert.pub", []byte(strings.TrimSpace(k.SignedKey)+"\n"), 0644)
+}
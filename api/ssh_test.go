@@ -0,0 +1,134 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSHSignPublicKey(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caSigner, err := ssh.NewSignerFromKey(caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/ssh/public_key":
+			w.Write(ssh.MarshalAuthorizedKey(caSigner.PublicKey()))
+		case "/v1/ssh/sign/users":
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(body["public_key"].(string)))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			cert := &ssh.Certificate{
+				Key:             pub,
+				Serial:          42,
+				CertType:        ssh.UserCert,
+				KeyId:           body["key_id"].(string),
+				ValidPrincipals: strings.Split(body["valid_principals"].(string), ","),
+				ValidAfter:      uint64(time.Now().Unix()),
+				ValidBefore:     uint64(time.Now().Add(time.Hour).Unix()),
+			}
+			if err := cert.SignCert(rand.Reader, caSigner); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"serial_number": "000000000000002a",
+					"signed_key":    string(ssh.MarshalAuthorizedKey(cert)),
+				},
+			})
+		case "/v1/ssh/creds/otp":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"key":      "2f7e25a2-24c9-4b7b-0d35-27d5e5203a5c",
+					"key_type": "otp",
+					"username": "ubuntu",
+					"ip":       "10.0.0.5",
+					"port":     22,
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userPub, err := ssh.NewPublicKey(&userKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := client.SSH().SignPublicKey("users", ssh.MarshalAuthorizedKey(userPub), &SSHSignOptions{
+		KeyID:           "alice",
+		ValidPrincipals: []string{"alice", "admin"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.SerialNumber != "000000000000002a" || signed.Certificate.Serial != 42 || signed.Certificate.KeyId != "alice" {
+		t.Fatalf("bad signed key: %#v", signed)
+	}
+
+	added := signed.AgentKey(userKey)
+	if added.Certificate != signed.Certificate || added.LifetimeSecs == 0 || added.LifetimeSecs > 3600 {
+		t.Fatalf("bad agent key: %#v", added)
+	}
+
+	dir, err := ioutil.TempDir("", "ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	keyPath := filepath.Join(dir, "id_ecdsa")
+	if err := signed.WriteCertificate(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if contents, err := ioutil.ReadFile(keyPath + "-cert.pub"); err != nil || string(contents) != signed.SignedKey {
+		t.Fatalf("bad certificate file: %q, %v", contents, err)
+	}
+
+	caPub, err := client.SSH().ReadCAPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caPub != strings.TrimSpace(string(ssh.MarshalAuthorizedKey(caSigner.PublicKey()))) {
+		t.Fatalf("bad CA public key: %q", caPub)
+	}
+
+	cred, err := client.SSH().OTPCredential("otp", "10.0.0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.Username != "ubuntu" || cred.Port != 22 || cred.KeyType != "otp" {
+		t.Fatalf("bad credential: %#v", cred)
+	}
+}
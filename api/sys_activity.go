@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// ActivityCounts are the client counts of a period, namespace or mount.
+// Clients is the total of entity and non-entity clients; DistinctEntities
+// and NonEntityTokens are the names used by older servers.
+type ActivityCounts struct {
+	Clients          int `mapstructure:"clients"`
+	EntityClients    int `mapstructure:"entity_clients"`
+	NonEntityClients int `mapstructure:"non_entity_clients"`
+	DistinctEntities int `mapstructure:"distinct_entities"`
+	NonEntityTokens  int `mapstructure:"non_entity_tokens"`
+}
+
+// ActivityNamespace are the client counts of a namespace, broken down by
+// mount.
+type ActivityNamespace struct {
+	NamespaceID   string          `mapstructure:"namespace_id"`
+	NamespacePath string          `mapstructure:"namespace_path"`
+	Counts        ActivityCounts  `mapstructure:"counts"`
+	Mounts        []ActivityMount `mapstructure:"mounts"`
+}
+
+// ActivityMount are the client counts of an auth mount.
+type ActivityMount struct {
+	MountPath string         `mapstructure:"mount_path"`
+	Counts    ActivityCounts `mapstructure:"counts"`
+}
+
+// ActivityMonth are the client counts of a month of a report. NewClients
+// only counts the clients first seen during the month.
+type ActivityMonth struct {
+	Timestamp  time.Time           `mapstructure:"timestamp"`
+	Counts     ActivityCounts      `mapstructure:"counts"`
+	Namespaces []ActivityNamespace `mapstructure:"namespaces"`
+	NewClients *ActivityNewClients `mapstructure:"new_clients"`
+}
+
+// ActivityNewClients are the counts of the clients first seen during a
+// month.
+type ActivityNewClients struct {
+	Counts     ActivityCounts      `mapstructure:"counts"`
+	Namespaces []ActivityNamespace `mapstructure:"namespaces"`
+}
+
+// ActivityReport are the client counts of a billing period.
+type ActivityReport struct {
+	StartTime   time.Time           `mapstructure:"start_time"`
+	EndTime     time.Time           `mapstructure:"end_time"`
+	Total       ActivityCounts      `mapstructure:"total"`
+	ByNamespace []ActivityNamespace `mapstructure:"by_namespace"`
+	Months      []ActivityMonth     `mapstructure:"months"`
+}
+
+// ActivityMonthly are the client counts of the current month so far.
+type ActivityMonthly struct {
+	ActivityCounts `mapstructure:",squash"`
+	ByNamespace    []ActivityNamespace `mapstructure:"by_namespace"`
+	Months         []ActivityMonth     `mapstructure:"months"`
+}
+
+// ActivityQuery are the parameters of an activity report. Zero times are
+// left to the server's defaults, the current billing period.
+type ActivityQuery struct {
+	StartTime time.Time
+	EndTime   time.Time
+
+	// LimitNamespaces limits the namespace breakdown to the given number of
+	// namespaces with the most clients. Zero means no limit.
+	LimitNamespaces int
+}
+
+// Activity returns the client counts of the period given by the query, or
+// nil if the server has no activity data for it.
+func (c *Sys) Activity(ctx context.Context, query *ActivityQuery) (*ActivityReport, error) {
+	params := map[string][]string{}
+	if query != nil {
+		if !query.StartTime.IsZero() {
+			params["start_time"] = []string{query.StartTime.UTC().Format(time.RFC3339)}
+		}
+		if !query.EndTime.IsZero() {
+			params["end_time"] = []string{query.EndTime.UTC().Format(time.RFC3339)}
+		}
+		if query.LimitNamespaces > 0 {
+			params["limit_namespaces"] = []string{strconv.Itoa(query.LimitNamespaces)}
+		}
+	}
+
+	secret, err := c.c.Logical().readWithContext(ctx, "sys/internal/counters/activity", params)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var report ActivityReport
+	if err := secret.DecodeData(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ActivityMonthly returns the client counts of the current month so far,
+// or nil if the server has no activity data for it.
+func (c *Sys) ActivityMonthly(ctx context.Context) (*ActivityMonthly, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, "sys/internal/counters/activity/monthly", nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var monthly ActivityMonthly
+	if err := secret.DecodeData(&monthly); err != nil {
+		return nil, err
+	}
+	return &monthly, nil
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSysActivity(t *testing.T) {
+	var query map[string][]string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/sys/internal/counters/activity":
+			query = req.URL.Query()
+			w.Write([]byte(`{"data": {
+				"start_time": "2020-01-01T00:00:00Z",
+				"end_time": "2020-02-29T23:59:59Z",
+				"total": {"clients": 15, "entity_clients": 10, "non_entity_clients": 5, "distinct_entities": 10, "non_entity_tokens": 5},
+				"by_namespace": [{
+					"namespace_id": "root",
+					"namespace_path": "",
+					"counts": {"clients": 15, "entity_clients": 10, "non_entity_clients": 5},
+					"mounts": [{"mount_path": "auth/userpass/", "counts": {"clients": 15}}]
+				}],
+				"months": [{
+					"timestamp": "2020-01-01T00:00:00Z",
+					"counts": {"clients": 7},
+					"namespaces": [],
+					"new_clients": {"counts": {"clients": 7}, "namespaces": []}
+				}, {
+					"timestamp": "2020-02-01T00:00:00Z",
+					"counts": null,
+					"namespaces": null,
+					"new_clients": null
+				}]
+			}}`))
+		case "/v1/sys/internal/counters/activity/monthly":
+			w.Write([]byte(`{"data": {"clients": 3, "entity_clients": 2, "non_entity_clients": 1, "by_namespace": [{"namespace_id": "root", "counts": {"clients": 3}}], "months": []}}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	report, err := client.Sys().Activity(ctx, &ActivityQuery{
+		StartTime:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:         time.Date(2020, 2, 29, 23, 59, 59, 0, time.UTC),
+		LimitNamespaces: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query["start_time"][0] != "2020-01-01T00:00:00Z" || query["end_time"][0] != "2020-02-29T23:59:59Z" || query["limit_namespaces"][0] != "5" {
+		t.Fatalf("unexpected query %v", query)
+	}
+	if report.Total.Clients != 15 || report.Total.NonEntityClients != 5 || report.StartTime.Month() != time.January {
+		t.Fatalf("unexpected report %#v", report)
+	}
+	if len(report.ByNamespace) != 1 || report.ByNamespace[0].Mounts[0].MountPath != "auth/userpass/" || report.ByNamespace[0].Mounts[0].Counts.Clients != 15 {
+		t.Fatalf("unexpected namespaces %#v", report.ByNamespace)
+	}
+	if len(report.Months) != 2 || report.Months[0].NewClients.Counts.Clients != 7 || report.Months[1].NewClients != nil {
+		t.Fatalf("unexpected months %#v", report.Months)
+	}
+
+	monthly, err := client.Sys().ActivityMonthly(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if monthly.Clients != 3 || monthly.EntityClients != 2 || monthly.ByNamespace[0].Counts.Clients != 3 {
+		t.Fatalf("unexpected monthly counts %#v", monthly)
+	}
+}
@@ -73,6 +73,14 @@ func (c *Sys) DisableAuth(path string) error {
 	return err
 }
 
+func (c *Sys) TuneAuth(path string, config AuthConfigInput) error {
+	return c.tune(fmt.Sprintf("/v1/sys/auth/%s/tune", path), config)
+}
+
+func (c *Sys) AuthConfig(path string) (*AuthConfigOutput, error) {
+	return c.tuneConfig(fmt.Sprintf("/v1/sys/auth/%s/tune", path))
+}
+
 // Rather than duplicate, we can use modern Go's type aliasing
 type EnableAuthOptions = MountInput
 type AuthConfigInput = MountConfigInput
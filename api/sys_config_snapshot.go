@@ -0,0 +1,365 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// ConfigSnapshot is a declarative description of a cluster's logical
+// configuration: its secrets engines, auth methods, ACL policies, and rate
+// limit quotas. It contains no secret data. Snapshots are JSON-serializable,
+// so they can be stored alongside other infrastructure configuration and
+// applied to another cluster with ApplyConfig.
+type ConfigSnapshot struct {
+	Mounts   map[string]*ConfigSnapshotMount   `json:"mounts"`
+	Auth     map[string]*ConfigSnapshotMount   `json:"auth"`
+	Policies map[string]string                 `json:"policies"`
+	Quotas   map[string]map[string]interface{} `json:"quotas"`
+}
+
+// ConfigSnapshotMount describes a single secrets engine or auth method.
+type ConfigSnapshotMount struct {
+	Type        string            `json:"type"`
+	Description string            `json:"description"`
+	Local       bool              `json:"local"`
+	SealWrap    bool              `json:"seal_wrap"`
+	Options     map[string]string `json:"options,omitempty"`
+	Config      MountConfigInput  `json:"config"`
+}
+
+// ConfigChangeAction is the kind of modification a ConfigChange makes.
+type ConfigChangeAction string
+
+const (
+	ConfigChangeCreate ConfigChangeAction = "create"
+	ConfigChangeUpdate ConfigChangeAction = "update"
+	ConfigChangeDelete ConfigChangeAction = "delete"
+)
+
+// ConfigChange is a single difference between two snapshots.
+type ConfigChange struct {
+	// Kind is one of "mount", "auth", "policy", or "quota".
+	Kind   string
+	Name   string
+	Action ConfigChangeAction
+}
+
+func (c ConfigChange) String() string {
+	return fmt.Sprintf("%s %s %q", c.Action, c.Kind, c.Name)
+}
+
+// ApplyConfigInput is used to configure ApplyConfig.
+type ApplyConfigInput struct {
+	// Prune removes mounts, auth methods, policies, and quotas that exist on
+	// the cluster but not in the snapshot. Removing a mount destroys its
+	// data, so this is off by default.
+	Prune bool
+
+	// DryRun computes the changes without making them.
+	DryRun bool
+}
+
+// builtinMounts cannot be created, removed, or meaningfully recreated, so they
+// are left out of snapshots.
+var builtinMounts = map[string]bool{
+	"sys/":       true,
+	"cubbyhole/": true,
+	"identity/":  true,
+}
+
+var builtinAuth = map[string]bool{
+	"token/": true,
+}
+
+// ExportConfig captures the logical configuration of the cluster.
+func (c *Sys) ExportConfig() (*ConfigSnapshot, error) {
+	snapshot := &ConfigSnapshot{
+		Mounts:   make(map[string]*ConfigSnapshotMount),
+		Auth:     make(map[string]*ConfigSnapshotMount),
+		Policies: make(map[string]string),
+		Quotas:   make(map[string]map[string]interface{}),
+	}
+
+	mounts, err := c.ListMounts()
+	if err != nil {
+		return nil, errwrap.Wrapf("error listing mounts: {{err}}", err)
+	}
+	for path, mount := range mounts {
+		if builtinMounts[path] {
+			continue
+		}
+		snapshot.Mounts[path] = snapshotMount(mount)
+	}
+
+	auths, err := c.ListAuth()
+	if err != nil {
+		return nil, errwrap.Wrapf("error listing auth methods: {{err}}", err)
+	}
+	for path, auth := range auths {
+		if builtinAuth[path] {
+			continue
+		}
+		snapshot.Auth[path] = snapshotMount(auth)
+	}
+
+	policies, err := c.ListPolicies()
+	if err != nil {
+		return nil, errwrap.Wrapf("error listing policies: {{err}}", err)
+	}
+	for _, name := range policies {
+		if name == "root" {
+			continue
+		}
+		rules, err := c.GetPolicy(name)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("error reading policy %q: {{err}}", name), err)
+		}
+		snapshot.Policies[name] = rules
+	}
+
+	quotas, err := c.c.Logical().List("sys/quotas/rate-limit")
+	if err != nil {
+		return nil, errwrap.Wrapf("error listing quotas: {{err}}", err)
+	}
+	if quotas != nil && quotas.Data != nil {
+		keys, _ := quotas.Data["keys"].([]interface{})
+		for _, k := range keys {
+			name, ok := k.(string)
+			if !ok {
+				continue
+			}
+			quota, err := c.c.Logical().Read("sys/quotas/rate-limit/" + name)
+			if err != nil {
+				return nil, errwrap.Wrapf(fmt.Sprintf("error reading quota %q: {{err}}", name), err)
+			}
+			if quota == nil {
+				continue
+			}
+			snapshot.Quotas[name] = quota.Data
+		}
+	}
+
+	return snapshot, nil
+}
+
+func snapshotMount(mount *MountOutput) *ConfigSnapshotMount {
+	m := &ConfigSnapshotMount{
+		Type:        mount.Type,
+		Description: mount.Description,
+		Local:       mount.Local,
+		SealWrap:    mount.SealWrap,
+		Options:     mount.Options,
+		Config: MountConfigInput{
+			ForceNoCache:              mount.Config.ForceNoCache,
+			AuditNonHMACRequestKeys:   mount.Config.AuditNonHMACRequestKeys,
+			AuditNonHMACResponseKeys:  mount.Config.AuditNonHMACResponseKeys,
+			ListingVisibility:         mount.Config.ListingVisibility,
+			PassthroughRequestHeaders: mount.Config.PassthroughRequestHeaders,
+			AllowedResponseHeaders:    mount.Config.AllowedResponseHeaders,
+			TokenType:                 mount.Config.TokenType,
+		},
+	}
+	if mount.Config.DefaultLeaseTTL != 0 {
+		m.Config.DefaultLeaseTTL = fmt.Sprintf("%ds", mount.Config.DefaultLeaseTTL)
+	}
+	if mount.Config.MaxLeaseTTL != 0 {
+		m.Config.MaxLeaseTTL = fmt.Sprintf("%ds", mount.Config.MaxLeaseTTL)
+	}
+	return m
+}
+
+// DiffConfig returns the changes needed to turn the configuration described by
+// current into the one described by desired. Changes are ordered so that they
+// can be applied in sequence: policies before the mounts and auth methods
+// that may reference them, and quotas last; deletions happen in reverse.
+func DiffConfig(current, desired *ConfigSnapshot) []ConfigChange {
+	if current == nil {
+		current = &ConfigSnapshot{}
+	}
+	if desired == nil {
+		desired = &ConfigSnapshot{}
+	}
+
+	var changes, deletions []ConfigChange
+	diff := func(kind string, currentKeys, desiredKeys []string, equal func(string) bool) {
+		have := make(map[string]bool, len(currentKeys))
+		for _, k := range currentKeys {
+			have[k] = true
+		}
+		want := make(map[string]bool, len(desiredKeys))
+		for _, k := range desiredKeys {
+			want[k] = true
+			switch {
+			case !have[k]:
+				changes = append(changes, ConfigChange{Kind: kind, Name: k, Action: ConfigChangeCreate})
+			case !equal(k):
+				changes = append(changes, ConfigChange{Kind: kind, Name: k, Action: ConfigChangeUpdate})
+			}
+		}
+		var removed []ConfigChange
+		for _, k := range currentKeys {
+			if !want[k] {
+				removed = append(removed, ConfigChange{Kind: kind, Name: k, Action: ConfigChangeDelete})
+			}
+		}
+		deletions = append(removed, deletions...)
+	}
+
+	diff("policy", sortedKeys(current.Policies), sortedKeys(desired.Policies), func(k string) bool {
+		return strings.TrimSpace(current.Policies[k]) == strings.TrimSpace(desired.Policies[k])
+	})
+	diff("mount", sortedKeys(current.Mounts), sortedKeys(desired.Mounts), func(k string) bool {
+		return jsonEqual(current.Mounts[k], desired.Mounts[k])
+	})
+	diff("auth", sortedKeys(current.Auth), sortedKeys(desired.Auth), func(k string) bool {
+		return jsonEqual(current.Auth[k], desired.Auth[k])
+	})
+	diff("quota", sortedKeys(current.Quotas), sortedKeys(desired.Quotas), func(k string) bool {
+		return jsonEqual(current.Quotas[k], desired.Quotas[k])
+	})
+
+	return append(changes, deletions...)
+}
+
+// jsonEqual compares values by their JSON encoding, so that snapshots read
+// from the server compare equal to ones that were round-tripped through a
+// file, where numbers and empty maps may be represented differently.
+func jsonEqual(a, b interface{}) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aJSON, bJSON)
+}
+
+// sortedKeys returns the keys of a map with string keys, in order.
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ApplyConfig brings the cluster's configuration in line with the snapshot,
+// returning the changes that were made (or, for a dry run, would be made).
+// Changing the type of an existing mount is not supported and results in an
+// error. Application stops at the first change that fails; the returned
+// changes are those made before the failure.
+func (c *Sys) ApplyConfig(desired *ConfigSnapshot, input *ApplyConfigInput) ([]ConfigChange, error) {
+	if desired == nil {
+		return nil, errors.New("no configuration to apply")
+	}
+	if input == nil {
+		input = &ApplyConfigInput{}
+	}
+
+	current, err := c.ExportConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var planned []ConfigChange
+	for _, change := range DiffConfig(current, desired) {
+		if change.Action == ConfigChangeDelete && !input.Prune {
+			continue
+		}
+		if change.Action == ConfigChangeUpdate {
+			var before, after *ConfigSnapshotMount
+			switch change.Kind {
+			case "mount":
+				before, after = current.Mounts[change.Name], desired.Mounts[change.Name]
+			case "auth":
+				before, after = current.Auth[change.Name], desired.Auth[change.Name]
+			}
+			if before != nil && after != nil && before.Type != after.Type {
+				return nil, fmt.Errorf("cannot change type of %s %q from %q to %q", change.Kind, change.Name, before.Type, after.Type)
+			}
+		}
+		planned = append(planned, change)
+	}
+
+	if input.DryRun {
+		return planned, nil
+	}
+
+	var applied []ConfigChange
+	for _, change := range planned {
+		if err := c.applyConfigChange(change, desired); err != nil {
+			return applied, errwrap.Wrapf(fmt.Sprintf("error applying %s: {{err}}", change), err)
+		}
+		applied = append(applied, change)
+	}
+
+	return applied, nil
+}
+
+func (c *Sys) applyConfigChange(change ConfigChange, desired *ConfigSnapshot) error {
+	name := strings.TrimSuffix(change.Name, "/")
+
+	switch change.Kind {
+	case "policy":
+		if change.Action == ConfigChangeDelete {
+			return c.DeletePolicy(name)
+		}
+		return c.PutPolicy(name, desired.Policies[change.Name])
+
+	case "mount", "auth":
+		var mount *ConfigSnapshotMount
+		if change.Kind == "mount" {
+			mount = desired.Mounts[change.Name]
+		} else {
+			mount = desired.Auth[change.Name]
+		}
+
+		switch change.Action {
+		case ConfigChangeDelete:
+			if change.Kind == "mount" {
+				return c.Unmount(name)
+			}
+			return c.DisableAuth(name)
+
+		case ConfigChangeCreate:
+			input := &MountInput{
+				Type:        mount.Type,
+				Description: mount.Description,
+				Config:      mount.Config,
+				Local:       mount.Local,
+				SealWrap:    mount.SealWrap,
+				Options:     mount.Options,
+			}
+			if change.Kind == "mount" {
+				return c.Mount(name, input)
+			}
+			return c.EnableAuthWithOptions(name, input)
+
+		default:
+			config := mount.Config
+			description := mount.Description
+			config.Description = &description
+			config.Options = mount.Options
+			if change.Kind == "auth" {
+				name = "auth/" + name
+			}
+			return c.TuneMount(name, config)
+		}
+
+	case "quota":
+		path := "sys/quotas/rate-limit/" + name
+		if change.Action == ConfigChangeDelete {
+			_, err := c.c.Logical().Delete(path)
+			return err
+		}
+		_, err := c.c.Logical().Write(path, desired.Quotas[change.Name])
+		return err
+	}
+
+	return fmt.Errorf("unknown configuration kind %q", change.Kind)
+}
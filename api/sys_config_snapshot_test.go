@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiffConfig(t *testing.T) {
+	current := &ConfigSnapshot{
+		Mounts: map[string]*ConfigSnapshotMount{
+			"kv/":  {Type: "kv", Options: map[string]string{}},
+			"old/": {Type: "kv"},
+		},
+		Policies: map[string]string{
+			"default": "path \"a\" {}\n",
+			"stale":   "path \"b\" {}",
+		},
+		Quotas: map[string]map[string]interface{}{
+			"global": {"rate": json.Number("100")},
+		},
+	}
+	desired := &ConfigSnapshot{
+		Mounts: map[string]*ConfigSnapshotMount{
+			"kv/":  {Type: "kv"},
+			"pki/": {Type: "pki"},
+		},
+		Auth: map[string]*ConfigSnapshotMount{
+			"approle/": {Type: "approle"},
+		},
+		Policies: map[string]string{
+			"default": "path \"a\" {}",
+		},
+		Quotas: map[string]map[string]interface{}{
+			"global": {"rate": float64(200)},
+		},
+	}
+
+	expected := []ConfigChange{
+		{Kind: "mount", Name: "pki/", Action: ConfigChangeCreate},
+		{Kind: "auth", Name: "approle/", Action: ConfigChangeCreate},
+		{Kind: "quota", Name: "global", Action: ConfigChangeUpdate},
+		{Kind: "mount", Name: "old/", Action: ConfigChangeDelete},
+		{Kind: "policy", Name: "stale", Action: ConfigChangeDelete},
+	}
+	if changes := DiffConfig(current, desired); !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("bad changes:\nexpected: %v\ngot:      %v", expected, changes)
+	}
+
+	if changes := DiffConfig(desired, desired); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+}
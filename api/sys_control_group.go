@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ControlGroupRequest identifies a request held by a control group. The
+// original response is released as a wrapped response once the request has
+// been authorized.
+type ControlGroupRequest struct {
+	Accessor     string
+	Token        string
+	CreationPath string
+	CreationTime time.Time
+	TTL          time.Duration
+}
+
+// ControlGroup returns the control group request of a response that a
+// control group is holding for approval, or nil if the response is not
+// wrapped. Control group responses are wrapped responses, so clients that
+// request response wrapping themselves cannot tell the two apart.
+func (s *Secret) ControlGroup() *ControlGroupRequest {
+	if s == nil || s.WrapInfo == nil || s.WrapInfo.Accessor == "" {
+		return nil
+	}
+
+	return &ControlGroupRequest{
+		Accessor:     s.WrapInfo.Accessor,
+		Token:        s.WrapInfo.Token,
+		CreationPath: s.WrapInfo.CreationPath,
+		CreationTime: s.WrapInfo.CreationTime,
+		TTL:          time.Duration(s.WrapInfo.TTL) * time.Second,
+	}
+}
+
+// ControlGroupStatus is the approval status of a control group request.
+type ControlGroupStatus struct {
+	Approved       bool                         `mapstructure:"approved"`
+	RequestPath    string                       `mapstructure:"request_path"`
+	RequestEntity  *ControlGroupRequestEntity   `mapstructure:"request_entity"`
+	Authorizations []*ControlGroupAuthorization `mapstructure:"authorizations"`
+}
+
+// ControlGroupRequestEntity is the entity that made a control group request.
+type ControlGroupRequestEntity struct {
+	ID   string `mapstructure:"id"`
+	Name string `mapstructure:"name"`
+}
+
+// ControlGroupAuthorization is an authorization of a control group request.
+type ControlGroupAuthorization struct {
+	EntityID   string `mapstructure:"entity_id"`
+	EntityName string `mapstructure:"entity_name"`
+}
+
+// ControlGroupAuthorize authorizes the control group request with the given
+// accessor as the client's entity.
+func (c *Sys) ControlGroupAuthorize(accessor string) (*ControlGroupStatus, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	return c.controlGroupRequest(ctx, "/v1/sys/control-group/authorize", accessor)
+}
+
+// ControlGroupStatus returns the approval status of the control group
+// request with the given accessor.
+func (c *Sys) ControlGroupStatus(accessor string) (*ControlGroupStatus, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	return c.controlGroupRequest(ctx, "/v1/sys/control-group/request", accessor)
+}
+
+// WaitForControlGroup polls the status of the given control group request at
+// the given interval until it is approved, then unwraps and returns the
+// original response. It returns the context's error if the context is done
+// before the request is approved.
+func (c *Sys) WaitForControlGroup(ctx context.Context, request *ControlGroupRequest, interval time.Duration) (*Secret, error) {
+	if request == nil {
+		return nil, errors.New("nil control group request")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := c.controlGroupRequest(ctx, "/v1/sys/control-group/request", request.Accessor)
+		if err != nil {
+			return nil, err
+		}
+		if status.Approved {
+			return c.c.Logical().Unwrap(request.Token)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Sys) controlGroupRequest(ctx context.Context, path, accessor string) (*ControlGroupStatus, error) {
+	r := c.c.NewRequest("POST", path)
+	if err := r.SetJSONBody(map[string]interface{}{
+		"accessor": accessor,
+	}); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result ControlGroupStatus
+	if err := secret.DecodeData(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSysWaitForControlGroup(t *testing.T) {
+	var polls int
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/secret/foo":
+			w.Write([]byte(`{"wrap_info": {"token": "wrapping-token", "accessor": "cg-accessor", "ttl": 86400, "creation_path": "secret/foo"}}`))
+		case "/v1/sys/control-group/request":
+			var body map[string]string
+			json.NewDecoder(req.Body).Decode(&body)
+			if body["accessor"] != "cg-accessor" {
+				t.Errorf("unexpected accessor %q", body["accessor"])
+			}
+			polls++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"approved":       polls == 2,
+					"request_path":   "secret/foo",
+					"request_entity": map[string]string{"id": "e1", "name": "alice"},
+				},
+			})
+		case "/v1/sys/wrapping/unwrap":
+			var body map[string]string
+			json.NewDecoder(req.Body).Decode(&body)
+			if body["token"] != "wrapping-token" {
+				t.Errorf("unexpected wrapping token %q", body["token"])
+			}
+			w.Write([]byte(`{"data": {"value": "bar"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("requester-token")
+
+	secret, err := client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := secret.ControlGroup()
+	if request == nil || request.Accessor != "cg-accessor" || request.TTL != 24*time.Hour {
+		t.Fatalf("unexpected control group request: %#v", request)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	secret, err = client.Sys().WaitForControlGroup(ctx, request, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["value"] != "bar" || polls != 2 {
+		t.Fatalf("unexpected result %#v after %d polls", secret, polls)
+	}
+}
@@ -1,6 +1,15 @@
 package api
 
-import "context"
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/helper/base62"
+)
 
 func (c *Sys) GenerateRootStatus() (*GenerateRootStatusResponse, error) {
 	return c.generateRootStatusCommon("/v1/sys/generate-root/attempt")
@@ -126,6 +135,123 @@ func (c *Sys) generateRootUpdateCommon(path, shard, nonce string) (*GenerateRoot
 	return &result, err
 }
 
+// GenerateRoot generates a root token from the given unseal or recovery key
+// shares. It starts a new attempt with a freshly generated one-time password,
+// submits the shares, and returns the decoded token once enough shares have
+// been provided. The attempt is cancelled if it fails.
+func (c *Sys) GenerateRoot(keyShares []string) (string, error) {
+	return c.generateOperationTokenCommon("/v1/sys/generate-root/attempt", "/v1/sys/generate-root/update", keyShares)
+}
+
+// GenerateRecoveryOperationToken generates a recovery operation token from
+// the given recovery key shares. See GenerateRoot.
+func (c *Sys) GenerateRecoveryOperationToken(keyShares []string) (string, error) {
+	return c.generateOperationTokenCommon("/v1/sys/generate-recovery-token/attempt", "/v1/sys/generate-recovery-token/update", keyShares)
+}
+
+func (c *Sys) generateOperationTokenCommon(attemptPath, updatePath string, keyShares []string) (string, error) {
+	status, err := c.generateRootStatusCommon(attemptPath)
+	if err != nil {
+		return "", err
+	}
+	if status.Started {
+		return "", errors.New("a token generation attempt is already in progress")
+	}
+
+	otp, err := GenerateOperationTokenOTP(status.OTPLength)
+	if err != nil {
+		return "", err
+	}
+	status, err = c.generateRootInitCommon(attemptPath, otp, "")
+	if err != nil {
+		return "", err
+	}
+
+	for _, share := range keyShares {
+		status, err = c.generateRootUpdateCommon(updatePath, share, status.Nonce)
+		if err != nil {
+			c.generateRootCancelCommon(attemptPath)
+			return "", err
+		}
+		if status.Complete {
+			break
+		}
+	}
+	if !status.Complete {
+		c.generateRootCancelCommon(attemptPath)
+		return "", fmt.Errorf("not enough key shares: %d of %d provided", status.Progress, status.Required)
+	}
+
+	encoded := status.EncodedToken
+	if encoded == "" {
+		encoded = status.EncodedRootToken
+	}
+	return DecodeOperationToken(encoded, otp, status.OTPLength)
+}
+
+// GenerateOperationTokenOTP generates a one-time password of the length
+// expected by the server, as reported in the OTPLength of the generation
+// status. A length of zero indicates an older server, which expects a base64
+// encoded 16 byte value.
+func GenerateOperationTokenOTP(length int) (string, error) {
+	if length > 0 {
+		return base62.Random(length)
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// DecodeOperationToken decodes a root, DR operation or recovery operation
+// token encoded with the given one-time password. otpLength is the OTP
+// length reported by the generation status, which determines the encoding.
+func DecodeOperationToken(encoded, otp string, otpLength int) (string, error) {
+	if otpLength == 0 {
+		// Older servers encode UUID tokens with a base64 encoded OTP
+		encodedBytes, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", errwrap.Wrapf("error decoding base64'd token: {{err}}", err)
+		}
+		otpBytes, err := base64.StdEncoding.DecodeString(otp)
+		if err != nil {
+			return "", errwrap.Wrapf("error decoding base64'd OTP: {{err}}", err)
+		}
+		tokenBytes, err := xorBytes(encodedBytes, otpBytes)
+		if err != nil {
+			return "", err
+		}
+		if len(tokenBytes) != 16 {
+			return "", fmt.Errorf("wrong token length: %d", len(tokenBytes))
+		}
+		return fmt.Sprintf("%x-%x-%x-%x-%x", tokenBytes[0:4], tokenBytes[4:6], tokenBytes[6:8], tokenBytes[8:10], tokenBytes[10:16]), nil
+	}
+
+	tokenBytes, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errwrap.Wrapf("error decoding base64'd token: {{err}}", err)
+	}
+	tokenBytes, err = xorBytes(tokenBytes, []byte(otp))
+	if err != nil {
+		return "", err
+	}
+	return string(tokenBytes), nil
+}
+
+func xorBytes(a, b []byte) ([]byte, error) {
+	if len(a) != len(b) {
+		return nil, fmt.Errorf("length of byte slices is not equivalent: %d != %d", len(a), len(b))
+	}
+
+	buf := make([]byte, len(a))
+	for i := range a {
+		buf[i] = a[i] ^ b[i]
+	}
+	return buf, nil
+}
+
 type GenerateRootStatusResponse struct {
 	Nonce            string `json:"nonce"`
 	Started          bool   `json:"started"`
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestGenerateOperationTokenOTP(t *testing.T) {
+	otp, err := GenerateOperationTokenOTP(26)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(otp) != 26 {
+		t.Fatalf("expected an OTP of length 26, got %q", otp)
+	}
+
+	otp, err = GenerateOperationTokenOTP(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := base64.StdEncoding.DecodeString(otp); err != nil || len(b) != 16 {
+		t.Fatalf("expected 16 base64 encoded bytes, got %q", otp)
+	}
+}
+
+func TestDecodeOperationTokenLegacy(t *testing.T) {
+	tokenBytes := []byte{0x6b, 0x4c, 0x9e, 0x1d, 0x2f, 0x33, 0x4a, 0x11, 0x8e, 0x27, 0x1b, 0x5d, 0x3c, 0x7f, 0x01, 0x99}
+	otpBytes := []byte("0123456789abcdef")
+	encoded, _ := xorBytes(tokenBytes, otpBytes)
+
+	token, err := DecodeOperationToken(base64.StdEncoding.EncodeToString(encoded), base64.StdEncoding.EncodeToString(otpBytes), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "6b4c9e1d-2f33-4a11-8e27-1b5d3c7f0199"; token != expected {
+		t.Fatalf("expected %q, got %q", expected, token)
+	}
+}
@@ -1,8 +1,23 @@
 package api
 
-import "context"
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultSealStateWatcherInterval is the default interval at which a
+// SealStateWatcher polls sys/health.
+const DefaultSealStateWatcherInterval = 5 * time.Second
 
 func (c *Sys) Health() (*HealthResponse, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	return c.healthWithContext(ctx)
+}
+
+func (c *Sys) healthWithContext(ctx context.Context) (*HealthResponse, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/health")
 	// If the code is 400 or above it will automatically turn into an error,
 	// but the sys/health API defaults to returning 5xx when not sealed or
@@ -13,8 +28,6 @@ func (c *Sys) Health() (*HealthResponse, error) {
 	r.Params.Add("drsecondarycode", "299")
 	r.Params.Add("performancestandbycode", "299")
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
-	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
@@ -39,3 +52,225 @@ type HealthResponse struct {
 	ClusterID                  string `json:"cluster_id,omitempty"`
 	LastWAL                    uint64 `json:"last_wal,omitempty"`
 }
+
+// SealState summarizes the state of a Vault node as reported by sys/health.
+type SealState struct {
+	Initialized        bool
+	Sealed             bool
+	Standby            bool
+	PerformanceStandby bool
+	DRSecondary        bool
+}
+
+// Active returns whether the node is the active node of its cluster.
+func (s *SealState) Active() bool {
+	return s.Initialized && !s.Sealed && !s.Standby
+}
+
+func (s *SealState) String() string {
+	switch {
+	case !s.Initialized:
+		return "uninitialized"
+	case s.Sealed:
+		return "sealed"
+	case s.PerformanceStandby:
+		return "performance standby"
+	case s.Standby:
+		return "standby"
+	case s.DRSecondary:
+		return "active (DR secondary)"
+	default:
+		return "active"
+	}
+}
+
+// sealStateFromStatusCode maps the default status codes of sys/health to the
+// state they stand for, for servers or proxies that do not honor the code
+// overrides sent by healthWithContext.
+func sealStateFromStatusCode(code int) (*SealState, bool) {
+	switch code {
+	case 200:
+		return &SealState{Initialized: true}, true
+	case 429:
+		return &SealState{Initialized: true, Standby: true}, true
+	case 472:
+		return &SealState{Initialized: true, DRSecondary: true}, true
+	case 473:
+		return &SealState{Initialized: true, Standby: true, PerformanceStandby: true}, true
+	case 501:
+		return &SealState{Sealed: true}, true
+	case 503:
+		return &SealState{Initialized: true, Sealed: true}, true
+	}
+	return nil, false
+}
+
+func (c *Sys) sealStateWithContext(ctx context.Context) (*SealState, error) {
+	health, err := c.healthWithContext(ctx)
+	if err != nil {
+		if respErr, ok := err.(*ResponseError); ok {
+			if state, ok := sealStateFromStatusCode(respErr.StatusCode); ok {
+				return state, nil
+			}
+		}
+		return nil, err
+	}
+
+	return &SealState{
+		Initialized:        health.Initialized,
+		Sealed:             health.Sealed,
+		Standby:            health.Standby || health.PerformanceStandby,
+		PerformanceStandby: health.PerformanceStandby,
+		DRSecondary:        health.ReplicationDRMode == "secondary",
+	}, nil
+}
+
+// WaitForActive polls sys/health at the given interval until the node is the
+// active node of its cluster, or the context is done. Errors reading the
+// health of the node, for instance while it is restarting, are retried.
+func (c *Sys) WaitForActive(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		state, err := c.sealStateWithContext(ctx)
+		if err == nil && state.Active() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// SealStateTransition is published by a SealStateWatcher when the state of
+// the node changes. From is nil for the first state observed.
+type SealStateTransition struct {
+	From *SealState
+	To   *SealState
+	Time time.Time
+}
+
+// SealStateWatcherInput is used as input to NewSealStateWatcher.
+type SealStateWatcherInput struct {
+	// Interval is how often sys/health is polled.
+	Interval time.Duration
+
+	// OnError is called with errors encountered while polling, which are
+	// otherwise ignored.
+	OnError func(error)
+}
+
+// SealStateWatcher polls sys/health and publishes transitions of the state of
+// the node, such as sealed to unsealed or standby to active.
+//
+//	watcher, err := client.Sys().NewSealStateWatcher(nil)
+//	go watcher.Start()
+//	defer watcher.Stop()
+//
+//	for transition := range watcher.TransitionCh() {
+//		log.Printf("vault is now %s", transition.To)
+//	}
+type SealStateWatcher struct {
+	l sync.Mutex
+
+	sys          *Sys
+	interval     time.Duration
+	onError      func(error)
+	transitionCh chan *SealStateTransition
+
+	stopped bool
+	stopCh  chan struct{}
+}
+
+// NewSealStateWatcher creates a new seal state watcher from the given input,
+// which may be nil to use the defaults.
+func (c *Sys) NewSealStateWatcher(i *SealStateWatcherInput) (*SealStateWatcher, error) {
+	if i == nil {
+		i = &SealStateWatcherInput{}
+	}
+
+	interval := i.Interval
+	if interval <= 0 {
+		interval = DefaultSealStateWatcherInterval
+	}
+
+	return &SealStateWatcher{
+		sys:          c,
+		interval:     interval,
+		onError:      i.OnError,
+		transitionCh: make(chan *SealStateTransition, 1),
+		stopCh:       make(chan struct{}),
+	}, nil
+}
+
+// TransitionCh returns the channel where the watcher publishes transitions.
+// It is closed when the watcher stops.
+func (w *SealStateWatcher) TransitionCh() <-chan *SealStateTransition {
+	return w.transitionCh
+}
+
+// Stop stops the watcher.
+func (w *SealStateWatcher) Stop() {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	if !w.stopped {
+		close(w.stopCh)
+		w.stopped = true
+	}
+}
+
+// Start polls sys/health until the watcher is stopped. It blocks, so it
+// should usually be run in a goroutine.
+func (w *SealStateWatcher) Start() {
+	defer close(w.transitionCh)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	go func() {
+		select {
+		case <-w.stopCh:
+			cancelFunc()
+		case <-w.sys.c.closedCh():
+			w.Stop()
+			cancelFunc()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	var current *SealState
+	for {
+		state, err := w.sys.sealStateWithContext(ctx)
+		switch {
+		case err != nil:
+			if w.onError != nil && ctx.Err() == nil {
+				w.onError(err)
+			}
+		case current == nil || *state != *current:
+			transition := &SealStateTransition{
+				From: current,
+				To:   state,
+				Time: time.Now(),
+			}
+			select {
+			case w.transitionCh <- transition:
+			case <-w.stopCh:
+				return
+			}
+			current = state
+		}
+
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// testHealthServer serves the given sequence of sys/health states, repeating
+// the last one. A state with a nil response is served as the bare status
+// code, as a server ignoring the code overrides would.
+func testHealthServer(t *testing.T, states []func(w http.ResponseWriter)) (*Config, func()) {
+	var calls int
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/sys/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		i := calls
+		if i >= len(states) {
+			i = len(states) - 1
+		}
+		calls++
+		states[i](w)
+	}))
+	return config, func() { ln.Close() }
+}
+
+func healthCode(code int) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(code)
+	}
+}
+
+func healthBody(health *HealthResponse) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		json.NewEncoder(w).Encode(health)
+	}
+}
+
+func TestSysWaitForActive(t *testing.T) {
+	config, closer := testHealthServer(t, []func(w http.ResponseWriter){
+		healthCode(473),
+		healthBody(&HealthResponse{Initialized: true, Standby: true}),
+		healthBody(&HealthResponse{Initialized: true}),
+	})
+	defer closer()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Sys().WaitForActive(ctx, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSysSealStateWatcher(t *testing.T) {
+	config, closer := testHealthServer(t, []func(w http.ResponseWriter){
+		healthBody(&HealthResponse{Initialized: true, Sealed: true}),
+		healthBody(&HealthResponse{Initialized: true, Sealed: true}),
+		func(w http.ResponseWriter) {
+			w.WriteHeader(429)
+			json.NewEncoder(w).Encode(&HealthResponse{Initialized: true, Standby: true})
+		},
+		healthCode(472),
+	})
+	defer closer()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := client.Sys().NewSealStateWatcher(&SealStateWatcherInput{
+		Interval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go watcher.Start()
+	defer watcher.Stop()
+
+	expected := []string{"sealed", "standby", "active (DR secondary)"}
+	for i, state := range expected {
+		select {
+		case transition := <-watcher.TransitionCh():
+			if transition.To.String() != state {
+				t.Fatalf("transition %d: expected %q, got %q", i, state, transition.To)
+			}
+			if (i == 0) != (transition.From == nil) {
+				t.Fatalf("transition %d: unexpected from state %v", i, transition.From)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for transition to %q", state)
+		}
+	}
+
+	watcher.Stop()
+	select {
+	case transition, ok := <-watcher.TransitionCh():
+		if ok {
+			t.Fatalf("unexpected transition after stop: %v", transition)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for watcher to stop")
+	}
+}
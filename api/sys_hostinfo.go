@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// HostInfo returns information about the host the server runs on. Sections
+// the server failed to collect are left empty.
+func (c *Sys) HostInfo() (*HostInfoResponse, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	return c.hostInfoWithContext(ctx)
+}
+
+func (c *Sys) hostInfoWithContext(ctx context.Context) (*HostInfoResponse, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, "sys/host-info", nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result HostInfoResponse
+	err = secret.DecodeData(&result)
+	return &result, err
+}
+
+type HostInfoResponse struct {
+	Timestamp time.Time         `mapstructure:"timestamp"`
+	Host      *HostInfoHost     `mapstructure:"host"`
+	Memory    *HostInfoMemory   `mapstructure:"memory"`
+	CPU       []HostInfoCPU     `mapstructure:"cpu"`
+	CPUTimes  []HostInfoCPUTime `mapstructure:"cpu_times"`
+	Disk      []HostInfoDisk    `mapstructure:"disk"`
+}
+
+type HostInfoHost struct {
+	Hostname        string `mapstructure:"hostname"`
+	HostID          string `mapstructure:"hostid"`
+	OS              string `mapstructure:"os"`
+	Platform        string `mapstructure:"platform"`
+	PlatformFamily  string `mapstructure:"platformFamily"`
+	PlatformVersion string `mapstructure:"platformVersion"`
+	KernelVersion   string `mapstructure:"kernelVersion"`
+	Uptime          uint64 `mapstructure:"uptime"`
+	BootTime        uint64 `mapstructure:"bootTime"`
+	Procs           uint64 `mapstructure:"procs"`
+}
+
+type HostInfoMemory struct {
+	Total       uint64  `mapstructure:"total"`
+	Available   uint64  `mapstructure:"available"`
+	Used        uint64  `mapstructure:"used"`
+	Free        uint64  `mapstructure:"free"`
+	UsedPercent float64 `mapstructure:"usedPercent"`
+}
+
+type HostInfoCPU struct {
+	CPU       int32   `mapstructure:"cpu"`
+	VendorID  string  `mapstructure:"vendorId"`
+	ModelName string  `mapstructure:"modelName"`
+	Cores     int32   `mapstructure:"cores"`
+	Mhz       float64 `mapstructure:"mhz"`
+}
+
+type HostInfoCPUTime struct {
+	CPU    string  `mapstructure:"cpu"`
+	User   float64 `mapstructure:"user"`
+	System float64 `mapstructure:"system"`
+	Idle   float64 `mapstructure:"idle"`
+	Iowait float64 `mapstructure:"iowait"`
+}
+
+type HostInfoDisk struct {
+	Path        string  `mapstructure:"path"`
+	Fstype      string  `mapstructure:"fstype"`
+	Total       uint64  `mapstructure:"total"`
+	Free        uint64  `mapstructure:"free"`
+	Used        uint64  `mapstructure:"used"`
+	UsedPercent float64 `mapstructure:"usedPercent"`
+}
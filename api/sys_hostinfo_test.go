@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSysHostInfo(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"data": {
+			"timestamp": "2020-01-01T00:00:00Z",
+			"host": {"hostname": "vault-0", "os": "linux", "platformFamily": "debian", "uptime": 3600, "procs": 120},
+			"memory": {"total": 2048, "used": 1024, "usedPercent": 50},
+			"cpu": [{"cpu": 0, "modelName": "Xeon", "cores": 4, "mhz": 2400}],
+			"cpu_times": [{"cpu": "cpu0", "user": 1.5, "idle": 10}],
+			"disk": [{"path": "/", "fstype": "ext4", "total": 100, "used": 25, "usedPercent": 25}]
+		}}`))
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := client.Sys().HostInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Timestamp.Year() != 2020 || info.Host.Hostname != "vault-0" || info.Host.PlatformFamily != "debian" || info.Host.Uptime != 3600 {
+		t.Fatalf("unexpected host %#v", info.Host)
+	}
+	if info.Memory.UsedPercent != 50 || info.CPU[0].Cores != 4 || info.CPUTimes[0].User != 1.5 || info.Disk[0].Fstype != "ext4" {
+		t.Fatalf("unexpected host info %#v", info)
+	}
+}
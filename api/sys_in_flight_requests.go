@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// InFlightRequest is a request being processed by the server.
+type InFlightRequest struct {
+	ID               string    `json:"-"`
+	StartTime        time.Time `json:"start_time"`
+	ClientRemoteAddr string    `json:"client_remote_address"`
+	RequestPath      string    `json:"request_path"`
+	RequestMethod    string    `json:"request_method"`
+	ClientID         string    `json:"client_id"`
+}
+
+// InFlightRequests returns the requests being processed by the node the
+// client is talking to, oldest first, which shows what is stuck when the
+// server becomes unresponsive. Only unauthenticated access to the endpoint
+// in the listener configuration allows calling it without a token.
+func (c *Sys) InFlightRequests() ([]*InFlightRequest, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	return c.inFlightRequestsWithContext(ctx)
+}
+
+func (c *Sys) inFlightRequestsWithContext(ctx context.Context) ([]*InFlightRequest, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/in-flight-req")
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The requests are keyed by their ID
+	var byID map[string]*InFlightRequest
+	if err := resp.DecodeJSON(&byID); err != nil {
+		return nil, err
+	}
+
+	result := make([]*InFlightRequest, 0, len(byID))
+	for id, req := range byID {
+		if req == nil {
+			continue
+		}
+		req.ID = id
+		result = append(result, req)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].StartTime.Equal(result[j].StartTime) {
+			return result[i].StartTime.Before(result[j].StartTime)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSysInFlightRequests(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/sys/in-flight-req" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{
+			"req-2": {"start_time": "2020-01-01T00:00:05Z", "client_remote_address": "10.0.0.2:4321", "request_path": "/v1/sys/in-flight-req", "request_method": "GET", "client_id": ""},
+			"req-1": {"start_time": "2020-01-01T00:00:00Z", "client_remote_address": "10.0.0.1:1234", "request_path": "/v1/secret/data/app", "request_method": "PUT", "client_id": "client-1"}
+		}`))
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqs, err := client.Sys().InFlightRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(reqs))
+	}
+	if reqs[0].ID != "req-1" || reqs[0].ClientRemoteAddr != "10.0.0.1:1234" || reqs[0].RequestPath != "/v1/secret/data/app" || reqs[0].RequestMethod != "PUT" || reqs[0].ClientID != "client-1" {
+		t.Fatalf("unexpected first request %#v", reqs[0])
+	}
+	if reqs[1].ID != "req-2" || reqs[1].StartTime.Second() != 5 {
+		t.Fatalf("unexpected second request %#v", reqs[1])
+	}
+}
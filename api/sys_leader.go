@@ -3,10 +3,15 @@ package api
 import "context"
 
 func (c *Sys) Leader() (*LeaderResponse, error) {
-	r := c.c.NewRequest("GET", "/v1/sys/leader")
-
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
+
+	return c.leaderWithContext(ctx)
+}
+
+func (c *Sys) leaderWithContext(ctx context.Context) (*LeaderResponse, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/leader")
+
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
 		return nil, err
@@ -3,6 +3,9 @@ package api
 import (
 	"context"
 	"errors"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
 )
 
 func (c *Sys) Renew(id string, increment int) (*Secret, error) {
@@ -109,3 +112,78 @@ type RevokeOptions struct {
 	Prefix  bool
 	Sync    bool
 }
+
+// LeaseInfo describes a lease, as returned by Lookup.
+type LeaseInfo struct {
+	ID              string     `mapstructure:"id"`
+	IssueTime       time.Time  `mapstructure:"issue_time"`
+	ExpireTime      *time.Time `mapstructure:"expire_time"`
+	LastRenewalTime *time.Time `mapstructure:"last_renewal"`
+	Renewable       bool       `mapstructure:"renewable"`
+	TTL             int        `mapstructure:"ttl"`
+}
+
+// Lookup returns information about the lease with the given ID.
+func (c *Sys) Lookup(id string) (*LeaseInfo, error) {
+	r := c.c.NewRequest("PUT", "/v1/sys/leases/lookup")
+	body := map[string]interface{}{
+		"lease_id": id,
+	}
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result LeaseInfo
+	if err := secret.DecodeData(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListLeases returns the IDs of the leases, and the prefixes containing
+// further leases, directly under the given prefix.
+func (c *Sys) ListLeases(prefix string) ([]string, error) {
+	secret, err := c.c.Logical().List("sys/leases/lookup/" + prefix)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var keys []string
+	if err := mapstructure.Decode(secret.Data["keys"], &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Tidy cleans up the dangling storage entries of leases. The operation runs
+// in the background on the server.
+func (c *Sys) Tidy() error {
+	r := c.c.NewRequest("PUT", "/v1/sys/leases/tidy")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
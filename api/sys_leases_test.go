@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSysLookup(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"data":{"id":"database/creds/readonly/abc","issue_time":"2020-05-01T10:00:00.5Z","expire_time":"2020-05-01T11:00:00.5Z","last_renewal":null,"renewable":true,"ttl":3599}}`))
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lease, err := client.Sys().Lookup("database/creds/readonly/abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued := time.Date(2020, 5, 1, 10, 0, 0, 5e8, time.UTC)
+	if lease.ID != "database/creds/readonly/abc" || !lease.IssueTime.Equal(issued) || !lease.Renewable || lease.TTL != 3599 {
+		t.Fatalf("bad lease: %#v", lease)
+	}
+	if lease.ExpireTime == nil || !lease.ExpireTime.Equal(issued.Add(time.Hour)) {
+		t.Fatalf("bad expire time: %v", lease.ExpireTime)
+	}
+	if lease.LastRenewalTime != nil {
+		t.Fatalf("expected no last renewal time, got %v", lease.LastRenewalTime)
+	}
+}
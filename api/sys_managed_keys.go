@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// ManagedKey is a key held by an external key store, such as an HSM or a
+// cloud KMS, which secrets engines use through the managed key
+// configuration. Config holds the parameters specific to the type of key
+// store, e.g. the library and slot of a PKCS#11 key.
+type ManagedKey struct {
+	Name             string                 `mapstructure:"name"`
+	Type             string                 `mapstructure:"type"`
+	AllowGenerateKey bool                   `mapstructure:"allow_generate_key"`
+	AllowReplaceKey  bool                   `mapstructure:"allow_replace_key"`
+	AllowStoreKey    bool                   `mapstructure:"allow_store_key"`
+	AnyMount         bool                   `mapstructure:"any_mount"`
+	Usages           []string               `mapstructure:"usages"`
+	Config           map[string]interface{} `mapstructure:",remain"`
+}
+
+// ManagedKeyInput are the parameters for creating or updating a managed
+// key. Config holds the parameters specific to the type of key store, and
+// is sent as is.
+type ManagedKeyInput struct {
+	AllowGenerateKey bool
+	AllowReplaceKey  bool
+	AllowStoreKey    bool
+	AnyMount         bool
+	Usages           []string
+	Config           map[string]interface{}
+}
+
+func (i *ManagedKeyInput) body() map[string]interface{} {
+	body := make(map[string]interface{}, len(i.Config)+5)
+	for k, v := range i.Config {
+		body[k] = v
+	}
+	body["allow_generate_key"] = i.AllowGenerateKey
+	body["allow_replace_key"] = i.AllowReplaceKey
+	body["allow_store_key"] = i.AllowStoreKey
+	body["any_mount"] = i.AnyMount
+	if i.Usages != nil {
+		body["usages"] = i.Usages
+	}
+	return body
+}
+
+// CreateManagedKey creates or updates the named managed key of the given
+// type of key store, e.g. "pkcs11", "awskms", "azurekeyvault" or "gcpckms".
+func (c *Sys) CreateManagedKey(ctx context.Context, keyType, name string, input *ManagedKeyInput) error {
+	_, err := c.c.Logical().Do(ctx, "POST", managedKeyPath(keyType, name), input.body())
+	return err
+}
+
+// ReadManagedKey returns the named managed key, or nil if it does not exist.
+// Secret parameters, such as PINs and credentials, are redacted by the
+// server.
+func (c *Sys) ReadManagedKey(ctx context.Context, keyType, name string) (*ManagedKey, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, managedKeyPath(keyType, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var key ManagedKey
+	if err := secret.DecodeData(&key); err != nil {
+		return nil, err
+	}
+	if key.Name == "" {
+		key.Name = name
+	}
+	if key.Type == "" {
+		key.Type = keyType
+	}
+	return &key, nil
+}
+
+// ListManagedKeys returns the names of the managed keys of the given type
+// of key store.
+func (c *Sys) ListManagedKeys(ctx context.Context, keyType string) ([]string, error) {
+	secret, err := c.c.Logical().listWithContext(ctx, "sys/managed-keys/"+keyType, nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var keys []string
+	if err := mapstructure.Decode(secret.Data["keys"], &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// DeleteManagedKey deletes the named managed key. The key itself is left in
+// the key store.
+func (c *Sys) DeleteManagedKey(ctx context.Context, keyType, name string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", managedKeyPath(keyType, name), nil)
+	return err
+}
+
+// TestManagedKey checks that the named managed key is usable, by signing and
+// verifying data with it in the key store. hashAlgorithm is optional.
+func (c *Sys) TestManagedKey(ctx context.Context, keyType, name, hashAlgorithm string) error {
+	body := map[string]interface{}{}
+	if hashAlgorithm != "" {
+		body["hash_algorithm"] = hashAlgorithm
+	}
+	_, err := c.c.Logical().Do(ctx, "POST", managedKeyPath(keyType, name)+"/test/sign", body)
+	return err
+}
+
+func managedKeyPath(keyType, name string) string {
+	return fmt.Sprintf("sys/managed-keys/%s/%s", keyType, name)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSysManagedKeys(t *testing.T) {
+	var written, tested map[string]interface{}
+	deleted := false
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/v1/sys/managed-keys/pkcs11/hsm-key" && req.Method == http.MethodPost:
+			json.NewDecoder(req.Body).Decode(&written)
+			w.WriteHeader(http.StatusNoContent)
+		case req.URL.Path == "/v1/sys/managed-keys/pkcs11/hsm-key" && req.Method == http.MethodGet:
+			w.Write([]byte(`{"data": {"library": "hsm", "slot": "0", "pin": "redacted", "key_label": "vault", "allow_generate_key": true, "any_mount": false, "usages": ["sign", "verify"]}}`))
+		case req.URL.Path == "/v1/sys/managed-keys/pkcs11/hsm-key" && req.Method == http.MethodDelete:
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		case req.URL.Path == "/v1/sys/managed-keys/pkcs11/hsm-key/test/sign":
+			json.NewDecoder(req.Body).Decode(&tested)
+			w.WriteHeader(http.StatusNoContent)
+		case req.URL.Path == "/v1/sys/managed-keys/pkcs11" && req.URL.Query().Get("list") == "true":
+			w.Write([]byte(`{"data": {"keys": ["hsm-key"]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	sys := client.Sys()
+
+	err = sys.CreateManagedKey(ctx, "pkcs11", "hsm-key", &ManagedKeyInput{
+		AllowGenerateKey: true,
+		Usages:           []string{"sign", "verify"},
+		Config:           map[string]interface{}{"library": "hsm", "slot": "0", "pin": "1234"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written["library"] != "hsm" || written["pin"] != "1234" || written["allow_generate_key"] != true || written["any_mount"] != false {
+		t.Fatalf("unexpected body %#v", written)
+	}
+
+	key, err := sys.ReadManagedKey(ctx, "pkcs11", "hsm-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Name != "hsm-key" || key.Type != "pkcs11" || !key.AllowGenerateKey || len(key.Usages) != 2 {
+		t.Fatalf("unexpected key %#v", key)
+	}
+	if key.Config["key_label"] != "vault" || key.Config["pin"] != "redacted" || key.Config["usages"] != nil {
+		t.Fatalf("unexpected config %#v", key.Config)
+	}
+
+	missing, err := sys.ReadManagedKey(ctx, "pkcs11", "missing")
+	if err != nil || missing != nil {
+		t.Fatalf("expected no key, got %#v, %v", missing, err)
+	}
+
+	keys, err := sys.ListManagedKeys(ctx, "pkcs11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "hsm-key" {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+
+	if err := sys.TestManagedKey(ctx, "pkcs11", "hsm-key", "sha2-256"); err != nil {
+		t.Fatal(err)
+	}
+	if tested["hash_algorithm"] != "sha2-256" {
+		t.Fatalf("unexpected test body %#v", tested)
+	}
+
+	if err := sys.DeleteManagedKey(ctx, "pkcs11", "hsm-key"); err != nil {
+		t.Fatal(err)
+	}
+	if !deleted {
+		t.Fatal("expected key to be deleted")
+	}
+}
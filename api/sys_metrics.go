@@ -0,0 +1,276 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// MetricsFormat is the format of the telemetry returned by Sys().Metrics.
+type MetricsFormat string
+
+const (
+	// MetricsFormatJSON returns the in-memory telemetry summary of the server
+	// as JSON, decoded into a MetricsSummary.
+	MetricsFormatJSON MetricsFormat = ""
+
+	// MetricsFormatPrometheus returns the telemetry in the Prometheus text
+	// exposition format. The server must have Prometheus retention enabled.
+	MetricsFormatPrometheus MetricsFormat = "prometheus"
+)
+
+// MetricsResponse is the telemetry returned by the server. Summary is set
+// for the JSON format, and Prometheus for the Prometheus format.
+type MetricsResponse struct {
+	Summary    *MetricsSummary
+	Prometheus []byte
+}
+
+// MetricsSummary is the in-memory telemetry summary of the server, covering
+// the current aggregation interval.
+type MetricsSummary struct {
+	Timestamp string          `json:"Timestamp"`
+	Gauges    []MetricsGauge  `json:"Gauges"`
+	Points    []MetricsPoint  `json:"Points"`
+	Counters  []MetricsSample `json:"Counters"`
+	Samples   []MetricsSample `json:"Samples"`
+}
+
+// MetricsGauge is the last value of a gauge.
+type MetricsGauge struct {
+	Name   string            `json:"Name"`
+	Value  float64           `json:"Value"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// MetricsPoint holds the values emitted for a key.
+type MetricsPoint struct {
+	Name   string    `json:"Name"`
+	Points []float64 `json:"Points"`
+}
+
+// MetricsSample aggregates the values of a counter or timer over the
+// interval.
+type MetricsSample struct {
+	Name   string            `json:"Name"`
+	Count  int               `json:"Count"`
+	Rate   float64           `json:"Rate"`
+	Sum    float64           `json:"Sum"`
+	Min    float64           `json:"Min"`
+	Max    float64           `json:"Max"`
+	Mean   float64           `json:"Mean"`
+	Stddev float64           `json:"Stddev"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// PrometheusMetricFamily is a metric in the Prometheus text format, with the
+// samples of all its label sets. The samples of summaries and histograms
+// include their _sum, _count and _bucket series.
+type PrometheusMetricFamily struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []PrometheusSample
+}
+
+// PrometheusSample is a single series value of a metric family.
+type PrometheusSample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// Metrics returns the telemetry of the server in the given format. The
+// request is authenticated with the client's token like any other, so
+// monitoring bridges do not need a separate HTTP client.
+func (c *Sys) Metrics(ctx context.Context, format MetricsFormat) (*MetricsResponse, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/metrics")
+	if format != MetricsFormatJSON {
+		r.Params.Set("format", string(format))
+	}
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if format == MetricsFormatPrometheus {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &MetricsResponse{Prometheus: body}, nil
+	}
+
+	var summary MetricsSummary
+	if err := resp.DecodeJSON(&summary); err != nil {
+		return nil, errwrap.Wrapf("error decoding metrics: {{err}}", err)
+	}
+	return &MetricsResponse{Summary: &summary}, nil
+}
+
+// PrometheusFamilies parses the Prometheus telemetry of the response into
+// metric families, in the order they appear.
+func (m *MetricsResponse) PrometheusFamilies() ([]*PrometheusMetricFamily, error) {
+	if m.Prometheus == nil {
+		return nil, fmt.Errorf("no Prometheus metrics in the response")
+	}
+	return ParsePrometheusMetrics(m.Prometheus)
+}
+
+// ParsePrometheusMetrics parses metrics in the Prometheus text exposition
+// format. Samples without a preceding TYPE line form untyped families of
+// their own.
+func ParsePrometheusMetrics(text []byte) ([]*PrometheusMetricFamily, error) {
+	var families []*PrometheusMetricFamily
+	byName := make(map[string]*PrometheusMetricFamily)
+	family := func(name string) *PrometheusMetricFamily {
+		f, ok := byName[name]
+		if !ok {
+			f = &PrometheusMetricFamily{Name: name, Type: "untyped"}
+			byName[name] = f
+			families = append(families, f)
+		}
+		return f
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			fields := strings.SplitN(strings.TrimSpace(line[1:]), " ", 3)
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[0] {
+			case "HELP":
+				family(fields[1]).Help = fields[2]
+			case "TYPE":
+				family(fields[1]).Type = fields[2]
+			}
+			continue
+		}
+
+		sample, err := parsePrometheusSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing line %d: %v", lineNum, err)
+		}
+		f, ok := byName[prometheusFamilyName(sample.Name, byName)]
+		if !ok {
+			f = family(sample.Name)
+		}
+		f.Samples = append(f.Samples, *sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return families, nil
+}
+
+// prometheusFamilyName returns the name of the family a series belongs to,
+// which differs from the series name for the sum, count and buckets of
+// summaries and histograms.
+func prometheusFamilyName(name string, families map[string]*PrometheusMetricFamily) string {
+	for _, suffix := range []string{"_sum", "_count", "_bucket"} {
+		base := strings.TrimSuffix(name, suffix)
+		if base == name {
+			continue
+		}
+		if f, ok := families[base]; ok && (f.Type == "summary" || f.Type == "histogram") {
+			return base
+		}
+	}
+	return name
+}
+
+func parsePrometheusSample(line string) (*PrometheusSample, error) {
+	sample := &PrometheusSample{Labels: make(map[string]string)}
+
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+	sample.Name = line[:end]
+	rest := line[end:]
+
+	if rest[0] == '{' {
+		var err error
+		if rest, err = parsePrometheusLabels(rest[1:], sample.Labels); err != nil {
+			return nil, err
+		}
+	}
+
+	// The value may be followed by a timestamp, which is ignored
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", fields[0])
+	}
+	sample.Value = value
+	return sample, nil
+}
+
+// parsePrometheusLabels parses the labels of a series up to the closing
+// brace, returning the rest of the line.
+func parsePrometheusLabels(s string, labels map[string]string) (string, error) {
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return "", fmt.Errorf("unterminated labels")
+		}
+		if s[0] == '}' {
+			return s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || len(s) < eq+2 || s[eq+1] != '"' {
+			return "", fmt.Errorf("invalid label")
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = s[eq+2:]
+
+		var value strings.Builder
+		closed := false
+		for i := 0; i < len(s); i++ {
+			switch c := s[i]; {
+			case c == '\\' && i+1 < len(s):
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+			case c == '"':
+				s = s[i+1:]
+				closed = true
+			default:
+				value.WriteByte(c)
+			}
+			if closed {
+				break
+			}
+		}
+		if !closed {
+			return "", fmt.Errorf("unterminated value of label %q", name)
+		}
+		labels[name] = value.String()
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const testPrometheusMetrics = `# HELP vault_core_unsealed vault_core_unsealed
+# TYPE vault_core_unsealed gauge
+vault_core_unsealed{cluster="vault-cluster-1"} 1
+# HELP vault_core_handle_request vault_core_handle_request
+# TYPE vault_core_handle_request summary
+vault_core_handle_request{quantile="0.5"} 0.25
+vault_core_handle_request_sum 12.5
+vault_core_handle_request_count 50
+vault_route_read{mount="kv-\"a\"\\b"} NaN 1600000000000
+`
+
+func TestSysMetrics(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/sys/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.URL.Query().Get("format") == "prometheus" {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+			w.Write([]byte(testPrometheusMetrics))
+			return
+		}
+		w.Write([]byte(`{
+			"Timestamp": "2020-01-02 03:04:00 +0000 UTC",
+			"Gauges": [{"Name": "vault.core.unsealed", "Value": 1, "Labels": {"cluster": "vault-cluster-1"}}],
+			"Points": [],
+			"Counters": [{"Name": "vault.route.read", "Count": 3, "Rate": 0.3, "Sum": 3, "Min": 1, "Max": 1, "Mean": 1, "Stddev": 0, "Labels": {}}],
+			"Samples": [{"Name": "vault.core.handle_request", "Count": 50, "Rate": 1.25, "Sum": 12.5, "Min": 0.1, "Max": 0.5, "Mean": 0.25, "Stddev": 0.1, "Labels": {}}]
+		}`))
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metrics, err := client.Sys().Metrics(context.Background(), MetricsFormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := metrics.Summary
+	if summary == nil || len(summary.Gauges) != 1 || summary.Gauges[0].Labels["cluster"] != "vault-cluster-1" ||
+		summary.Counters[0].Count != 3 || summary.Samples[0].Mean != 0.25 {
+		t.Fatalf("unexpected summary %#v", summary)
+	}
+	if _, err := metrics.PrometheusFamilies(); err == nil {
+		t.Fatal("expected error parsing JSON metrics as Prometheus")
+	}
+
+	metrics, err = client.Sys().Metrics(context.Background(), MetricsFormatPrometheus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(metrics.Prometheus) != testPrometheusMetrics {
+		t.Fatalf("unexpected Prometheus metrics %q", metrics.Prometheus)
+	}
+	families, err := metrics.PrometheusFamilies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(families) != 3 {
+		t.Fatalf("expected 3 families, got %d", len(families))
+	}
+	if f := families[0]; f.Name != "vault_core_unsealed" || f.Type != "gauge" || f.Samples[0].Value != 1 || f.Samples[0].Labels["cluster"] != "vault-cluster-1" {
+		t.Fatalf("unexpected family %#v", f)
+	}
+	f := families[1]
+	var names []string
+	for _, sample := range f.Samples {
+		names = append(names, sample.Name)
+	}
+	expected := []string{"vault_core_handle_request", "vault_core_handle_request_sum", "vault_core_handle_request_count"}
+	if f.Type != "summary" || !reflect.DeepEqual(names, expected) || f.Samples[2].Value != 50 {
+		t.Fatalf("unexpected family %#v", f)
+	}
+	if f := families[2]; f.Type != "untyped" || f.Samples[0].Labels["mount"] != `kv-"a"\b` || f.Samples[0].Value == f.Samples[0].Value {
+		t.Fatalf("unexpected family %#v", f)
+	}
+
+	if _, err := ParsePrometheusMetrics([]byte(`metric{label="unterminated} 1`)); err == nil {
+		t.Fatal("expected error parsing unterminated label")
+	}
+}
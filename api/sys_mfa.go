@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// MFARequirement describes the MFA that must be validated to complete a
+// login. Each constraint must be satisfied by one of its methods.
+type MFARequirement struct {
+	MFARequestID   string                       `json:"mfa_request_id"`
+	MFAConstraints map[string]*MFAConstraintAny `json:"mfa_constraints"`
+}
+
+// MFAConstraintAny is a constraint satisfied by any one of its methods.
+type MFAConstraintAny struct {
+	Any []*MFAMethodID `json:"any"`
+}
+
+// MFAMethodID identifies an MFA method. Methods that do not use a passcode
+// are validated out of band, for instance by a push notification.
+type MFAMethodID struct {
+	Type         string `json:"type"`
+	ID           string `json:"id"`
+	UsesPasscode bool   `json:"uses_passcode"`
+	Name         string `json:"name"`
+}
+
+// MFAValidate validates the MFA of the login with the given request ID. The
+// payload maps method IDs to the passcodes provided for them. It returns the
+// login's auth response.
+func (c *Sys) MFAValidate(requestID string, payload map[string]interface{}) (*Secret, error) {
+	r := c.c.NewRequest("PUT", "/v1/sys/mfa/validate")
+	if err := r.SetJSONBody(map[string]interface{}{
+		"mfa_request_id": requestID,
+		"mfa_payload":    payload,
+	}); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
+// MFALoginOptions controls how ValidateLoginMFA satisfies the constraints of
+// an MFA requirement.
+type MFALoginOptions struct {
+	// TOTPSeeds maps method IDs or names to base32 encoded TOTP seeds. The
+	// passcodes of these methods are generated automatically.
+	TOTPSeeds map[string]string
+
+	// PasscodeFunc is called to obtain the passcode of a method not covered
+	// by TOTPSeeds, for instance by prompting the user. Returning an empty
+	// passcode moves on to the next method of the constraint.
+	PasscodeFunc func(constraint string, method *MFAMethodID) (string, error)
+}
+
+// ValidateLoginMFA completes a login that requires MFA, returning the auth
+// response with the token. For each constraint it uses the first method with
+// a TOTP seed, else the first method without a passcode, else the first
+// method for which PasscodeFunc provides a passcode.
+func (c *Sys) ValidateLoginMFA(login *Secret, opts *MFALoginOptions) (*Secret, error) {
+	if login == nil || login.Auth == nil || login.Auth.MFARequirement == nil {
+		return nil, errors.New("login response has no MFA requirement")
+	}
+	if opts == nil {
+		opts = &MFALoginOptions{}
+	}
+	requirement := login.Auth.MFARequirement
+
+	payload := make(map[string]interface{}, len(requirement.MFAConstraints))
+	for name, constraint := range requirement.MFAConstraints {
+		methodID, passcode, err := opts.passcode(name, constraint)
+		if err != nil {
+			return nil, err
+		}
+		if passcode == "" {
+			payload[methodID] = []string{}
+		} else {
+			payload[methodID] = []string{passcode}
+		}
+	}
+
+	return c.MFAValidate(requirement.MFARequestID, payload)
+}
+
+func (o *MFALoginOptions) passcode(name string, constraint *MFAConstraintAny) (string, string, error) {
+	if constraint == nil || len(constraint.Any) == 0 {
+		return "", "", fmt.Errorf("MFA constraint %q has no methods", name)
+	}
+
+	for _, method := range constraint.Any {
+		seed, ok := o.TOTPSeeds[method.ID]
+		if !ok {
+			seed, ok = o.TOTPSeeds[method.Name]
+		}
+		if ok && method.UsesPasscode {
+			passcode, err := TOTPPasscode(seed, time.Now())
+			if err != nil {
+				return "", "", errwrap.Wrapf(fmt.Sprintf("error generating passcode for MFA method %q: {{err}}", method.ID), err)
+			}
+			return method.ID, passcode, nil
+		}
+	}
+
+	for _, method := range constraint.Any {
+		if !method.UsesPasscode {
+			return method.ID, "", nil
+		}
+	}
+
+	if o.PasscodeFunc == nil {
+		return "", "", fmt.Errorf("MFA constraint %q requires a passcode", name)
+	}
+	for _, method := range constraint.Any {
+		passcode, err := o.PasscodeFunc(name, method)
+		if err != nil {
+			return "", "", err
+		}
+		if passcode != "" {
+			return method.ID, passcode, nil
+		}
+	}
+	return "", "", fmt.Errorf("no passcode provided for MFA constraint %q", name)
+}
+
+// TOTPPasscode generates the RFC 6238 passcode for the given base32 encoded
+// seed at time t, using the defaults of Vault's TOTP MFA method: SHA1, six
+// digits and a 30 second period.
+func TOTPPasscode(seed string, t time.Time) (string, error) {
+	seed = strings.ToUpper(strings.Replace(seed, " ", "", -1))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(seed, "="))
+	if err != nil {
+		return "", errwrap.Wrapf("error decoding TOTP seed: {{err}}", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/30))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1000000), nil
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTOTPPasscode(t *testing.T) {
+	// Test vectors from RFC 6238, truncated to six digits
+	seed := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		2000000000: "279037",
+	}
+	for unix, expected := range cases {
+		passcode, err := TOTPPasscode(seed, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if passcode != expected {
+			t.Errorf("%d: expected %q, got %q", unix, expected, passcode)
+		}
+	}
+}
+
+func TestSysValidateLoginMFA(t *testing.T) {
+	var payload map[string]interface{}
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/auth/userpass/login/alice":
+			w.Write([]byte(`{"auth": {"mfa_requirement": {
+				"mfa_request_id": "req-id",
+				"mfa_constraints": {
+					"totp": {"any": [{"type": "totp", "id": "totp-id", "name": "authenticator", "uses_passcode": true}]},
+					"push": {"any": [{"type": "duo", "id": "duo-id", "uses_passcode": false}]},
+					"pin": {"any": [{"type": "pingid", "id": "pingid-id", "uses_passcode": true}, {"type": "okta", "id": "okta-id", "uses_passcode": true}]}
+				}
+			}}}`))
+		case "/v1/sys/mfa/validate":
+			var body struct {
+				RequestID string                 `json:"mfa_request_id"`
+				Payload   map[string]interface{} `json:"mfa_payload"`
+			}
+			json.NewDecoder(req.Body).Decode(&body)
+			if body.RequestID != "req-id" {
+				t.Errorf("unexpected request ID %q", body.RequestID)
+			}
+			payload = body.Payload
+			w.Write([]byte(`{"auth": {"client_token": "s.token"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	login, err := client.Logical().Write("auth/userpass/login/alice", map[string]interface{}{"password": "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if login.Auth == nil || login.Auth.MFARequirement == nil {
+		t.Fatalf("expected an MFA requirement, got %#v", login.Auth)
+	}
+
+	seed := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	secret, err := client.Sys().ValidateLoginMFA(login, &MFALoginOptions{
+		TOTPSeeds: map[string]string{"authenticator": seed},
+		PasscodeFunc: func(constraint string, method *MFAMethodID) (string, error) {
+			if constraint != "pin" {
+				t.Errorf("unexpected passcode prompt for %q, %#v", constraint, method)
+			}
+			// The first method has no passcode, so the next one is used
+			if method.ID != "okta-id" {
+				return "", nil
+			}
+			return "1234", nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Auth.ClientToken != "s.token" {
+		t.Fatalf("unexpected auth %#v", secret.Auth)
+	}
+
+	totp, err := TOTPPasscode(seed, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"totp-id": []interface{}{totp},
+		"duo-id":  []interface{}{},
+		"okta-id": []interface{}{"1234"},
+	}
+	if !reflect.DeepEqual(payload, expected) {
+		t.Fatalf("expected payload %v, got %v", expected, payload)
+	}
+}
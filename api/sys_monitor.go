@@ -4,61 +4,130 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"time"
+)
+
+const (
+	// monitorReconnectMin and monitorReconnectMax bound the backoff used when
+	// the log stream is interrupted and the client reconnects.
+	monitorReconnectMin = 500 * time.Millisecond
+	monitorReconnectMax = 30 * time.Second
 )
 
 // Monitor returns a channel that outputs strings containing the log messages
-// coming from the server.
+// coming from the server. The log level defaults to "info".
+//
+// If the stream is interrupted the client transparently reconnects, so logs
+// emitted meanwhile are missed. The channel is closed once the context is
+// cancelled or the client is closed.
 func (c *Sys) Monitor(ctx context.Context, logLevel string) (chan string, error) {
-	r := c.c.NewRequest("GET", "/v1/sys/monitor")
-
-	if logLevel == "" {
-		r.Params.Add("log_level", "info")
-	} else {
-		r.Params.Add("log_level", logLevel)
-	}
+	return c.MonitorWithFormat(ctx, logLevel, "")
+}
 
-	resp, err := c.c.RawRequestWithContext(ctx, r)
+// MonitorWithFormat is like Monitor, with the log messages in the given
+// format, "standard" or "json". The format defaults to the server's.
+func (c *Sys) MonitorWithFormat(ctx context.Context, logLevel string, logFormat string) (chan string, error) {
+	ctx, cancelFunc := c.c.withCloseContext(ctx)
+	resp, err := c.monitor(ctx, logLevel, logFormat)
 	if err != nil {
+		cancelFunc()
 		return nil, err
 	}
 
 	logCh := make(chan string, 64)
 
 	go func() {
-		scanner := bufio.NewScanner(resp.Body)
-		droppedCount := 0
-
+		defer cancelFunc()
 		defer close(logCh)
-		defer resp.Body.Close()
 
+		droppedCount := 0
+		wait := monitorReconnectMin
 		for {
-			if ctx.Err() != nil {
-				return
+			if resp != nil {
+				if readMonitorStream(ctx, resp, logCh, &droppedCount) {
+					wait = monitorReconnectMin
+				}
+				resp.Body.Close()
 			}
 
-			if !scanner.Scan() {
+			select {
+			case <-ctx.Done():
 				return
+			case <-time.After(wait):
 			}
 
-			logMessage := scanner.Text()
-
-			if droppedCount > 0 {
-				select {
-				case logCh <- fmt.Sprintf("Monitor dropped %d logs during monitor request\n", droppedCount):
-					droppedCount = 0
-				default:
-					droppedCount++
-					continue
-				}
+			wait *= 2
+			if wait > monitorReconnectMax {
+				wait = monitorReconnectMax
 			}
 
+			// Errors here are retried on the next pass of the loop; the
+			// stream only ends when the caller cancels the context.
+			resp, _ = c.monitor(ctx, logLevel, logFormat)
+		}
+	}()
+
+	return logCh, nil
+}
+
+func (c *Sys) monitor(ctx context.Context, logLevel, logFormat string) (*Response, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/monitor")
+
+	if logLevel == "" {
+		r.Params.Add("log_level", "info")
+	} else {
+		r.Params.Add("log_level", logLevel)
+	}
+	if logFormat != "" {
+		r.Params.Add("log_format", logFormat)
+	}
+
+	// The stream stays open until ctx is cancelled, so the client's timeout
+	// would only cut it off and lose logs while reconnecting
+	resp, err := c.c.withoutTimeout().RawRequestWithContext(ctx, r)
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// readMonitorStream delivers the log lines of the response on logCh until
+// the stream ends, returning whether any was read. Lines are dropped rather
+// than block the stream when the channel is full, and droppedCount keeps
+// track of them across reconnections.
+func readMonitorStream(ctx context.Context, resp *Response, logCh chan<- string, droppedCount *int) bool {
+	scanner := bufio.NewScanner(resp.Body)
+	read := false
+
+	for {
+		if ctx.Err() != nil {
+			return read
+		}
+
+		if !scanner.Scan() {
+			return read
+		}
+		read = true
+
+		logMessage := scanner.Text()
+
+		if *droppedCount > 0 {
 			select {
-			case logCh <- logMessage:
+			case logCh <- fmt.Sprintf("Monitor dropped %d logs during monitor request\n", *droppedCount):
+				*droppedCount = 0
 			default:
-				droppedCount++
+				*droppedCount++
+				continue
 			}
 		}
-	}()
 
-	return logCh, nil
+		select {
+		case logCh <- logMessage:
+		default:
+			*droppedCount++
+		}
+	}
 }
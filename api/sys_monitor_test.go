@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSysMonitor_Reconnect(t *testing.T) {
+	var l sync.Mutex
+	var connections int
+	var queries []string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		l.Lock()
+		connections++
+		n := connections
+		queries = append(queries, req.URL.RawQuery)
+		l.Unlock()
+
+		// The first stream is interrupted after a line
+		fmt.Fprintf(w, "line %d\n", n)
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logCh, err := client.Sys().MonitorWithFormat(ctx, "debug", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		select {
+		case line := <-logCh:
+			if line != fmt.Sprintf("line %d", i) {
+				t.Fatalf("unexpected line %q", line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for log line")
+		}
+	}
+
+	l.Lock()
+	if queries[0] != "log_format=json&log_level=debug" || queries[1] != queries[0] {
+		t.Fatalf("unexpected queries %v", queries)
+	}
+	l.Unlock()
+
+	cancel()
+	for range logCh {
+	}
+}
+
+func TestSysMonitor_DefaultFormat(t *testing.T) {
+	queries := make(chan string, 1)
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case queries <- req.URL.RawQuery:
+		default:
+		}
+		w.(http.Flusher).Flush()
+		<-req.Context().Done()
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	logCh, err := client.Sys().Monitor(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	for range logCh {
+	}
+
+	if query := <-queries; query != "log_level=info" {
+		t.Fatalf("unexpected query %q", query)
+	}
+}
+
+func TestSysMonitor_NoTimeout(t *testing.T) {
+	var l sync.Mutex
+	var connections int
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		l.Lock()
+		connections++
+		l.Unlock()
+
+		w.(http.Flusher).Flush()
+		// Log a line after the client's timeout has passed
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprintf(w, "line\n")
+		w.(http.Flusher).Flush()
+		<-req.Context().Done()
+	}))
+	defer ln.Close()
+
+	config.Timeout = 50 * time.Millisecond
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logCh, err := client.Sys().Monitor(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case line := <-logCh:
+		if line != "line" {
+			t.Fatalf("unexpected line %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for log line")
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	if connections != 1 {
+		t.Fatalf("expected the stream to outlive the client timeout, got %d connections", connections)
+	}
+}
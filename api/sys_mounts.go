@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -65,7 +66,40 @@ func (c *Sys) Unmount(path string) error {
 	return err
 }
 
+// Remount moves the mount at from to to. Servers that perform the move
+// asynchronously return a migration ID, in which case Remount polls the
+// migration status until it succeeds or fails.
 func (c *Sys) Remount(from, to string) error {
+	migration, err := c.StartRemount(from, to)
+	if err != nil {
+		return err
+	}
+	// Older servers move the mount before responding
+	if migration.MigrationID == "" {
+		return nil
+	}
+
+	for {
+		status, err := c.RemountStatus(migration.MigrationID)
+		if err != nil {
+			return err
+		}
+		if status.MigrationInfo != nil {
+			switch status.MigrationInfo.MigrationStatus {
+			case MountMigrationStatusSuccess:
+				return nil
+			case MountMigrationStatusFailure:
+				return fmt.Errorf("error moving mount %s to %s, with migration ID %s", from, to, migration.MigrationID)
+			}
+		}
+		time.Sleep(remountPollInterval)
+	}
+}
+
+// StartRemount starts moving the mount at from to to, without waiting for
+// the move to complete. The returned migration ID can be passed to
+// RemountStatus; it is empty if the server moved the mount synchronously.
+func (c *Sys) StartRemount(from, to string) (*MountMigrationOutput, error) {
 	body := map[string]interface{}{
 		"from": from,
 		"to":   to,
@@ -73,20 +107,71 @@ func (c *Sys) Remount(from, to string) error {
 
 	r := c.c.NewRequest("POST", "/v1/sys/remount")
 	if err := r.SetJSONBody(body); err != nil {
-		return err
+		return nil, err
 	}
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
 	resp, err := c.c.RawRequestWithContext(ctx, r)
-	if err == nil {
-		defer resp.Body.Close()
+	if err != nil {
+		return nil, err
 	}
-	return err
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result MountMigrationOutput
+	if secret != nil && secret.Data != nil {
+		if err := mapstructure.Decode(secret.Data, &result); err != nil {
+			return nil, err
+		}
+	}
+
+	return &result, nil
+}
+
+// RemountStatus returns the status of the mount migration with the given ID.
+func (c *Sys) RemountStatus(migrationID string) (*MountMigrationStatusOutput, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/remount/status/%s", migrationID))
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result MountMigrationStatusOutput
+	err = mapstructure.Decode(secret.Data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, err
 }
 
 func (c *Sys) TuneMount(path string, config MountConfigInput) error {
-	r := c.c.NewRequest("POST", fmt.Sprintf("/v1/sys/mounts/%s/tune", path))
+	return c.tune(fmt.Sprintf("/v1/sys/mounts/%s/tune", path), config)
+}
+
+func (c *Sys) MountConfig(path string) (*MountConfigOutput, error) {
+	return c.tuneConfig(fmt.Sprintf("/v1/sys/mounts/%s/tune", path))
+}
+
+func (c *Sys) tune(path string, config MountConfigInput) error {
+	r := c.c.NewRequest("POST", path)
 	if err := r.SetJSONBody(config); err != nil {
 		return err
 	}
@@ -100,8 +185,8 @@ func (c *Sys) TuneMount(path string, config MountConfigInput) error {
 	return err
 }
 
-func (c *Sys) MountConfig(path string) (*MountConfigOutput, error) {
-	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/mounts/%s/tune", path))
+func (c *Sys) tuneConfig(path string) (*MountConfigOutput, error) {
+	r := c.c.NewRequest("GET", path)
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
@@ -128,6 +213,15 @@ func (c *Sys) MountConfig(path string) (*MountConfigOutput, error) {
 	return &result, err
 }
 
+const (
+	MountMigrationStatusInProgress = "in-progress"
+	MountMigrationStatusSuccess    = "success"
+	MountMigrationStatusFailure    = "failure"
+)
+
+// remountPollInterval is how often Remount checks the status of a migration.
+var remountPollInterval = time.Second
+
 type MountInput struct {
 	Type                  string            `json:"type"`
 	Description           string            `json:"description"`
@@ -185,3 +279,18 @@ type MountConfigOutput struct {
 	// Deprecated: This field will always be blank for newer server responses.
 	PluginName string `json:"plugin_name,omitempty" mapstructure:"plugin_name"`
 }
+
+type MountMigrationOutput struct {
+	MigrationID string `mapstructure:"migration_id"`
+}
+
+type MountMigrationStatusOutput struct {
+	MigrationID   string                    `mapstructure:"migration_id"`
+	MigrationInfo *MountMigrationStatusInfo `mapstructure:"migration_info"`
+}
+
+type MountMigrationStatusInfo struct {
+	SourceMount     string `mapstructure:"source_mount"`
+	TargetMount     string `mapstructure:"target_mount"`
+	MigrationStatus string `mapstructure:"status"`
+}
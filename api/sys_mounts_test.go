@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSysRemount(t *testing.T) {
+	defer func(d time.Duration) { remountPollInterval = d }(remountPollInterval)
+	remountPollInterval = time.Millisecond
+
+	var polls int
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/sys/remount":
+			var body map[string]string
+			json.NewDecoder(req.Body).Decode(&body)
+			if body["from"] != "secret" || body["to"] != "kv" {
+				t.Errorf("unexpected body: %v", body)
+			}
+			w.Write([]byte(`{"data": {"migration_id": "abc"}}`))
+		case "/v1/sys/remount/status/abc":
+			polls++
+			status := MountMigrationStatusInProgress
+			if polls == 3 {
+				status = MountMigrationStatusSuccess
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"migration_id": "abc",
+					"migration_info": map[string]interface{}{
+						"source_mount": "secret/",
+						"target_mount": "kv/",
+						"status":       status,
+					},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Sys().Remount("secret", "kv"); err != nil {
+		t.Fatal(err)
+	}
+	if polls != 3 {
+		t.Fatalf("expected 3 status checks, got %d", polls)
+	}
+}
+
+func TestSysRemount_Failure(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/sys/remount":
+			w.Write([]byte(`{"data": {"migration_id": "abc"}}`))
+		default:
+			w.Write([]byte(`{"data": {"migration_id": "abc", "migration_info": {"status": "failure"}}}`))
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Sys().Remount("secret", "kv")
+	if err == nil || !strings.Contains(err.Error(), "migration ID abc") {
+		t.Fatalf("expected migration failure, got %v", err)
+	}
+}
+
+func TestSysRemount_Synchronous(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/sys/remount" {
+			t.Errorf("unexpected request to %s", req.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Sys().Remount("secret", "kv"); err != nil {
+		t.Fatal(err)
+	}
+}
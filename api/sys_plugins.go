@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/mitchellh/mapstructure"
@@ -26,6 +27,22 @@ type ListPluginsResponse struct {
 	// Deprecated: Newer server responses should be returning PluginsByType (json:
 	// "types") instead.
 	Names []string `json:"names"`
+
+	// Details are the plugins of all types with their versions, returned by
+	// servers supporting plugin versioning when listing without a type.
+	Details []PluginDetails `json:"detailed"`
+}
+
+// PluginDetails describes a plugin registered in the catalog.
+type PluginDetails struct {
+	Type              string `json:"type"`
+	Name              string `json:"name"`
+	Version           string `json:"version"`
+	Builtin           bool   `json:"builtin"`
+	DeprecationStatus string `json:"deprecation_status,omitempty" mapstructure:"deprecation_status"`
+	OCIImage          string `json:"oci_image,omitempty" mapstructure:"oci_image"`
+	Runtime           string `json:"runtime,omitempty"`
+	SHA256            string `json:"sha256,omitempty"`
 }
 
 // ListPlugins lists all plugins in the catalog and returns their names as a
@@ -94,6 +111,13 @@ func (c *Sys) ListPlugins(i *ListPluginsInput) (*ListPluginsResponse, error) {
 	}
 	if i.Type == consts.PluginTypeUnknown {
 		for pluginTypeStr, pluginsRaw := range secret.Data {
+			if pluginTypeStr == "detailed" {
+				if err := mapstructure.Decode(pluginsRaw, &result.Details); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
 			pluginType, err := consts.ParsePluginType(pluginTypeStr)
 			if err != nil {
 				return nil, err
@@ -108,7 +132,7 @@ func (c *Sys) ListPlugins(i *ListPluginsInput) (*ListPluginsResponse, error) {
 			for i, nameIfc := range pluginsIfc {
 				name, ok := nameIfc.(string)
 				if !ok {
-
+					return nil, fmt.Errorf("unable to parse plugin name for %q type", pluginTypeStr)
 				}
 				plugins[i] = name
 			}
@@ -131,6 +155,10 @@ type GetPluginInput struct {
 
 	// Type of the plugin. Required.
 	Type consts.PluginType `json:"type"`
+
+	// Version of the plugin. Optional, the unversioned plugin is returned
+	// if empty.
+	Version string `json:"version,omitempty"`
 }
 
 // GetPluginResponse is the response from the GetPlugin call.
@@ -140,12 +168,20 @@ type GetPluginResponse struct {
 	Command string   `json:"command"`
 	Name    string   `json:"name"`
 	SHA256  string   `json:"sha256"`
+
+	Version           string `json:"version,omitempty"`
+	DeprecationStatus string `json:"deprecation_status,omitempty"`
+	OCIImage          string `json:"oci_image,omitempty"`
+	Runtime           string `json:"runtime,omitempty"`
 }
 
 // GetPlugin retrieves information about the plugin.
 func (c *Sys) GetPlugin(i *GetPluginInput) (*GetPluginResponse, error) {
 	path := catalogPathByType(i.Type, i.Name)
 	req := c.c.NewRequest(http.MethodGet, path)
+	if i.Version != "" {
+		req.Params.Set("version", i.Version)
+	}
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
@@ -181,6 +217,22 @@ type RegisterPluginInput struct {
 
 	// SHA256 is the shasum of the plugin.
 	SHA256 string `json:"sha256,omitempty"`
+
+	// Version is the semantic version of the plugin, allowing several
+	// versions of it to be registered at once. Optional.
+	Version string `json:"version,omitempty"`
+
+	// Env is the list of KEY=VALUE environment variables to spawn the
+	// process with.
+	Env []string `json:"env,omitempty"`
+
+	// OCIImage is the image to run the plugin from, in which case Command is
+	// the path of the binary within the image. Optional.
+	OCIImage string `json:"oci_image,omitempty"`
+
+	// Runtime is the name of the plugin runtime to run the OCI image with.
+	// Optional.
+	Runtime string `json:"runtime,omitempty"`
 }
 
 // RegisterPlugin registers the plugin with the given information.
@@ -208,6 +260,10 @@ type DeregisterPluginInput struct {
 
 	// Type of the plugin. Required.
 	Type consts.PluginType `json:"type"`
+
+	// Version of the plugin to remove. Optional, the unversioned plugin is
+	// removed if empty.
+	Version string `json:"version,omitempty"`
 }
 
 // DeregisterPlugin removes the plugin with the given name from the plugin
@@ -215,6 +271,9 @@ type DeregisterPluginInput struct {
 func (c *Sys) DeregisterPlugin(i *DeregisterPluginInput) error {
 	path := catalogPathByType(i.Type, i.Name)
 	req := c.c.NewRequest(http.MethodDelete, path)
+	if i.Version != "" {
+		req.Params.Set("version", i.Version)
+	}
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
@@ -232,26 +291,91 @@ type ReloadPluginInput struct {
 
 	// Mounts is the array of string mount paths of the plugin backends to reload
 	Mounts []string `json:"mounts"`
+
+	// Scope is the scope of the plugin reload. Optional; "global" reloads
+	// the plugin on every node of the cluster.
+	Scope string `json:"scope,omitempty"`
 }
 
 // ReloadPlugin reloads mounted plugin backends
 func (c *Sys) ReloadPlugin(i *ReloadPluginInput) error {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	_, err := c.ReloadPluginWithContext(ctx, i)
+	return err
+}
+
+// ReloadPluginWithContext reloads mounted plugin backends, returning the ID
+// of the reload, which is only set for a global scope and allows following
+// its progress with ReloadPluginStatus.
+func (c *Sys) ReloadPluginWithContext(ctx context.Context, i *ReloadPluginInput) (string, error) {
 	path := "/v1/sys/plugins/reload/backend"
 	req := c.c.NewRequest(http.MethodPut, path)
 
 	if err := req.SetJSONBody(i); err != nil {
-		return err
+		return "", err
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
-	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Local reloads return no content
+	if i.Scope == "" {
+		return "", nil
+	}
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if secret == nil || secret.Data == nil {
+		return "", nil
+	}
+	reloadID, _ := secret.Data["reload_id"].(string)
+	return reloadID, nil
+}
+
+// ReloadStatus is the result of the reload of a plugin on a node.
+type ReloadStatus struct {
+	Timestamp time.Time `json:"timestamp"`
+	Error     string    `json:"error"`
+}
+
+// ReloadStatusResponse is the progress of a global plugin reload, keyed by
+// the ID of the nodes which completed it.
+type ReloadStatusResponse struct {
+	ReloadID string                   `mapstructure:"reload_id"`
+	Results  map[string]*ReloadStatus `mapstructure:"results"`
+}
+
+// ReloadPluginStatus returns the progress of the global plugin reload with
+// the given ID.
+func (c *Sys) ReloadPluginStatus(ctx context.Context, reloadID string) (*ReloadStatusResponse, error) {
+	req := c.c.NewRequest(http.MethodGet, "/v1/sys/plugins/reload/backend/status")
+	req.Params.Set("reload_id", reloadID)
 
 	resp, err := c.c.RawRequestWithContext(ctx, req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
-	return err
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result ReloadStatusResponse
+	if err := secret.DecodeData(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
 // catalogPathByType is a helper to construct the proper API path by plugin type
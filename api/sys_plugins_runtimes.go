@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// PluginRuntimeType is the type of a plugin runtime. Currently only
+// "container" runtimes are supported.
+type PluginRuntimeType string
+
+const (
+	PluginRuntimeTypeUnsupported PluginRuntimeType = ""
+	PluginRuntimeTypeContainer   PluginRuntimeType = "container"
+)
+
+// ParsePluginRuntimeType parses a plugin runtime type from its string form.
+func ParsePluginRuntimeType(pluginRuntimeType string) (PluginRuntimeType, error) {
+	switch pluginRuntimeType {
+	case string(PluginRuntimeTypeContainer):
+		return PluginRuntimeTypeContainer, nil
+	default:
+		return PluginRuntimeTypeUnsupported, fmt.Errorf("%q is not a supported plugin runtime type", pluginRuntimeType)
+	}
+}
+
+// GetPluginRuntimeInput is used as input to the GetPluginRuntime function.
+type GetPluginRuntimeInput struct {
+	Name string `json:"-"`
+
+	// Type of the plugin runtime. Required.
+	Type PluginRuntimeType `json:"type"`
+}
+
+// GetPluginRuntimeResponse is the response from the GetPluginRuntime call.
+type GetPluginRuntimeResponse struct {
+	Type         string `json:"type" mapstructure:"type"`
+	Name         string `json:"name" mapstructure:"name"`
+	OCIRuntime   string `json:"oci_runtime" mapstructure:"oci_runtime"`
+	CgroupParent string `json:"cgroup_parent" mapstructure:"cgroup_parent"`
+	CPU          int64  `json:"cpu_nanos" mapstructure:"cpu_nanos"`
+	Memory       int64  `json:"memory_bytes" mapstructure:"memory_bytes"`
+	Rootless     bool   `json:"rootless" mapstructure:"rootless"`
+}
+
+// GetPluginRuntime retrieves information about the plugin runtime.
+func (c *Sys) GetPluginRuntime(i *GetPluginRuntimeInput) (*GetPluginRuntimeResponse, error) {
+	path := pluginRuntimeCatalogPathByType(i.Type, i.Name)
+	req := c.c.NewRequest(http.MethodGet, path)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data *GetPluginRuntimeResponse
+	}
+	err = resp.DecodeJSON(&result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Data, err
+}
+
+// RegisterPluginRuntimeInput is used as input to the RegisterPluginRuntime
+// function.
+type RegisterPluginRuntimeInput struct {
+	// Name is the name of the plugin runtime. Required.
+	Name string `json:"-"`
+
+	// Type of the plugin runtime. Required.
+	Type PluginRuntimeType `json:"type"`
+
+	// OCIRuntime is the OCI runtime used to run plugins, e.g. "runsc".
+	OCIRuntime string `json:"oci_runtime,omitempty"`
+
+	// CgroupParent is the parent cgroup for plugin containers.
+	CgroupParent string `json:"cgroup_parent,omitempty"`
+
+	// CPU is the CPU limit for plugin containers, in nanocpus.
+	CPU int64 `json:"cpu_nanos,omitempty"`
+
+	// Memory is the memory limit for plugin containers, in bytes.
+	Memory int64 `json:"memory_bytes,omitempty"`
+
+	// Rootless indicates that the container runtime is configured to run as
+	// a non-privileged user.
+	Rootless bool `json:"rootless,omitempty"`
+}
+
+// RegisterPluginRuntime registers the plugin runtime with the given
+// information.
+func (c *Sys) RegisterPluginRuntime(i *RegisterPluginRuntimeInput) error {
+	path := pluginRuntimeCatalogPathByType(i.Type, i.Name)
+	req := c.c.NewRequest(http.MethodPut, path)
+
+	if err := req.SetJSONBody(i); err != nil {
+		return err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, req)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// DeregisterPluginRuntimeInput is used as input to the DeregisterPluginRuntime
+// function.
+type DeregisterPluginRuntimeInput struct {
+	// Name is the name of the plugin runtime. Required.
+	Name string `json:"-"`
+
+	// Type of the plugin runtime. Required.
+	Type PluginRuntimeType `json:"type"`
+}
+
+// DeregisterPluginRuntime removes the plugin runtime with the given name from
+// the plugin runtime catalog.
+func (c *Sys) DeregisterPluginRuntime(i *DeregisterPluginRuntimeInput) error {
+	path := pluginRuntimeCatalogPathByType(i.Type, i.Name)
+	req := c.c.NewRequest(http.MethodDelete, path)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, req)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// ListPluginRuntimesInput is used as input to the ListPluginRuntimes function.
+type ListPluginRuntimesInput struct {
+	// Type of the plugin runtime. Optional; if unset, runtimes of all types
+	// are listed.
+	Type PluginRuntimeType `json:"type"`
+}
+
+// ListPluginRuntimesResponse is the response from the ListPluginRuntimes
+// call.
+type ListPluginRuntimesResponse struct {
+	Runtimes []GetPluginRuntimeResponse `json:"runtimes" mapstructure:"runtimes"`
+}
+
+// ListPluginRuntimes lists all plugin runtimes in the catalog.
+func (c *Sys) ListPluginRuntimes(i *ListPluginRuntimesInput) (*ListPluginRuntimesResponse, error) {
+	req := c.c.NewRequest("LIST", "/v1/sys/plugins/runtimes/catalog")
+	// Set this for broader compatibility, but we use LIST above to be able to
+	// handle the wrapping lookup function
+	req.Method = "GET"
+	req.Params.Set("list", "true")
+	if i != nil && i.Type != PluginRuntimeTypeUnsupported {
+		req.Params.Set("type", string(i.Type))
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result ListPluginRuntimesResponse
+	if err := mapstructure.Decode(secret.Data, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// pluginRuntimeCatalogPathByType is a helper to construct the proper API path
+// by plugin runtime type
+func pluginRuntimeCatalogPathByType(runtimeType PluginRuntimeType, name string) string {
+	return fmt.Sprintf("/v1/sys/plugins/runtimes/catalog/%s/%s", runtimeType, name)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSysPluginRuntimes(t *testing.T) {
+	var registered map[string]interface{}
+	var deregistered, listType string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/v1/sys/plugins/runtimes/catalog" && req.URL.Query().Get("list") == "true":
+			listType = req.URL.Query().Get("type")
+			w.Write([]byte(`{"data": {"runtimes": [
+				{"type": "container", "name": "gvisor", "oci_runtime": "runsc", "cgroup_parent": "vault", "cpu_nanos": 1000, "memory_bytes": 4096, "rootless": true},
+				{"type": "container", "name": "runc", "oci_runtime": "runc"}
+			]}}`))
+		case req.URL.Path == "/v1/sys/plugins/runtimes/catalog/container/gvisor":
+			switch req.Method {
+			case http.MethodGet:
+				w.Write([]byte(`{"data": {"type": "container", "name": "gvisor", "oci_runtime": "runsc", "cgroup_parent": "vault", "cpu_nanos": 1000, "memory_bytes": 4096, "rootless": true}}`))
+			case http.MethodPut:
+				json.NewDecoder(req.Body).Decode(&registered)
+				w.WriteHeader(http.StatusNoContent)
+			case http.MethodDelete:
+				deregistered = "gvisor"
+				w.WriteHeader(http.StatusNoContent)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sys := client.Sys()
+
+	err = sys.RegisterPluginRuntime(&RegisterPluginRuntimeInput{
+		Name:       "gvisor",
+		Type:       PluginRuntimeTypeContainer,
+		OCIRuntime: "runsc",
+		CPU:        1000,
+		Rootless:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered["type"] != "container" || registered["oci_runtime"] != "runsc" || registered["cpu_nanos"] != float64(1000) || registered["rootless"] != true {
+		t.Fatalf("unexpected registration %#v", registered)
+	}
+	if _, ok := registered["memory_bytes"]; ok {
+		t.Fatalf("expected unset fields to be omitted, got %#v", registered)
+	}
+
+	runtime, err := sys.GetPluginRuntime(&GetPluginRuntimeInput{Name: "gvisor", Type: PluginRuntimeTypeContainer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := GetPluginRuntimeResponse{
+		Type:         "container",
+		Name:         "gvisor",
+		OCIRuntime:   "runsc",
+		CgroupParent: "vault",
+		CPU:          1000,
+		Memory:       4096,
+		Rootless:     true,
+	}
+	if *runtime != expected {
+		t.Fatalf("expected %#v, got %#v", expected, runtime)
+	}
+
+	if _, err := sys.GetPluginRuntime(&GetPluginRuntimeInput{Name: "missing", Type: PluginRuntimeTypeContainer}); err == nil {
+		t.Fatal("expected error for missing runtime")
+	}
+
+	list, err := sys.ListPluginRuntimes(&ListPluginRuntimesInput{Type: PluginRuntimeTypeContainer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listType != "container" {
+		t.Fatalf("expected type filter, got %q", listType)
+	}
+	if len(list.Runtimes) != 2 || list.Runtimes[0] != expected || list.Runtimes[1].Name != "runc" {
+		t.Fatalf("unexpected runtimes %#v", list.Runtimes)
+	}
+
+	if _, err := sys.ListPluginRuntimes(nil); err != nil {
+		t.Fatal(err)
+	}
+	if listType != "" {
+		t.Fatalf("expected no type filter, got %q", listType)
+	}
+
+	if err := sys.DeregisterPluginRuntime(&DeregisterPluginRuntimeInput{Name: "gvisor", Type: PluginRuntimeTypeContainer}); err != nil {
+		t.Fatal(err)
+	}
+	if deregistered != "gvisor" {
+		t.Fatal("expected runtime to be deregistered")
+	}
+
+	if _, err := ParsePluginRuntimeType("vm"); err == nil {
+		t.Fatal("expected error for unsupported runtime type")
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+func TestSysPlugins(t *testing.T) {
+	var registered map[string]interface{}
+	var deregisteredVersion string
+	var reload map[string]interface{}
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/v1/sys/plugins/catalog" && req.Method == http.MethodGet:
+			w.Write([]byte(`{"data": {
+				"auth": ["jwt"],
+				"secret": ["kv", "my-plugin"],
+				"detailed": [
+					{"type": "secret", "name": "my-plugin", "version": "v1.2.0", "builtin": false, "sha256": "abc", "oci_image": "example/my-plugin", "runtime": "gvisor"},
+					{"type": "auth", "name": "jwt", "version": "v0.15.0+builtin", "builtin": true, "deprecation_status": "supported"}
+				]
+			}}`))
+		case req.URL.Path == "/v1/sys/plugins/catalog/secret/my-plugin" && req.Method == http.MethodGet:
+			if req.URL.Query().Get("version") != "v1.2.0" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"data": {"name": "my-plugin", "command": "my-plugin", "sha256": "abc", "version": "v1.2.0", "oci_image": "example/my-plugin", "runtime": "gvisor"}}`))
+		case req.URL.Path == "/v1/sys/plugins/catalog/secret/my-plugin" && req.Method == http.MethodPut:
+			json.NewDecoder(req.Body).Decode(&registered)
+			w.WriteHeader(http.StatusNoContent)
+		case req.URL.Path == "/v1/sys/plugins/catalog/secret/my-plugin" && req.Method == http.MethodDelete:
+			deregisteredVersion = req.URL.Query().Get("version")
+			w.WriteHeader(http.StatusNoContent)
+		case req.URL.Path == "/v1/sys/plugins/reload/backend":
+			json.NewDecoder(req.Body).Decode(&reload)
+			if reload["scope"] == nil {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Write([]byte(`{"data": {"reload_id": "reload-1"}}`))
+		case req.URL.Path == "/v1/sys/plugins/reload/backend/status":
+			if req.URL.Query().Get("reload_id") != "reload-1" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"data": {"reload_id": "reload-1", "results": {"node-1": {"timestamp": "2020-01-01T00:00:00Z", "error": ""}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sys := client.Sys()
+
+	list, err := sys.ListPlugins(&ListPluginsInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.PluginsByType[consts.PluginTypeSecrets]) != 2 || list.PluginsByType[consts.PluginTypeCredential][0] != "jwt" {
+		t.Fatalf("unexpected plugins %#v", list.PluginsByType)
+	}
+	if len(list.Details) != 2 || list.Details[0].Version != "v1.2.0" || list.Details[0].OCIImage != "example/my-plugin" || list.Details[1].DeprecationStatus != "supported" || !list.Details[1].Builtin {
+		t.Fatalf("unexpected details %#v", list.Details)
+	}
+
+	plugin, err := sys.GetPlugin(&GetPluginInput{Name: "my-plugin", Type: consts.PluginTypeSecrets, Version: "v1.2.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plugin.Version != "v1.2.0" || plugin.SHA256 != "abc" || plugin.Runtime != "gvisor" {
+		t.Fatalf("unexpected plugin %#v", plugin)
+	}
+
+	err = sys.RegisterPlugin(&RegisterPluginInput{
+		Name:     "my-plugin",
+		Type:     consts.PluginTypeSecrets,
+		Command:  "my-plugin",
+		SHA256:   "def",
+		Version:  "v1.3.0",
+		Env:      []string{"FOO=bar"},
+		OCIImage: "example/my-plugin",
+		Runtime:  "gvisor",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered["sha256"] != "def" || registered["version"] != "v1.3.0" || registered["oci_image"] != "example/my-plugin" || registered["runtime"] != "gvisor" {
+		t.Fatalf("unexpected registration %#v", registered)
+	}
+
+	if err := sys.DeregisterPlugin(&DeregisterPluginInput{Name: "my-plugin", Type: consts.PluginTypeSecrets, Version: "v1.2.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if deregisteredVersion != "v1.2.0" {
+		t.Fatalf("unexpected deregistered version %q", deregisteredVersion)
+	}
+
+	if err := sys.ReloadPlugin(&ReloadPluginInput{Plugin: "my-plugin"}); err != nil {
+		t.Fatal(err)
+	}
+	if reload["plugin"] != "my-plugin" {
+		t.Fatalf("unexpected reload %#v", reload)
+	}
+
+	ctx := context.Background()
+	reloadID, err := sys.ReloadPluginWithContext(ctx, &ReloadPluginInput{Plugin: "my-plugin", Scope: "global"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloadID != "reload-1" {
+		t.Fatalf("unexpected reload ID %q", reloadID)
+	}
+	status, err := sys.ReloadPluginStatus(ctx, reloadID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ReloadID != "reload-1" || status.Results["node-1"] == nil || status.Results["node-1"].Timestamp.Year() != 2020 {
+		t.Fatalf("unexpected status %#v", status)
+	}
+}
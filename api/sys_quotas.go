@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/mitchellh/mapstructure"
+)
+
+// RateLimitQuota is a rate limit quota, which limits the rate of requests to
+// the paths under Path, or to the whole namespace or server if Path is empty.
+type RateLimitQuota struct {
+	Name          string        `mapstructure:"name"`
+	Path          string        `mapstructure:"path"`
+	Role          string        `mapstructure:"role"`
+	Rate          float64       `mapstructure:"rate"`
+	Interval      time.Duration `mapstructure:"interval"`
+	BlockInterval time.Duration `mapstructure:"block_interval"`
+	Inheritable   bool          `mapstructure:"inheritable"`
+}
+
+func (q *RateLimitQuota) body() map[string]interface{} {
+	body := map[string]interface{}{
+		"path": q.Path,
+		"rate": q.Rate,
+	}
+	if q.Role != "" {
+		body["role"] = q.Role
+	}
+	if q.Interval > 0 {
+		body["interval"] = q.Interval.String()
+	}
+	if q.BlockInterval > 0 {
+		body["block_interval"] = q.BlockInterval.String()
+	}
+	if q.Inheritable {
+		body["inheritable"] = true
+	}
+	return body
+}
+
+// QuotaConfig is the server-wide configuration of resource quotas.
+// AbsoluteRateLimitExemptPaths is set by the server and cannot be updated.
+type QuotaConfig struct {
+	EnableRateLimitAuditLogging    bool     `mapstructure:"enable_rate_limit_audit_logging"`
+	EnableRateLimitResponseHeaders bool     `mapstructure:"enable_rate_limit_response_headers"`
+	RateLimitExemptPaths           []string `mapstructure:"rate_limit_exempt_paths"`
+	AbsoluteRateLimitExemptPaths   []string `mapstructure:"absolute_rate_limit_exempt_paths"`
+}
+
+// CreateRateLimitQuota creates or replaces the rate limit quota with the
+// name of the given quota.
+func (c *Sys) CreateRateLimitQuota(ctx context.Context, quota *RateLimitQuota) error {
+	if quota == nil || quota.Name == "" {
+		return errors.New("missing quota name")
+	}
+	_, err := c.c.Logical().Do(ctx, "POST", "sys/quotas/rate-limit/"+quota.Name, quota.body())
+	return err
+}
+
+// ReadRateLimitQuota returns the named rate limit quota, or nil if it does
+// not exist.
+func (c *Sys) ReadRateLimitQuota(ctx context.Context, name string) (*RateLimitQuota, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, "sys/quotas/rate-limit/"+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var quota RateLimitQuota
+	if err := secret.DecodeData(&quota); err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// ListRateLimitQuotas returns the names of the rate limit quotas.
+func (c *Sys) ListRateLimitQuotas(ctx context.Context) ([]string, error) {
+	secret, err := c.c.Logical().listWithContext(ctx, "sys/quotas/rate-limit", nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var names []string
+	if err := mapstructure.Decode(secret.Data["keys"], &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// DeleteRateLimitQuota deletes the named rate limit quota.
+func (c *Sys) DeleteRateLimitQuota(ctx context.Context, name string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", "sys/quotas/rate-limit/"+name, nil)
+	return err
+}
+
+// ReadQuotaConfig returns the configuration of resource quotas.
+func (c *Sys) ReadQuotaConfig(ctx context.Context) (*QuotaConfig, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, "sys/quotas/config", nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var config QuotaConfig
+	if err := secret.DecodeData(&config); err != nil {
+		return nil, errwrap.Wrapf("error decoding quota configuration: {{err}}", err)
+	}
+	return &config, nil
+}
+
+// UpdateQuotaConfig replaces the configuration of resource quotas.
+func (c *Sys) UpdateQuotaConfig(ctx context.Context, config *QuotaConfig) error {
+	body := map[string]interface{}{
+		"enable_rate_limit_audit_logging":    config.EnableRateLimitAuditLogging,
+		"enable_rate_limit_response_headers": config.EnableRateLimitResponseHeaders,
+		"rate_limit_exempt_paths":            config.RateLimitExemptPaths,
+	}
+	_, err := c.c.Logical().Do(ctx, "POST", "sys/quotas/config", body)
+	return err
+}
+
+var (
+	quotaExceededPathRe = regexp.MustCompile(`request path "([^"]*)"`)
+	quotaExceededNameRe = regexp.MustCompile(`quota "([^"]+)"`)
+)
+
+// QuotaExceeded describes a request rejected by a rate limit quota. The
+// limits are only known if the server sends rate limit response headers,
+// and the quota name if the server includes it in its error.
+type QuotaExceeded struct {
+	// QuotaName is the name of the quota that was exceeded, if known.
+	QuotaName string
+
+	// Path is the path of the rejected request, as reported by the server.
+	Path string
+
+	// Limit is the number of requests allowed per interval, and Remaining
+	// the number left in the current one, or -1 if unknown.
+	Limit     int
+	Remaining int
+
+	// Reset is the time until the current interval ends.
+	Reset time.Duration
+
+	// RetryAfter is the time to wait before retrying, if the server sent a
+	// Retry-After header.
+	RetryAfter time.Duration
+
+	// Errors are the errors returned by the server.
+	Errors []string
+}
+
+// ParseQuotaExceeded returns the details of the quota exceeded if err is a
+// response error for a request rejected by a rate limit quota, and false
+// otherwise.
+func ParseQuotaExceeded(err error) (*QuotaExceeded, bool) {
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		respErr, _ = errwrap.GetType(err, &ResponseError{}).(*ResponseError)
+	}
+	if respErr == nil || respErr.StatusCode != http.StatusTooManyRequests {
+		return nil, false
+	}
+
+	result := &QuotaExceeded{
+		Limit:     -1,
+		Remaining: -1,
+		Errors:    respErr.Errors,
+	}
+	for _, e := range respErr.Errors {
+		if m := quotaExceededPathRe.FindStringSubmatch(e); m != nil && result.Path == "" {
+			result.Path = m[1]
+		}
+		if m := quotaExceededNameRe.FindStringSubmatch(e); m != nil && result.QuotaName == "" {
+			result.QuotaName = m[1]
+		}
+	}
+
+	header := respErr.Header
+	if v, err := strconv.Atoi(header.Get("X-Ratelimit-Limit")); err == nil {
+		result.Limit = v
+	}
+	if v, err := strconv.Atoi(header.Get("X-Ratelimit-Remaining")); err == nil {
+		result.Remaining = v
+	}
+	if v, err := strconv.Atoi(header.Get("X-Ratelimit-Reset")); err == nil {
+		result.Reset = time.Duration(v) * time.Second
+	}
+	result.RetryAfter = parseRetryAfter(header.Get("Retry-After"), time.Now())
+	return result, true
+}
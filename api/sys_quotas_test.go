@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+func TestSysRateLimitQuotas(t *testing.T) {
+	var written map[string]interface{}
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method + " " + req.URL.Path {
+		case "POST /v1/sys/quotas/rate-limit/global":
+			json.NewDecoder(req.Body).Decode(&written)
+			w.WriteHeader(http.StatusNoContent)
+		case "GET /v1/sys/quotas/rate-limit/global":
+			w.Write([]byte(`{"data": {"name": "global", "path": "", "rate": 100.5, "interval": 1, "block_interval": 0, "type": "rate-limit"}}`))
+		case "GET /v1/sys/quotas/rate-limit":
+			w.Write([]byte(`{"data": {"keys": ["global"]}}`))
+		case "GET /v1/sys/quotas/config":
+			w.Write([]byte(`{"data": {"enable_rate_limit_audit_logging": false, "enable_rate_limit_response_headers": true, "rate_limit_exempt_paths": ["sys/health"], "absolute_rate_limit_exempt_paths": ["sys/generate-recovery-token/attempt"]}}`))
+		case "GET /v1/kv/foo":
+			w.Header().Set("X-Ratelimit-Limit", "100")
+			w.Header().Set("X-Ratelimit-Remaining", "0")
+			w.Header().Set("X-Ratelimit-Reset", "1")
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"errors": ["request path \"kv/foo\": rate limit quota exceeded", "quota \"kv-limit\" exceeded"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	config.MaxRetries = 0
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sys := client.Sys()
+	ctx := context.Background()
+
+	if err := sys.CreateRateLimitQuota(ctx, &RateLimitQuota{Name: "global", Rate: 100.5, Interval: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"path": "", "rate": 100.5, "interval": "1s"}
+	if !reflect.DeepEqual(written, expected) {
+		t.Fatalf("unexpected request body %#v", written)
+	}
+
+	quota, err := sys.ReadRateLimitQuota(ctx, "global")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quota.Name != "global" || quota.Rate != 100.5 || quota.Interval != time.Second {
+		t.Fatalf("unexpected quota %#v", quota)
+	}
+	if quota, err := sys.ReadRateLimitQuota(ctx, "missing"); err != nil || quota != nil {
+		t.Fatalf("expected no quota, got %#v and %v", quota, err)
+	}
+	names, err := sys.ListRateLimitQuotas(ctx)
+	if err != nil || !reflect.DeepEqual(names, []string{"global"}) {
+		t.Fatalf("unexpected quotas %v and %v", names, err)
+	}
+
+	quotaConfig, err := sys.ReadQuotaConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !quotaConfig.EnableRateLimitResponseHeaders || !reflect.DeepEqual(quotaConfig.RateLimitExemptPaths, []string{"sys/health"}) {
+		t.Fatalf("unexpected quota configuration %#v", quotaConfig)
+	}
+
+	_, err = client.Logical().Read("kv/foo")
+	exceeded, ok := ParseQuotaExceeded(errwrap.Wrapf("error reading: {{err}}", err))
+	if !ok {
+		t.Fatalf("expected quota exceeded error, got %v", err)
+	}
+	if exceeded.Path != "kv/foo" || exceeded.QuotaName != "kv-limit" || exceeded.Limit != 100 || exceeded.Remaining != 0 ||
+		exceeded.Reset != time.Second || exceeded.RetryAfter != 2*time.Second {
+		t.Fatalf("unexpected details %#v", exceeded)
+	}
+
+	if _, ok := ParseQuotaExceeded(fmt.Errorf("other error")); ok {
+		t.Fatal("expected other errors not to be quota errors")
+	}
+}
@@ -2,10 +2,13 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 )
 
@@ -46,16 +49,167 @@ func (c *Sys) RaftJoin(opts *RaftJoinRequest) (*RaftJoinResponse, error) {
 	return &result, err
 }
 
+// RaftConfiguration is the configuration of the raft cluster.
+type RaftConfiguration struct {
+	Servers []*RaftServer `mapstructure:"servers"`
+	Index   uint64        `mapstructure:"index"`
+}
+
+// RaftServer is a member of the raft cluster.
+type RaftServer struct {
+	NodeID          string `mapstructure:"node_id"`
+	Address         string `mapstructure:"address"`
+	Leader          bool   `mapstructure:"leader"`
+	Voter           bool   `mapstructure:"voter"`
+	ProtocolVersion string `mapstructure:"protocol_version"`
+}
+
+// RaftConfiguration returns the configuration of the raft cluster.
+func (c *Sys) RaftConfiguration() (*RaftConfiguration, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/storage/raft/configuration")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result RaftConfiguration
+	if err := secret.DecodeData(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// AutopilotState is the health of the raft cluster as seen by autopilot.
+type AutopilotState struct {
+	Healthy          bool                        `mapstructure:"healthy"`
+	FailureTolerance int                         `mapstructure:"failure_tolerance"`
+	Leader           string                      `mapstructure:"leader"`
+	Voters           []string                    `mapstructure:"voters"`
+	NonVoters        []string                    `mapstructure:"non_voters"`
+	Servers          map[string]*AutopilotServer `mapstructure:"servers"`
+}
+
+// AutopilotServer is the state of a member of the raft cluster as seen by
+// autopilot.
+type AutopilotServer struct {
+	ID             string `mapstructure:"id"`
+	Name           string `mapstructure:"name"`
+	Address        string `mapstructure:"address"`
+	NodeStatus     string `mapstructure:"node_status"`
+	LastContact    string `mapstructure:"last_contact"`
+	LastTerm       uint64 `mapstructure:"last_term"`
+	LastIndex      uint64 `mapstructure:"last_index"`
+	Healthy        bool   `mapstructure:"healthy"`
+	StableSince    string `mapstructure:"stable_since"`
+	Status         string `mapstructure:"status"`
+	Version        string `mapstructure:"version"`
+	UpgradeVersion string `mapstructure:"upgrade_version"`
+}
+
+// RaftAutopilotState returns the state of the raft cluster as seen by
+// autopilot.
+func (c *Sys) RaftAutopilotState() (*AutopilotState, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/storage/raft/autopilot/state")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result AutopilotState
+	if err := secret.DecodeData(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RaftSnapshotProgressFunc is called as a snapshot is transferred, with the
+// total number of bytes transferred so far.
+type RaftSnapshotProgressFunc func(bytes int64)
+
 // RaftSnapshot invokes the API that takes the snapshot of the raft cluster and
 // writes it to the supplied io.Writer.
 func (c *Sys) RaftSnapshot(snapWriter io.Writer) error {
-	r := c.c.NewRequest("GET", "/v1/sys/storage/raft/snapshot")
-	r.URL.RawQuery = r.Params.Encode()
+	return c.RaftSnapshotWithProgress(snapWriter, nil)
+}
 
-	req, err := http.NewRequest(http.MethodGet, r.URL.RequestURI(), nil)
+// RaftSnapshotWithProgress is like RaftSnapshot, calling progress as the
+// snapshot is written. The snapshot is streamed to the writer rather than
+// buffered in memory.
+func (c *Sys) RaftSnapshotWithProgress(snapWriter io.Writer, progress RaftSnapshotProgressFunc) error {
+	resp, err := c.raftSnapshotRequest(http.MethodGet, "/v1/sys/storage/raft/snapshot", nil)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(snapWriter, &progressReader{r: resp.Body, progress: progress})
+	return err
+}
+
+// RaftSnapshotRestore reads the snapshot from the io.Reader and installs that
+// snapshot, returning the cluster to the state defined by it.
+func (c *Sys) RaftSnapshotRestore(snapReader io.Reader, force bool) error {
+	return c.RaftSnapshotRestoreWithProgress(snapReader, force, nil)
+}
+
+// RaftSnapshotRestoreWithProgress is like RaftSnapshotRestore, calling
+// progress as the snapshot is uploaded. The snapshot is streamed from the
+// reader rather than buffered in memory, so if the request is redirected to
+// the active node, the reader must implement io.Seeker to be read again.
+func (c *Sys) RaftSnapshotRestoreWithProgress(snapReader io.Reader, force bool, progress RaftSnapshotProgressFunc) error {
+	path := "/v1/sys/storage/raft/snapshot"
+	if force {
+		path = "/v1/sys/storage/raft/snapshot-force"
+	}
+
+	resp, err := c.raftSnapshotRequest(http.MethodPost, path, &progressReader{r: snapReader, progress: progress})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// raftSnapshotRequest sends a snapshot request. It avoids
+// RawRequestWithContext, which buffers request bodies for retries and reads
+// response bodies to determine if they contain an error message.
+func (c *Sys) raftSnapshotRequest(method, path string, body *progressReader) (*http.Response, error) {
+	r := c.c.NewRequest(method, path)
+	r.URL.RawQuery = r.Params.Encode()
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = body
+	}
+	req, err := http.NewRequest(method, r.URL.RequestURI(), reqBody)
+	if err != nil {
+		return nil, err
+	}
 
 	req.URL.User = r.URL.User
 	req.URL.Scheme = r.URL.Scheme
@@ -88,72 +242,77 @@ func (c *Sys) RaftSnapshot(snapWriter io.Writer) error {
 		req.Header.Set("X-Vault-Policy-Override", "true")
 	}
 
-	// Avoiding the use of RawRequestWithContext which reads the response body
-	// to determine if the body contains error message.
-	var result *Response
-	resp, err := c.c.config.HttpClient.Do(req)
+	resp, err := c.c.config.requestHTTPClient().Do(req)
 	if err != nil {
-		return err
-	}
-
-	if resp == nil {
-		return nil
+		return nil, err
 	}
 
 	// Check for a redirect, only allowing for a single redirect
 	if resp.StatusCode == 301 || resp.StatusCode == 302 || resp.StatusCode == 307 {
+		resp.Body.Close()
+
 		// Parse the updated location
 		respLoc, err := resp.Location()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Ensure a protocol downgrade doesn't happen
 		if req.URL.Scheme == "https" && respLoc.Scheme != "https" {
-			return fmt.Errorf("redirect would cause protocol downgrade")
+			return nil, fmt.Errorf("redirect would cause protocol downgrade")
 		}
 
-		// Update the request
+		// Update the request, rewinding the body if there is one
 		req.URL = respLoc
+		if body != nil {
+			if err := body.rewind(); err != nil {
+				return nil, errwrap.Wrapf(fmt.Sprintf("request was redirected to %s: {{err}}", respLoc), err)
+			}
+			req.Body = ioutil.NopCloser(body)
+		}
 
 		// Retry the request
-		resp, err = c.c.config.HttpClient.Do(req)
+		resp, err = c.c.config.requestHTTPClient().Do(req)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	result = &Response{Response: resp}
+	result := &Response{Response: resp}
 	if err := result.Error(); err != nil {
-		return err
+		resp.Body.Close()
+		return nil, err
 	}
 
-	_, err = io.Copy(snapWriter, resp.Body)
-	if err != nil {
-		return err
-	}
+	return resp, nil
+}
 
-	return nil
+// progressReader reports the number of bytes read through it.
+type progressReader struct {
+	r        io.Reader
+	progress RaftSnapshotProgressFunc
+	total    int64
 }
 
-// RaftSnapshotRestore reads the snapshot from the io.Reader and installs that
-// snapshot, returning the cluster to the state defined by it.
-func (c *Sys) RaftSnapshotRestore(snapReader io.Reader, force bool) error {
-	path := "/v1/sys/storage/raft/snapshot"
-	if force {
-		path = "/v1/sys/storage/raft/snapshot-force"
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.total += int64(n)
+		if p.progress != nil {
+			p.progress(p.total)
+		}
 	}
-	r := c.c.NewRequest("POST", path)
-
-	r.Body = snapReader
+	return n, err
+}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
-	defer cancelFunc()
-	resp, err := c.c.RawRequestWithContext(ctx, r)
-	if err != nil {
+func (p *progressReader) rewind() error {
+	seeker, ok := p.r.(io.Seeker)
+	if !ok {
+		return errors.New("snapshot cannot be sent again as the reader is not seekable")
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
+	p.total = 0
 	return nil
 }
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSysRaftSnapshot(t *testing.T) {
+	snapshot := bytes.Repeat([]byte("snapshot"), 1<<14)
+
+	var restored []byte
+	var redirected bool
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == "GET":
+			w.Write(snapshot)
+		case req.URL.Path == "/v1/sys/storage/raft/snapshot-force" && !redirected:
+			redirected = true
+			ioutil.ReadAll(req.Body)
+			http.Redirect(w, req, "/v1/sys/storage/raft/snapshot-force?active=true", http.StatusTemporaryRedirect)
+		default:
+			restored, _ = ioutil.ReadAll(req.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	var saved int64
+	if err := client.Sys().RaftSnapshotWithProgress(&buf, func(n int64) { saved = n }); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), snapshot) || saved != int64(len(snapshot)) {
+		t.Fatalf("bad snapshot: %d bytes, progress %d", buf.Len(), saved)
+	}
+
+	var uploaded int64
+	if err := client.Sys().RaftSnapshotRestoreWithProgress(bytes.NewReader(snapshot), true, func(n int64) { uploaded = n }); err != nil {
+		t.Fatal(err)
+	}
+	if !redirected || !bytes.Equal(restored, snapshot) || uploaded != int64(len(snapshot)) {
+		t.Fatalf("bad restore: redirected %t, %d bytes, progress %d", redirected, len(restored), uploaded)
+	}
+
+	// Non-seekable readers cannot follow redirects.
+	redirected = false
+	err = client.Sys().RaftSnapshotRestore(ioutil.NopCloser(bytes.NewReader(snapshot)), true)
+	if err == nil || !strings.Contains(err.Error(), "not seekable") {
+		t.Fatalf("expected an error, got: %v", err)
+	}
+}
+
+func TestSysRaftAutopilotState(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"data":{"healthy":true,"failure_tolerance":1,"leader":"node1","voters":["node1","node2","node3"],"servers":{"node1":{"id":"node1","address":"10.0.0.1:8201","healthy":true,"last_index":42,"status":"leader"}}}}`))
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := client.Sys().RaftAutopilotState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Healthy || state.FailureTolerance != 1 || len(state.Voters) != 3 {
+		t.Fatalf("bad state: %#v", state)
+	}
+	if server := state.Servers["node1"]; server == nil || server.LastIndex != 42 || server.Status != "leader" {
+		t.Fatalf("bad server: %#v", server)
+	}
+}
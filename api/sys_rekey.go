@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -335,6 +336,46 @@ func (c *Sys) RekeyRecoveryKeyVerificationUpdate(shard, nonce string) (*RekeyVer
 	return &result, err
 }
 
+// RekeyWithShares rekeys the unseal keys: it starts a new rekey with the
+// given configuration and submits the given unseal key shares until the rekey
+// completes. The rekey is cancelled if it fails. If the configuration
+// requires verification, the returned response carries the verification
+// nonce to pass to RekeyVerificationUpdate along with the new keys.
+func (c *Sys) RekeyWithShares(config *RekeyInitRequest, shares []string) (*RekeyUpdateResponse, error) {
+	return rekeyWithSharesCommon(shares, func() (*RekeyStatusResponse, error) {
+		return c.RekeyInit(config)
+	}, c.RekeyUpdate, c.RekeyCancel)
+}
+
+// RekeyRecoveryKeyWithShares rekeys the recovery keys from the given recovery
+// key shares. See RekeyWithShares.
+func (c *Sys) RekeyRecoveryKeyWithShares(config *RekeyInitRequest, shares []string) (*RekeyUpdateResponse, error) {
+	return rekeyWithSharesCommon(shares, func() (*RekeyStatusResponse, error) {
+		return c.RekeyRecoveryKeyInit(config)
+	}, c.RekeyRecoveryKeyUpdate, c.RekeyRecoveryKeyCancel)
+}
+
+func rekeyWithSharesCommon(shares []string, init func() (*RekeyStatusResponse, error), update func(shard, nonce string) (*RekeyUpdateResponse, error), cancel func() error) (*RekeyUpdateResponse, error) {
+	status, err := init()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, share := range shares {
+		result, err := update(share, status.Nonce)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		if result.Complete {
+			return result, nil
+		}
+	}
+
+	cancel()
+	return nil, fmt.Errorf("not enough key shares: %d required", status.Required)
+}
+
 type RekeyInitRequest struct {
 	SecretShares        int      `json:"secret_shares"`
 	SecretThreshold     int      `json:"secret_threshold"`
@@ -0,0 +1,108 @@
+package api
+
+import "context"
+
+// ReplicationStatusResponse is the status of both replication modes.
+type ReplicationStatusResponse struct {
+	DR          *ReplicationModeStatus `json:"dr"`
+	Performance *ReplicationModeStatus `json:"performance"`
+}
+
+// ReplicationModeStatus is the status of a replication mode. Which fields are
+// set depends on whether the cluster is a primary or a secondary.
+type ReplicationModeStatus struct {
+	Mode                     string                        `json:"mode"`
+	State                    string                        `json:"state"`
+	ClusterID                string                        `json:"cluster_id"`
+	PrimaryClusterAddr       string                        `json:"primary_cluster_addr"`
+	KnownPrimaryClusterAddrs []string                      `json:"known_primary_cluster_addrs"`
+	KnownSecondaries         []string                      `json:"known_secondaries"`
+	Secondaries              []*ReplicationSecondaryStatus `json:"secondaries"`
+	Primaries                []*ReplicationPrimaryStatus   `json:"primaries"`
+	SecondaryID              string                        `json:"secondary_id"`
+	LastWAL                  uint64                        `json:"last_wal"`
+	LastRemoteWAL            uint64                        `json:"last_remote_wal"`
+	LastReindexEpoch         string                        `json:"last_reindex_epoch"`
+	MerkleRoot               string                        `json:"merkle_root"`
+	ConnectionState          string                        `json:"connection_state"`
+}
+
+// ReplicationSecondaryStatus describes a secondary known to a primary.
+type ReplicationSecondaryStatus struct {
+	NodeID           string `json:"node_id"`
+	APIAddress       string `json:"api_address"`
+	ClusterAddress   string `json:"cluster_address"`
+	ConnectionStatus string `json:"connection_status"`
+	LastHeartbeat    string `json:"last_heartbeat"`
+}
+
+// ReplicationPrimaryStatus describes the primary a secondary replicates
+// from.
+type ReplicationPrimaryStatus struct {
+	APIAddress       string `json:"api_address"`
+	ClusterAddress   string `json:"cluster_address"`
+	ConnectionStatus string `json:"connection_status"`
+	LastHeartbeat    string `json:"last_heartbeat"`
+}
+
+// ReplicationStatus returns the status of both replication modes.
+func (c *Sys) ReplicationStatus() (*ReplicationStatusResponse, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	return c.replicationStatusWithContext(ctx)
+}
+
+func (c *Sys) replicationStatusWithContext(ctx context.Context) (*ReplicationStatusResponse, error) {
+	var result struct {
+		Data ReplicationStatusResponse `json:"data"`
+	}
+	if err := c.replicationStatus(ctx, "/v1/sys/replication/status", &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// DRStatus returns the status of disaster recovery replication.
+func (c *Sys) DRStatus() (*ReplicationModeStatus, error) {
+	return c.replicationModeStatus("/v1/sys/replication/dr/status")
+}
+
+// PerformanceStatus returns the status of performance replication.
+func (c *Sys) PerformanceStatus() (*ReplicationModeStatus, error) {
+	return c.replicationModeStatus("/v1/sys/replication/performance/status")
+}
+
+func (c *Sys) replicationModeStatus(path string) (*ReplicationModeStatus, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	var result struct {
+		Data ReplicationModeStatus `json:"data"`
+	}
+	if err := c.replicationStatus(ctx, path, &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+func (c *Sys) replicationStatus(ctx context.Context, path string, out interface{}) error {
+	r := c.c.NewRequest("GET", path)
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return resp.DecodeJSON(out)
+}
+
+// GenerateDROperationToken generates a DR operation token on a DR secondary
+// from the given unseal or recovery key shares. See GenerateRoot.
+func (c *Sys) GenerateDROperationToken(keyShares []string) (string, error) {
+	return c.generateOperationTokenCommon(
+		"/v1/sys/replication/dr/secondary/generate-operation-token/attempt",
+		"/v1/sys/replication/dr/secondary/generate-operation-token/update",
+		keyShares)
+}
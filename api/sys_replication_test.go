@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSysGenerateDROperationToken(t *testing.T) {
+	const token = "s.Zmn3LUBKRTvQbHXXsdvXeVoA"
+
+	var otp string
+	var progress int
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		status := map[string]interface{}{
+			"nonce":      "abc",
+			"started":    otp != "",
+			"progress":   progress,
+			"required":   2,
+			"otp_length": len(token),
+		}
+		switch req.URL.Path {
+		case "/v1/sys/replication/dr/secondary/generate-operation-token/attempt":
+			if req.Method == "PUT" {
+				var body map[string]string
+				json.NewDecoder(req.Body).Decode(&body)
+				otp = body["otp"]
+				status["started"] = true
+			}
+		case "/v1/sys/replication/dr/secondary/generate-operation-token/update":
+			progress++
+			status["progress"] = progress
+			if progress == 2 {
+				encoded, _ := xorBytes([]byte(token), []byte(otp))
+				status["complete"] = true
+				status["encoded_token"] = base64.RawStdEncoding.EncodeToString(encoded)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(status)
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := client.Sys().GenerateDROperationToken([]string{"share1", "share2", "share3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != token {
+		t.Fatalf("expected %q, got %q", token, got)
+	}
+	if len(otp) != len(token) || progress != 2 {
+		t.Fatalf("unexpected attempt: otp %q, progress %d", otp, progress)
+	}
+}
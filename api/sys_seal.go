@@ -1,6 +1,9 @@
 package api
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 func (c *Sys) SealStatus() (*SealStatusResponse, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/seal-status")
@@ -50,6 +53,27 @@ func (c *Sys) UnsealWithOptions(opts *UnsealOpts) (*SealStatusResponse, error) {
 	return sealStatusRequest(c, r)
 }
 
+// UnsealWithShares submits the given unseal key shares until Vault is
+// unsealed, returning the final seal status. Shares remaining once Vault is
+// unsealed are not submitted.
+func (c *Sys) UnsealWithShares(shares []string) (*SealStatusResponse, error) {
+	var status *SealStatusResponse
+	for _, share := range shares {
+		var err error
+		status, err = c.Unseal(share)
+		if err != nil {
+			return nil, err
+		}
+		if !status.Sealed {
+			return status, nil
+		}
+	}
+	if status == nil {
+		return nil, fmt.Errorf("no unseal key shares provided")
+	}
+	return status, fmt.Errorf("not enough unseal key shares: %d of %d provided", status.Progress, status.T)
+}
+
 func sealStatusRequest(c *Sys, r *Request) (*SealStatusResponse, error) {
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
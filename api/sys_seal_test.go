@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSysUnsealWithShares(t *testing.T) {
+	var submitted []string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		submitted = append(submitted, body["key"].(string))
+
+		json.NewEncoder(w).Encode(&SealStatusResponse{
+			Sealed:   len(submitted) < 2,
+			T:        2,
+			N:        3,
+			Progress: len(submitted) % 2,
+		})
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := client.Sys().UnsealWithShares([]string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Sealed || len(submitted) != 2 {
+		t.Fatalf("expected unseal after 2 shares, got %#v after %v", status, submitted)
+	}
+
+	submitted = nil
+	status, err = client.Sys().UnsealWithShares([]string{"a"})
+	if err == nil || status == nil || !status.Sealed {
+		t.Fatalf("expected error with sealed status, got %#v, %v", status, err)
+	}
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// UICustomMessageTypeBanner messages are displayed inline and need no
+	// acknowledgement.
+	UICustomMessageTypeBanner = "banner"
+
+	// UICustomMessageTypeModal messages interrupt the user and must be
+	// acknowledged before continuing.
+	UICustomMessageTypeModal = "modal"
+)
+
+// UICustomMessage is an operator-configured message displayed to users.
+type UICustomMessage struct {
+	ID            string                 `json:"id" mapstructure:"id"`
+	Title         string                 `json:"title" mapstructure:"title"`
+	Message       string                 `json:"message" mapstructure:"message"`
+	Authenticated bool                   `json:"authenticated" mapstructure:"authenticated"`
+	Type          string                 `json:"type" mapstructure:"type"`
+	StartTime     string                 `json:"start_time" mapstructure:"start_time"`
+	EndTime       string                 `json:"end_time,omitempty" mapstructure:"end_time"`
+	Link          map[string]string      `json:"link,omitempty" mapstructure:"link"`
+	Options       map[string]interface{} `json:"options,omitempty" mapstructure:"options"`
+	Active        bool                   `json:"active" mapstructure:"active"`
+}
+
+// RequiresAcknowledgement reports whether the message must be explicitly
+// acknowledged by the user before they proceed, as is the case for modal
+// messages.
+func (m *UICustomMessage) RequiresAcknowledgement() bool {
+	return m.Type == UICustomMessageTypeModal
+}
+
+// UICustomMessageRequest is used to create or update a custom message.
+type UICustomMessageRequest struct {
+	Title         string                 `json:"title"`
+	Message       string                 `json:"message"`
+	Authenticated bool                   `json:"authenticated"`
+	Type          string                 `json:"type"`
+	StartTime     time.Time              `json:"start_time"`
+	EndTime       *time.Time             `json:"end_time,omitempty"`
+	Link          map[string]string      `json:"link,omitempty"`
+	Options       map[string]interface{} `json:"options,omitempty"`
+}
+
+// UICustomMessageListRequest filters the messages returned by
+// ListUICustomMessages. Unset fields do not filter.
+type UICustomMessageListRequest struct {
+	Authenticated *bool
+	Type          string
+	Active        *bool
+}
+
+// ListUICustomMessages lists the custom messages configured for the UI.
+func (c *Sys) ListUICustomMessages(req UICustomMessageListRequest) ([]*UICustomMessage, error) {
+	r := c.c.NewRequest("LIST", "/v1/sys/config/ui/custom-messages")
+	// Set this for broader compatibility, but we use LIST above to be able to
+	// handle the wrapping lookup function
+	r.Method = "GET"
+	r.Params.Set("list", "true")
+	if req.Authenticated != nil {
+		r.Params.Set("authenticated", fmt.Sprintf("%t", *req.Authenticated))
+	}
+	if req.Type != "" {
+		r.Params.Set("type", req.Type)
+	}
+	if req.Active != nil {
+		r.Params.Set("active", fmt.Sprintf("%t", *req.Active))
+	}
+
+	return c.customMessages(r, "key_info")
+}
+
+// CreateUICustomMessage creates a custom message, returning its ID.
+func (c *Sys) CreateUICustomMessage(req UICustomMessageRequest) (string, error) {
+	r := c.c.NewRequest("POST", "/v1/sys/config/ui/custom-messages")
+	if err := r.SetJSONBody(req); err != nil {
+		return "", err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if secret == nil || secret.Data == nil {
+		return "", errors.New("data from server response is empty")
+	}
+
+	id, _ := secret.Data["id"].(string)
+	return id, nil
+}
+
+// ReadUICustomMessage reads the custom message with the given ID.
+func (c *Sys) ReadUICustomMessage(id string) (*UICustomMessage, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/config/ui/custom-messages/%s", id))
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result UICustomMessage
+	if err := mapstructure.Decode(secret.Data, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// UpdateUICustomMessage replaces the custom message with the given ID.
+func (c *Sys) UpdateUICustomMessage(id string, req UICustomMessageRequest) error {
+	r := c.c.NewRequest("POST", fmt.Sprintf("/v1/sys/config/ui/custom-messages/%s", id))
+	if err := r.SetJSONBody(req); err != nil {
+		return err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// DeleteUICustomMessage deletes the custom message with the given ID.
+func (c *Sys) DeleteUICustomMessage(id string) error {
+	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/config/ui/custom-messages/%s", id))
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// AuthenticatedMessages returns the currently active messages to display to
+// authenticated users.
+func (c *Sys) AuthenticatedMessages() ([]*UICustomMessage, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/internal/ui/authenticated-messages")
+	return c.customMessages(r, "key_info")
+}
+
+// UnauthenticatedMessages returns the currently active messages to display
+// before users log in.
+func (c *Sys) UnauthenticatedMessages() ([]*UICustomMessage, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/internal/ui/unauthenticated-messages")
+	return c.customMessages(r, "key_info")
+}
+
+// customMessages performs the request and decodes the messages from the
+// given key of the response data, which maps message IDs to messages.
+func (c *Sys) customMessages(r *Request, key string) ([]*UICustomMessage, error) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var messagesByID map[string]*UICustomMessage
+	if err := mapstructure.Decode(secret.Data[key], &messagesByID); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	if err := mapstructure.Decode(secret.Data["keys"], &keys); err != nil {
+		return nil, err
+	}
+
+	result := make([]*UICustomMessage, 0, len(keys))
+	for _, id := range keys {
+		message, ok := messagesByID[id]
+		if !ok {
+			continue
+		}
+		message.ID = id
+		result = append(result, message)
+	}
+
+	return result, nil
+}
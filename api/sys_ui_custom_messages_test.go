@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSysUICustomMessages(t *testing.T) {
+	var listQuery url.Values
+	var created map[string]interface{}
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/sys/config/ui/custom-messages":
+			switch req.Method {
+			case http.MethodGet:
+				listQuery = req.URL.Query()
+				w.Write([]byte(`{"data": {
+					"keys": ["b", "a", "missing"],
+					"key_info": {
+						"a": {"title": "Maintenance", "type": "modal", "authenticated": true, "start_time": "2024-01-01T00:00:00Z", "active": true},
+						"b": {"title": "Welcome", "type": "banner", "start_time": "2024-01-01T00:00:00Z"}
+					}
+				}}`))
+			case http.MethodPost:
+				json.NewDecoder(req.Body).Decode(&created)
+				w.Write([]byte(`{"data": {"id": "c"}}`))
+			}
+		case "/v1/sys/config/ui/custom-messages/a":
+			w.Write([]byte(`{"data": {"id": "a", "title": "Maintenance", "message": "aGVsbG8=", "type": "modal", "authenticated": true, "start_time": "2024-01-01T00:00:00Z", "end_time": "2024-01-02T00:00:00Z", "link": {"Docs": "https://example.com"}, "active": true}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sys := client.Sys()
+
+	// Messages are returned in the order of the keys, skipping keys without
+	// details
+	messages, err := sys.ListUICustomMessages(UICustomMessageListRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 || messages[0].ID != "b" || messages[1].ID != "a" {
+		t.Fatalf("unexpected messages %#v", messages)
+	}
+	if messages[1].Title != "Maintenance" || !messages[1].Authenticated || !messages[1].Active {
+		t.Fatalf("unexpected message %#v", messages[1])
+	}
+	if listQuery.Get("list") != "true" {
+		t.Fatalf("expected list query, got %v", listQuery)
+	}
+	for _, param := range []string{"authenticated", "type", "active"} {
+		if _, ok := listQuery[param]; ok {
+			t.Fatalf("expected no %s filter, got %v", param, listQuery)
+		}
+	}
+
+	authenticated, active := true, false
+	_, err = sys.ListUICustomMessages(UICustomMessageListRequest{
+		Authenticated: &authenticated,
+		Type:          UICustomMessageTypeModal,
+		Active:        &active,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listQuery.Get("authenticated") != "true" || listQuery.Get("type") != "modal" || listQuery.Get("active") != "false" {
+		t.Fatalf("unexpected filters %v", listQuery)
+	}
+
+	message, err := sys.ReadUICustomMessage("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.ID != "a" || message.Message != "aGVsbG8=" || message.EndTime != "2024-01-02T00:00:00Z" || message.Link["Docs"] != "https://example.com" {
+		t.Fatalf("unexpected message %#v", message)
+	}
+	if _, err := sys.ReadUICustomMessage("missing"); err == nil {
+		t.Fatal("expected error for missing message")
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	id, err := sys.CreateUICustomMessage(UICustomMessageRequest{
+		Title:     "Welcome",
+		Message:   "aGVsbG8=",
+		Type:      UICustomMessageTypeBanner,
+		StartTime: start,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "c" {
+		t.Fatalf("expected id c, got %q", id)
+	}
+	if created["title"] != "Welcome" || created["type"] != "banner" || created["start_time"] != "2024-01-01T00:00:00Z" {
+		t.Fatalf("unexpected request %#v", created)
+	}
+	if _, ok := created["end_time"]; ok {
+		t.Fatalf("expected unset end time to be omitted, got %#v", created)
+	}
+}
+
+func TestUICustomMessage_RequiresAcknowledgement(t *testing.T) {
+	tests := map[string]bool{
+		UICustomMessageTypeModal:  true,
+		UICustomMessageTypeBanner: false,
+		"":                        false,
+	}
+	for messageType, expected := range tests {
+		message := &UICustomMessage{Type: messageType}
+		if actual := message.RequiresAcknowledgement(); actual != expected {
+			t.Fatalf("type %q: expected %t, got %t", messageType, expected, actual)
+		}
+	}
+}
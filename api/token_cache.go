@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// TokenCache persists a token, along with its lease metadata, to a file
+// encrypted with AES-GCM, so that short-lived processes can reuse a token
+// obtained by a long-running one without authenticating again. The key is
+// typically the shared key derived by both processes from a Diffie-Hellman
+// exchange, as done for agent sinks with dhutil.GenerateSharedKey.
+//
+// TokenCache is a TokenSource returning the cached token until it expires.
+type TokenCache struct {
+	// Path is the path of the cache file.
+	Path string
+
+	// Key is the AES key, which must be 16, 24, or 32 bytes long.
+	Key []byte
+
+	// AAD is optional additional data authenticated along with the token.
+	AAD []byte
+}
+
+// CachedToken is a token stored in a TokenCache.
+type CachedToken struct {
+	Token      string    `json:"token"`
+	Accessor   string    `json:"accessor,omitempty"`
+	Renewable  bool      `json:"renewable"`
+	ExpireTime time.Time `json:"expire_time,omitempty"`
+}
+
+// Expired reports whether the token has expired. Tokens without an expiry
+// time never expire.
+func (t *CachedToken) Expired() bool {
+	return !t.ExpireTime.IsZero() && !time.Now().Before(t.ExpireTime)
+}
+
+// tokenCacheEnvelope is the format of the cache file. It uses the same field
+// names as the envelopes of encrypted agent sinks.
+type tokenCacheEnvelope struct {
+	Nonce            []byte `json:"nonce"`
+	EncryptedPayload []byte `json:"encrypted_payload"`
+}
+
+// Store encrypts the token and writes it to the cache file, replacing any
+// previous token. The file is only readable by the current user.
+func (c *TokenCache) Store(token *CachedToken) error {
+	if token == nil || token.Token == "" {
+		return errors.New("no token to cache")
+	}
+
+	plaintext, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	gcm, err := c.gcm()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	contents, err := json.Marshal(&tokenCacheEnvelope{
+		Nonce:            nonce,
+		EncryptedPayload: gcm.Seal(nil, nonce, plaintext, c.AAD),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial file
+	tmp, err := ioutil.TempFile(filepath.Dir(c.Path), filepath.Base(c.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.Path)
+}
+
+// StoreSecret caches the token of the given secret, as returned by a login
+// or token creation, or by a token lookup.
+func (c *TokenCache) StoreSecret(secret *Secret) error {
+	id, err := secret.TokenID()
+	if err != nil {
+		return err
+	}
+	accessor, err := secret.TokenAccessor()
+	if err != nil {
+		return err
+	}
+	renewable, err := secret.TokenIsRenewable()
+	if err != nil {
+		return err
+	}
+	expireTime, err := secret.TokenExpireTime()
+	if err != nil {
+		return err
+	}
+
+	return c.Store(&CachedToken{
+		Token:      id,
+		Accessor:   accessor,
+		Renewable:  renewable,
+		ExpireTime: expireTime,
+	})
+}
+
+// Load reads and decrypts the cached token. It returns nil if there is no
+// cache file.
+func (c *TokenCache) Load() (*CachedToken, error) {
+	contents, err := ioutil.ReadFile(c.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope tokenCacheEnvelope
+	if err := json.Unmarshal(contents, &envelope); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to parse token cache %q: {{err}}", c.Path), err)
+	}
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce in token cache %q", c.Path)
+	}
+	plaintext, err := gcm.Open(nil, envelope.Nonce, envelope.EncryptedPayload, c.AAD)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to decrypt token cache %q: {{err}}", c.Path), err)
+	}
+
+	var token CachedToken
+	if err := json.Unmarshal(plaintext, &token); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to parse token cache %q: {{err}}", c.Path), err)
+	}
+	return &token, nil
+}
+
+// Erase removes the cache file.
+func (c *TokenCache) Erase() error {
+	if err := os.Remove(c.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Token returns the cached token, or the empty string if there is none or it
+// has expired.
+func (c *TokenCache) Token(context.Context, *Client) (string, error) {
+	token, err := c.Load()
+	if err != nil || token == nil || token.Expired() {
+		return "", err
+	}
+	return token.Token, nil
+}
+
+func (c *TokenCache) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.Key)
+	if err != nil {
+		return nil, errwrap.Wrapf("invalid token cache key: {{err}}", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTokenCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := &TokenCache{
+		Path: filepath.Join(dir, "token"),
+		Key:  bytes.Repeat([]byte{1}, 32),
+	}
+
+	token, err := cache.Load()
+	if err != nil || token != nil {
+		t.Fatalf("expected no cached token, got %#v, %v", token, err)
+	}
+
+	secret := &Secret{
+		Auth: &SecretAuth{
+			ClientToken:   "cached-token",
+			Accessor:      "accessor",
+			Renewable:     true,
+			LeaseDuration: 3600,
+		},
+	}
+	if err := cache.StoreSecret(secret); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(cache.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(contents, []byte("cached-token")) {
+		t.Fatal("token stored in plaintext")
+	}
+
+	token, err = cache.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "cached-token" || token.Accessor != "accessor" || !token.Renewable ||
+		token.ExpireTime.Before(time.Now().Add(59*time.Minute)) {
+		t.Fatalf("unexpected cached token %#v", token)
+	}
+
+	config := DefaultConfig()
+	config.TokenSources = []TokenSource{cache}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Token() != "cached-token" {
+		t.Fatalf("expected cached token, got %q", client.Token())
+	}
+
+	wrongKey := &TokenCache{Path: cache.Path, Key: bytes.Repeat([]byte{2}, 32)}
+	if _, err := wrongKey.Load(); err == nil {
+		t.Fatal("expected error decrypting with the wrong key")
+	}
+
+	if err := cache.Store(&CachedToken{Token: "expired", ExpireTime: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if token, err := cache.Token(context.Background(), nil); err != nil || token != "" {
+		t.Fatalf("expected no token once expired, got %q, %v", token, err)
+	}
+
+	if err := cache.Erase(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cache.Path); !os.IsNotExist(err) {
+		t.Fatal("expected cache file to be removed")
+	}
+}
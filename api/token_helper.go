@@ -0,0 +1,220 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// ErrKeychainUnsupported is returned by KeychainTokenHelper on platforms
+// without a supported keychain.
+var ErrKeychainUnsupported = errors.New("no supported keychain on this platform")
+
+// execCommand is swapped out in tests.
+var execCommand = exec.Command
+
+var (
+	_ TokenHelper = (*ExternalTokenHelper)(nil)
+	_ TokenHelper = (*KeychainTokenHelper)(nil)
+)
+
+// ExternalTokenHelper stores tokens with an external token helper, using the
+// same protocol as the Vault CLI: the executable at BinaryPath is run with
+// the operation as its only argument, which is one of:
+//
+//   - "get" - Write the stored token to stdout.
+//   - "store" - Store the token read from stdin.
+//   - "erase" - Erase the stored token.
+//
+// If the helper exits with a non-zero exit code, its stderr is made part of
+// the error. Unlike the CLI, the helper is executed directly rather than
+// through a shell, and BinaryPath must be absolute.
+type ExternalTokenHelper struct {
+	BinaryPath string
+
+	// Env is the environment of the helper. If nil, the helper inherits the
+	// environment of the current process.
+	Env []string
+}
+
+// Get returns the token stored by the helper.
+func (h *ExternalTokenHelper) Get() (string, error) {
+	var stdout bytes.Buffer
+	if err := h.run("get", nil, &stdout); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Store stores the token with the helper.
+func (h *ExternalTokenHelper) Store(token string) error {
+	return h.run("store", strings.NewReader(token), nil)
+}
+
+// Erase erases the token stored by the helper.
+func (h *ExternalTokenHelper) Erase() error {
+	return h.run("erase", nil, nil)
+}
+
+func (h *ExternalTokenHelper) run(op string, stdin *strings.Reader, stdout *bytes.Buffer) error {
+	if !filepath.IsAbs(h.BinaryPath) {
+		return fmt.Errorf("token helper path %q is not absolute", h.BinaryPath)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(h.BinaryPath, op)
+	cmd.Env = h.Env
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("token helper %s failed: %q: {{err}}", op, stderr.String()), err)
+	}
+	return nil
+}
+
+// KeychainTokenHelper stores tokens in the native keychain of the operating
+// system: the macOS Keychain, the Windows Credential Manager, or a Secret
+// Service provider such as GNOME Keyring on Linux. On macOS and Linux, the
+// keychain is accessed with the "security" and "secret-tool" commands
+// respectively, which must be installed.
+type KeychainTokenHelper struct {
+	// Service and Account identify the stored token. Service defaults to
+	// "vault".
+	Service string
+	Account string
+}
+
+func (h *KeychainTokenHelper) service() string {
+	if h.Service == "" {
+		return "vault"
+	}
+	return h.Service
+}
+
+// Get returns the token stored in the keychain, or the empty string if there
+// is none.
+func (h *KeychainTokenHelper) Get() (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := keychainCommand("security", nil,
+			"find-generic-password", "-s", h.service(), "-a", h.Account, "-w")
+		if exitCode(err) == 44 {
+			// errSecItemNotFound
+			return "", nil
+		}
+		return strings.TrimSpace(out), err
+	case "linux", "freebsd", "openbsd", "netbsd":
+		out, err := keychainCommand("secret-tool", nil,
+			"lookup", "service", h.service(), "account", h.Account)
+		if exitCode(err) == 1 && out == "" {
+			// secret-tool exits with 1 when no secret matches
+			return "", nil
+		}
+		return strings.TrimSpace(out), err
+	case "windows":
+		return credentialRead(h.target())
+	default:
+		return "", ErrKeychainUnsupported
+	}
+}
+
+// Store stores the token in the keychain, replacing any existing token.
+func (h *KeychainTokenHelper) Store(token string) error {
+	return h.store(runtime.GOOS, token)
+}
+
+func (h *KeychainTokenHelper) store(goos, token string) error {
+	var err error
+	switch goos {
+	case "darwin":
+		var command string
+		if command, err = h.securityStoreCommand(token); err == nil {
+			_, err = keychainCommand("security", strings.NewReader(command), "-i")
+		}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		_, err = keychainCommand("secret-tool", strings.NewReader(token),
+			"store", "--label", h.service()+" token", "service", h.service(), "account", h.Account)
+	case "windows":
+		err = credentialWrite(h.target(), h.Account, token)
+	default:
+		err = ErrKeychainUnsupported
+	}
+	return err
+}
+
+// Erase removes the token from the keychain.
+func (h *KeychainTokenHelper) Erase() error {
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		_, err = keychainCommand("security", nil,
+			"delete-generic-password", "-s", h.service(), "-a", h.Account)
+		if exitCode(err) == 44 {
+			err = nil
+		}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		_, err = keychainCommand("secret-tool", nil,
+			"clear", "service", h.service(), "account", h.Account)
+	case "windows":
+		err = credentialDelete(h.target())
+	default:
+		err = ErrKeychainUnsupported
+	}
+	return err
+}
+
+// securityStoreCommand returns the command storing the token, to be run by
+// "security" in interactive mode. The command is written to its stdin, as
+// passing the token as an argument would expose it to other processes.
+func (h *KeychainTokenHelper) securityStoreCommand(token string) (string, error) {
+	args := []string{"add-generic-password", "-U", "-s", h.service(), "-a", h.Account, "-w", token}
+	for i, arg := range args {
+		if strings.ContainsAny(arg, "\r\n") {
+			return "", fmt.Errorf("keychain item values cannot contain line breaks")
+		}
+		args[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+	}
+	return strings.Join(args, " ") + "\n", nil
+}
+
+// target is the name of the credential in the Windows Credential Manager.
+func (h *KeychainTokenHelper) target() string {
+	return h.service() + ":" + h.Account
+}
+
+func keychainCommand(name string, stdin *strings.Reader, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := execCommand(name, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), errwrap.Wrapf(fmt.Sprintf("%s failed: %q: {{err}}", name, strings.TrimSpace(stderr.String())), err)
+	}
+	return stdout.String(), nil
+}
+
+// exitCode returns the exit code of the command that failed with err, or -1
+// if it did not exit with an error.
+func exitCode(err error) int {
+	if err == nil {
+		return -1
+	}
+	if exitErr, ok := errwrap.GetType(err, &exec.ExitError{}).(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
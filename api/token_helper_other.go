@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package api
+
+func credentialRead(target string) (string, error) {
+	return "", ErrKeychainUnsupported
+}
+
+func credentialWrite(target, user, token string) error {
+	return ErrKeychainUnsupported
+}
+
+func credentialDelete(target string) error {
+	return ErrKeychainUnsupported
+}
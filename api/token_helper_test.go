@@ -0,0 +1,106 @@
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExternalTokenHelper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token-helper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	helperPath := filepath.Join(dir, "helper")
+	script := `#!/bin/sh
+store="$(dirname "$0")/token"
+case "$1" in
+	get) [ -f "$store" ] && cat "$store" ;;
+	store) cat > "$store" ;;
+	erase) rm -f "$store" ;;
+	*) echo "unknown operation" >&2; exit 1 ;;
+esac
+exit 0
+`
+	if err := ioutil.WriteFile(helperPath, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	helper := &ExternalTokenHelper{BinaryPath: helperPath}
+	if err := helper.Store("helper-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	config.TokenSources = []TokenSource{&TokenHelperSource{Helper: helper}}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Token() != "helper-token" {
+		t.Fatalf("expected token from helper, got %q", client.Token())
+	}
+
+	if err := helper.Erase(); err != nil {
+		t.Fatal(err)
+	}
+	token, err := helper.Get()
+	if err != nil || token != "" {
+		t.Fatalf("expected no token after erase, got %q, %v", token, err)
+	}
+
+	if _, err := (&ExternalTokenHelper{BinaryPath: "helper"}).Get(); err == nil {
+		t.Fatal("expected error for relative helper path")
+	}
+}
+
+func TestKeychainTokenHelper_StoreDarwin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token-helper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stdinPath := filepath.Join(dir, "stdin")
+
+	var name string
+	var args []string
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+	execCommand = func(command string, arg ...string) *exec.Cmd {
+		name, args = command, arg
+		return exec.Command("sh", "-c", `cat > "$0"`, stdinPath)
+	}
+
+	helper := &KeychainTokenHelper{Account: `the "user"`}
+	if err := helper.store("darwin", "s.secret-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The token is written to the stdin of security rather than passed as
+	// an argument, where other processes could read it
+	if name != "security" {
+		t.Fatalf("unexpected command %q", name)
+	}
+	for _, arg := range args {
+		if strings.Contains(arg, "s.secret-token") {
+			t.Fatalf("token found in arguments %q", args)
+		}
+	}
+	stdin, err := ioutil.ReadFile(stdinPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `"add-generic-password" "-U" "-s" "vault" "-a" "the \"user\"" "-w" "s.secret-token"` + "\n"
+	if string(stdin) != expected {
+		t.Fatalf("expected stdin %q, got %q", expected, stdin)
+	}
+
+	if err := helper.store("darwin", "s.secret\ntoken"); err == nil {
+		t.Fatal("expected error for token with line break")
+	}
+}
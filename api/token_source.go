@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// TokenSource provides the token a client authenticates with. Sources are
+// listed in Config.TokenSources and consulted in order when the client is
+// created; the first to return a non-empty token wins.
+type TokenSource interface {
+	// Token returns a token, or the empty string if the source has none to
+	// offer. The client is provided for sources that need to talk to Vault,
+	// e.g. to log in or unwrap a token; it has no token set.
+	Token(ctx context.Context, client *Client) (string, error)
+}
+
+// TokenSourceFunc adapts a function to a TokenSource.
+type TokenSourceFunc func(ctx context.Context, client *Client) (string, error)
+
+// Token calls f.
+func (f TokenSourceFunc) Token(ctx context.Context, client *Client) (string, error) {
+	return f(ctx, client)
+}
+
+// EnvTokenSource reads the token from an environment variable, VAULT_TOKEN by
+// default. This is the behavior of clients without TokenSources. The variable
+// is read the way the client's environment was, e.g. with its prefix.
+type EnvTokenSource struct {
+	Name string
+}
+
+// Token returns the value of the environment variable.
+func (s *EnvTokenSource) Token(_ context.Context, client *Client) (string, error) {
+	name := s.Name
+	if name == "" {
+		name = EnvVaultToken
+	}
+	if client == nil {
+		return os.Getenv(name), nil
+	}
+	return client.config.lookupEnv(name), nil
+}
+
+// FileTokenSource reads the token from a file, such as a file sink written by
+// a Vault agent. A missing file yields no token. If the sink is response
+// wrapped, set Unwrap to unwrap the token with the client.
+type FileTokenSource struct {
+	Path   string
+	Unwrap bool
+}
+
+// Token returns the contents of the file, unwrapping them if configured.
+func (s *FileTokenSource) Token(ctx context.Context, client *Client) (string, error) {
+	contents, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(contents))
+	if !s.Unwrap || token == "" {
+		return token, nil
+	}
+
+	var wrapInfo SecretWrapInfo
+	if err := json.Unmarshal([]byte(token), &wrapInfo); err != nil {
+		return "", errwrap.Wrapf(fmt.Sprintf("failed to parse wrapped token in %q: {{err}}", s.Path), err)
+	}
+	secret, err := client.Logical().Unwrap(wrapInfo.Token)
+	if err != nil {
+		return "", errwrap.Wrapf("failed to unwrap token: {{err}}", err)
+	}
+	if secret == nil || secret.Auth == nil {
+		return "", errors.New("wrapped response did not contain a token")
+	}
+	return secret.Auth.ClientToken, nil
+}
+
+// TokenHelper is the subset of the CLI's token helper interface needed to
+// read a stored token.
+type TokenHelper interface {
+	Get() (string, error)
+}
+
+// TokenHelperSource reads the token from a token helper, such as the one
+// used by the Vault CLI to store the token of the last login, an
+// ExternalTokenHelper, or a KeychainTokenHelper.
+type TokenHelperSource struct {
+	Helper TokenHelper
+}
+
+// Token returns the token stored by the helper.
+func (s *TokenHelperSource) Token(context.Context, *Client) (string, error) {
+	return s.Helper.Get()
+}
+
+// LoginTokenSource obtains a token by logging in with an auth method.
+type LoginTokenSource struct {
+	// Login performs the login, returning the secret holding the new token.
+	Login func(ctx context.Context, client *Client) (*Secret, error)
+}
+
+// Token logs in and returns the resulting token.
+func (s *LoginTokenSource) Token(ctx context.Context, client *Client) (string, error) {
+	secret, err := s.Login(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	if secret == nil || secret.Auth == nil {
+		return "", errors.New("login response did not contain a token")
+	}
+	return secret.Auth.ClientToken, nil
+}
+
+// tokenFromSources consults the sources in order, returning the first token
+// found. Errors from sources are skipped over, and only returned if no source
+// provides a token.
+func (c *Client) tokenFromSources(ctx context.Context, sources []TokenSource) (string, error) {
+	var result *multierror.Error
+	for _, source := range sources {
+		token, err := source.Token(ctx, c)
+		if err != nil {
+			result = multierror.Append(result, errwrap.Wrapf(fmt.Sprintf("token source %T: {{err}}", source), err))
+			continue
+		}
+		if token != "" {
+			return token, nil
+		}
+	}
+	return "", result.ErrorOrNil()
+}
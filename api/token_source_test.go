@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testTokenHelper string
+
+func (h testTokenHelper) Get() (string, error) {
+	return string(h), nil
+}
+
+func TestClientTokenSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token-source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sinkPath := filepath.Join(dir, "sink")
+
+	failing := TokenSourceFunc(func(context.Context, *Client) (string, error) {
+		return "", errors.New("unavailable")
+	})
+
+	config := DefaultConfig()
+	config.TokenSources = []TokenSource{
+		&FileTokenSource{Path: sinkPath},
+		failing,
+		&TokenHelperSource{Helper: testTokenHelper("helper-token")},
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Token() != "helper-token" {
+		t.Fatalf("expected token from helper, got %q", client.Token())
+	}
+
+	if err := ioutil.WriteFile(sinkPath, []byte("sink-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client, err = NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Token() != "sink-token" {
+		t.Fatalf("expected token from sink, got %q", client.Token())
+	}
+
+	config.TokenSources = []TokenSource{failing}
+	if _, err := NewClient(config); err == nil {
+		t.Fatal("expected error when no source provides a token")
+	}
+}
+
+func TestClientLoginTokenSource(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/auth/approle/login" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"auth": {"client_token": "login-token"}}`))
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	config.TokenSources = []TokenSource{
+		&EnvTokenSource{Name: "VAULT_TEST_UNSET_TOKEN"},
+		&LoginTokenSource{
+			Login: func(ctx context.Context, client *Client) (*Secret, error) {
+				return client.Logical().Write("auth/approle/login", map[string]interface{}{
+					"role_id": "role",
+				})
+			},
+		},
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Token() != "login-token" {
+		t.Fatalf("expected token from login, got %q", client.Token())
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ClusterTopology describes the nodes of a Vault cluster and its replication
+// relationships, as observed from the node the client is talking to.
+type ClusterTopology struct {
+	ClusterName string
+	ClusterID   string
+
+	// ActiveNode is the active node of the cluster, if known.
+	ActiveNode *TopologyNode
+
+	// Standbys are the standby nodes of the cluster. Populating these
+	// requires sys/ha-status, which is not available on all servers.
+	Standbys []*TopologyNode
+
+	// PerformanceMode and DRMode are the replication modes of this cluster,
+	// e.g. "primary", "secondary", or "disabled".
+	PerformanceMode string
+	DRMode          string
+
+	// PerformanceSecondaries and DRSecondaries are the IDs of the secondaries
+	// known to this cluster when it is a replication primary.
+	PerformanceSecondaries []string
+	DRSecondaries          []string
+
+	// Warnings lists the parts of the topology that could not be discovered,
+	// for instance because the token lacks permission on an endpoint.
+	Warnings []string
+
+	DiscoveredAt time.Time
+}
+
+// TopologyNode is a single node of a cluster.
+type TopologyNode struct {
+	Hostname       string
+	APIAddress     string
+	ClusterAddress string
+	Active         bool
+	LastEcho       *time.Time
+	Version        string
+}
+
+// DiscoverTopology combines sys/health, sys/leader, sys/ha-status, and
+// replication status into a single view of the cluster. Health and leader
+// information are required; the remaining endpoints are optional and failures
+// to read them are recorded in the topology's Warnings.
+func (c *Client) DiscoverTopology(ctx context.Context) (*ClusterTopology, error) {
+	sys := c.Sys()
+
+	health, err := sys.healthWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	leader, err := sys.leaderWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	topology := &ClusterTopology{
+		ClusterName:     health.ClusterName,
+		ClusterID:       health.ClusterID,
+		PerformanceMode: health.ReplicationPerformanceMode,
+		DRMode:          health.ReplicationDRMode,
+		DiscoveredAt:    time.Now(),
+	}
+
+	if leader.LeaderAddress != "" {
+		topology.ActiveNode = &TopologyNode{
+			APIAddress:     leader.LeaderAddress,
+			ClusterAddress: leader.LeaderClusterAddress,
+			Active:         true,
+		}
+	}
+
+	haStatus, err := sys.haStatusWithContext(ctx)
+	if err != nil {
+		topology.Warnings = append(topology.Warnings, fmt.Sprintf("unable to read HA status: %v", err))
+	} else {
+		for _, n := range haStatus.Nodes {
+			node := &TopologyNode{
+				Hostname:       n.Hostname,
+				APIAddress:     n.APIAddress,
+				ClusterAddress: n.ClusterAddress,
+				Active:         n.ActiveNode,
+				LastEcho:       n.LastEcho,
+				Version:        n.Version,
+			}
+			if node.Active {
+				topology.ActiveNode = node
+			} else {
+				topology.Standbys = append(topology.Standbys, node)
+			}
+		}
+	}
+
+	status, err := sys.replicationStatusWithContext(ctx)
+	if err != nil {
+		topology.Warnings = append(topology.Warnings, fmt.Sprintf("unable to read replication status: %v", err))
+	} else {
+		if status.Performance != nil && status.Performance.Mode == "primary" {
+			topology.PerformanceSecondaries = status.Performance.KnownSecondaries
+		}
+		if status.DR != nil && status.DR.Mode == "primary" {
+			topology.DRSecondaries = status.DR.KnownSecondaries
+		}
+	}
+
+	return topology, nil
+}
+
+// WatchTopology periodically rediscovers the cluster topology, delivering each
+// result on the returned channel. Discovery errors are skipped; the channel is
+// closed when the context is cancelled or the client is closed.
+func (c *Client) WatchTopology(ctx context.Context, interval time.Duration) <-chan *ClusterTopology {
+	topologyCh := make(chan *ClusterTopology, 1)
+	ctx, cancelFunc := c.withCloseContext(ctx)
+
+	go func() {
+		defer cancelFunc()
+		defer close(topologyCh)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if topology, err := c.DiscoverTopology(ctx); err == nil {
+				select {
+				case topologyCh <- topology:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return topologyCh
+}
@@ -0,0 +1,380 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Transform is used to work with the transform secrets engine, which
+// encodes data with format preserving encryption, masking or tokenization
+// according to the transformations of a role.
+type Transform struct {
+	c         *Client
+	mountPath string
+}
+
+// Transform returns the client for the transform secrets engine mounted at
+// the given path, usually "transform".
+func (c *Client) Transform(mountPath string) *Transform {
+	return &Transform{
+		c:         c,
+		mountPath: strings.Trim(mountPath, "/"),
+	}
+}
+
+// TransformInput is a value to encode, decode or validate. Transformation
+// is the name of the transformation to apply, and may only be omitted if
+// the role has a single one. Tweak is the FPE tweak for supplied tweak
+// sources, and Reference is an opaque value returned with batch results.
+type TransformInput struct {
+	Value          string
+	Transformation string
+	Tweak          []byte
+	Reference      string
+}
+
+func (i *TransformInput) body() map[string]interface{} {
+	body := map[string]interface{}{
+		"value": i.Value,
+	}
+	if i.Transformation != "" {
+		body["transformation"] = i.Transformation
+	}
+	if len(i.Tweak) > 0 {
+		body["tweak"] = base64.StdEncoding.EncodeToString(i.Tweak)
+	}
+	if i.Reference != "" {
+		body["reference"] = i.Reference
+	}
+	return body
+}
+
+// TransformResult is the result of encoding, decoding or validating a value.
+// Tweak is only set when encoding with a generated tweak, which must then be
+// supplied to decode the value. Error is only set for failed items of a
+// batch, on servers reporting them individually.
+type TransformResult struct {
+	Value     string
+	Valid     bool
+	Tweak     []byte
+	Reference string
+	Error     string
+}
+
+// TransformRole lists the transformations that may be used through a role.
+type TransformRole struct {
+	Transformations []string `mapstructure:"transformations"`
+}
+
+// TransformTemplate is a template describing the format of the values to
+// transform. Pattern is a regular expression whose capture groups are
+// transformed, and Alphabet the name of the set of characters they use.
+type TransformTemplate struct {
+	Type          string            `mapstructure:"type"`
+	Pattern       string            `mapstructure:"pattern"`
+	Alphabet      string            `mapstructure:"alphabet"`
+	EncodeFormat  string            `mapstructure:"encode_format"`
+	DecodeFormats map[string]string `mapstructure:"decode_formats"`
+}
+
+func (t *TransformTemplate) body() map[string]interface{} {
+	body := map[string]interface{}{
+		"type":    t.Type,
+		"pattern": t.Pattern,
+	}
+	if t.Type == "" {
+		body["type"] = "regex"
+	}
+	if t.Alphabet != "" {
+		body["alphabet"] = t.Alphabet
+	}
+	if t.EncodeFormat != "" {
+		body["encode_format"] = t.EncodeFormat
+	}
+	if t.DecodeFormats != nil {
+		body["decode_formats"] = t.DecodeFormats
+	}
+	return body
+}
+
+// TransformTransformation is a transformation, of type "fpe", "masking" or
+// "tokenization", which may only be used through its allowed roles.
+type TransformTransformation struct {
+	Type             string   `mapstructure:"type"`
+	Templates        []string `mapstructure:"templates"`
+	TweakSource      string   `mapstructure:"tweak_source"`
+	MaskingCharacter string   `mapstructure:"masking_character"`
+	AllowedRoles     []string `mapstructure:"allowed_roles"`
+	DeletionAllowed  bool     `mapstructure:"deletion_allowed"`
+}
+
+func (t *TransformTransformation) body() map[string]interface{} {
+	body := map[string]interface{}{
+		"type":             t.Type,
+		"deletion_allowed": t.DeletionAllowed,
+	}
+	if len(t.Templates) > 0 {
+		// Only a single template is supported by the server so far
+		body["template"] = t.Templates[0]
+	}
+	if t.TweakSource != "" {
+		body["tweak_source"] = t.TweakSource
+	}
+	if t.MaskingCharacter != "" {
+		body["masking_character"] = t.MaskingCharacter
+	}
+	if t.AllowedRoles != nil {
+		body["allowed_roles"] = t.AllowedRoles
+	}
+	return body
+}
+
+// transformResult is a result as returned by the server, whose value field
+// depends on the operation.
+type transformResult struct {
+	EncodedValue string `mapstructure:"encoded_value"`
+	DecodedValue string `mapstructure:"decoded_value"`
+	Valid        bool   `mapstructure:"valid"`
+	Tweak        string `mapstructure:"tweak"`
+	Reference    string `mapstructure:"reference"`
+	Error        string `mapstructure:"error"`
+}
+
+// Encode transforms the value with the role.
+func (c *Transform) Encode(ctx context.Context, role string, input *TransformInput) (*TransformResult, error) {
+	return c.transformOne(ctx, "encode", role, input)
+}
+
+// EncodeBatch transforms the values with the role in a single request,
+// returning the results in the same order.
+func (c *Transform) EncodeBatch(ctx context.Context, role string, inputs []*TransformInput) ([]*TransformResult, error) {
+	return c.transformBatch(ctx, "encode", role, inputs)
+}
+
+// Decode reverts the transformation of the value with the role.
+// Transformations of type masking cannot be decoded.
+func (c *Transform) Decode(ctx context.Context, role string, input *TransformInput) (*TransformResult, error) {
+	return c.transformOne(ctx, "decode", role, input)
+}
+
+// DecodeBatch reverts the transformation of the values with the role in a
+// single request, returning the results in the same order.
+func (c *Transform) DecodeBatch(ctx context.Context, role string, inputs []*TransformInput) ([]*TransformResult, error) {
+	return c.transformBatch(ctx, "decode", role, inputs)
+}
+
+// Validate returns whether the value is a token issued by a tokenization
+// transformation of the role.
+func (c *Transform) Validate(ctx context.Context, role string, input *TransformInput) (bool, error) {
+	result, err := c.transformOne(ctx, "validate", role, input)
+	if err != nil {
+		return false, err
+	}
+	return result.Valid, nil
+}
+
+// ValidateBatch validates the values with the role in a single request,
+// returning the results in the same order.
+func (c *Transform) ValidateBatch(ctx context.Context, role string, inputs []*TransformInput) ([]*TransformResult, error) {
+	return c.transformBatch(ctx, "validate", role, inputs)
+}
+
+// CreateRole creates or replaces the named role.
+func (c *Transform) CreateRole(ctx context.Context, name string, role *TransformRole) error {
+	body := map[string]interface{}{
+		"transformations": role.Transformations,
+	}
+	_, err := c.c.Logical().Do(ctx, "POST", c.path("role", name), body)
+	return err
+}
+
+// ReadRole returns the named role, or nil if it does not exist.
+func (c *Transform) ReadRole(ctx context.Context, name string) (*TransformRole, error) {
+	var role TransformRole
+	if ok, err := c.read(ctx, c.path("role", name), &role); err != nil || !ok {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// ListRoles returns the names of the roles.
+func (c *Transform) ListRoles(ctx context.Context) ([]string, error) {
+	return c.list(ctx, c.path("role"))
+}
+
+// DeleteRole deletes the named role.
+func (c *Transform) DeleteRole(ctx context.Context, name string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", c.path("role", name), nil)
+	return err
+}
+
+// CreateTemplate creates or replaces the named template. The type defaults
+// to "regex", the only one supported so far.
+func (c *Transform) CreateTemplate(ctx context.Context, name string, template *TransformTemplate) error {
+	_, err := c.c.Logical().Do(ctx, "POST", c.path("template", name), template.body())
+	return err
+}
+
+// ReadTemplate returns the named template, or nil if it does not exist.
+func (c *Transform) ReadTemplate(ctx context.Context, name string) (*TransformTemplate, error) {
+	var template TransformTemplate
+	if ok, err := c.read(ctx, c.path("template", name), &template); err != nil || !ok {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListTemplates returns the names of the templates.
+func (c *Transform) ListTemplates(ctx context.Context) ([]string, error) {
+	return c.list(ctx, c.path("template"))
+}
+
+// DeleteTemplate deletes the named template.
+func (c *Transform) DeleteTemplate(ctx context.Context, name string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", c.path("template", name), nil)
+	return err
+}
+
+// CreateTransformation creates or replaces the named transformation.
+func (c *Transform) CreateTransformation(ctx context.Context, name string, transformation *TransformTransformation) error {
+	_, err := c.c.Logical().Do(ctx, "POST", c.path("transformation", name), transformation.body())
+	return err
+}
+
+// ReadTransformation returns the named transformation, or nil if it does not
+// exist.
+func (c *Transform) ReadTransformation(ctx context.Context, name string) (*TransformTransformation, error) {
+	var transformation TransformTransformation
+	if ok, err := c.read(ctx, c.path("transformation", name), &transformation); err != nil || !ok {
+		return nil, err
+	}
+	return &transformation, nil
+}
+
+// ListTransformations returns the names of the transformations.
+func (c *Transform) ListTransformations(ctx context.Context) ([]string, error) {
+	return c.list(ctx, c.path("transformation"))
+}
+
+// DeleteTransformation deletes the named transformation, which must allow
+// deletion.
+func (c *Transform) DeleteTransformation(ctx context.Context, name string) error {
+	_, err := c.c.Logical().Do(ctx, "DELETE", c.path("transformation", name), nil)
+	return err
+}
+
+func (c *Transform) transformOne(ctx context.Context, op, role string, input *TransformInput) (*TransformResult, error) {
+	if input == nil {
+		return nil, errors.New("missing input")
+	}
+	secret, err := c.c.Logical().Do(ctx, "POST", c.path(op, role), input.body())
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result transformResult
+	if err := secret.DecodeData(&result); err != nil {
+		return nil, err
+	}
+	return result.convert()
+}
+
+func (c *Transform) transformBatch(ctx context.Context, op, role string, inputs []*TransformInput) ([]*TransformResult, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	batch := make([]map[string]interface{}, len(inputs))
+	for i, input := range inputs {
+		batch[i] = input.body()
+	}
+
+	body := map[string]interface{}{
+		"batch_input": batch,
+	}
+	secret, err := c.c.Logical().Do(ctx, "POST", c.path(op, role), body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var response struct {
+		BatchResults []transformResult `mapstructure:"batch_results"`
+	}
+	if err := secret.DecodeData(&response); err != nil {
+		return nil, err
+	}
+	if len(response.BatchResults) != len(inputs) {
+		return nil, fmt.Errorf("expected %d batch results, got %d", len(inputs), len(response.BatchResults))
+	}
+
+	results := make([]*TransformResult, len(response.BatchResults))
+	for i := range response.BatchResults {
+		if results[i], err = response.BatchResults[i].convert(); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (r *transformResult) convert() (*TransformResult, error) {
+	result := &TransformResult{
+		Value:     r.EncodedValue,
+		Valid:     r.Valid,
+		Reference: r.Reference,
+		Error:     r.Error,
+	}
+	if result.Value == "" {
+		result.Value = r.DecodedValue
+	}
+	if r.Tweak != "" {
+		tweak, err := base64.StdEncoding.DecodeString(r.Tweak)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tweak in response: %v", err)
+		}
+		result.Tweak = tweak
+	}
+	return result, nil
+}
+
+func (c *Transform) path(elems ...string) string {
+	return c.mountPath + "/" + strings.Join(elems, "/")
+}
+
+// read decodes the data at the given path into out, returning false if there
+// is none.
+func (c *Transform) read(ctx context.Context, path string, out interface{}) (bool, error) {
+	secret, err := c.c.Logical().readWithContext(ctx, path, nil)
+	if err != nil {
+		return false, err
+	}
+	if secret == nil || secret.Data == nil {
+		return false, nil
+	}
+	return true, secret.DecodeData(out)
+}
+
+func (c *Transform) list(ctx context.Context, path string) ([]string, error) {
+	secret, err := c.c.Logical().listWithContext(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var keys []string
+	if err := mapstructure.Decode(secret.Data["keys"], &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
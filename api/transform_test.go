@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	bodies := make(map[string]map[string]interface{})
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		if req.Method == http.MethodPost {
+			json.NewDecoder(req.Body).Decode(&body)
+			bodies[req.URL.Path] = body
+		}
+
+		switch {
+		case req.URL.Path == "/v1/transform/encode/payments":
+			if batch, ok := body["batch_input"].([]interface{}); ok {
+				results := make([]map[string]interface{}, len(batch))
+				for i, item := range batch {
+					item := item.(map[string]interface{})
+					results[i] = map[string]interface{}{"encoded_value": "enc-" + item["value"].(string), "reference": item["reference"]}
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"batch_results": results}})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+				"encoded_value": "enc-" + body["value"].(string),
+				"tweak":         base64.StdEncoding.EncodeToString([]byte("tweak12")),
+			}})
+		case req.URL.Path == "/v1/transform/decode/payments":
+			w.Write([]byte(`{"data": {"decoded_value": "4111-1111-1111-1111"}}`))
+		case req.URL.Path == "/v1/transform/validate/payments":
+			if _, ok := body["batch_input"]; ok {
+				w.Write([]byte(`{"data": {"batch_results": [{"valid": true}, {"valid": false}]}}`))
+				return
+			}
+			w.Write([]byte(`{"data": {"valid": true}}`))
+		case req.Method == http.MethodPost:
+			w.WriteHeader(http.StatusNoContent)
+		case req.URL.Query().Get("list") == "true" && req.URL.Path == "/v1/transform/role":
+			w.Write([]byte(`{"data": {"keys": ["payments"]}}`))
+		case req.URL.Path == "/v1/transform/role/payments":
+			w.Write([]byte(`{"data": {"transformations": ["ccn-fpe", "ccn-token"]}}`))
+		case req.URL.Path == "/v1/transform/template/ccn":
+			w.Write([]byte(`{"data": {"type": "regex", "pattern": "(\\d{4})-(\\d{4})-(\\d{4})-(\\d{4})", "alphabet": "builtin/numeric", "decode_formats": {"last-four": "$4"}}}`))
+		case req.URL.Path == "/v1/transform/transformation/ccn-fpe":
+			w.Write([]byte(`{"data": {"type": "fpe", "templates": ["ccn"], "tweak_source": "generated", "allowed_roles": ["payments"], "deletion_allowed": true}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	transform := client.Transform("/transform/")
+
+	encoded, err := transform.Encode(ctx, "payments", &TransformInput{Value: "4111", Transformation: "ccn-fpe", Tweak: []byte("abcdefg")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded.Value != "enc-4111" || string(encoded.Tweak) != "tweak12" {
+		t.Fatalf("unexpected result %#v", encoded)
+	}
+	if body := bodies["/v1/transform/encode/payments"]; body["transformation"] != "ccn-fpe" || body["tweak"] != base64.StdEncoding.EncodeToString([]byte("abcdefg")) {
+		t.Fatalf("unexpected body %#v", body)
+	}
+
+	batch, err := transform.EncodeBatch(ctx, "payments", []*TransformInput{
+		{Value: "1", Reference: "a"},
+		{Value: "2", Reference: "b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch) != 2 || batch[0].Value != "enc-1" || batch[1].Value != "enc-2" || batch[1].Reference != "b" {
+		t.Fatalf("unexpected batch results %#v", batch)
+	}
+
+	decoded, err := transform.Decode(ctx, "payments", &TransformInput{Value: "enc-4111"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Value != "4111-1111-1111-1111" {
+		t.Fatalf("unexpected decoded value %q", decoded.Value)
+	}
+
+	valid, err := transform.Validate(ctx, "payments", &TransformInput{Value: "token"})
+	if err != nil || !valid {
+		t.Fatalf("expected valid token, got %t, %v", valid, err)
+	}
+	validBatch, err := transform.ValidateBatch(ctx, "payments", []*TransformInput{{Value: "a"}, {Value: "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validBatch[0].Valid || validBatch[1].Valid {
+		t.Fatalf("unexpected validation results %#v", validBatch)
+	}
+
+	if err := transform.CreateRole(ctx, "payments", &TransformRole{Transformations: []string{"ccn-fpe"}}); err != nil {
+		t.Fatal(err)
+	}
+	if roles := bodies["/v1/transform/role/payments"]["transformations"].([]interface{}); len(roles) != 1 {
+		t.Fatalf("unexpected role body %#v", bodies["/v1/transform/role/payments"])
+	}
+	role, err := transform.ReadRole(ctx, "payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(role.Transformations) != 2 {
+		t.Fatalf("unexpected role %#v", role)
+	}
+	roles, err := transform.ListRoles(ctx)
+	if err != nil || len(roles) != 1 {
+		t.Fatalf("unexpected roles %v, %v", roles, err)
+	}
+	missing, err := transform.ReadRole(ctx, "missing")
+	if err != nil || missing != nil {
+		t.Fatalf("expected no role, got %#v, %v", missing, err)
+	}
+
+	if err := transform.CreateTemplate(ctx, "ccn", &TransformTemplate{Pattern: `(\d{4})`, Alphabet: "builtin/numeric"}); err != nil {
+		t.Fatal(err)
+	}
+	if body := bodies["/v1/transform/template/ccn"]; body["type"] != "regex" || body["alphabet"] != "builtin/numeric" {
+		t.Fatalf("unexpected template body %#v", body)
+	}
+	template, err := transform.ReadTemplate(ctx, "ccn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(template.Pattern, `(\d{4})`) || template.DecodeFormats["last-four"] != "$4" {
+		t.Fatalf("unexpected template %#v", template)
+	}
+
+	err = transform.CreateTransformation(ctx, "ccn-fpe", &TransformTransformation{
+		Type:         "fpe",
+		Templates:    []string{"ccn"},
+		TweakSource:  "generated",
+		AllowedRoles: []string{"payments"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := bodies["/v1/transform/transformation/ccn-fpe"]; body["template"] != "ccn" || body["tweak_source"] != "generated" {
+		t.Fatalf("unexpected transformation body %#v", body)
+	}
+	transformation, err := transform.ReadTransformation(ctx, "ccn-fpe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transformation.Type != "fpe" || transformation.Templates[0] != "ccn" || !transformation.DeletionAllowed {
+		t.Fatalf("unexpected transformation %#v", transformation)
+	}
+}
@@ -0,0 +1,399 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// TransitDefaultMountPoint is the default path at which the transit
+	// secrets engine is mounted.
+	TransitDefaultMountPoint = "transit"
+)
+
+// Transit is used to perform operations on the transit secrets engine.
+// Plaintexts, contexts and data keys are passed as raw bytes; the base64
+// encoding expected by the engine is handled by the client.
+type Transit struct {
+	c          *Client
+	MountPoint string
+}
+
+// Transit returns the client for the transit secrets engine mounted at the
+// default path.
+func (c *Client) Transit() *Transit {
+	return c.TransitWithMountPoint(TransitDefaultMountPoint)
+}
+
+// TransitWithMountPoint returns the client for the transit secrets engine
+// mounted at the given path.
+func (c *Client) TransitWithMountPoint(mountPoint string) *Transit {
+	return &Transit{
+		c:          c,
+		MountPoint: mountPoint,
+	}
+}
+
+// TransitOptions are the optional parameters of encryption operations.
+type TransitOptions struct {
+	// Context is the key derivation context, required for derived keys.
+	Context []byte
+
+	// KeyVersion is the version of the key to use. Zero means the latest
+	// version.
+	KeyVersion int
+}
+
+func (o *TransitOptions) apply(body map[string]interface{}) {
+	if o == nil {
+		return
+	}
+	if len(o.Context) > 0 {
+		body["context"] = base64.StdEncoding.EncodeToString(o.Context)
+	}
+	if o.KeyVersion > 0 {
+		body["key_version"] = o.KeyVersion
+	}
+}
+
+// TransitSignOptions are the optional parameters of Sign and Verify.
+type TransitSignOptions struct {
+	// Context is the key derivation context, required for derived keys.
+	Context []byte
+
+	// KeyVersion is the version of the key to sign with. Zero means the
+	// latest version. It is ignored by Verify, as the version is part of
+	// the signature.
+	KeyVersion int
+
+	// HashAlgorithm is the hash algorithm to use, e.g. "sha2-256".
+	HashAlgorithm string
+
+	// SignatureAlgorithm is the RSA signature algorithm, "pss" or
+	// "pkcs1v15".
+	SignatureAlgorithm string
+
+	// Prehashed indicates that the input is already hashed.
+	Prehashed bool
+}
+
+func (o *TransitSignOptions) apply(body map[string]interface{}) {
+	if o == nil {
+		return
+	}
+	if len(o.Context) > 0 {
+		body["context"] = base64.StdEncoding.EncodeToString(o.Context)
+	}
+	if o.KeyVersion > 0 {
+		body["key_version"] = o.KeyVersion
+	}
+	if o.HashAlgorithm != "" {
+		body["hash_algorithm"] = o.HashAlgorithm
+	}
+	if o.SignatureAlgorithm != "" {
+		body["signature_algorithm"] = o.SignatureAlgorithm
+	}
+	if o.Prehashed {
+		body["prehashed"] = true
+	}
+}
+
+// TransitDataKey is a data key generated by the transit engine. Plaintext is
+// only set if it was requested.
+type TransitDataKey struct {
+	Plaintext  []byte
+	Ciphertext string
+	KeyVersion int
+}
+
+// TransitKeyInput are the parameters for creating a key.
+type TransitKeyInput struct {
+	Type                 string `json:"type,omitempty"`
+	Derived              bool   `json:"derived,omitempty"`
+	ConvergentEncryption bool   `json:"convergent_encryption,omitempty"`
+	Exportable           bool   `json:"exportable,omitempty"`
+	AllowPlaintextBackup bool   `json:"allow_plaintext_backup,omitempty"`
+	AutoRotatePeriod     string `json:"auto_rotate_period,omitempty"`
+}
+
+// TransitKeyConfigInput are the parameters for updating the configuration of
+// a key. Nil fields are left unchanged.
+type TransitKeyConfigInput struct {
+	MinDecryptionVersion *int    `json:"min_decryption_version,omitempty"`
+	MinEncryptionVersion *int    `json:"min_encryption_version,omitempty"`
+	DeletionAllowed      *bool   `json:"deletion_allowed,omitempty"`
+	Exportable           *bool   `json:"exportable,omitempty"`
+	AllowPlaintextBackup *bool   `json:"allow_plaintext_backup,omitempty"`
+	AutoRotatePeriod     *string `json:"auto_rotate_period,omitempty"`
+}
+
+// TransitKey describes a transit key.
+type TransitKey struct {
+	Name                 string                 `mapstructure:"name"`
+	Type                 string                 `mapstructure:"type"`
+	Derived              bool                   `mapstructure:"derived"`
+	ConvergentEncryption bool                   `mapstructure:"convergent_encryption"`
+	Exportable           bool                   `mapstructure:"exportable"`
+	AllowPlaintextBackup bool                   `mapstructure:"allow_plaintext_backup"`
+	DeletionAllowed      bool                   `mapstructure:"deletion_allowed"`
+	LatestVersion        int                    `mapstructure:"latest_version"`
+	MinAvailableVersion  int                    `mapstructure:"min_available_version"`
+	MinDecryptionVersion int                    `mapstructure:"min_decryption_version"`
+	MinEncryptionVersion int                    `mapstructure:"min_encryption_version"`
+	SupportsEncryption   bool                   `mapstructure:"supports_encryption"`
+	SupportsDecryption   bool                   `mapstructure:"supports_decryption"`
+	SupportsDerivation   bool                   `mapstructure:"supports_derivation"`
+	SupportsSigning      bool                   `mapstructure:"supports_signing"`
+	Keys                 map[string]interface{} `mapstructure:"keys"`
+}
+
+// Encrypt encrypts the plaintext with the named key, returning the
+// ciphertext.
+func (c *Transit) Encrypt(key string, plaintext []byte, opts *TransitOptions) (string, error) {
+	body := map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	}
+	opts.apply(body)
+
+	secret, err := c.write(fmt.Sprintf("encrypt/%s", key), body)
+	if err != nil {
+		return "", err
+	}
+	return transitString(secret, "ciphertext")
+}
+
+// Decrypt decrypts the ciphertext with the named key, returning the
+// plaintext.
+func (c *Transit) Decrypt(key string, ciphertext string, opts *TransitOptions) ([]byte, error) {
+	body := map[string]interface{}{
+		"ciphertext": ciphertext,
+	}
+	opts.apply(body)
+
+	secret, err := c.write(fmt.Sprintf("decrypt/%s", key), body)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := transitString(secret, "plaintext")
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
+
+// Rewrap re-encrypts the ciphertext with the latest version of the named key,
+// or the version given in the options, without revealing the plaintext.
+func (c *Transit) Rewrap(key string, ciphertext string, opts *TransitOptions) (string, error) {
+	body := map[string]interface{}{
+		"ciphertext": ciphertext,
+	}
+	opts.apply(body)
+
+	secret, err := c.write(fmt.Sprintf("rewrap/%s", key), body)
+	if err != nil {
+		return "", err
+	}
+	return transitString(secret, "ciphertext")
+}
+
+// GenerateDataKey generates a new data key encrypted with the named key. If
+// includePlaintext is set, the plaintext of the data key is returned as well.
+// A bits value of zero uses the engine's default key size.
+func (c *Transit) GenerateDataKey(key string, includePlaintext bool, bits int, opts *TransitOptions) (*TransitDataKey, error) {
+	keyType := "wrapped"
+	if includePlaintext {
+		keyType = "plaintext"
+	}
+	body := map[string]interface{}{}
+	if bits > 0 {
+		body["bits"] = bits
+	}
+	opts.apply(body)
+
+	secret, err := c.write(fmt.Sprintf("datakey/%s/%s", keyType, key), body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result TransitDataKey
+	if result.Ciphertext, err = transitString(secret, "ciphertext"); err != nil {
+		return nil, err
+	}
+	if includePlaintext {
+		plaintext, err := transitString(secret, "plaintext")
+		if err != nil {
+			return nil, err
+		}
+		if result.Plaintext, err = base64.StdEncoding.DecodeString(plaintext); err != nil {
+			return nil, err
+		}
+	}
+	if version, ok := secret.Data["key_version"]; ok {
+		if result.KeyVersion, err = toInt(version); err != nil {
+			return nil, err
+		}
+	}
+	return &result, nil
+}
+
+// Sign signs the input with the named key, returning the signature.
+func (c *Transit) Sign(key string, input []byte, opts *TransitSignOptions) (string, error) {
+	body := map[string]interface{}{
+		"input": base64.StdEncoding.EncodeToString(input),
+	}
+	opts.apply(body)
+
+	secret, err := c.write(fmt.Sprintf("sign/%s", key), body)
+	if err != nil {
+		return "", err
+	}
+	return transitString(secret, "signature")
+}
+
+// Verify checks the signature of the input with the named key.
+func (c *Transit) Verify(key string, input []byte, signature string, opts *TransitSignOptions) (bool, error) {
+	body := map[string]interface{}{
+		"input":     base64.StdEncoding.EncodeToString(input),
+		"signature": signature,
+	}
+	opts.apply(body)
+	delete(body, "key_version")
+
+	secret, err := c.write(fmt.Sprintf("verify/%s", key), body)
+	if err != nil {
+		return false, err
+	}
+	if secret == nil || secret.Data == nil {
+		return false, errors.New("data from server response is empty")
+	}
+	valid, ok := secret.Data["valid"].(bool)
+	if !ok {
+		return false, errors.New("unexpected type for valid in response")
+	}
+	return valid, nil
+}
+
+// CreateKey creates a named key. A nil input creates a key with the engine's
+// defaults.
+func (c *Transit) CreateKey(name string, input *TransitKeyInput) error {
+	if input == nil {
+		input = &TransitKeyInput{}
+	}
+	r := c.c.NewRequest("POST", fmt.Sprintf("/v1/%s/keys/%s", c.MountPoint, name))
+	if err := r.SetJSONBody(input); err != nil {
+		return err
+	}
+	return c.do(r)
+}
+
+// ReadKey returns the named key, or nil if it does not exist.
+func (c *Transit) ReadKey(name string) (*TransitKey, error) {
+	secret, err := c.c.Logical().Read(fmt.Sprintf("%s/keys/%s", c.MountPoint, name))
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var key TransitKey
+	if err := secret.DecodeData(&key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListKeys returns the names of the keys.
+func (c *Transit) ListKeys() ([]string, error) {
+	secret, err := c.c.Logical().List(fmt.Sprintf("%s/keys", c.MountPoint))
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var keys []string
+	if err := mapstructure.Decode(secret.Data["keys"], &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// UpdateKeyConfig updates the configuration of the named key.
+func (c *Transit) UpdateKeyConfig(name string, input *TransitKeyConfigInput) error {
+	r := c.c.NewRequest("POST", fmt.Sprintf("/v1/%s/keys/%s/config", c.MountPoint, name))
+	if err := r.SetJSONBody(input); err != nil {
+		return err
+	}
+	return c.do(r)
+}
+
+// RotateKey creates a new version of the named key.
+func (c *Transit) RotateKey(name string) error {
+	return c.do(c.c.NewRequest("POST", fmt.Sprintf("/v1/%s/keys/%s/rotate", c.MountPoint, name)))
+}
+
+// DeleteKey deletes the named key. Keys can only be deleted once deletion
+// has been allowed in their configuration.
+func (c *Transit) DeleteKey(name string) error {
+	return c.do(c.c.NewRequest("DELETE", fmt.Sprintf("/v1/%s/keys/%s", c.MountPoint, name)))
+}
+
+func (c *Transit) write(path string, body map[string]interface{}) (*Secret, error) {
+	r := c.c.NewRequest("POST", fmt.Sprintf("/v1/%s/%s", c.MountPoint, path))
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
+func (c *Transit) do(r *Request) error {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+func transitString(secret *Secret, field string) (string, error) {
+	if secret == nil || secret.Data == nil {
+		return "", errors.New("data from server response is empty")
+	}
+	value, ok := secret.Data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected type for %s in response", field)
+	}
+	return value, nil
+}
+
+// toInt converts a number decoded from a response, which may be a
+// json.Number, to an int.
+func toInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	case fmt.Stringer:
+		return strconv.Atoi(v.String())
+	default:
+		return 0, fmt.Errorf("unexpected type %T for integer", value)
+	}
+}
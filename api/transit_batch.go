@@ -0,0 +1,227 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// DefaultTransitBatchSize is the number of items sent per request by the
+// batch operations unless overridden in TransitBatchOptions.
+const DefaultTransitBatchSize = 250
+
+// TransitBatchItem is an item of a batch operation. Plaintext is used by
+// EncryptBatch, Ciphertext by DecryptBatch and RewrapBatch, and Input by
+// SignBatch.
+type TransitBatchItem struct {
+	Plaintext  []byte
+	Ciphertext string
+	Input      []byte
+
+	// Context is the key derivation context, required for derived keys.
+	Context []byte
+
+	// KeyVersion is the version of the key to use. Zero means the latest
+	// version.
+	KeyVersion int
+}
+
+// TransitBatchResult is the result of a batch item. Results are returned in
+// the order of the items. Err is set if the item failed, in which case the
+// other fields are empty.
+type TransitBatchResult struct {
+	Ciphertext string
+	Plaintext  []byte
+	Signature  string
+	KeyVersion int
+	Err        error
+}
+
+// TransitBatchOptions are the optional parameters of batch operations.
+type TransitBatchOptions struct {
+	// BatchSize is the maximum number of items sent per request. Larger
+	// batches are split into several requests.
+	BatchSize int
+
+	// HashAlgorithm, SignatureAlgorithm and Prehashed are used by SignBatch,
+	// as in TransitSignOptions.
+	HashAlgorithm      string
+	SignatureAlgorithm string
+	Prehashed          bool
+}
+
+// EncryptBatch encrypts the plaintexts of the items with the named key.
+func (c *Transit) EncryptBatch(key string, items []TransitBatchItem, opts *TransitBatchOptions) ([]TransitBatchResult, error) {
+	return c.batch(fmt.Sprintf("encrypt/%s", key), items, opts, func(item TransitBatchItem) map[string]interface{} {
+		return map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString(item.Plaintext),
+		}
+	})
+}
+
+// DecryptBatch decrypts the ciphertexts of the items with the named key.
+func (c *Transit) DecryptBatch(key string, items []TransitBatchItem, opts *TransitBatchOptions) ([]TransitBatchResult, error) {
+	return c.batch(fmt.Sprintf("decrypt/%s", key), items, opts, func(item TransitBatchItem) map[string]interface{} {
+		return map[string]interface{}{
+			"ciphertext": item.Ciphertext,
+		}
+	})
+}
+
+// RewrapBatch re-encrypts the ciphertexts of the items with the named key.
+func (c *Transit) RewrapBatch(key string, items []TransitBatchItem, opts *TransitBatchOptions) ([]TransitBatchResult, error) {
+	return c.batch(fmt.Sprintf("rewrap/%s", key), items, opts, func(item TransitBatchItem) map[string]interface{} {
+		return map[string]interface{}{
+			"ciphertext": item.Ciphertext,
+		}
+	})
+}
+
+// SignBatch signs the inputs of the items with the named key.
+func (c *Transit) SignBatch(key string, items []TransitBatchItem, opts *TransitBatchOptions) ([]TransitBatchResult, error) {
+	return c.batch(fmt.Sprintf("sign/%s", key), items, opts, func(item TransitBatchItem) map[string]interface{} {
+		return map[string]interface{}{
+			"input": base64.StdEncoding.EncodeToString(item.Input),
+		}
+	})
+}
+
+// batch performs a batch operation, splitting the items into chunks of the
+// configured size. A request that fails as a whole stops the operation and
+// its error is returned; errors of individual items are set on their results
+// and combined into the returned error.
+func (c *Transit) batch(path string, items []TransitBatchItem, opts *TransitBatchOptions, itemBody func(TransitBatchItem) map[string]interface{}) ([]TransitBatchResult, error) {
+	if opts == nil {
+		opts = &TransitBatchOptions{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultTransitBatchSize
+	}
+
+	results := make([]TransitBatchResult, 0, len(items))
+	var itemErrs *multierror.Error
+	for start := 0; start < len(items); start += batchSize {
+		end := start + batchSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		batchInput := make([]map[string]interface{}, 0, end-start)
+		for _, item := range items[start:end] {
+			body := itemBody(item)
+			if len(item.Context) > 0 {
+				body["context"] = base64.StdEncoding.EncodeToString(item.Context)
+			}
+			if item.KeyVersion > 0 {
+				body["key_version"] = item.KeyVersion
+			}
+			batchInput = append(batchInput, body)
+		}
+
+		body := map[string]interface{}{
+			"batch_input": batchInput,
+		}
+		if opts.HashAlgorithm != "" {
+			body["hash_algorithm"] = opts.HashAlgorithm
+		}
+		if opts.SignatureAlgorithm != "" {
+			body["signature_algorithm"] = opts.SignatureAlgorithm
+		}
+		if opts.Prehashed {
+			body["prehashed"] = true
+		}
+
+		chunk, err := c.writeBatch(path, body)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("error processing items %d to %d: {{err}}", start, end-1), err)
+		}
+		if len(chunk) != end-start {
+			return nil, fmt.Errorf("expected %d batch results, got %d", end-start, len(chunk))
+		}
+
+		for i, raw := range chunk {
+			result, err := parseTransitBatchResult(raw)
+			if err != nil {
+				result.Err = err
+			}
+			if result.Err != nil {
+				itemErrs = multierror.Append(itemErrs, errwrap.Wrapf(fmt.Sprintf("item %d: {{err}}", start+i), result.Err))
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results, itemErrs.ErrorOrNil()
+}
+
+// writeBatch sends a batch request, returning the batch results. Vault
+// responds with an error status if any item failed, in which case the
+// results are still read from the response.
+func (c *Transit) writeBatch(path string, body map[string]interface{}) ([]interface{}, error) {
+	r := c.c.NewRequest("POST", fmt.Sprintf("/v1/%s/%s", c.MountPoint, path))
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if resp == nil {
+		return nil, err
+	}
+
+	secret, parseErr := ParseSecret(resp.Body)
+	if parseErr != nil || secret == nil || secret.Data == nil {
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New("data from server response is empty")
+	}
+	results, ok := secret.Data["batch_results"].([]interface{})
+	if !ok {
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New("unexpected type for batch_results in response")
+	}
+	return results, nil
+}
+
+func parseTransitBatchResult(raw interface{}) (TransitBatchResult, error) {
+	var result TransitBatchResult
+
+	item, ok := raw.(map[string]interface{})
+	if !ok {
+		return result, errors.New("unexpected type for batch result")
+	}
+	if msg, ok := item["error"].(string); ok && msg != "" {
+		result.Err = errors.New(msg)
+		return result, nil
+	}
+
+	result.Ciphertext, _ = item["ciphertext"].(string)
+	result.Signature, _ = item["signature"].(string)
+	if plaintext, ok := item["plaintext"].(string); ok {
+		decoded, err := base64.StdEncoding.DecodeString(plaintext)
+		if err != nil {
+			return result, err
+		}
+		result.Plaintext = decoded
+	}
+	if version, ok := item["key_version"]; ok {
+		v, err := toInt(version)
+		if err != nil {
+			return result, err
+		}
+		result.KeyVersion = v
+	}
+	return result, nil
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTransit(t *testing.T) {
+	var lastBody map[string]interface{}
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		lastBody = nil
+		json.NewDecoder(req.Body).Decode(&lastBody)
+
+		var data map[string]interface{}
+		switch req.URL.Path {
+		case "/v1/transit/encrypt/my-key":
+			data = map[string]interface{}{"ciphertext": "vault:v1:" + lastBody["plaintext"].(string)}
+		case "/v1/transit/decrypt/my-key":
+			data = map[string]interface{}{"plaintext": strings.TrimPrefix(lastBody["ciphertext"].(string), "vault:v1:")}
+		case "/v1/transit/datakey/plaintext/my-key":
+			data = map[string]interface{}{
+				"plaintext":   base64.StdEncoding.EncodeToString([]byte("data key")),
+				"ciphertext":  "vault:v2:wrapped",
+				"key_version": 2,
+			}
+		case "/v1/transit/verify/my-key":
+			data = map[string]interface{}{"valid": lastBody["signature"] == "vault:v1:sig"}
+		case "/v1/transit/keys/my-key":
+			data = map[string]interface{}{"name": "my-key", "type": "aes256-gcm96", "latest_version": 2}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transit := client.Transit()
+
+	ciphertext, err := transit.Encrypt("my-key", []byte("secret"), &TransitOptions{Context: []byte("ctx"), KeyVersion: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastBody["context"] != base64.StdEncoding.EncodeToString([]byte("ctx")) || lastBody["key_version"] != float64(1) {
+		t.Fatalf("options not sent: %v", lastBody)
+	}
+	plaintext, err := transit.Decrypt("my-key", ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, []byte("secret")) {
+		t.Fatalf("expected round trip, got %q", plaintext)
+	}
+
+	dataKey, err := transit.GenerateDataKey("my-key", true, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(dataKey.Plaintext) != "data key" || dataKey.Ciphertext != "vault:v2:wrapped" || dataKey.KeyVersion != 2 {
+		t.Fatalf("bad data key: %#v", dataKey)
+	}
+
+	valid, err := transit.Verify("my-key", []byte("input"), "vault:v1:sig", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !valid {
+		t.Fatal("expected signature to be valid")
+	}
+
+	key, err := transit.ReadKey("my-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Name != "my-key" || key.LatestVersion != 2 {
+		t.Fatalf("bad key: %#v", key)
+	}
+	key, err = transit.ReadKey("other-key")
+	if err != nil || key != nil {
+		t.Fatalf("expected no key, got %#v, %v", key, err)
+	}
+}
+
+func TestTransitEncryptBatch(t *testing.T) {
+	var batchSizes []int
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			BatchInput []map[string]interface{} `json:"batch_input"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		batchSizes = append(batchSizes, len(body.BatchInput))
+
+		var results []map[string]interface{}
+		failed := false
+		for _, item := range body.BatchInput {
+			plaintext, _ := base64.StdEncoding.DecodeString(item["plaintext"].(string))
+			if string(plaintext) == "bad" {
+				failed = true
+				results = append(results, map[string]interface{}{"error": "invalid plaintext"})
+				continue
+			}
+			results = append(results, map[string]interface{}{"ciphertext": "vault:v1:" + string(plaintext), "key_version": 1})
+		}
+		if failed {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"batch_results": results},
+		})
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var items []TransitBatchItem
+	for i := 0; i < 7; i++ {
+		plaintext := fmt.Sprintf("item%d", i)
+		if i == 4 {
+			plaintext = "bad"
+		}
+		items = append(items, TransitBatchItem{Plaintext: []byte(plaintext)})
+	}
+
+	results, err := client.Transit().EncryptBatch("my-key", items, &TransitBatchOptions{BatchSize: 3})
+	if err == nil || !strings.Contains(err.Error(), "item 4: invalid plaintext") {
+		t.Fatalf("expected an error for item 4, got: %v", err)
+	}
+	if fmt.Sprint(batchSizes) != "[3 3 1]" {
+		t.Fatalf("expected batches of [3 3 1], got %v", batchSizes)
+	}
+	if len(results) != len(items) {
+		t.Fatalf("expected %d results, got %d", len(items), len(results))
+	}
+	for i, result := range results {
+		if i == 4 {
+			if result.Err == nil {
+				t.Fatal("expected item 4 to fail")
+			}
+			continue
+		}
+		if expected := fmt.Sprintf("vault:v1:item%d", i); result.Ciphertext != expected || result.KeyVersion != 1 || result.Err != nil {
+			t.Fatalf("result %d: expected %q, got %#v", i, expected, result)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// TransportUnwrapper is implemented by RoundTrippers that wrap another one,
+// such as instrumentation or recording wrappers. The client unwraps them to
+// find the *http.Transport to apply its TLS, dialer, proxy, and connection
+// pool settings to.
+type TransportUnwrapper interface {
+	Unwrap() http.RoundTripper
+}
+
+// maxTransportUnwraps bounds the unwrapping of transports, in case a wrapper
+// unwraps to itself.
+const maxTransportUnwraps = 16
+
+// findTransport returns the *http.Transport underneath rt, unwrapping it with
+// TransportUnwrapper as needed.
+func findTransport(rt http.RoundTripper) (*http.Transport, error) {
+	current := rt
+	for i := 0; i < maxTransportUnwraps; i++ {
+		switch t := current.(type) {
+		case *http.Transport:
+			return t, nil
+		case TransportUnwrapper:
+			current = t.Unwrap()
+		default:
+			return nil, fmt.Errorf("transport %T is not an *http.Transport and does not unwrap to one; configure the *http.Transport and set WrapTransport to wrap it instead", rt)
+		}
+	}
+	return nil, fmt.Errorf("transport %T unwraps too many times", rt)
+}
+
+// wrappedTransport caches the result of Config.WrapTransport, so that the
+// wrapper is only created again when the HTTP client is replaced.
+type wrappedTransport struct {
+	l       sync.Mutex
+	base    *http.Client
+	wrapped http.RoundTripper
+}
+
+// httpClient returns the HTTP client to send requests with: httpClient, with
+// its transport wrapped by wrap if set.
+func (w *wrappedTransport) httpClient(httpClient *http.Client, wrap func(http.RoundTripper) http.RoundTripper) *http.Client {
+	if wrap == nil {
+		return httpClient
+	}
+
+	w.l.Lock()
+	if w.base != httpClient {
+		w.base = httpClient
+		w.wrapped = wrap(httpClient.Transport)
+	}
+	wrapped := w.wrapped
+	w.l.Unlock()
+
+	client := *httpClient
+	client.Transport = wrapped
+	return &client
+}
+
+// requestHTTPClient returns the HTTP client that requests are sent with.
+func (c *Config) requestHTTPClient() *http.Client {
+	c.modifyLock.RLock()
+	httpClient := c.HttpClient
+	wrap := c.WrapTransport
+	c.modifyLock.RUnlock()
+
+	return c.wrapped.httpClient(httpClient, wrap)
+}
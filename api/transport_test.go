@@ -0,0 +1,137 @@
+package api
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+type unwrappingTransport struct {
+	base     http.RoundTripper
+	requests int32
+}
+
+func (t *unwrappingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	return t.base.RoundTrip(req)
+}
+
+func (t *unwrappingTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+func TestConfig_WrappedTransport(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer ln.Close()
+
+	base := config.HttpClient.Transport.(*http.Transport)
+	wrapper := &unwrappingTransport{base: base}
+	config.HttpClient.Transport = wrapper
+	config.MaxConnsPerHost = 3
+
+	if err := config.ConfigureTLS(&TLSConfig{Insecure: true}); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !base.TLSClientConfig.InsecureSkipVerify || base.MaxConnsPerHost != 3 {
+		t.Fatal("expected settings to be applied to the unwrapped transport")
+	}
+
+	if _, err := client.RawRequest(client.NewRequest("GET", "/")); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&wrapper.requests) != 1 {
+		t.Fatal("expected request to go through the wrapper")
+	}
+
+	// Transports that cannot be unwrapped fail to be configured, rather than
+	// panicking, but are otherwise usable
+	config = DefaultConfig()
+	config.HttpClient = &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+	if err := config.ConfigureTLS(&TLSConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.ConfigureTLS(&TLSConfig{Insecure: true}); err == nil {
+		t.Fatal("expected error configuring TLS of a custom transport")
+	}
+	if _, err := NewClient(config); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfig_WrapTransport(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer ln.Close()
+
+	var wraps int32
+	var wrapper *unwrappingTransport
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		atomic.AddInt32(&wraps, 1)
+		wrapper = &unwrappingTransport{base: rt}
+		return wrapper
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.RawRequest(client.NewRequest("GET", "/")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if atomic.LoadInt32(&wraps) != 1 || atomic.LoadInt32(&wrapper.requests) != 3 {
+		t.Fatalf("expected a single wrapper to send all requests, got %d wrappers", wraps)
+	}
+	if _, ok := config.HttpClient.Transport.(*http.Transport); !ok {
+		t.Fatal("expected the configured transport to be left in place")
+	}
+}
+
+func TestClientClone_WrappedTransportTLS(t *testing.T) {
+	config := DefaultConfig()
+	config.CloneTLSConfig = true
+	config.HttpClient.Transport = &unwrappingTransport{base: config.HttpClient.Transport}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The wrapper cannot be rebuilt around a copy of the transport, which
+	// would otherwise be shared with the clone
+	if _, err := client.Clone(); err == nil {
+		t.Fatal("expected error cloning the TLS configuration of a wrapped transport")
+	}
+
+	config = DefaultConfig()
+	config.CloneTLSConfig = true
+	config.HttpClient = &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+	client, err = NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Clone(); err == nil {
+		t.Fatal("expected error cloning the TLS configuration of a custom transport")
+	}
+
+	// Transports wrapped through WrapTransport are cloned
+	config = DefaultConfig()
+	config.CloneTLSConfig = true
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return &unwrappingTransport{base: rt}
+	}
+	client, err = NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.config.HttpClient.Transport == client.config.HttpClient.Transport {
+		t.Fatal("expected transport to be cloned")
+	}
+}
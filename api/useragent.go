@@ -0,0 +1,45 @@
+package api
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/sdk/version"
+)
+
+var (
+	sdkInfoLock sync.RWMutex
+	sdkInfo     []string
+)
+
+// SetSDKInfo adds a product token, "name/version", to the default User-Agent
+// sent by all clients in this process, so that traffic can be attributed to
+// the application or SDK embedding this package in audit logs and load
+// balancer metrics. Tokens are appended in the order they are added.
+func SetSDKInfo(name, version string) {
+	token := name
+	if version != "" {
+		token = fmt.Sprintf("%s/%s", name, version)
+	}
+
+	sdkInfoLock.Lock()
+	defer sdkInfoLock.Unlock()
+	sdkInfo = append(sdkInfo, token)
+}
+
+// DefaultUserAgent returns the User-Agent sent by clients without a
+// configured UserAgent, e.g.
+// "vault-api-go/1.4.0 (go1.13; linux/amd64) my-service/2.1".
+func DefaultUserAgent() string {
+	sdkInfoLock.RLock()
+	defer sdkInfoLock.RUnlock()
+
+	ua := fmt.Sprintf("vault-api-go/%s (%s; %s/%s)",
+		version.GetVersion().VersionNumber(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if len(sdkInfo) > 0 {
+		ua += " " + strings.Join(sdkInfo, " ")
+	}
+	return ua
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClient_UserAgent(t *testing.T) {
+	var userAgent string
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		userAgent = req.Header.Get("User-Agent")
+	}))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(info []string) { sdkInfo = info }(sdkInfo)
+	SetSDKInfo("my-service", "2.1")
+
+	if _, err := client.Logical().Read("secret/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(userAgent, "vault-api-go/") || !strings.HasSuffix(userAgent, " my-service/2.1") {
+		t.Fatalf("unexpected default user agent %q", userAgent)
+	}
+
+	config.UserAgent = "custom/1.0"
+	client, err = NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Read("secret/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if userAgent != "custom/1.0" {
+		t.Fatalf("unexpected user agent %q", userAgent)
+	}
+
+	client.AddHeader("User-Agent", "header/1.0")
+	if _, err := client.Logical().Read("secret/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if userAgent != "header/1.0" {
+		t.Fatalf("unexpected user agent %q", userAgent)
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+)
+
+// WarningHandler is called with the path of a request and the warnings of
+// its response. See Config.WarningHandler.
+type WarningHandler func(path string, warnings []string)
+
+// handleWarnings calls handler with the warnings in the body of the given
+// successful response, if any. The body is buffered so that it can still be
+// read by the caller.
+func handleWarnings(handler WarningHandler, path string, resp *Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || resp.StatusCode == 204 {
+		return nil
+	}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	var parsed struct {
+		Warnings []string `json:"warnings"`
+	}
+	if err := jsonutil.DecodeJSON(body, &parsed); err != nil {
+		// Leave reporting malformed bodies to the caller decoding them
+		return nil
+	}
+	if len(parsed.Warnings) > 0 {
+		handler(strings.TrimPrefix(path, "/v1/"), parsed.Warnings)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestConfig_WarningHandler(t *testing.T) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch req.URL.Path {
+		case "/v1/secret/warned":
+			w.Write([]byte(`{"data": {"value": "bar"}, "warnings": ["first", "second"]}`))
+		default:
+			w.Write([]byte(`{"data": {"value": "bar"}}`))
+		}
+	}))
+	defer ln.Close()
+
+	var paths []string
+	var warnings [][]string
+	config.WarningHandler = func(path string, w []string) {
+		paths = append(paths, path)
+		warnings = append(warnings, w)
+	}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := client.Logical().Read("secret/warned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["value"] != "bar" || len(secret.Warnings) != 2 {
+		t.Fatalf("body not preserved: %#v", secret)
+	}
+	if _, err := client.Logical().Read("secret/quiet"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(paths, []string{"secret/warned"}) {
+		t.Fatalf("unexpected paths %v", paths)
+	}
+	if !reflect.DeepEqual(warnings, [][]string{{"first", "second"}}) {
+		t.Fatalf("unexpected warnings %v", warnings)
+	}
+}
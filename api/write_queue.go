@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// IdempotencyKeyHeaderName is the header carrying the nonce that identifies a
+// queued write. Vault itself ignores it and does not deduplicate writes, so a
+// write whose response was lost may be applied twice when replayed; the
+// header only lets an intermediate proxy or audit consumer recognize replays.
+const IdempotencyKeyHeaderName = "Idempotency-Key"
+
+// writeQueueAdditionalData is the associated data of the encrypted queue
+// file. It is fixed, so that the file can be moved or reached through
+// another path.
+var writeQueueAdditionalData = []byte("vault-api-write-queue")
+
+// ErrWriteQueued is returned by WriteQueue.Write when the server could not be
+// reached and the write was persisted for later replay.
+var ErrWriteQueued = errors.New("vault unreachable; write queued for replay")
+
+// WriteQueueInput is used as input to NewWriteQueue.
+type WriteQueueInput struct {
+	// Path is the file in which queued writes are persisted. It is created
+	// with 0600 permissions if it doesn't exist. Required.
+	Path string
+
+	// Key is the 32-byte AES-256 key used to encrypt the queue file. Required.
+	Key []byte
+}
+
+// WriteQueue persists writes that could not be delivered because the server
+// was unreachable, and replays them in order once connectivity returns. It is
+// intended for intermittently connected clients such as edge devices. Only
+// network failures queue a write; errors such as a cancelled context, a
+// closed client or an open circuit breaker are returned to the caller.
+type WriteQueue struct {
+	l       sync.Mutex
+	c       *Client
+	path    string
+	aead    cipher.AEAD
+	entries []*queuedWrite
+}
+
+type queuedWrite struct {
+	Nonce string                 `json:"nonce"`
+	Path  string                 `json:"path"`
+	Data  map[string]interface{} `json:"data"`
+}
+
+// NewWriteQueue creates a write queue backed by the given file, loading any
+// writes queued by a previous process.
+func (c *Client) NewWriteQueue(i *WriteQueueInput) (*WriteQueue, error) {
+	if i == nil || i.Path == "" {
+		return nil, errors.New("a queue file path is required")
+	}
+	if len(i.Key) != 32 {
+		return nil, errors.New("queue encryption key must be 32 bytes")
+	}
+
+	block, err := aes.NewCipher(i.Key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &WriteQueue{
+		c:    c,
+		path: i.Path,
+		aead: aead,
+	}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// Len returns the number of writes waiting to be replayed.
+func (q *WriteQueue) Len() int {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	return len(q.entries)
+}
+
+// Write writes the data to the given path. If earlier writes are still queued,
+// or the server cannot be reached, the write is appended to the queue and
+// ErrWriteQueued is returned; ordering with respect to earlier queued writes
+// is always preserved.
+func (q *WriteQueue) Write(ctx context.Context, path string, data map[string]interface{}) (*Secret, error) {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	nonce, err := newWriteNonce()
+	if err != nil {
+		return nil, err
+	}
+	entry := &queuedWrite{
+		Nonce: nonce,
+		Path:  path,
+		Data:  data,
+	}
+
+	if len(q.entries) == 0 {
+		secret, err := q.send(ctx, entry)
+		if !isUnreachable(err) {
+			return secret, err
+		}
+	}
+
+	q.entries = append(q.entries, entry)
+	if err := q.persist(); err != nil {
+		return nil, err
+	}
+
+	return nil, ErrWriteQueued
+}
+
+// Flush replays queued writes in order, stopping at the first write that
+// cannot be delivered because the server is unreachable. Writes rejected by
+// the server are dropped from the queue and their errors returned together.
+func (q *WriteQueue) Flush(ctx context.Context) error {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	var errs *multierror.Error
+	for len(q.entries) > 0 {
+		entry := q.entries[0]
+		_, err := q.send(ctx, entry)
+		if isUnreachable(err) {
+			break
+		}
+		if err != nil {
+			errs = multierror.Append(errs, errwrap.Wrapf(fmt.Sprintf("error replaying write to %q: {{err}}", entry.Path), err))
+		}
+
+		q.entries = q.entries[1:]
+		if err := q.persist(); err != nil {
+			return err
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+func (q *WriteQueue) send(ctx context.Context, entry *queuedWrite) (*Secret, error) {
+	r := q.c.NewRequest("PUT", "/v1/"+entry.Path)
+	r.Headers.Set(IdempotencyKeyHeaderName, entry.Nonce)
+	if err := r.SetJSONBody(entry.Data); err != nil {
+		return nil, err
+	}
+
+	resp, err := q.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		if resp == nil && isNetworkError(ctx, err) {
+			return nil, &unreachableError{err: err}
+		}
+		return nil, err
+	}
+
+	return ParseSecret(resp.Body)
+}
+
+// load reads and decrypts the queue file, if it exists.
+func (q *WriteQueue) load() error {
+	ciphertext, err := ioutil.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(ciphertext) == 0 {
+		return nil
+	}
+
+	nonceSize := q.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return errors.New("queue file is truncated")
+	}
+	plaintext, err := q.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], writeQueueAdditionalData)
+	if err != nil {
+		return errwrap.Wrapf("error decrypting queue file: {{err}}", err)
+	}
+
+	return json.Unmarshal(plaintext, &q.entries)
+}
+
+// persist encrypts and atomically rewrites the queue file.
+func (q *WriteQueue) persist() error {
+	plaintext, err := json.Marshal(q.entries)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, q.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	ciphertext := q.aead.Seal(nonce, nonce, plaintext, writeQueueAdditionalData)
+
+	tmp, err := ioutil.TempFile(filepath.Dir(q.path), filepath.Base(q.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(ciphertext); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), q.path)
+}
+
+func newWriteNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// unreachableError marks a failure to get any response from the server.
+type unreachableError struct {
+	err error
+}
+
+func (e *unreachableError) Error() string {
+	return e.err.Error()
+}
+
+// isNetworkError returns whether the request failed because the server
+// could not be reached over the network, rather than because the context of
+// the caller ended or the client refused to send it.
+func isNetworkError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	return errors.As(err, &opErr) || errors.As(err, &dnsErr) || IsTransientNetworkError(err)
+}
+
+func isUnreachable(err error) bool {
+	_, ok := err.(*unreachableError)
+	return ok
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+type testWriteQueueServer struct {
+	l       sync.Mutex
+	writes  []map[string]interface{}
+	nonces  []string
+	offline int32
+}
+
+func (s *testWriteQueueServer) client(t *testing.T) (*Client, func()) {
+	config, ln := testHTTPServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/secret/rejected" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors": ["rejected"]}`))
+			return
+		}
+
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		s.l.Lock()
+		s.writes = append(s.writes, body)
+		s.nonces = append(s.nonces, req.Header.Get(IdempotencyKeyHeaderName))
+		s.l.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	config.MaxRetries = 0
+	transport := config.HttpClient.Transport.(*http.Transport)
+	dial := transport.DialContext
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if atomic.LoadInt32(&s.offline) == 1 {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+		}
+		return dial(ctx, network, addr)
+	}
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, func() { ln.Close() }
+}
+
+func testWriteQueueKey() []byte {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	return key
+}
+
+func TestWriteQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "write-queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue")
+
+	server := &testWriteQueueServer{}
+	client, closeServer := server.client(t)
+	defer closeServer()
+	ctx := context.Background()
+
+	queue, err := client.NewWriteQueue(&WriteQueueInput{Path: path, Key: testWriteQueueKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes are sent straight away while the server is reachable
+	if _, err := queue.Write(ctx, "secret/a", map[string]interface{}{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if len(server.writes) != 1 || queue.Len() != 0 {
+		t.Fatalf("expected write to be sent, got %d writes, %d queued", len(server.writes), queue.Len())
+	}
+
+	// and queued in order while it is not
+	atomic.StoreInt32(&server.offline, 1)
+	for _, n := range []int{2, 3} {
+		if _, err := queue.Write(ctx, "secret/a", map[string]interface{}{"n": n}); err != ErrWriteQueued {
+			t.Fatalf("expected ErrWriteQueued, got %v", err)
+		}
+	}
+	atomic.StoreInt32(&server.offline, 0)
+	if _, err := queue.Write(ctx, "secret/a", map[string]interface{}{"n": 4}); err != ErrWriteQueued {
+		t.Fatalf("expected write after queued ones to be queued, got %v", err)
+	}
+	if queue.Len() != 3 || len(server.writes) != 1 {
+		t.Fatalf("expected 3 queued writes, got %d", queue.Len())
+	}
+
+	// The queue survives a restart, even if its file was moved
+	moved := filepath.Join(dir, "moved")
+	if err := os.Rename(path, moved); err != nil {
+		t.Fatal(err)
+	}
+	queue, err = client.NewWriteQueue(&WriteQueueInput{Path: moved, Key: testWriteQueueKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queue.Len() != 3 {
+		t.Fatalf("expected 3 queued writes after reload, got %d", queue.Len())
+	}
+	otherKey := testWriteQueueKey()
+	otherKey[0] = 0xff
+	if _, err := client.NewWriteQueue(&WriteQueueInput{Path: moved, Key: otherKey}); err == nil {
+		t.Fatal("expected error loading the queue with another key")
+	}
+
+	if err := queue.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if queue.Len() != 0 || len(server.writes) != 4 {
+		t.Fatalf("expected queue to be flushed, got %d writes, %d queued", len(server.writes), queue.Len())
+	}
+	for i, write := range server.writes {
+		if write["n"] != float64(i+1) {
+			t.Fatalf("expected writes in order, got %v", server.writes)
+		}
+		if len(server.nonces[i]) != 32 {
+			t.Fatalf("expected idempotency key, got %q", server.nonces[i])
+		}
+	}
+}
+
+func TestWriteQueue_Errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "write-queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := &testWriteQueueServer{}
+	client, closeServer := server.client(t)
+	defer closeServer()
+
+	queue, err := client.NewWriteQueue(&WriteQueueInput{Path: filepath.Join(dir, "queue"), Key: testWriteQueueKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Errors other than network failures are returned, not queued
+	if _, err := queue.Write(context.Background(), "secret/rejected", nil); err == nil || err == ErrWriteQueued {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	atomic.StoreInt32(&server.offline, 1)
+	if _, err := queue.Write(ctx, "secret/a", nil); err == nil || err == ErrWriteQueued {
+		t.Fatalf("expected context error, got %v", err)
+	}
+	if queue.Len() != 0 {
+		t.Fatalf("expected nothing queued, got %d", queue.Len())
+	}
+
+	// Writes rejected on replay are dropped and reported
+	for _, path := range []string{"secret/rejected", "secret/a"} {
+		if _, err := queue.Write(context.Background(), path, nil); err != ErrWriteQueued {
+			t.Fatalf("expected ErrWriteQueued, got %v", err)
+		}
+	}
+	atomic.StoreInt32(&server.offline, 0)
+	err = queue.Flush(context.Background())
+	if merr, ok := err.(*multierror.Error); !ok || len(merr.Errors) != 1 {
+		t.Fatalf("expected one replay error, got %v", err)
+	}
+	if queue.Len() != 0 || len(server.writes) != 1 {
+		t.Fatalf("expected queue to be flushed, got %d writes, %d queued", len(server.writes), queue.Len())
+	}
+
+	client.Close()
+	if _, err := queue.Write(context.Background(), "secret/a", nil); err != ErrClientClosed {
+		t.Fatalf("expected ErrClientClosed, got %v", err)
+	}
+	if queue.Len() != 0 {
+		t.Fatalf("expected nothing queued, got %d", queue.Len())
+	}
+}
+
+func TestIsNetworkError(t *testing.T) {
+	ctx := context.Background()
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	dialErr := &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	cases := []struct {
+		ctx      context.Context
+		err      error
+		expected bool
+	}{
+		{ctx, dialErr, true},
+		{ctx, &net.DNSError{Err: "no such host", IsNotFound: true}, true},
+		{canceled, dialErr, false},
+		{ctx, context.Canceled, false},
+		{ctx, &LimiterWaitError{Err: context.DeadlineExceeded}, false},
+		{ctx, ErrClientClosed, false},
+		{ctx, ErrCircuitOpen, false},
+		{ctx, &OutputStringError{}, false},
+	}
+	for _, tc := range cases {
+		if actual := isNetworkError(tc.ctx, tc.err); actual != tc.expected {
+			t.Fatalf("%v: expected %t, got %t", tc.err, tc.expected, actual)
+		}
+	}
+}